@@ -17,12 +17,21 @@ export interface DynamoDbTableAttribute {
     readonly globalSecondaryIndexes?: GlobalSecondaryIndexProps[];
     readonly localSecondaryIndexes?: LocalSecondaryIndexProps[];
     readonly billingMode: BillingMode;
+    readonly timeToLiveAttribute?: string;
 }
 
 export enum TableName {
     REVIEW = 'Review',
     USER = 'User',
     BUSINESS = 'Business',
+    REVIEW_EVENT = 'ReviewEvent',
+    AI_REPLY_DRAFT = 'AiReplyDraft',
+    AI_REPLY_CANDIDATE_SET = 'AiReplyCandidateSet',
+    AI_USAGE = 'AiUsage',
+    AI_REPLY_CACHE = 'AiReplyCache',
+    OAUTH_STATE_NONCE = 'OAuthStateNonce',
+    LOCATION_SELECTION = 'LocationSelection',
+    VENDOR_REVIEW_ID = 'VendorReviewId',
 }
 
 const reviewTable: DynamoDbTableAttribute = {
@@ -108,6 +117,27 @@ const userTable: DynamoDbTableAttribute = {
             },
         },
     ],
+    globalSecondaryIndexes: [
+        // sparse index of the users with a Google refresh token, whose tokens are refreshed by googleTokenHealthHandler
+        {
+            indexName: 'googleTokenStoredAt-gsi',
+            projectionType: ProjectionType.KEYS_ONLY,
+            partitionKey: {
+                name: 'googleTokenStoredAt',
+                type: AttributeType.NUMBER,
+            },
+        },
+        // sparse index of the users who unfollowed the LINE Official Account, whose Google tokens are purged by unfollowedUserPurgeHandler
+        {
+            indexName: 'unfollowedAt-gsi',
+            projectionType: ProjectionType.INCLUDE,
+            nonKeyAttributes: ['googleTokenStoredAt'],
+            partitionKey: {
+                name: 'unfollowedAt',
+                type: AttributeType.NUMBER,
+            },
+        },
+    ],
     billingMode: BillingMode.PAY_PER_REQUEST,
 };
 
@@ -141,4 +171,125 @@ const businessTable: DynamoDbTableAttribute = {
     ],
     billingMode: BillingMode.PAY_PER_REQUEST,
 };
-export const DdbTable: DynamoDbTableAttribute[] = [reviewTable, userTable, businessTable];
+
+// progress of processing new review events, so that redelivered events are not processed twice
+const reviewEventTable: DynamoDbTableAttribute = {
+    tableName: TableName.REVIEW_EVENT,
+    partitionKey: {
+        name: 'vendorEventId',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// AI auto replies awaiting approval in LINE. Drafts are removed some time after they expire.
+const aiReplyDraftTable: DynamoDbTableAttribute = {
+    tableName: TableName.AI_REPLY_DRAFT,
+    partitionKey: {
+        name: 'businessId',
+        type: AttributeType.STRING,
+    },
+    sortKey: {
+        name: 'reviewId',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// AI reply candidates shown on AI reply cards in LINE. Candidates are removed some time after they expire.
+const aiReplyCandidateSetTable: DynamoDbTableAttribute = {
+    tableName: TableName.AI_REPLY_CANDIDATE_SET,
+    partitionKey: {
+        name: 'businessId',
+        type: AttributeType.STRING,
+    },
+    sortKey: {
+        name: 'candidateSetId',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// AI token usage and estimated cost of businesses and users by month ("2023-11") and by day ("2023-11-07").
+// Daily usage is removed some time after the day; monthly usage is kept.
+const aiUsageTable: DynamoDbTableAttribute = {
+    tableName: TableName.AI_USAGE,
+    partitionKey: {
+        name: 'period',
+        type: AttributeType.STRING,
+    },
+    sortKey: {
+        name: 'subject',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// AI replies reused for identical short reviews of a business. Entries are removed some time after they expire.
+const aiReplyCacheTable: DynamoDbTableAttribute = {
+    tableName: TableName.AI_REPLY_CACHE,
+    partitionKey: {
+        name: 'businessId',
+        type: AttributeType.STRING,
+    },
+    sortKey: {
+        name: 'cacheKey',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// nonces of OAuth states used to complete Google OAuth, so that a callback cannot be replayed. Nonces are removed some time after their state expires.
+const oauthStateNonceTable: DynamoDbTableAttribute = {
+    tableName: TableName.OAUTH_STATE_NONCE,
+    partitionKey: {
+        name: 'nonce',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// Google business locations discovered in the latest OAuth of each user, which the user connects or skips in LINE.
+// Selections keeping the Google token of a user who connects no location are removed some time after they expire.
+const locationSelectionTable: DynamoDbTableAttribute = {
+    tableName: TableName.LOCATION_SELECTION,
+    partitionKey: {
+        name: 'userId',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+    timeToLiveAttribute: 'expireAt',
+};
+
+// key of the review item of each Google review ID of a business, so that a review is found by its vendor review ID without querying all reviews of the business
+const vendorReviewIdTable: DynamoDbTableAttribute = {
+    tableName: TableName.VENDOR_REVIEW_ID,
+    partitionKey: {
+        name: 'businessId',
+        type: AttributeType.STRING,
+    },
+    sortKey: {
+        name: 'reviewId',
+        type: AttributeType.STRING,
+    },
+    billingMode: BillingMode.PAY_PER_REQUEST,
+};
+export const DdbTable: DynamoDbTableAttribute[] = [
+    reviewTable,
+    userTable,
+    businessTable,
+    reviewEventTable,
+    aiReplyDraftTable,
+    aiReplyCandidateSetTable,
+    aiUsageTable,
+    aiReplyCacheTable,
+    oauthStateNonceTable,
+    locationSelectionTable,
+    vendorReviewIdTable,
+];
@@ -3,4 +3,9 @@ export enum LambdaHandlerName {
     LINE_EVENTS_HANDLER = 'lineEventsHandler',
     NEW_REVIEW_EVENT_HANDLER = 'newReviewEventHandler',
     AUTH_HANDLER = 'authHandler',
+    GOOGLE_NOTIFICATION_HANDLER = 'googleNotificationHandler',
+    REVIEW_BACKFILL_HANDLER = 'reviewBackfillHandler',
+    AI_USAGE_REPORT_HANDLER = 'aiUsageReportHandler',
+    UNFOLLOWED_USER_PURGE_HANDLER = 'unfollowedUserPurgeHandler',
+    GOOGLE_TOKEN_HEALTH_HANDLER = 'googleTokenHealthHandler',
 }
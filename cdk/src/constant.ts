@@ -1,3 +1,11 @@
 import { STAGED_SERVICE } from 'common-cdk';
 
 export const SERVICE_NAME = STAGED_SERVICE.REVIEW_HANDLERS;
+
+// SSM parameter holding the token appended to the Pub/Sub push endpoint of Google Business Profile notifications.
+// The parameter is created manually so that the token is not checked in.
+export const PUBSUB_VERIFICATION_TOKEN_PARAMETER_NAME = '/ReviewHandlers/pubSubVerificationToken';
+
+// SSM parameter holding the secret signing the state of Google OAuth requests sent by lineEventsHandler and verified by authHandler.
+// The parameter is created manually so that the secret is not checked in.
+export const OAUTH_STATE_SECRET_PARAMETER_NAME = '/ReviewHandlers/oauthStateSecret';
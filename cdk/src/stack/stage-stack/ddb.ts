@@ -40,6 +40,7 @@ export class DdbStack extends Stack {
             sortKey: definition.sortKey,
             billingMode: definition.billingMode,
             pointInTimeRecovery: true,
+            timeToLiveAttribute: definition.timeToLiveAttribute,
         });
 
         if (definition.localSecondaryIndexes) {
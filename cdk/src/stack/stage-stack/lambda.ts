@@ -10,7 +10,10 @@ import { GoFunction } from '@aws-cdk/aws-lambda-go-alpha';
 import { RetentionDays } from 'aws-cdk-lib/aws-logs';
 import { FunctionUrl } from 'aws-cdk-lib/aws-lambda/lib/function-url';
 import { StringParameter } from 'aws-cdk-lib/aws-ssm';
+import { Rule, Schedule } from 'aws-cdk-lib/aws-events';
+import { LambdaFunction } from 'aws-cdk-lib/aws-events-targets';
 import { LambdaHandlerName } from '../../config/lambdaHandler';
+import { OAUTH_STATE_SECRET_PARAMETER_NAME, PUBSUB_VERIFICATION_TOKEN_PARAMETER_NAME } from '../../constant';
 
 export interface LambdaStackProps {
     readonly stackCreationInfo: StackCreationInfo;
@@ -45,6 +48,7 @@ export class LambdaStack extends Stack {
             LambdaHandlerName.LINE_EVENTS_HANDLER,
             {
                 AUTH_REDIRECT_URL_PARAMETER_NAME: AUTH_REDIRECT_URL_PARAMETER_NAME,
+                OAUTH_STATE_SECRET_PARAMETER_NAME: OAUTH_STATE_SECRET_PARAMETER_NAME,
             }
         ).lambdaFn;
 
@@ -52,11 +56,54 @@ export class LambdaStack extends Stack {
             LambdaHandlerName.NEW_REVIEW_EVENT_HANDLER,
             {
                 AUTH_REDIRECT_URL_PARAMETER_NAME: AUTH_REDIRECT_URL_PARAMETER_NAME,
+                OAUTH_STATE_SECRET_PARAMETER_NAME: OAUTH_STATE_SECRET_PARAMETER_NAME,
             }
         ).lambdaFn;
 
+        this.lambdaFunctions[LambdaHandlerName.GOOGLE_NOTIFICATION_HANDLER] = this.createWebhookHandler(
+            LambdaHandlerName.GOOGLE_NOTIFICATION_HANDLER,
+            {
+                PUBSUB_VERIFICATION_TOKEN_PARAMETER_NAME: PUBSUB_VERIFICATION_TOKEN_PARAMETER_NAME,
+                AUTH_REDIRECT_URL_PARAMETER_NAME: AUTH_REDIRECT_URL_PARAMETER_NAME,
+                OAUTH_STATE_SECRET_PARAMETER_NAME: OAUTH_STATE_SECRET_PARAMETER_NAME,
+            }
+        ).lambdaFn;
+
+        // reconcile reviews against Google in case notifications were missed
+        this.lambdaFunctions[LambdaHandlerName.REVIEW_BACKFILL_HANDLER] = this.createScheduledHandler(
+            LambdaHandlerName.REVIEW_BACKFILL_HANDLER,
+            Schedule.rate(Duration.hours(6)),
+            {
+                AUTH_REDIRECT_URL_PARAMETER_NAME: AUTH_REDIRECT_URL_PARAMETER_NAME,
+                OAUTH_STATE_SECRET_PARAMETER_NAME: OAUTH_STATE_SECRET_PARAMETER_NAME,
+            }
+        );
+
+        // summarize the AI usage of the previous day to Slack at 9:00 Taiwan time
+        this.lambdaFunctions[LambdaHandlerName.AI_USAGE_REPORT_HANDLER] = this.createScheduledHandler(
+            LambdaHandlerName.AI_USAGE_REPORT_HANDLER,
+            Schedule.cron({ hour: '1', minute: '0' })
+        );
+
+        // purge the Google tokens of users who unfollowed the LINE Official Account longer than the grace period ago
+        this.lambdaFunctions[LambdaHandlerName.UNFOLLOWED_USER_PURGE_HANDLER] = this.createScheduledHandler(
+            LambdaHandlerName.UNFOLLOWED_USER_PURGE_HANDLER,
+            Schedule.rate(Duration.days(1))
+        );
+
+        // refresh the Google tokens of all users to detect revoked tokens before replies fail
+        this.lambdaFunctions[LambdaHandlerName.GOOGLE_TOKEN_HEALTH_HANDLER] = this.createScheduledHandler(
+            LambdaHandlerName.GOOGLE_TOKEN_HEALTH_HANDLER,
+            Schedule.rate(Duration.hours(12)),
+            {
+                AUTH_REDIRECT_URL_PARAMETER_NAME: AUTH_REDIRECT_URL_PARAMETER_NAME,
+                OAUTH_STATE_SECRET_PARAMETER_NAME: OAUTH_STATE_SECRET_PARAMETER_NAME,
+            }
+        );
+
         const authHandlerWebhook = this.createWebhookHandler(LambdaHandlerName.AUTH_HANDLER, {
             AUTH_REDIRECT_URL_PARAMETER_NAME: AUTH_REDIRECT_URL_PARAMETER_NAME,
+            OAUTH_STATE_SECRET_PARAMETER_NAME: OAUTH_STATE_SECRET_PARAMETER_NAME,
         });
         this.lambdaFunctions[LambdaHandlerName.AUTH_HANDLER] = authHandlerWebhook.lambdaFn;
 
@@ -87,6 +134,78 @@ export class LambdaStack extends Stack {
     ): WebhookHandler {
         const { stage } = this.props.stackCreationInfo;
 
+        const handlerFunction = this.createGoFunction(handlerName, additionalEnv, ...layers);
+
+        const functionUrl = handlerFunction.addFunctionUrl({
+            authType: FunctionUrlAuthType.NONE,
+            ...((stage == STAGE.PROD || stage == STAGE.GAMMA) && {
+                cors: {
+                    // TODO: tighten
+                    allowedOrigins: ['*'],
+                },
+            }),
+        });
+
+        // TODO: INT-48 create timeout metrics
+        // if (fn.timeout) {
+        //     new cloudwatch.Alarm(this, `MyAlarm`, {
+        //         metric: fn.metricDuration().with({
+        //             statistic: 'Maximum',
+        //         }),
+        //         evaluationPeriods: 1,
+        //         datapointsToAlarm: 1,
+        //         threshold: fn.timeout.toMilliseconds(),
+        //         treatMissingData: cloudwatch.TreatMissingData.IGNORE,
+        //         alarmName: 'My Lambda Timeout',
+        //     });
+        // }
+
+        return {
+            lambdaFn: handlerFunction,
+            functionUrl: functionUrl,
+        };
+    }
+
+    /**
+     * Create Go Lambda function triggered on the schedule
+     * handlerName must be src/cmd/{handlerName}/main.go
+     *
+     * @param handlerName
+     * @param schedule
+     * @param additionalEnv
+     * @private
+     */
+    private createScheduledHandler(
+        handlerName: LambdaHandlerName,
+        schedule: Schedule,
+        additionalEnv: EnvObject = {}
+    ): GoFunction {
+        const handlerFunction = this.createGoFunction(handlerName, additionalEnv);
+
+        new Rule(this, `${handlerName}Schedule`, {
+            schedule,
+            targets: [new LambdaFunction(handlerFunction, { retryAttempts: 0 })],
+        });
+
+        return handlerFunction;
+    }
+
+    /**
+     * Create Go Lambda function
+     * handlerName must be src/cmd/{handlerName}/main.go
+     *
+     * @param handlerName
+     * @param additionalEnv
+     * @param layers - layers to be added to the function
+     * @private
+     */
+    private createGoFunction(
+        handlerName: LambdaHandlerName,
+        additionalEnv: EnvObject = {},
+        ...layers: LayerVersion[]
+    ): GoFunction {
+        const { stage } = this.props.stackCreationInfo;
+
         const handlerRole = new Role(this, `${handlerName}Role`, {
             assumedBy: new ServicePrincipal('lambda.amazonaws.com'),
         });
@@ -129,34 +248,7 @@ export class LambdaStack extends Stack {
             // },
         });
 
-        const functionUrl = handlerFunction.addFunctionUrl({
-            authType: FunctionUrlAuthType.NONE,
-            ...((stage == STAGE.PROD || stage == STAGE.GAMMA) && {
-                cors: {
-                    // TODO: tighten
-                    allowedOrigins: ['*'],
-                },
-            }),
-        });
-
-        // TODO: INT-48 create timeout metrics
-        // if (fn.timeout) {
-        //     new cloudwatch.Alarm(this, `MyAlarm`, {
-        //         metric: fn.metricDuration().with({
-        //             statistic: 'Maximum',
-        //         }),
-        //         evaluationPeriods: 1,
-        //         datapointsToAlarm: 1,
-        //         threshold: fn.timeout.toMilliseconds(),
-        //         treatMissingData: cloudwatch.TreatMissingData.IGNORE,
-        //         alarmName: 'My Lambda Timeout',
-        //     });
-        // }
-
-        return {
-            lambdaFn: handlerFunction,
-            functionUrl: functionUrl,
-        };
+        return handlerFunction;
     }
 
     private buildGetSecretPolicy(): PolicyStatement {
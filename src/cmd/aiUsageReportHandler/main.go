@@ -0,0 +1,114 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "github.com/IntelliLead/CoreCommonUtil/aws"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/enum"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/logger"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    enum3 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
+    "github.com/IntelliLead/CoreCommonUtil/secretUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/slackUtil"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/aws/aws-lambda-go/lambda"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "os"
+    "time"
+)
+
+var (
+    log       = logger.NewLogger()
+    awsConfig = aws.DefaultAwsConfig()
+    secrets   = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
+)
+
+// Runs as a scheduled Lambda, or as a local CLI when not in Lambda, e.g.,
+//
+//	STAGE=alpha go run main.go -day 2023-11-07
+func main() {
+    if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+        runCli()
+        return
+    }
+    lambda.Start(handleRequest)
+}
+
+func runCli() {
+    day := flag.String("day", model.DailyAiUsagePeriod(time.Now().AddDate(0, 0, -1)), "the day to report in Taiwan time, e.g., 2023-11-07")
+    flag.Parse()
+
+    err := report(context.Background(), *day)
+    if err != nil {
+        log.Fatalf("Reporting AI usage failed: %v", err)
+    }
+}
+
+func handleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+    log.Infof("Received scheduled event in %s: %s", os.Getenv(constant.StageEnvKey), jsonUtil.AnyToJson(event))
+
+    // runs after midnight in Taiwan time, so the previous day is complete
+    day := model.DailyAiUsagePeriod(time.Now().AddDate(0, 0, -1))
+    err := report(ctx, day)
+    if err != nil {
+        log.Errorf("Error reporting AI usage on %s: %v", day, err)
+        metric.EmitLambdaMetric(enum3.Metric5xxError, enum2.HandlerNameAiUsageReportHandler.String(), 1)
+        return err
+    }
+
+    log.Infof("Successfully reported AI usage on %s", day)
+    return nil
+}
+
+// report sends the AI usage of businesses and users on the day to Slack
+func report(ctx context.Context, day string) error {
+    stage := enum.ToStage(os.Getenv(constant.StageEnvKey)) // panic if invalid stage
+
+    ddbClient := dynamodb.NewFromConfig(awsConfig)
+    usages, err := dao.NewAiUsageDao(ddbClient, log).ListUsage(ctx, day)
+    if err != nil {
+        return err
+    }
+    log.Infof("Found AI usage of %d businesses and users on %s", len(usages), day)
+
+    names := namesOf(usages, ddbDao.NewBusinessDao(ddbClient, log), ddbDao.NewUserDao(ddbClient, log))
+    return slackUtil.NewSlack(log, stage, secrets.SlackToken, secrets.NewUserSlackBotChannelId).SendAiUsageSummaryMessage(day, usages, names)
+}
+
+// namesOf maps the business and user IDs of the usages to business names and LINE usernames.
+// IDs whose name cannot be read are left out, as the summary is still useful without them.
+func namesOf(usages []model.AiUsage, businessDao *ddbDao.BusinessDao, userDao *ddbDao.UserDao) map[string]string {
+    names := make(map[string]string)
+    for _, usage := range usages {
+        if businessIdStr, ok := usage.BusinessId(); ok {
+            businessId, err := bid.NewBusinessId(businessIdStr)
+            if err != nil {
+                log.Warnf("Invalid business ID '%s' in AI usage: %v", businessIdStr, err)
+                continue
+            }
+            business, err := businessDao.GetBusiness(businessId)
+            if err != nil || business == nil {
+                log.Warnf("Error getting business '%s' of AI usage: %v", businessIdStr, err)
+                continue
+            }
+            names[businessIdStr] = business.BusinessName
+            continue
+        }
+
+        userId, _ := usage.UserId()
+        user, err := userDao.GetUser(userId)
+        if err != nil || user == nil {
+            log.Warnf("Error getting user '%s' of AI usage: %v", userId, err)
+            continue
+        }
+        names[userId] = user.LineUsername
+    }
+    return names
+}
@@ -3,6 +3,7 @@ package main
 import (
     "context"
     "errors"
+    "fmt"
     "github.com/IntelliLead/CoreCommonUtil/aws"
     "github.com/IntelliLead/CoreCommonUtil/constant"
     enum3 "github.com/IntelliLead/CoreCommonUtil/enum"
@@ -18,11 +19,14 @@ import (
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
     "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
     "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
-    "github.com/IntelliLead/CoreDataAccess/exception"
     model2 "github.com/IntelliLead/CoreDataAccess/model"
     "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    exception2 "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
     enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/slackUtil"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/aws/aws-lambda-go/events"
@@ -32,6 +36,7 @@ import (
     "google.golang.org/api/mybusinessaccountmanagement/v1"
     "os"
     "strings"
+    "time"
 )
 
 func main() {
@@ -39,10 +44,11 @@ func main() {
 }
 
 var (
-    log             = logger.NewLogger()
-    awsConfig       = aws.DefaultAwsConfig()
-    secrets         = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
-    authRedirectUrl = ssmUtil.NewSsm(awsConfig, log).GetSsmParameterValue(os.Getenv(constant.AuthRedirectUrlParameterNameEnvKey))
+    log              = logger.NewLogger()
+    awsConfig        = aws.DefaultAwsConfig()
+    secrets          = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
+    authRedirectUrl  = ssmUtil.NewSsm(awsConfig, log).GetSsmParameterValue(os.Getenv(constant.AuthRedirectUrlParameterNameEnvKey))
+    oauthStateSecret = ssmUtil.NewSsm(awsConfig, log).GetSsmParameterValue(os.Getenv(util.OAuthStateSecretParameterNameEnvKey))
 )
 
 func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
@@ -86,8 +92,8 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
     log.Debugf("Received authorization code from Google OAUTH response: %s", code)
 
     // parse the state parameter
-    userId := request.QueryStringParameters["state"]
-    if userId == "" {
+    stateParam := request.QueryStringParameters["state"]
+    if stateParam == "" {
         log.Errorf("Missing state parameter from Google OAUTH response containing userId.")
         return events.LambdaFunctionURLResponse{
             StatusCode: 400,
@@ -95,15 +101,32 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
         }, nil
     }
 
-    log.Info("Received OAUTH request from user: ", userId)
-
     // ----
     // 2. Initialize resources
     // ----
     businessDao := ddbDao.NewBusinessDao(dynamodb.NewFromConfig(awsConfig), log)
     userDao := ddbDao.NewUserDao(dynamodb.NewFromConfig(awsConfig), log)
+    googleAccountDao := dao.NewGoogleAccountDao(dynamodb.NewFromConfig(awsConfig), businessDao, userDao, log)
     line := lineUtil.NewLineUtil(secrets.LineChannelSecret, secrets.LineChannelAccessToken, log)
 
+    stateSigner, err := oauthState.NewSigner(oauthStateSecret)
+    if err != nil {
+        log.Error("Error creating OAuth state signer: ", err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       `{"error": "Error creating OAuth state signer"}`,
+        }, err
+    }
+
+    // the state binds the callback to the user the auth request was sent to. Reject it unless it is signed and unexpired.
+    state, err := verifyState(stateParam, stateSigner, line)
+    if err != nil {
+        return stateRejectedResponse(err)
+    }
+    userId := state.UserId
+
+    log.Info("Received OAUTH request from user: ", userId)
+
     google, err := googleUtil.NewGoogleWithAuthCode(
         authRedirectUrl,
         secrets.GoogleClientID,
@@ -123,6 +146,12 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
         }, err
     }
 
+    // the state is used up only once the code is exchanged, so that the user can retry the same auth request if the exchange fails
+    err = useState(ctx, state, dao.NewOAuthStateNonceDao(dynamodb.NewFromConfig(awsConfig), log))
+    if err != nil {
+        return stateRejectedResponse(err)
+    }
+
     userPtr, err := userDao.GetUser(userId)
     if err != nil {
         log.Error("Error checking if user exists: ", err)
@@ -138,23 +167,19 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
     }
 
     // ----
-    // 2. update businesses and user
+    // 2. update user and discovered locations
     // ----
 
     /*
+       Locations are not connected here. The user selects the discovered locations to connect in LINE, see locationConnector.
        scenarios:
-        1. Neither user nor business exist: create new user and associated business (primary user first time auth)
-        2. user exists, but business does not exist: create new business, associate with user, and create/update Google metadata for user (backfill - primary user)
-        2. Business exists, but user does not exist: create new user associated with business (secondary user first time auth)
-        3. user and business both exist, user associated with business: simply update Google metadata for user
-        4. user and business both exist, but user does not have this business: create associate, and update Google metadata for user (backfill - secondary user)
-
-        Other scenarios are error state
+        1. user does not exist: keep the Google metadata with the discovered locations, so that the user is created on connecting the first location
+        2. user exists: update Google metadata for user. Businesses of the user stay connected.
     */
 
-    businesses, businessAccountId, err := updateBusinesses(userId, userPtr, businessDao, google)
+    locations, businessAccountIds, err := discoverLocations(google)
     if err != nil {
-        log.Errorf("Error updating businesses: %s", err)
+        log.Errorf("Error discovering locations: %s", err)
 
         lineSendErr := line.Base.SendText(userId, "驗證失敗。請確認您有勾選授權智引力訪問您的商家訊息再重試！若已勾選，請聯繫客服。很抱歉為您造成不便。")
         if lineSendErr != nil {
@@ -166,11 +191,27 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
 
         return events.LambdaFunctionURLResponse{
             StatusCode: 500,
-            Body:       `{"error": "Error updating businesses"}`,
+            Body:       `{"error": "Error discovering locations"}`,
         }, err
     }
 
-    user, err := updateUser(userId, businesses, businessAccountId, userPtr, userDao, google, line)
+    user, googleMetadata, err := updateUser(userId, businessAccountIds, userPtr, userDao, googleAccountDao, google)
+    if err == nil && user != nil && !stringUtil.IsEmptyString(google.Token.RefreshToken) {
+        // the token of the user is refreshed by googleTokenHealthHandler from now on
+        err = dao.NewGoogleTokenDao(dynamodb.NewFromConfig(awsConfig), log).MarkTokenStored(ctx, userId, time.Now())
+    }
+    if err == nil {
+        selection := model.LocationSelection{
+            UserId:             userId,
+            Locations:          locations,
+            BusinessAccountIds: businessAccountIds,
+            UpdatedAt:          time.Now(),
+        }
+        if user == nil {
+            selection.KeepGoogle(googleMetadata)
+        }
+        err = dao.NewLocationSelectionDao(dynamodb.NewFromConfig(awsConfig), log).PutLocationSelection(ctx, selection)
+    }
     if err != nil {
         log.Errorf("Error updating user: %s", err)
 
@@ -188,16 +229,33 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
         }, err
     }
 
+    var connectedBusinessIds []bid.BusinessId
+    slackUser := model2.User{UserId: userId}
+    if user != nil {
+        connectedBusinessIds = user.BusinessIds
+        updateBusinessAccountIds(userId, locations, connectedBusinessIds, googleAccountDao)
+        slackUser = *user
+    } else if lineGetUserResp, err := line.Base.GetUser(userId); err == nil {
+        slackUser.LineUsername = lineGetUserResp.DisplayName
+    }
+
     // ----------------
-    // Notify Slack channel of new business creation
+    // Notify Slack channel of the discovered businesses
     // ----------------
-    err = slackUtil.NewSlack(log, stage, secrets.SlackToken, secrets.NewUserSlackBotChannelId).SendNewUserOauthCompletionMessage(user, businesses)
+    var discoveredBusinesses []model2.Business
+    for _, location := range locations {
+        discoveredBusinesses = append(discoveredBusinesses, model2.Business{BusinessId: bid.BusinessId(location.BusinessId), BusinessName: location.Title})
+    }
+    err = slackUtil.NewSlack(log, stage, secrets.SlackToken, secrets.NewUserSlackBotChannelId).SendNewUserOauthCompletionMessage(slackUser, discoveredBusinesses)
     if err != nil {
         log.Errorf("Error sending Slack message: %s", err)
         metric.EmitLambdaMetric(enum4.Metric5xxError, enum2.HandlerNameAuthHandler.String(), 1)
     }
 
-    err = line.Base.SendText(userId, "驗證成功。可以開始使用啦！")
+    err = line.Base.SendText(userId, "驗證成功。請選擇要連結的商家，您會收到已連結商家的新評論通知。")
+    if err == nil {
+        err = line.SendLocationSelection(userId, locations, connectedBusinessIds)
+    }
     if err != nil {
         log.Errorf("Error sending LINE message to '%s': %s", userId, err)
         return events.LambdaFunctionURLResponse{
@@ -217,6 +275,61 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
     }, nil
 }
 
+// verifyState returns the state of the state parameter.
+// Returns exception.InvalidOAuthStateException if the state is malformed, tampered with or expired.
+// The user of an expired state is sent a new auth request, as the user ID of a signed state can be trusted.
+func verifyState(stateParam string, stateSigner *oauthState.Signer, line *lineUtil.LineUtil) (oauthState.State, error) {
+    state, err := stateSigner.Verify(stateParam)
+    var invalidOAuthStateException exception2.InvalidOAuthStateException
+    if errors.As(err, &invalidOAuthStateException) && invalidOAuthStateException.Expired {
+        newState, signErr := stateSigner.Sign(state.UserId)
+        if signErr == nil {
+            signErr = line.SendAuthRequest(state.UserId, newState, authRedirectUrl)
+        }
+        if signErr != nil {
+            log.Errorf("Error sending new auth request to '%s' with expired state: %s", state.UserId, signErr)
+            metric.EmitLambdaMetric(enum4.Metric5xxError, enum2.HandlerNameAuthHandler.String(), 1)
+        }
+        return oauthState.State{}, err
+    }
+    if err != nil {
+        return oauthState.State{}, err
+    }
+    return state, nil
+}
+
+// useState records the verified state as used.
+// Returns exception.InvalidOAuthStateException if the state is already used.
+func useState(ctx context.Context, state oauthState.State, oauthStateNonceDao *dao.OAuthStateNonceDao) error {
+    unused, err := oauthStateNonceDao.UseNonce(ctx, state.Nonce, state.UserId, state.ExpireAt)
+    if err != nil {
+        return err
+    }
+    if !unused {
+        return exception2.NewInvalidOAuthStateException(fmt.Sprintf("state of user '%s' already used", state.UserId), false)
+    }
+    return nil
+}
+
+// stateRejectedResponse returns the response to the callback whose state failed verifyState or useState
+func stateRejectedResponse(err error) (events.LambdaFunctionURLResponse, error) {
+    var invalidOAuthStateException exception2.InvalidOAuthStateException
+    if errors.As(err, &invalidOAuthStateException) {
+        log.Warnf("Rejected Google OAUTH callback: %v", err)
+        metric.EmitMetricWithNamespace(enum2.MetricOAuthStateRejected.String(), 1.0, util.AuthMetricNamespace)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 400,
+            Body:       `{"error": "Invalid state parameter from Google OAUTH response"}`,
+        }, nil
+    }
+
+    log.Errorf("Error verifying state parameter: %s", err)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 500,
+        Body:       `{"error": "Error verifying state parameter"}`,
+    }, err
+}
+
 func buildUpdateTokenAttributeActions(token oauth2.Token) ([]dbModel.AttributeAction, error) {
     accessTokenAction, err := dbModel.NewAttributeAction(enum.ActionUpdate, "google.accessToken", token.AccessToken)
     if err != nil {
@@ -235,149 +348,138 @@ func buildUpdateTokenAttributeActions(token oauth2.Token) ([]dbModel.AttributeAc
     return []dbModel.AttributeAction{accessTokenAction, accessTokenExpireAtAction, refreshTokenAction}, nil
 }
 
-// TODO: [INT-91] Remove backfill logic once all users have been backfilled
-// backfillBusinessAttributesFromUser in-place backfills business attributes from user
-func backfillBusinessAttributesFromUser(business model2.Business, user model2.User) {
-    business.BusinessDescription = user.BusinessDescription
-    business.Keywords = user.Keywords
-    if user.KeywordEnabled == nil {
-        business.KeywordEnabled = false
-    } else {
-        business.KeywordEnabled = *user.KeywordEnabled
-    }
-    business.QuickReplyMessage = user.QuickReplyMessage
-    if user.AutoQuickReplyEnabled == nil {
-        business.AutoQuickReplyEnabled = false
-    } else {
-        business.AutoQuickReplyEnabled = *user.AutoQuickReplyEnabled
-    }
-}
-
-// updateBusinesses updates businesses and returns the updated businesses and business account ID
-func updateBusinesses(
-    userId string,
-    user *model2.User, // TODO: [INT-91] Remove backfill logic once all legacy users have completed OAUTH
-    businessDao *ddbDao.BusinessDao,
-    google *googleUtil.GoogleClient,
-) ([]model2.Business, string, error) {
+// discoverLocations lists the open locations under all Google business accounts of the user.
+// Returns the locations and the IDs of the business accounts with open locations.
+func discoverLocations(google *googleUtil.GoogleClient) ([]model.DiscoveredLocation, []string, error) {
     // Google businesses have two portions: business accountID and business locationID
 
     accounts, err := google.ListBusinessAccounts()
     if err != nil {
-        return []model2.Business{}, "", err
+        return nil, nil, err
     }
-    var businessAccount mybusinessaccountmanagement.Account
-    switch len(accounts) {
-    case 0:
+    if len(accounts) == 0 {
         log.Warn("User has no Google business accounts")
-        return []model2.Business{}, "", errors.New("user has no Google business accounts")
-    case 1:
-        businessAccount = accounts[0]
-    default:
-        log.Warn("User has multiple Google business accounts. Using the first one: ", jsonUtil.AnyToJson(accounts))
+        return nil, nil, errors.New("user has no Google business accounts")
+    }
+    if len(accounts) > 1 {
+        log.Info("User has multiple Google business accounts: ", jsonUtil.AnyToJson(accounts))
         metric.EmitMetricWithNamespace(enum2.MetricMultipleBusinessAccounts.String(), 1.0, util.AuthMetricNamespace)
-        businessAccount = accounts[0]
     }
 
-    businessLocations, err := google.ListBusinessLocations(businessAccount)
+    locations, businessAccountIds, err := listOpenBusinessLocations(accounts, google)
     if err != nil {
-        return []model2.Business{}, "", err
+        return nil, businessAccountIds, err
     }
-
-    businessAccountNameSlice := strings.Split(businessAccount.Name, "/")
-    if len(businessAccountNameSlice) != 2 {
-        log.Errorf("Error parsing business account ID %s", businessAccount.Name)
-        return []model2.Business{}, "", errors.New("error parsing business account name")
-    }
-    businessAccountId := businessAccountNameSlice[1]
-
-    businessLocations = googleUtil.FilterOpenBusinessLocations(businessLocations)
-    if len(businessLocations) == 0 {
-        log.Error("User has no open Google business locations under account ", businessAccountId)
-        return []model2.Business{}, businessAccountId, errors.New("user has no open Google business locations")
-    }
-    if len(businessLocations) > 1 {
+    if len(locations) > 1 {
         log.Info("User has multiple open Google business locations.")
         metric.EmitMetricWithNamespace(enum2.MetricMultipleBusinessLocations.String(), 1.0, util.AuthMetricNamespace)
     }
-    log.Info("User's open Google business locations are: ", jsonUtil.AnyToJson(businessLocations))
+    log.Info("User's open Google business locations are: ", jsonUtil.AnyToJson(locations))
 
-    var businesses []model2.Business
-    for _, location := range businessLocations {
-        businessLocationSlice := strings.Split(location.Name, "/")
-        if len(businessLocationSlice) != 2 {
-            log.Errorf("Error parsing business location ID %s", location.Name)
-            return []model2.Business{}, businessAccountId, errors.New("error parsing business location name")
-        }
+    return locations, businessAccountIds, nil
+}
 
-        businessId, err := bid.NewBusinessId(businessLocationSlice[1])
-        if err != nil {
-            log.Errorf("Error creating businessId from business location.Name %s: %s", location.Name, err)
-            return []model2.Business{}, businessAccountId, err
+// listOpenBusinessLocations lists the open locations under all the business accounts, skipping accounts whose locations cannot be listed.
+// A location listed under more than one account is kept under the first account.
+// Returns the locations and the IDs of the accounts with open locations, in the order of the accounts.
+func listOpenBusinessLocations(accounts []mybusinessaccountmanagement.Account, google *googleUtil.GoogleClient) ([]model.DiscoveredLocation, []string, error) {
+    var locations []model.DiscoveredLocation
+    var businessAccountIds []string
+    seen := map[string]bool{}
+    var errs []error
+    for _, businessAccount := range accounts {
+        businessAccountNameSlice := strings.Split(businessAccount.Name, "/")
+        if len(businessAccountNameSlice) != 2 {
+            log.Errorf("Error parsing business account ID %s", businessAccount.Name)
+            errs = append(errs, errors.New("error parsing business account name"))
+            continue
         }
+        businessAccountId := businessAccountNameSlice[1]
 
-        businessPtr, err := businessDao.GetBusiness(businessId)
+        businessLocations, err := google.ListBusinessLocations(businessAccount)
         if err != nil {
-            log.Errorf("Error retrieving business %s: %s", businessId, err)
-            return []model2.Business{}, businessAccountId, err
+            log.Errorf("Error listing business locations under account %s: %s", businessAccountId, err)
+            errs = append(errs, err)
+            continue
         }
 
-        var business model2.Business
-        if businessPtr == nil {
-            log.Infof("Business '%s' does not exist. Creating new business.", businessId)
-
-            // create business
-            business = model2.NewBusiness(
-                businessId,
-                location.Title,
-                userId,
-            )
-
-            // TODO: [INT-91] Remove backfill logic once all legacy users have completed OAUTH
-            if user != nil {
-                backfillBusinessAttributesFromUser(business, *user)
+        businessLocations = googleUtil.FilterOpenBusinessLocations(businessLocations)
+        if len(businessLocations) == 0 {
+            log.Info("User has no open Google business locations under account ", businessAccountId)
+            continue
+        }
+        businessAccountIds = append(businessAccountIds, businessAccountId)
+        for _, location := range businessLocations {
+            // location name is in the format of "locations/BUSINESS_ID"
+            businessLocationSlice := strings.Split(location.Name, "/")
+            if len(businessLocationSlice) != 2 {
+                log.Errorf("Error parsing business location ID %s", location.Name)
+                errs = append(errs, errors.New("error parsing business location name"))
+                continue
             }
-
-            err = businessDao.CreateBusiness(business)
+            businessId, err := bid.NewBusinessId(businessLocationSlice[1])
             if err != nil {
-                log.Errorf("Error creating business object %v: %v", business, err)
-                return businesses, businessAccountId, err
+                log.Errorf("Error creating businessId from business location name %s: %s", location.Name, err)
+                errs = append(errs, err)
+                continue
             }
-        } else {
-            business = *businessPtr
-            if !stringUtil.StringInSlice(userId, business.UserIds) {
-                log.Infof("Business '%s' is unaware of '%s' yet. Creating association.", businessId, userId)
-
-                // add user to business and update business Google token
-                userIdAppendAction, err := dbModel.NewAttributeAction(enum.ActionAppendStringSet, "userIds", []string{userId})
-                if err != nil {
-                    log.Errorf("Error building user id append action: %s", err)
-                    return businesses, businessAccountId, err
-                }
-
-                business, err = businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{userIdAppendAction}, userId)
+
+            if seen[businessId.String()] {
+                continue
             }
+            seen[businessId.String()] = true
+            locations = append(locations, model.DiscoveredLocation{
+                BusinessId: businessId.String(),
+                Title:      location.Title,
+                AccountId:  businessAccountId,
+            })
+        }
+    }
+
+    if len(locations) == 0 {
+        log.Error("User has no open Google business locations under any account")
+        if len(errs) > 0 {
+            return nil, businessAccountIds, errors.Join(errs...)
         }
-        businesses = append(businesses, business)
+        return nil, businessAccountIds, errors.New("user has no open Google business locations")
     }
+    return locations, businessAccountIds, nil
+}
 
-    return businesses, businessAccountId, nil
+// updateBusinessAccountIds records the Google business accounts the connected businesses of the user are under, as listed in this OAuth.
+// Failing to record them does not fail the OAuth, as the businesses keep the accounts recorded earlier.
+func updateBusinessAccountIds(userId string, locations []model.DiscoveredLocation, connectedBusinessIds []bid.BusinessId, googleAccountDao *dao.GoogleAccountDao) {
+    for _, location := range locations {
+        if !stringUtil.StringInSlice(location.BusinessId, bid.BusinessIdsToStringSlice(connectedBusinessIds)) {
+            continue
+        }
+        businessId, err := bid.NewBusinessId(location.BusinessId)
+        if err != nil {
+            log.Errorf("Error creating businessId %s: %s", location.BusinessId, err)
+            continue
+        }
+        _, err = googleAccountDao.UpdateBusinessAccountId(businessId, location.AccountId, userId)
+        if err != nil {
+            log.Errorf("Error updating Google business account ID of business '%s'. Proceeding: %s", businessId, err)
+        }
+    }
 }
 
+// updateUser updates the Google metadata of the user. The businesses of the user are connected by the user in LINE.
+// Returns nil if the user does not exist, with the Google metadata to create the user with on connecting the first location.
 func updateUser(
     userId string,
-    businesses []model2.Business,
-    businessAccountId string,
+    businessAccountIds []string,
     userPtr *model2.User,
     userDao *ddbDao.UserDao,
+    googleAccountDao *dao.GoogleAccountDao,
     google *googleUtil.GoogleClient,
-    line *lineUtil.LineUtil,
-) (model2.User, error) {
+) (*model2.User, model2.Google, error) {
     // get user info from Google
     googleUserInfo, err := google.GetGoogleUserInfo()
     if err != nil {
         log.Errorf("Error retrieving Google user info: %s", err)
-        return model2.User{}, err
+        return nil, model2.Google{}, err
     }
 
     log.Debug("Google user info: ", jsonUtil.AnyToJson(googleUserInfo))
@@ -391,108 +493,66 @@ func updateUser(
         Email:               googleUserInfo.Email,
         ImageUrl:            googleUserInfo.Picture,
         Locale:              googleUserInfo.Locale,
-        BusinessAccountId:   businessAccountId,
+        BusinessAccountId:   businessAccountIds[0],
     }
 
-    // extract business IDs from businesses
-    var businessIds []bid.BusinessId
-    for _, business := range businesses {
-        businessIds = append(businessIds, business.BusinessId)
+    if userPtr == nil {
+        log.Infof("User '%s' does not exist. The user is created on connecting the first location.", userId)
+        return nil, googleMetadata, nil
     }
 
-    var user model2.User
-    if userPtr == nil {
-        log.Infof("User '%s' does not exist. Creating new user.", userId)
+    log.Infof("User %s already exists. Updating Google token", userId)
 
-        lineGetUserResp, err := line.Base.GetUser(userId)
-        if err != nil {
-            log.Errorf("Error retrieving user %s from LINE: %s", userId, err)
-            return model2.User{}, err
-        }
+    user := *userPtr
 
-        user, err = model2.NewUser(userId, businessIds, lineGetUserResp, googleMetadata)
+    // build google metadata update action
+    var actions []dbModel.AttributeAction
+    // TODO: [INT-91] Remove backfill logic once all users have completed googleMetadata migration
+    if stringUtil.IsEmptyString(userPtr.Google.Id) {
+        log.Infof("User %s does not have Google metadata. Creating.", userId)
+        action, err := dbModel.NewAttributeAction(enum.ActionUpdate, "google", googleMetadata)
         if err != nil {
-            log.Errorf("Error creating new user object: %s", err)
-            return model2.User{}, err
+            log.Errorf("Error building update Google attribute action: %s", err)
+            return nil, googleMetadata, err
         }
-
-        err = userDao.CreateUser(user)
+        actions = []dbModel.AttributeAction{action}
+    } else {
+        actions, err = buildUpdateTokenAttributeActions(google.Token)
         if err != nil {
-            log.Errorf("Error creating user %v: %v", user, err)
-
-            var userAlreadyExistException *exception.UserAlreadyExistException
-            if errors.As(err, &userAlreadyExistException) {
-                log.Errorf("User %s already exists. Concurrency issue?", userId)
-            }
-            return user, err
+            log.Errorf("Error building update Google attribute action: %s", err)
+            return nil, googleMetadata, err
         }
-    } else {
-        log.Infof("User %s already exists. Updating Google token and adding missing businessId associations", userId)
-
-        user = *userPtr
-
-        // build google metadata update action
-        var actions []dbModel.AttributeAction
-        var err error
-        // TODO: [INT-91] Remove backfill logic once all users have completed googleMetadata migration
-        if stringUtil.IsEmptyString(userPtr.Google.Id) {
-            log.Infof("User %s does not have Google metadata. Creating.", userId)
-            action, err := dbModel.NewAttributeAction(enum.ActionUpdate, "google", googleMetadata)
-            if err != nil {
-                log.Errorf("Error building update Google attribute action: %s", err)
-                return model2.User{}, err
-            }
-            actions = []dbModel.AttributeAction{action}
-        } else {
-            actions, err = buildUpdateTokenAttributeActions(google.Token)
-            if err != nil {
-                log.Errorf("Error building update Google attribute action: %s", err)
-                return model2.User{}, err
-            }
-            updateBusinessAccountIdAction, err := dbModel.NewAttributeAction(enum.ActionUpdate, "google.businessAccountId", businessAccountId)
-            if err != nil {
-                log.Errorf("Error building update business account ID attribute action: %s", err)
-                return model2.User{}, err
-            }
-            actions = append(actions, updateBusinessAccountIdAction)
+        updateBusinessAccountIdAction, err := dbModel.NewAttributeAction(enum.ActionUpdate, "google.businessAccountId", businessAccountIds[0])
+        if err != nil {
+            log.Errorf("Error building update business account ID attribute action: %s", err)
+            return nil, googleMetadata, err
         }
+        actions = append(actions, updateBusinessAccountIdAction)
+    }
 
-        // find businessIds not in user's businessIds and add them
-        var businessIdsMissingInUser []bid.BusinessId
-        for _, businessId := range businessIds {
-            if !stringUtil.StringInSlice(businessId.String(), bid.BusinessIdsToStringSlice(user.BusinessIds)) {
-                businessIdsMissingInUser = append(businessIdsMissingInUser, businessId)
-            }
-        }
-        if len(businessIdsMissingInUser) > 0 {
-            for _, businessId := range businessIdsMissingInUser {
-                action, err := dbModel.NewAttributeAction(enum.ActionAppendStringSet, "businessIds", []string{businessId.String()})
-                if err != nil {
-                    log.Errorf("Error building businessIds append action: %s", err)
-                    return user, err
-                }
-                actions = append(actions, action)
-            }
+    // repair active businessID if it is missing
+    if stringUtil.IsEmptyString(user.ActiveBusinessId.String()) && len(user.BusinessIds) > 0 {
+        log.Infof("User %s does not have active businessId. Repairing.", userId)
+        action, err := dbModel.NewAttributeAction(enum.ActionUpdate, "activeBusinessId", user.BusinessIds[0].String())
+        if err != nil {
+            log.Errorf("Error building activeBusinessId update action: %s", err)
+            return nil, googleMetadata, err
         }
+        actions = append(actions, action)
+    }
 
-        // repair active businessID if it is missing
-        if stringUtil.IsEmptyString(user.ActiveBusinessId.String()) {
-            log.Infof("User %s does not have active businessId. Repairing.", userId)
-            action, err := dbModel.NewAttributeAction(enum.ActionUpdate, "activeBusinessId", businessIds[0].String())
-            if err != nil {
-                log.Errorf("Error building activeBusinessId update action: %s", err)
-                return user, err
-            }
-            actions = append(actions, action)
-        }
+    // update user
+    _, err = userDao.UpdateAttributes(userId, actions)
+    if err != nil {
+        log.Errorf("Error updating user %s: %s", userId, err)
+        return nil, googleMetadata, err
+    }
 
-        // update user
-        user, err = userDao.UpdateAttributes(userId, actions)
-        if err != nil {
-            log.Errorf("Error updating user %s: %s", userId, err)
-            return user, err
-        }
+    user, err = googleAccountDao.UpdateUserAccountIds(userId, businessAccountIds)
+    if err != nil {
+        log.Errorf("Error updating Google business account IDs of user %s: %s", userId, err)
+        return nil, googleMetadata, err
     }
 
-    return user, nil
+    return &user, googleMetadata, nil
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/aws"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/logger"
+    "github.com/IntelliLead/CoreCommonUtil/middleware"
+    "github.com/IntelliLead/CoreCommonUtil/secretUtil"
+    "github.com/IntelliLead/CoreCommonUtil/ssmUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    exception2 "github.com/IntelliLead/CoreDataAccess/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/googleNotification"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyGuard"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/reviewIntake"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/aws/aws-lambda-go/lambda"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "os"
+)
+
+var (
+    log                     = logger.NewLogger()
+    awsConfig               = aws.DefaultAwsConfig()
+    secrets                 = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
+    pubSubVerificationToken = ssmUtil.NewSsm(awsConfig, log).GetSsmParameterValue(os.Getenv(util.PubSubVerificationTokenParameterNameEnvKey))
+    // reads its SSM parameters once per Lambda container
+    googleTokenHealth, googleTokenHealthErr = tokenHealth.NewTokenHealthFromEnv(
+        awsConfig,
+        businessProfileUtil.NewBusinessProfile(secrets.GoogleClientID, secrets.GoogleClientSecret, log),
+        ddbDao.NewUserDao(dynamodb.NewFromConfig(awsConfig), log),
+        dao.NewGoogleTokenDao(dynamodb.NewFromConfig(awsConfig), log),
+        lineUtil.NewLineUtil(secrets.LineChannelSecret, secrets.LineChannelAccessToken, log),
+        log)
+)
+
+func main() {
+    lambda.Start(middleware.MetricMiddleware(enum2.HandlerNameGoogleNotificationHandler.String(), handleRequest))
+}
+
+// handleRequest handles Google Business Profile notifications pushed by Pub/Sub.
+// Pub/Sub redelivers the notification unless it is acknowledged with a 2xx response,
+// so notifications that can never be processed are acknowledged with 200 and only errors worth retrying return 500.
+func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+    stage := os.Getenv(constant.StageEnvKey)
+    log.Infof("Received request in %s: %s", stage, jsonUtil.AnyToJson(request))
+
+    // --------------------
+    // verify and parse request
+    // --------------------
+    if !googleNotification.VerifyPushToken(pubSubVerificationToken, request.QueryStringParameters["token"]) {
+        log.Errorf("Invalid Pub/Sub push token")
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Invalid token"}`, StatusCode: 401}, nil
+    }
+
+    pushRequest, notification, err := googleNotification.ParsePushRequest(request.Body)
+    if err != nil {
+        log.Error("Error parsing Pub/Sub push request. Acknowledging to stop redelivery: ", err)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored malformed notification"}`, StatusCode: 200}, nil
+    }
+    log.Infof("Received notification '%s' from subscription '%s': %s", pushRequest.Message.MessageId, pushRequest.Subscription, jsonUtil.AnyToJson(notification))
+
+    switch notification.Type {
+    case googleNotification.NotificationTypeNewReview, googleNotification.NotificationTypeUpdatedReview:
+    default:
+        log.Infof("Ignoring notification of type '%s'", notification.Type)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored notification type"}`, StatusCode: 200}, nil
+    }
+
+    businessId, err := reviewIntake.BusinessIdOf(notification.Review)
+    if err != nil {
+        log.Errorf("Error parsing business ID from review '%s'. Acknowledging to stop redelivery: %v", notification.Review, err)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored notification without review"}`, StatusCode: 200}, nil
+    }
+
+    // --------------------
+    // initialize resources
+    // --------------------
+    line := lineUtil.NewLineUtil(secrets.LineChannelSecret, secrets.LineChannelAccessToken, log)
+    businessDao := ddbDao.NewBusinessDao(dynamodb.NewFromConfig(awsConfig), log)
+    userDao := ddbDao.NewUserDao(dynamodb.NewFromConfig(awsConfig), log)
+    reviewDao := ddbDao.NewReviewDao(dynamodb.NewFromConfig(awsConfig), log)
+
+    businessProfile := businessProfileUtil.NewBusinessProfile(secrets.GoogleClientID, secrets.GoogleClientSecret, log)
+    if googleTokenHealthErr != nil {
+        log.Error("Error creating token health: ", googleTokenHealthErr)
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Error creating token health"}`, StatusCode: 500}, nil
+    }
+    publisher, err := replyPublisher.NewReplyPublisher(businessDao, userDao, businessProfile, googleTokenHealth, log)
+    if err != nil {
+        log.Error("Error creating reply publisher: ", err)
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Error creating reply publisher"}`, StatusCode: 500}, nil
+    }
+
+    // --------------------
+    // fetch review with the stored Google credentials of the business
+    // --------------------
+    businessPtr, err := businessDao.GetBusiness(businessId)
+    if err != nil {
+        log.Errorf("Error getting business '%s': %v", businessId, err)
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Error getting business"}`, StatusCode: 500}, nil
+    }
+    if businessPtr == nil {
+        log.Errorf("Business '%s' of review '%s' does not exist. Acknowledging to stop redelivery", businessId, notification.Review)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored notification of unknown business"}`, StatusCode: 200}, nil
+    }
+
+    credentialOwner, err := businessProfileUtil.FindCredentialOwner(*businessPtr, "", userDao)
+    if err != nil {
+        if errors.Is(err, businessProfileUtil.ErrNoGoogleCredentials) {
+            log.Errorf("Business '%s' has no Google credentials to fetch review '%s'. Acknowledging to stop redelivery", businessId, notification.Review)
+            return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored notification of business without Google credentials"}`, StatusCode: 200}, nil
+        }
+        log.Errorf("Error finding Google credentials of business '%s': %v", businessId, err)
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Error finding Google credentials of business"}`, StatusCode: 500}, nil
+    }
+
+    ddbClient := dynamodb.NewFromConfig(awsConfig)
+    vendorReviewIdDao := dao.NewVendorReviewIdDao(ddbClient, log)
+    reviewEventDao := dao.NewReviewEventDao(ddbClient, log)
+    autoReplyRuleDao := dao.NewAutoReplyRuleDao(ddbClient, businessDao, log)
+    aiReplyDraftDao := dao.NewAiReplyDraftDao(ddbClient, log)
+    ai, err := aiUtil.NewAiFromEnv(awsConfig, secrets.GptApiKey, dao.NewLLMConfigDao(ddbClient, log), dao.NewReplyHistoryDao(ddbClient, log), autoReplyRuleDao, dao.NewAiUsageDao(ddbClient, log), dao.NewAiReplyCacheDao(ddbClient, log), log)
+    if err != nil {
+        log.Error("Error creating AI: ", err)
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Error creating AI"}`, StatusCode: 500}, nil
+    }
+    // check replies before publishing them
+    publisher = replyPublisher.NewGuardedPublisher(publisher, replyGuard.NewReplyGuardFromEnv(businessDao, dao.NewReplyGuardDao(ddbClient, businessDao, log), ai, log), log)
+    intake := reviewIntake.NewReviewIntake(businessDao, userDao, reviewDao, vendorReviewIdDao, reviewEventDao, autoReplyRuleDao, aiReplyDraftDao, ai, line, publisher, log)
+
+    token := businessProfileUtil.TokenOf(credentialOwner)
+    googleReview, usedToken, err := businessProfile.GetReview(ctx, token, notification.Review)
+    businessProfileUtil.PersistRefreshedToken(credentialOwner, token, usedToken, userDao, log)
+    var result reviewIntake.Result
+    switch {
+    case errors.Is(err, businessProfileUtil.ErrNotFound):
+        // --------------------
+        // the review was removed
+        // --------------------
+        log.Infof("Review '%s' no longer exists. Marking it removed", notification.Review)
+        result, err = intake.RemoveReview(ctx, notification.Review)
+    case err != nil:
+        log.Errorf("Error fetching review '%s' with credentials of user '%s': %v", notification.Review, credentialOwner.UserId, err)
+        // the redelivered notification is fetched with the credentials of another user of the business, if any
+        googleTokenHealth.CheckError(ctx, credentialOwner, err)
+        return events.LambdaFunctionURLResponse{Body: `{"error": "Error fetching review"}`, StatusCode: 500}, nil
+    default:
+        event, convertErr := reviewIntake.NewReviewEventFromGoogleReview(googleReview)
+        if convertErr != nil {
+            log.Errorf("Error converting review '%s' to review event. Acknowledging to stop redelivery: %v", notification.Review, convertErr)
+            return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored unsupported review"}`, StatusCode: 200}, nil
+        }
+
+        // --------------------
+        // store, notify and auto reply
+        // --------------------
+        result, err = intake.ProcessReviewEvent(ctx, event)
+    }
+    if err != nil {
+        var invalidReviewException *exception.InvalidReviewException
+        var businessNotFoundException *exception.BusinessNotFoundException
+        var reviewAlreadyExistException exception2.ReviewAlreadyExistException
+        switch {
+        case errors.As(err, &invalidReviewException), errors.As(err, &businessNotFoundException):
+            log.Errorf("Review '%s' cannot be processed. Acknowledging to stop redelivery: %v", notification.Review, err)
+            return events.LambdaFunctionURLResponse{Body: `{"message": "Ignored invalid review"}`, StatusCode: 200}, nil
+        case errors.As(err, &reviewAlreadyExistException):
+            log.Infof("Review '%s' was stored concurrently. Acknowledging notification of type '%s'", notification.Review, notification.Type)
+            return events.LambdaFunctionURLResponse{Body: `{"message": "Review already exists"}`, StatusCode: 200}, nil
+        default:
+            log.Errorf("Error processing review '%s': %v", notification.Review, err)
+            return events.LambdaFunctionURLResponse{Body: fmt.Sprintf(`{"error": "Error processing review: %s"}`, err), StatusCode: 500}, nil
+        }
+    }
+
+    log.Infof("Successfully processed notification '%s' of review '%s': %s", pushRequest.Message.MessageId, notification.Review, result.Outcome)
+    return events.LambdaFunctionURLResponse{Body: `{"message": "OK"}`, StatusCode: 200}, nil
+}
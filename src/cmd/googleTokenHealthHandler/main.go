@@ -0,0 +1,129 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/aws"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/logger"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    enum3 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
+    "github.com/IntelliLead/CoreCommonUtil/secretUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/aws/aws-lambda-go/lambda"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "os"
+)
+
+var (
+    log       = logger.NewLogger()
+    awsConfig = aws.DefaultAwsConfig()
+    secrets   = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
+)
+
+// Runs as a scheduled Lambda, or as a local CLI when not in Lambda, e.g.,
+//
+//	STAGE=alpha go run main.go
+//	STAGE=alpha go run main.go -markStoredTokens
+func main() {
+    if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+        runCli()
+        return
+    }
+    lambda.Start(handleRequest)
+}
+
+func runCli() {
+    markStoredTokens := flag.Bool("markStoredTokens", false, "mark the users whose Google token was stored before they were indexed instead of refreshing")
+    flag.Parse()
+
+    if *markStoredTokens {
+        marked, err := dao.NewGoogleTokenDao(dynamodb.NewFromConfig(awsConfig), log).MarkStoredTokens(context.Background())
+        log.Infof("Marked the Google tokens of %d users", marked)
+        if err != nil {
+            log.Fatalf("Marking stored Google tokens failed: %v", err)
+        }
+        return
+    }
+
+    err := refreshAll(context.Background())
+    if err != nil {
+        log.Fatalf("Refreshing Google tokens failed: %v", err)
+    }
+}
+
+func handleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+    log.Infof("Received scheduled event in %s: %s", os.Getenv(constant.StageEnvKey), jsonUtil.AnyToJson(event))
+
+    err := refreshAll(ctx)
+    if err != nil {
+        log.Errorf("Error refreshing Google tokens: %v", err)
+        metric.EmitLambdaMetric(enum3.Metric5xxError, enum2.HandlerNameGoogleTokenHealthHandler.String(), 1)
+        return err
+    }
+
+    log.Info("Successfully refreshed Google tokens")
+    return nil
+}
+
+// refreshAll refreshes the Google tokens of all users with one, so that revoked tokens are found before publishing a reply fails.
+// Revoked tokens are expected and do not fail the run. A failing user does not stop the others from being refreshed.
+func refreshAll(ctx context.Context) error {
+    line := lineUtil.NewLineUtil(secrets.LineChannelSecret, secrets.LineChannelAccessToken, log)
+
+    ddbClient := dynamodb.NewFromConfig(awsConfig)
+    userDao := ddbDao.NewUserDao(ddbClient, log)
+    googleTokenDao := dao.NewGoogleTokenDao(ddbClient, log)
+
+    businessProfile := businessProfileUtil.NewBusinessProfile(secrets.GoogleClientID, secrets.GoogleClientSecret, log)
+    googleTokenHealth, err := tokenHealth.NewTokenHealthFromEnv(awsConfig, businessProfile, userDao, googleTokenDao, line, log)
+    if err != nil {
+        log.Error("Error creating token health: ", err)
+        return err
+    }
+
+    userIds, err := googleTokenDao.ListUserIdsWithGoogleTokens(ctx)
+    if err != nil {
+        return err
+    }
+    log.Infof("Found %d users with Google tokens", len(userIds))
+
+    refreshed := 0
+    revoked := 0
+    var errs []error
+    for _, userId := range userIds {
+        userPtr, err := userDao.GetUser(userId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error getting user '%s': %w", userId, err))
+            continue
+        }
+        if userPtr == nil {
+            log.Warnf("User '%s' does not exist. Skipping.", userId)
+            continue
+        }
+
+        err = googleTokenHealth.Refresh(ctx, *userPtr)
+        if errors.Is(err, businessProfileUtil.ErrTokenRevoked) {
+            revoked++
+            // the revoked token is cleared even if the user could not be asked to complete OAuth again
+            continue
+        }
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error refreshing Google token of user '%s': %w", userId, err))
+            continue
+        }
+        refreshed++
+    }
+
+    log.Infof("Refreshed Google tokens of %d users. %d were revoked.", refreshed, revoked)
+    return errors.Join(errs...)
+}
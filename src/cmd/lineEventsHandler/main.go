@@ -12,12 +12,22 @@ import (
     "github.com/IntelliLead/CoreCommonUtil/secretUtil"
     "github.com/IntelliLead/CoreCommonUtil/ssmUtil"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor/dispatcher"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor/messageEvent"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor/postbackEvent"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/locationConnector"
     enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyGuard"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/slackUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/IntelliLead/ReviewHandlers/tst/data/lineEventsHandlerTestEvents/postback"
     "github.com/aws/aws-lambda-go/events"
     "github.com/aws/aws-lambda-go/lambda"
@@ -31,10 +41,11 @@ func main() {
 }
 
 var (
-    log             = logger.NewLogger()
-    cfg             = aws.DefaultAwsConfig()
-    authRedirectUrl = ssmUtil.NewSsm(cfg, log).GetSsmParameterValue(os.Getenv(constant.AuthRedirectUrlParameterNameEnvKey))
-    secrets         = secretUtil.NewSecretUtil(cfg, log).GetSecrets()
+    log              = logger.NewLogger()
+    cfg              = aws.DefaultAwsConfig()
+    authRedirectUrl  = ssmUtil.NewSsm(cfg, log).GetSsmParameterValue(os.Getenv(constant.AuthRedirectUrlParameterNameEnvKey))
+    oauthStateSecret = ssmUtil.NewSsm(cfg, log).GetSsmParameterValue(os.Getenv(util.OAuthStateSecretParameterNameEnvKey))
+    secrets          = secretUtil.NewSecretUtil(cfg, log).GetSecrets()
 )
 
 func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
@@ -68,9 +79,59 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
     businessDao := ddbDao.NewBusinessDao(dynamodb.NewFromConfig(cfg), log)
     userDao := ddbDao.NewUserDao(dynamodb.NewFromConfig(cfg), log)
     reviewDao := ddbDao.NewReviewDao(dynamodb.NewFromConfig(cfg), log)
+    autoReplyRuleDao := dao.NewAutoReplyRuleDao(dynamodb.NewFromConfig(cfg), businessDao, log)
+    aiReplyDraftDao := dao.NewAiReplyDraftDao(dynamodb.NewFromConfig(cfg), log)
+    aiReplyCandidateSetDao := dao.NewAiReplyCandidateSetDao(dynamodb.NewFromConfig(cfg), log)
+    replyGuardDao := dao.NewReplyGuardDao(dynamodb.NewFromConfig(cfg), businessDao, log)
+    businessUserDao := dao.NewBusinessUserDao(dynamodb.NewFromConfig(cfg), log)
+    locationSelectionDao := dao.NewLocationSelectionDao(dynamodb.NewFromConfig(cfg), log)
+    lineFollowDao := dao.NewLineFollowDao(dynamodb.NewFromConfig(cfg), log)
+    googleTokenDao := dao.NewGoogleTokenDao(dynamodb.NewFromConfig(cfg), log)
 
     // LINE
     line := lineUtil.NewLineUtil(secrets.LineChannelSecret, secrets.LineChannelAccessToken, log)
+    stateSigner, err := oauthState.NewSigner(oauthStateSecret)
+    if err != nil {
+        log.Error("Error creating OAuth state signer: ", err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to create OAuth state signer: %s"}`, err),
+        }, err
+    }
+    connector := locationConnector.NewLocationConnector(
+        businessDao,
+        userDao,
+        dao.NewGoogleAccountDao(dynamodb.NewFromConfig(cfg), businessDao, userDao, log),
+        businessUserDao,
+        locationSelectionDao,
+        googleTokenDao,
+        line,
+        log)
+
+    // Reply
+    businessProfile := businessProfileUtil.NewBusinessProfile(secrets.GoogleClientID, secrets.GoogleClientSecret, log)
+    googleTokenHealth := tokenHealth.NewTokenHealth(businessProfile, userDao, googleTokenDao, line, stateSigner, authRedirectUrl, log)
+    publisher, err := replyPublisher.NewReplyPublisher(businessDao, userDao, businessProfile, googleTokenHealth, log)
+    if err != nil {
+        log.Error("Error creating reply publisher: ", err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to create reply publisher: %s"}`, err),
+        }, err
+    }
+
+    // AI
+    ai, err := aiUtil.NewAiFromEnv(cfg, secrets.GptApiKey, dao.NewLLMConfigDao(dynamodb.NewFromConfig(cfg), log), dao.NewReplyHistoryDao(dynamodb.NewFromConfig(cfg), log), autoReplyRuleDao, dao.NewAiUsageDao(dynamodb.NewFromConfig(cfg), log), dao.NewAiReplyCacheDao(dynamodb.NewFromConfig(cfg), log), log)
+    if err != nil {
+        log.Error("Error creating AI: ", err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to create AI: %s"}`, err),
+        }, err
+    }
+
+    // check replies before publishing them
+    publisher = replyPublisher.NewGuardedPublisher(publisher, replyGuard.NewReplyGuardFromEnv(businessDao, replyGuardDao, ai, log), log)
 
     // --------------------
     // parse message to LINE events
@@ -102,40 +163,29 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
     // --------------------
     // process LINE events
     // --------------------
-    for _, event := range lineEvents {
-        log.Infof("Processing event: %s\n", jsonUtil.AnyToJson(event))
-
-        if lineUtil.IsEventFromUser(event) == false {
-            log.Info("Postback event is not from user. No action taken", jsonUtil.AnyToJson(event))
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 200,
-                Body:       `{"message": "Postback event is not from user. No action taken."}`,
-            }, nil
-        }
-        userId := event.Source.UserID
-
-        switch event.Type {
-        case linebot.EventTypeMessage:
-            log.Info("Received Message event")
-            return messageEvent.ProcessMessageEvent(event, userId, businessDao, userDao, reviewDao, line, log, authRedirectUrl)
-
-        case linebot.EventTypeFollow:
-            log.Info("Received Follow event")
+    eventDispatcher := dispatcher.NewDispatcher(log).
+        Register(linebot.EventTypeMessage, func(event *linebot.Event, userId string) (events.LambdaFunctionURLResponse, error) {
+            return messageEvent.ProcessMessageEvent(event, userId, businessDao, userDao, reviewDao, autoReplyRuleDao, replyGuardDao, connector, line, publisher, log, authRedirectUrl, stateSigner)
+        }).
+        Register(linebot.EventTypeFollow, func(event *linebot.Event, userId string) (events.LambdaFunctionURLResponse, error) {
             slack := slackUtil.NewSlack(log, stage, secrets.SlackToken, secrets.NewUserSlackBotChannelId)
-            return lineEventProcessor.ProcessFollowEvent(event, userDao, slack, line, log, authRedirectUrl)
-
-        case linebot.EventTypePostback:
-            log.Info("Received Postback event")
-            return postbackEvent.ProcessPostbackEvent(event, userId, businessDao, userDao, reviewDao, line, log, authRedirectUrl, secrets.GptApiKey)
-
-        default:
-            log.Info("Unhandled event type: ", event.Type)
-            if event.Type == linebot.EventTypePostback {
-                log.Info("Postback data: ", event.Postback.Data)
-            }
-        }
+            return lineEventProcessor.ProcessFollowEvent(event, businessDao, userDao, lineFollowDao, slack, line, log, authRedirectUrl, stateSigner)
+        }).
+        Register(linebot.EventTypeUnfollow, func(event *linebot.Event, userId string) (events.LambdaFunctionURLResponse, error) {
+            slack := slackUtil.NewSlack(log, stage, secrets.SlackToken, secrets.NewUserSlackBotChannelId)
+            return lineEventProcessor.ProcessUnfollowEvent(event, businessDao, userDao, businessUserDao, lineFollowDao, locationSelectionDao, slack, log)
+        }).
+        Register(linebot.EventTypePostback, func(event *linebot.Event, userId string) (events.LambdaFunctionURLResponse, error) {
+            return postbackEvent.ProcessPostbackEvent(event, userId, businessDao, userDao, reviewDao, autoReplyRuleDao, aiReplyDraftDao, aiReplyCandidateSetDao, connector, line, publisher, log, authRedirectUrl, stateSigner, ai)
+        })
+
+    batchResult := eventDispatcher.Dispatch(lineEvents)
+    if batchResult.FailedCount() > 0 {
+        log.Errorf("Failed to handle %d of %d LINE events: %v", batchResult.FailedCount(), len(batchResult.Results), batchResult.Err())
+    } else {
+        log.Infof("Successfully handled %d LINE events", len(batchResult.Results))
     }
 
-    // Return a 200 OK response
-    return events.LambdaFunctionURLResponse{Body: `{"message": "OK"}`, StatusCode: 200}, nil
+    // acknowledge the batch even if some events failed, so that LINE does not redeliver the events that succeeded
+    return batchResult.ToResponse(), nil
 }
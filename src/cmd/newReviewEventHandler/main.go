@@ -7,34 +7,42 @@ import (
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/aws"
     "github.com/IntelliLead/CoreCommonUtil/constant"
-    "github.com/IntelliLead/CoreCommonUtil/enum"
     "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
     "github.com/IntelliLead/CoreCommonUtil/logger"
     "github.com/IntelliLead/CoreCommonUtil/middleware"
     "github.com/IntelliLead/CoreCommonUtil/secretUtil"
-    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
-    "github.com/IntelliLead/CoreDataAccess/exception"
+    exception2 "github.com/IntelliLead/CoreDataAccess/exception"
     "github.com/IntelliLead/CoreDataAccess/model"
-    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
-    "github.com/IntelliLead/CoreDataAccess/model/type/rid"
-    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
     enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
-    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyGuard"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/reviewIntake"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
     "github.com/aws/aws-lambda-go/events"
     "github.com/aws/aws-lambda-go/lambda"
     "github.com/aws/aws-sdk-go-v2/config"
     "github.com/aws/aws-sdk-go-v2/service/dynamodb"
-    "github.com/google/uuid"
     "os"
-    "strings"
 )
 
 var (
     log       = logger.NewLogger()
     awsConfig = aws.DefaultAwsConfig()
     Secrets   = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
+    // reads its SSM parameters once per Lambda container
+    googleTokenHealth, googleTokenHealthErr = tokenHealth.NewTokenHealthFromEnv(
+        awsConfig,
+        businessProfileUtil.NewBusinessProfile(Secrets.GoogleClientID, Secrets.GoogleClientSecret, log),
+        ddbDao.NewUserDao(dynamodb.NewFromConfig(awsConfig), log),
+        dao.NewGoogleTokenDao(dynamodb.NewFromConfig(awsConfig), log),
+        lineUtil.NewLineUtil(Secrets.LineChannelSecret, Secrets.LineChannelAccessToken, log),
+        log)
 )
 
 func main() {
@@ -54,15 +62,6 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
         log.Error("Error parsing request body: ", err)
         return events.LambdaFunctionURLResponse{Body: `{"message": "Error parsing request body"}`, StatusCode: 400}, nil
     }
-    err = event.Validate()
-    if err != nil {
-        // Handle validation error
-        log.Error("Validation error when parsing request body: ", err)
-        return events.LambdaFunctionURLResponse{Body: `{"message": "Validation error when parsing request body"}`, StatusCode: 400}, nil
-    }
-
-    // Google may translate Mandarin to English. Remove the translation
-    removeGoogleTranslate(&event)
 
     // --------------------
     // initialize resources
@@ -79,154 +78,53 @@ func handleRequest(ctx context.Context, request events.LambdaFunctionURLRequest)
     userDao := ddbDao.NewUserDao(dynamodb.NewFromConfig(cfg), log)
     reviewDao := ddbDao.NewReviewDao(dynamodb.NewFromConfig(cfg), log)
 
-    /*
-       1. Extract business ID from event and get business from DB
-       2. If business is not found, user is not authed. Use userId as partition key to create new review
-    */
-    var business model.Business
-    // VendorReviewId is in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID"
-    businessId, err := bid.NewBusinessId(strings.Split(event.VendorReviewId, "/")[3])
-    if err != nil {
-        log.Errorf("Error parsing business ID from vendorReviewId '%s': %v", event.VendorReviewId, err)
-        return events.LambdaFunctionURLResponse{Body: `{"message": "Error parsing business ID from vendorReviewId"}`, StatusCode: 500}, nil
+    // Reply
+    businessProfile := businessProfileUtil.NewBusinessProfile(Secrets.GoogleClientID, Secrets.GoogleClientSecret, log)
+    if googleTokenHealthErr != nil {
+        log.Error("Error creating token health: ", googleTokenHealthErr)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Error creating token health"}`, StatusCode: 500}, nil
     }
-    businessPtr, err := businessDao.GetBusiness(businessId)
-    if err != nil {
-        log.Errorf("Error getting business '%s': %v", businessId, err)
-        return events.LambdaFunctionURLResponse{Body: `{"message": "Error getting business"}`, StatusCode: 500}, nil
-    }
-    if businessPtr == nil {
-        userId := ""
-        if !stringUtil.IsEmptyStringPtr(event.UserId) {
-            userId = *event.UserId
-        } else {
-            log.Errorf("User ID not found in event.")
-        }
-        log.Errorf("Business '%s' does not exist for user '%s'", businessId, userId)
-        return events.LambdaFunctionURLResponse{Body: `{"message": "No business ID in event."}`, StatusCode: 400}, nil
-    }
-
-    business = *businessPtr
-    var reviewId rid.ReviewId
-    reviewId, err = reviewDao.GenerateNextReviewID(business.BusinessId)
+    publisher, err := replyPublisher.NewReplyPublisher(businessDao, userDao, businessProfile, googleTokenHealth, log)
     if err != nil {
-        log.Errorf("Error getting next review id for business %s: %v", business.BusinessId, err)
-        return events.LambdaFunctionURLResponse{Body: `{"message": "Error getting next review id"}`, StatusCode: 500}, nil
+        log.Error("Error creating reply publisher: ", err)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Error creating reply publisher"}`, StatusCode: 500}, nil
     }
 
-    var review model.Review
-    review, err = model.NewReview(business.BusinessId.String(), reviewId, event)
-
-    // For local testing: generate a new vendor review ID to prevent duplication
-    if stage == enum.StageLocal.String() {
-        review.VendorReviewId = uuid.New().String()
-    }
-
-    log.Debug("Storing new review object from request: ", jsonUtil.AnyToJson(review))
-
     // --------------------
-    // store review
+    // store, notify and auto reply
     // --------------------
-    err = reviewDao.PutReview(review)
+    ddbClient := dynamodb.NewFromConfig(cfg)
+    vendorReviewIdDao := dao.NewVendorReviewIdDao(ddbClient, log)
+    reviewEventDao := dao.NewReviewEventDao(ddbClient, log)
+    autoReplyRuleDao := dao.NewAutoReplyRuleDao(ddbClient, businessDao, log)
+    aiReplyDraftDao := dao.NewAiReplyDraftDao(ddbClient, log)
+    ai, err := aiUtil.NewAiFromEnv(cfg, Secrets.GptApiKey, dao.NewLLMConfigDao(ddbClient, log), dao.NewReplyHistoryDao(ddbClient, log), autoReplyRuleDao, dao.NewAiUsageDao(ddbClient, log), dao.NewAiReplyCacheDao(ddbClient, log), log)
     if err != nil {
-        log.Error("Error creating review: ", err)
+        log.Error("Error creating AI: ", err)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Error creating AI"}`, StatusCode: 500}, nil
+    }
+    // check replies before publishing them
+    publisher = replyPublisher.NewGuardedPublisher(publisher, replyGuard.NewReplyGuardFromEnv(businessDao, dao.NewReplyGuardDao(ddbClient, businessDao, log), ai, log), log)
+    intake := reviewIntake.NewReviewIntake(businessDao, userDao, reviewDao, vendorReviewIdDao, reviewEventDao, autoReplyRuleDao, aiReplyDraftDao, ai, line, publisher, log)
+    result, err := intake.ProcessReviewEvent(ctx, event)
+    if err != nil {
+        log.Error("Error processing review event: ", err)
 
-        var reviewAlreadyExistException exception.ReviewAlreadyExistException
+        var invalidReviewException *exception.InvalidReviewException
+        var businessNotFoundException *exception.BusinessNotFoundException
+        var reviewAlreadyExistException exception2.ReviewAlreadyExistException
         switch {
+        case errors.As(err, &invalidReviewException):
+            return events.LambdaFunctionURLResponse{Body: `{"message": "Validation error when parsing request body"}`, StatusCode: 400}, nil
+        case errors.As(err, &businessNotFoundException):
+            return events.LambdaFunctionURLResponse{Body: `{"message": "No business ID in event."}`, StatusCode: 400}, nil
         case errors.As(err, &reviewAlreadyExistException):
             return events.LambdaFunctionURLResponse{Body: `{"message": "Review already exists"}`, StatusCode: 400}, nil
         default:
-            return events.LambdaFunctionURLResponse{Body: `{"message": "Error creating review"}`, StatusCode: 500}, nil
-        }
-    }
-
-    // --------------------------------
-    // forward to LINE by calling LINE messaging API
-    // --------------------------------
-    if businessPtr != nil {
-        business = *businessPtr
-        err = line.SendNewReview(review, business, userDao)
-        if err != nil {
-            log.Errorf("Error sending new review to users of business '%s': %s", business.BusinessId, err)
-            return events.LambdaFunctionURLResponse{Body: `{"message": "Error sending new review to LINE users of business"}`, StatusCode: 500}, nil
-        }
-        log.Info("Successfully sent new review to all users belonging to business: ", business.BusinessId)
-    } else {
-        if stringUtil.IsEmptyStringPtr(event.UserId) {
-            log.Errorf("No business ID in event and no user ID in event. Unable to create new review")
-            return events.LambdaFunctionURLResponse{Body: `{"message": "No business ID in event and no user ID in event"}`, StatusCode: 400}, nil
-        }
-        userId := *event.UserId
-
-        err = line.SendNewReviewToUser(review, userId)
-        if err != nil {
-            log.Errorf("Error sending new review to user '%s': %s", userId, err)
-            return events.LambdaFunctionURLResponse{Body: `{"message": "Error sending new review to LINE user"}`, StatusCode: 500}, nil
-        }
-        log.Info("Successfully sent new review to user: ", userId)
-    }
-
-    // --------------------------------
-    // auto reply
-    // --------------------------------
-    if businessPtr != nil {
-        autoQuickReplyEnabled := business.AutoQuickReplyEnabled
-        quickReplyMessagePtr := business.QuickReplyMessage
-
-        if autoQuickReplyEnabled && stringUtil.IsEmptyStringPtr(quickReplyMessagePtr) {
-            log.Errorf("AutoQuickReplyEnabled set to true but no quickReplyMessage")
-            return events.LambdaFunctionURLResponse{
-                Body: `{"message": "Error getting quick reply message"}`, StatusCode: 500}, nil
-        }
-
-        if autoQuickReplyEnabled && stringUtil.IsEmptyStringPtr(review.Review) && review.NumberRating == 5 {
-            quickReplyMessage := *quickReplyMessagePtr
-            err = lineEventProcessor.ReplyReview(util.AutoReplyUserId, quickReplyMessage, review, reviewDao, log)
-            if err != nil {
-                log.Errorf("Error handling replying '%s' to review '%s' : %v", quickReplyMessage, review.ReviewId.String(), err)
-
-                notifyUserErr := line.NotifyUsersReplyFailed(business.UserIds, review.ReviewerName, true)
-                if notifyUserErr != nil {
-                    log.Errorf("Error notifying users of business '%s' reply failed for review '%s': %v", businessId, review.ReviewId.String(), notifyUserErr)
-                    return events.LambdaFunctionURLResponse{
-                        StatusCode: 500,
-                        Body:       fmt.Sprintf(`{"error": "Auto reply failed: %s. Failed to notify user of failure: %s"}`, err, notifyUserErr),
-                    }, nil
-                }
-
-                log.Infof("Successfully notified users of business '%s' auto reply failed for review '%s'", businessId, review.ReviewId.String())
-
-                return events.LambdaFunctionURLResponse{
-                    StatusCode: 500,
-                    Body:       fmt.Sprintf(`{"error": "Reply failed: %s"}`, err),
-                }, err
-            }
-
-            // --------------------
-            // Notify review quick replied
-            // --------------------
-            err = line.NotifyReviewAutoReplied(review, quickReplyMessage, business, userDao)
-            if err != nil {
-                log.Errorf("Error sending review reply notification to all users of business '%s': %v", business.BusinessId, err)
-                return events.LambdaFunctionURLResponse{
-                    StatusCode: 500,
-                    Body:       fmt.Sprintf(`{"error": "Failed to send review reply notification to all users of business '%s': %v"}`, business.BusinessId, err),
-                }, err
-            }
-
-            log.Infof("Successfully auto replied review for business '%s' for review '%s'", business.BusinessId, review.ReviewId.String())
+            return events.LambdaFunctionURLResponse{Body: fmt.Sprintf(`{"error": "Error processing review event: %s"}`, err), StatusCode: 500}, nil
         }
     }
 
-    log.Info("Successfully processed new review event: ", jsonUtil.AnyToJson(review))
-
+    log.Infof("Successfully processed review event. Review '%s' %s", result.Review.ReviewId.String(), result.Outcome)
     return events.LambdaFunctionURLResponse{Body: `{"message": "OK"}`, StatusCode: 200}, nil
 }
-
-func removeGoogleTranslate(event *model.ZapierNewReviewEvent) {
-    if event.Review == nil {
-        return
-    }
-    strippedText := stringUtil.StripGoogleTranslate(*event.Review)
-    event.Review = &strippedText
-}
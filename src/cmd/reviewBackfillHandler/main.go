@@ -0,0 +1,178 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/aws"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/logger"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    enum3 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
+    "github.com/IntelliLead/CoreCommonUtil/secretUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyGuard"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/reviewBackfill"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/reviewIntake"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/aws/aws-lambda-go/lambda"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "os"
+    "strings"
+    "time"
+)
+
+// defaultLookback is how far back reviews are reconciled on every scheduled run.
+// It overlaps consecutive runs so that a failed run is covered by the next one.
+const defaultLookback = 72 * time.Hour
+
+var (
+    log       = logger.NewLogger()
+    awsConfig = aws.DefaultAwsConfig()
+    secrets   = secretUtil.NewSecretUtil(awsConfig, log).GetSecrets()
+)
+
+// Runs as a scheduled Lambda, or as a local CLI when not in Lambda, e.g.,
+//
+//	STAGE=alpha go run main.go -businessIds 4496688115335717986 -lookback 720h
+func main() {
+    if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+        runCli()
+        return
+    }
+    lambda.Start(handleRequest)
+}
+
+func runCli() {
+    businessIdsFlag := flag.String("businessIds", "", "comma separated business IDs to backfill. Backfills all businesses if empty")
+    lookback := flag.Duration("lookback", defaultLookback, "backfill reviews updated within the lookback duration")
+    indexVendorReviewIds := flag.Bool("indexVendorReviewIds", false, "map the vendor review IDs of the stored reviews to their items instead of backfilling")
+    flag.Parse()
+
+    var businessIds []bid.BusinessId
+    if *businessIdsFlag != "" {
+        for _, businessIdStr := range strings.Split(*businessIdsFlag, ",") {
+            businessId, err := bid.NewBusinessId(strings.TrimSpace(businessIdStr))
+            if err != nil {
+                log.Fatalf("Invalid business ID '%s': %v", businessIdStr, err)
+            }
+            businessIds = append(businessIds, businessId)
+        }
+    }
+
+    if *indexVendorReviewIds {
+        err := indexReviews(context.Background(), businessIds)
+        if err != nil {
+            log.Fatalf("Indexing vendor review IDs failed: %v", err)
+        }
+        return
+    }
+
+    result, err := backfill(context.Background(), businessIds, *lookback)
+    log.Info("Backfill result: ", jsonUtil.AnyToJson(result))
+    if err != nil {
+        log.Fatalf("Backfill failed: %v", err)
+    }
+}
+
+func handleRequest(ctx context.Context, event events.CloudWatchEvent) (reviewBackfill.Result, error) {
+    log.Infof("Received scheduled event in %s: %s", os.Getenv(constant.StageEnvKey), jsonUtil.AnyToJson(event))
+
+    result, err := backfill(ctx, nil, defaultLookback)
+    if err != nil {
+        log.Errorf("Error backfilling reviews: %v", err)
+        metric.EmitLambdaMetric(enum3.Metric5xxError, enum2.HandlerNameReviewBackfillHandler.String(), 1)
+        return result, err
+    }
+
+    log.Info("Successfully backfilled reviews: ", jsonUtil.AnyToJson(result))
+    return result, nil
+}
+
+// backfill backfills the given businesses, or all businesses if none is given
+func backfill(ctx context.Context, businessIds []bid.BusinessId, lookback time.Duration) (reviewBackfill.Result, error) {
+    line := lineUtil.NewLineUtil(secrets.LineChannelSecret, secrets.LineChannelAccessToken, log)
+
+    ddbClient := dynamodb.NewFromConfig(awsConfig)
+    businessDao := ddbDao.NewBusinessDao(ddbClient, log)
+    userDao := ddbDao.NewUserDao(ddbClient, log)
+    reviewDao := ddbDao.NewReviewDao(ddbClient, log)
+
+    businessProfile := businessProfileUtil.NewBusinessProfile(secrets.GoogleClientID, secrets.GoogleClientSecret, log)
+    googleTokenHealth, err := tokenHealth.NewTokenHealthFromEnv(awsConfig, businessProfile, userDao, dao.NewGoogleTokenDao(ddbClient, log), line, log)
+    if err != nil {
+        log.Error("Error creating token health: ", err)
+        return reviewBackfill.Result{}, err
+    }
+    publisher, err := replyPublisher.NewReplyPublisher(businessDao, userDao, businessProfile, googleTokenHealth, log)
+    if err != nil {
+        log.Error("Error creating reply publisher: ", err)
+        return reviewBackfill.Result{}, err
+    }
+
+    vendorReviewIdDao := dao.NewVendorReviewIdDao(ddbClient, log)
+    reviewEventDao := dao.NewReviewEventDao(ddbClient, log)
+    autoReplyRuleDao := dao.NewAutoReplyRuleDao(ddbClient, businessDao, log)
+    aiReplyDraftDao := dao.NewAiReplyDraftDao(ddbClient, log)
+    ai, err := aiUtil.NewAiFromEnv(awsConfig, secrets.GptApiKey, dao.NewLLMConfigDao(ddbClient, log), dao.NewReplyHistoryDao(ddbClient, log), autoReplyRuleDao, dao.NewAiUsageDao(ddbClient, log), dao.NewAiReplyCacheDao(ddbClient, log), log)
+    if err != nil {
+        log.Error("Error creating AI: ", err)
+        return reviewBackfill.Result{}, err
+    }
+    // check replies before publishing them
+    publisher = replyPublisher.NewGuardedPublisher(publisher, replyGuard.NewReplyGuardFromEnv(businessDao, dao.NewReplyGuardDao(ddbClient, businessDao, log), ai, log), log)
+    intake := reviewIntake.NewReviewIntake(businessDao, userDao, reviewDao, vendorReviewIdDao, reviewEventDao, autoReplyRuleDao, aiReplyDraftDao, ai, line, publisher, log)
+    backfiller := reviewBackfill.NewReviewBackfill(
+        businessDao,
+        userDao,
+        dao.NewBusinessIdDao(ddbClient, log),
+        vendorReviewIdDao,
+        dao.NewGoogleAccountDao(ddbClient, businessDao, userDao, log),
+        businessProfile,
+        googleTokenHealth,
+        intake,
+        log)
+
+    since := time.Now().Add(-lookback)
+    if len(businessIds) == 0 {
+        return backfiller.BackfillAll(ctx, since)
+    }
+    return backfiller.Backfill(ctx, businessIds, since)
+}
+
+// indexReviews maps the vendor review IDs of the stored reviews of the given businesses, or all businesses if none is given, to their items.
+// It is run once for reviews stored before the mapping was written with each review.
+func indexReviews(ctx context.Context, businessIds []bid.BusinessId) error {
+    ddbClient := dynamodb.NewFromConfig(awsConfig)
+    vendorReviewIdDao := dao.NewVendorReviewIdDao(ddbClient, log)
+
+    if len(businessIds) == 0 {
+        var err error
+        businessIds, err = dao.NewBusinessIdDao(ddbClient, log).ListBusinessIds(ctx)
+        if err != nil {
+            return err
+        }
+    }
+
+    var errs []error
+    for _, businessId := range businessIds {
+        count, err := vendorReviewIdDao.IndexVendorReviewIds(ctx, businessId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error indexing reviews of business '%s': %w", businessId, err))
+            continue
+        }
+        log.Infof("Indexed %d reviews of business '%s'", count, businessId)
+    }
+
+    return errors.Join(errs...)
+}
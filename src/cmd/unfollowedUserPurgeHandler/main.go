@@ -0,0 +1,87 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/aws"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/logger"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    enum3 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/aws/aws-lambda-go/lambda"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "os"
+    "time"
+)
+
+var (
+    log       = logger.NewLogger()
+    awsConfig = aws.DefaultAwsConfig()
+)
+
+// Runs as a scheduled Lambda, or as a local CLI when not in Lambda, e.g.,
+//
+//	STAGE=alpha go run main.go -gracePeriod 24h
+func main() {
+    if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+        runCli()
+        return
+    }
+    lambda.Start(handleRequest)
+}
+
+func runCli() {
+    gracePeriod := flag.Duration("gracePeriod", util.UnfollowedUserGracePeriod, "purge the Google tokens of users who unfollowed longer than this ago")
+    flag.Parse()
+
+    err := purge(context.Background(), time.Now().Add(-*gracePeriod))
+    if err != nil {
+        log.Fatalf("Purging Google tokens of unfollowed users failed: %v", err)
+    }
+}
+
+func handleRequest(ctx context.Context, event events.CloudWatchEvent) error {
+    log.Infof("Received scheduled event in %s: %s", os.Getenv(constant.StageEnvKey), jsonUtil.AnyToJson(event))
+
+    err := purge(ctx, time.Now().Add(-util.UnfollowedUserGracePeriod))
+    if err != nil {
+        log.Errorf("Error purging Google tokens of unfollowed users: %v", err)
+        metric.EmitLambdaMetric(enum3.Metric5xxError, enum2.HandlerNameUnfollowedUserPurgeHandler.String(), 1)
+        return err
+    }
+
+    log.Info("Successfully purged Google tokens of unfollowed users")
+    return nil
+}
+
+// purge removes the Google tokens of users who unfollowed before the cutoff.
+// A failing user does not stop the others from being purged.
+func purge(ctx context.Context, unfollowedBefore time.Time) error {
+    ddbClient := dynamodb.NewFromConfig(awsConfig)
+    googleTokenDao := dao.NewGoogleTokenDao(ddbClient, log)
+
+    userIds, err := dao.NewLineFollowDao(ddbClient, log).ListUnfollowedUserIdsWithGoogleTokens(ctx, unfollowedBefore)
+    if err != nil {
+        return err
+    }
+    log.Infof("Found %d users who unfollowed before %s with Google tokens", len(userIds), unfollowedBefore.Format(time.RFC3339))
+
+    var errs []error
+    for _, userId := range userIds {
+        err = googleTokenDao.ClearTokens(ctx, userId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error purging Google tokens of user '%s': %w", userId, err))
+            continue
+        }
+        log.Infof("Purged Google tokens of user '%s'", userId)
+    }
+
+    return errors.Join(errs...)
+}
@@ -6,147 +6,348 @@ import (
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
     "github.com/IntelliLead/CoreCommonUtil/stringUtil"
-    "github.com/IntelliLead/CoreDataAccess/model"
+    model2 "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/languageUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/cenkalti/backoff/v4"
-    "github.com/sashabaranov/go-openai"
     "go.uber.org/zap"
     "time"
 )
 
+// AiReplyCandidateCount is the number of alternative replies generated at a time for the user to pick from
+const AiReplyCandidateCount = 3
+
+// Ai generates replies to reviews with the LLM provider.
+// Requests failing transiently are retried with exponential backoff regardless of the provider.
 type Ai struct {
-    gptClient *openai.Client
-    log       *zap.SugaredLogger
+    provider LLMProvider
+    // config is the LLM config of the stage
+    config model.LLMConfig
+    // llmConfigDao reads the LLM config of businesses. The config of the stage applies to all businesses if nil.
+    llmConfigDao *dao.LLMConfigDao
+    // replyHistoryDao reads past replies of businesses given to AI as examples. No examples are given if nil.
+    replyHistoryDao *dao.ReplyHistoryDao
+    // autoReplyRuleDao reads the reply language mode of businesses. All businesses reply in Chinese if nil.
+    autoReplyRuleDao *dao.AutoReplyRuleDao
+    // aiUsageDao records the AI usage of businesses and users and enforces the monthly quota of businesses. Usage is neither recorded nor limited if nil.
+    aiUsageDao *dao.AiUsageDao
+    // aiReplyCacheDao stores replies reused for identical short reviews. Replies are always generated if nil.
+    aiReplyCacheDao *dao.AiReplyCacheDao
+    log             *zap.SugaredLogger
 }
 
-func NewAi(logger *zap.SugaredLogger, gptApiKey string) *Ai {
+func NewAi(
+    logger *zap.SugaredLogger,
+    provider LLMProvider,
+    config model.LLMConfig,
+    llmConfigDao *dao.LLMConfigDao,
+    replyHistoryDao *dao.ReplyHistoryDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
+    aiUsageDao *dao.AiUsageDao,
+    aiReplyCacheDao *dao.AiReplyCacheDao,
+) *Ai {
     return &Ai{
-        gptClient: newGptClient(gptApiKey),
-        log:       logger,
+        provider:         provider,
+        config:           config,
+        llmConfigDao:     llmConfigDao,
+        replyHistoryDao:  replyHistoryDao,
+        autoReplyRuleDao: autoReplyRuleDao,
+        aiUsageDao:       aiUsageDao,
+        aiReplyCacheDao:  aiReplyCacheDao,
+        log:              logger,
     }
 }
 
-func (ai *Ai) GenerateReply(review string, business model.Business, user model.User) (string, error) {
-    temp := 1.12
-    prompt := ai.buildPrompt(business, user)
-    totalPromptTokens := 0
-    totalCompletionTokens := 0
+// GenerateReply generates a reply to the review, including reviews without text.
+// Replies to identical short reviews may be reused. See AiReplyCacheMaxReviewLength.
+// Returns the reply and the ID of the prompt template it was generated with.
+func (ai *Ai) GenerateReply(review model2.Review, business model2.Business, user model2.User) (string, string, error) {
+    replies, promptTemplateId, err := ai.generate(review, business, user, nil, 1, true)
+    if err != nil {
+        return "", promptTemplateId, err
+    }
+    return replies[0], promptTemplateId, nil
+}
 
-    operation := func() (openai.ChatCompletionResponse, error) {
-        response, err := ai.gptClient.CreateChatCompletion(
-            context.Background(),
-            openai.ChatCompletionRequest{
-                Temperature: float32(temp),
-                MaxTokens:   512,
-                Model:       openai.GPT4o,
-                Messages: []openai.ChatCompletionMessage{
-                    {
-                        Role:    openai.ChatMessageRoleSystem,
-                        Content: prompt,
-                    },
-                    {
-                        Role:    openai.ChatMessageRoleUser,
-                        Content: review,
-                    },
-                },
-            },
-        )
-        if err != nil {
-            // Increment the counters
-            totalPromptTokens += response.Usage.PromptTokens
-            totalCompletionTokens += response.Usage.CompletionTokens
-
-            e := &openai.APIError{}
-            if errors.As(err, &e) {
-                switch e.HTTPStatusCode {
-                case 401:
-                    ai.log.Error("Error generating AI reply due to invalid API key: ", err)
-                    return response, backoff.Permanent(err)
-                case 429, 502:
-                    // rate limiting or bad gateway (retry these errors)
-                    ai.log.Error("Error generating AI reply. Retrying: ", err)
-                    return response, err
-                default:
-                    ai.log.Error("Error generating AI reply due to unknown error: ", err)
-                    return response, backoff.Permanent(err) // Permanent error, no retry
-                }
-            }
-            return response, err
+// RegenerateReply generates a reply to the review like GenerateReply, without reusing replies to identical reviews.
+// Used when the user asks for another reply.
+func (ai *Ai) RegenerateReply(review model2.Review, business model2.Business, user model2.User) (string, string, error) {
+    replies, promptTemplateId, err := ai.generate(review, business, user, nil, 1, false)
+    if err != nil {
+        return "", promptTemplateId, err
+    }
+    return replies[0], promptTemplateId, nil
+}
+
+// GenerateReplies generates AiReplyCandidateCount alternative replies to the review.
+// Replies to identical short reviews may be reused. See AiReplyCacheMaxReviewLength.
+// Returns the replies and the ID of the prompt template they were generated with.
+func (ai *Ai) GenerateReplies(review model2.Review, business model2.Business, user model2.User) ([]string, string, error) {
+    return ai.generate(review, business, user, nil, AiReplyCandidateCount, true)
+}
+
+// ReviseReply generates AiReplyCandidateCount alternative revisions of the previous reply to the review.
+// The previous reply is given as the conversation so far, followed by the revision instruction.
+// Returns the revisions and the ID of the prompt template they were generated with.
+func (ai *Ai) ReviseReply(review model2.Review, previousReply string, revision enum.ReplyRevision, business model2.Business, user model2.User) ([]string, string, error) {
+    followUp := []LLMMessage{
+        {
+            Role:    LLMRoleAssistant,
+            Content: previousReply,
+        },
+        {
+            Role:    LLMRoleUser,
+            Content: revisionPrompt(revision),
+        },
+    }
+    return ai.generate(review, business, user, followUp, AiReplyCandidateCount, false)
+}
+
+// generate requests n completions of the reply conversation to the review, followed by followUp messages if any.
+// The conversation starts with past replies of the business to other reviews as examples of its voice.
+// Replies to identical short reviews without followUp are cached, and reused if useCache.
+// Returns exception.AiQuotaExceededException if the business has used up its monthly AI quota.
+func (ai *Ai) generate(review model2.Review, business model2.Business, user model2.User, followUp []LLMMessage, n int, useCache bool) ([]string, string, error) {
+    config := ai.llmConfigOf(business)
+    promptTemplate := ai.promptTemplateOf(config, business)
+    input := newPromptInput(review, business, user, ai.pastRepliesOf(review, business), ai.replyLanguageModeOf(business))
+
+    cacheKey, cacheable := "", false
+    if ai.aiReplyCacheDao != nil && len(followUp) == 0 {
+        cacheKey, cacheable = aiReplyCacheKey(input, promptTemplate, config)
+    }
+    if cacheable && useCache {
+        if replies, hit := ai.cachedReplies(business.BusinessId.String(), cacheKey, review.ReviewerName, n); hit {
+            ai.log.Infof("Reused %d cached AI replies for business '%s' with prompt template %s", len(replies), business.BusinessId, promptTemplate.Id())
+            return replies, promptTemplate.Id(), nil
         }
+    }
 
-        totalPromptTokens += response.Usage.PromptTokens
-        totalCompletionTokens += response.Usage.CompletionTokens
-        return response, nil
+    // cached replies cost nothing, so the quota is checked on cache misses only
+    err := ai.checkQuota(business.BusinessId)
+    if err != nil {
+        return nil, promptTemplate.Id(), err
     }
 
-    backoffPolicy := backoff.NewExponentialBackOff()
-    backoffPolicy.InitialInterval = 1 * time.Millisecond
-    response, err := backoff.RetryNotifyWithData(operation, backoffPolicy, func(err error, duration time.Duration) {
-        ai.log.Error("Retrying due to error: ", err, ". Next attempt in ", duration)
+    ai.logMissingSettings(business, user)
+    prompt, err := promptTemplate.Render(input)
+    if err != nil {
+        ai.log.Errorf("Error building AI reply prompt for business '%s': %v", business.BusinessId, err)
+        return nil, promptTemplate.Id(), err
+    }
+
+    messages := []LLMMessage{
+        {
+            Role:    LLMRoleSystem,
+            Content: prompt,
+        },
+    }
+    messages = append(messages, exampleMessages(input.PastReplies)...)
+    messages = append(messages, LLMMessage{
+        Role:    LLMRoleUser,
+        Content: reviewMessage(input.ReviewerName, input.Rating, input.Review),
     })
+    messages = append(messages, followUp...)
+
+    replies, err := ai.complete(config, messages, n, business.BusinessId, user.UserId)
     if err != nil {
-        ai.log.Errorf("Generating AI reply failed: %s", err)
-        return "", err
+        return nil, promptTemplate.Id(), err
+    }
+    ai.log.Infof("Generated %d AI replies for business '%s' with prompt template %s", len(replies), business.BusinessId, promptTemplate.Id())
+    if cacheable {
+        ai.cacheReplies(business.BusinessId.String(), cacheKey, promptTemplate.Id(), review.ReviewerName, replies)
+    }
+    return replies, promptTemplate.Id(), nil
+}
+
+// checkQuota returns exception.AiQuotaExceededException if the estimated AI cost of the business this month has reached the quota of its plan tier.
+// The business is not limited if its usage cannot be read.
+func (ai *Ai) checkQuota(businessId bid.BusinessId) error {
+    if ai.aiUsageDao == nil {
+        return nil
+    }
+    ctx := context.Background()
+
+    tier, err := ai.aiUsageDao.GetPlanTier(ctx, businessId)
+    if err != nil {
+        ai.log.Warnf("Error getting plan tier of business '%s'. Skipping AI quota check: %v", businessId, err)
+        return nil
+    }
+    usage, err := ai.aiUsageDao.GetUsage(ctx, model.MonthlyAiUsagePeriod(time.Now()), model.BusinessAiUsageSubject(businessId.String()))
+    if err != nil {
+        ai.log.Warnf("Error getting AI usage of business '%s'. Skipping AI quota check: %v", businessId, err)
+        return nil
     }
 
-    // response format: https://platform.openai.com/docs/guides/gpt/completions-response-format
-    if response.Choices[0].FinishReason != openai.FinishReasonStop {
-        ai.log.Errorf("Error generating AI reply due to failure finish reason: %s", jsonUtil.AnyToJson(response.Choices[0]))
-        return "", errors.New(response.Choices[0].Message.Content)
+    quota := model.MonthlyAiQuotaUsd[tier]
+    if usage.EstimatedCostUsd >= quota {
+        ai.log.Infof("Business '%s' on plan %s has used %s of its monthly AI quota %s", businessId, tier, model.FormatUsd(usage.EstimatedCostUsd), model.FormatUsd(quota))
+        return exception.NewAiQuotaExceededException(fmt.Sprintf("business '%s' used %s in %s", businessId, model.FormatUsd(usage.EstimatedCostUsd), usage.Period), tier, quota)
     }
+    return nil
+}
 
-    ai.log.Infof("AI reply used %d input tokens and %d output tokens", totalPromptTokens, totalCompletionTokens)
-    return response.Choices[0].Message.Content, nil
+// recordUsage records the tokens used with the model for the business and, if userId is not empty, the user.
+// Failing to record usage does not fail the request.
+func (ai *Ai) recordUsage(llmModel string, promptTokens int, completionTokens int, businessId bid.BusinessId, userId string) {
+    if ai.aiUsageDao == nil {
+        return
+    }
+    cost, found := model.EstimateLLMCostUsd(llmModel, promptTokens, completionTokens)
+    if !found {
+        ai.log.Warnf("Model %s has no list price. Estimating its cost with the price of the most expensive model", llmModel)
+    }
+    err := ai.aiUsageDao.RecordUsage(context.Background(), businessId, userId, promptTokens, completionTokens, cost, time.Now())
+    if err != nil {
+        ai.log.Warnf("Error recording AI usage of business '%s' user '%s': %v", businessId, userId, err)
+    }
 }
 
-func newGptClient(gptApiKey string) *openai.Client {
-    return openai.NewClient(gptApiKey)
+// promptTemplateOf returns the prompt template configured for the business, falling back to the latest default template if it does not exist
+func (ai *Ai) promptTemplateOf(config model.LLMConfig, business model2.Business) PromptTemplate {
+    promptTemplate, found := LookupPromptTemplate(config.PromptTemplate)
+    if !found {
+        ai.log.Warnf("Prompt template '%s' of business '%s' does not exist. Using %s", config.PromptTemplate, business.BusinessId, DefaultPromptTemplateName)
+        promptTemplate, _ = LookupPromptTemplate(DefaultPromptTemplateName)
+    }
+    return promptTemplate
 }
 
-func (ai *Ai) buildPrompt(business model.Business, user model.User) string {
-    keywordEnabled := business.KeywordEnabled
-    businessDescription := business.BusinessDescription
-    keywords := business.Keywords
+// replyLanguageModeOf returns the reply language mode of the business, falling back to Chinese if it cannot be read
+func (ai *Ai) replyLanguageModeOf(business model2.Business) enum.ReplyLanguageMode {
+    if ai.autoReplyRuleDao == nil {
+        return enum.ReplyLanguageModeChinese
+    }
+    mode, err := ai.autoReplyRuleDao.GetReplyLanguageMode(context.Background(), business.BusinessId)
+    if err != nil {
+        ai.log.Warnf("Error getting reply language mode of business '%s'. Replying in Chinese: %v", business.BusinessId, err)
+        return enum.ReplyLanguageModeChinese
+    }
+    return mode
+}
 
-    businessPrompt, emojiPrompt, keywordsPrompt, serviceRecommendationPrompt, signaturePrompt := "", "", "", "", ""
+// logMissingSettings logs AI reply settings that are enabled without a value, which prompt templates skip
+func (ai *Ai) logMissingSettings(business model2.Business, user model2.User) {
+    if business.KeywordEnabled && stringUtil.IsEmptyStringPtr(business.Keywords) {
+        ai.log.Errorf("Keywords is empty for business %s user %s but keyword is enabled", business.BusinessId, user.UserId)
+    }
+    if user.SignatureEnabled && stringUtil.IsEmptyStringPtr(user.Signature) {
+        ai.log.Errorf("Signature is empty for user %s but signature is enabled", user.UserId)
+    }
+}
 
-    // business prompt
-    if !stringUtil.IsEmptyStringPtr(businessDescription) {
-        businessPrompt = fmt.Sprintf(util.BusinessDescriptionPromptFormat, *businessDescription)
+func newPromptInput(
+    review model2.Review,
+    business model2.Business,
+    user model2.User,
+    pastReplies []model.PastReply,
+    replyLanguageMode enum.ReplyLanguageMode,
+) PromptInput {
+    text := ""
+    if !stringUtil.IsEmptyStringPtr(review.Review) {
+        text = *review.Review
     }
+    return PromptInput{
+        Business:          business,
+        User:              user,
+        ReviewerName:      review.ReviewerName,
+        Review:            text,
+        Rating:            int(review.NumberRating),
+        Language:          languageUtil.Detect(text),
+        PastReplies:       pastReplies,
+        ReplyLanguageMode: replyLanguageMode,
+    }
+}
 
-    // emoji prompt
-    if user.EmojiEnabled {
-        emojiPrompt = util.EmojiPrompt
+// llmConfigOf returns the LLM config of the stage overridden by that of the business.
+// Falls back to the config of the stage if the config of the business cannot be read.
+func (ai *Ai) llmConfigOf(business model2.Business) model.LLMConfig {
+    if ai.llmConfigDao == nil {
+        return ai.config
     }
+    businessConfig, err := ai.llmConfigDao.GetLLMConfig(context.Background(), business.BusinessId)
+    if err != nil {
+        ai.log.Warnf("Error getting LLM config of business '%s'. Using the config of the stage: %v", business.BusinessId, err)
+        return ai.config
+    }
+    return ai.config.Override(businessConfig)
+}
 
-    // service recommendation prompt
-    if user.ServiceRecommendationEnabled {
-        if stringUtil.IsEmptyStringPtr(user.ServiceRecommendation) {
-            serviceRecommendationPrompt = fmt.Sprintf(util.ServiceRecommendationPromptFormat, "")
-        } else {
-            serviceRecommendationPrompt = fmt.Sprintf(
-                util.ServiceRecommendationPromptFormat, fmt.Sprintf(util.ServiceToRecommendPromptFormat, *user.ServiceRecommendation))
-        }
+// complete requests n completions of the conversation with the LLM config and returns the finished ones.
+// Tokens used, including by failed attempts, are recorded for the business and, if userId is not empty, the user.
+func (ai *Ai) complete(config model.LLMConfig, messages []LLMMessage, n int, businessId bid.BusinessId, userId string) ([]string, error) {
+    request := LLMRequest{
+        Messages:    messages,
+        Model:       config.Model,
+        Temperature: *config.Temperature,
+        MaxTokens:   config.MaxTokens,
+        N:           n,
     }
+    totalPromptTokens := 0
+    totalCompletionTokens := 0
 
-    // keyword prompt
-    if keywordEnabled {
-        if stringUtil.IsEmptyStringPtr(keywords) {
-            ai.log.Errorf("Keywords is empty for business %s user %s but keyword is enabled", business.BusinessId, user.UserId)
-        } else {
-            keywordsPrompt = fmt.Sprintf(util.KeywordPromptFormat, *keywords)
+    operation := func() (LLMResponse, error) {
+        response, err := ai.provider.Complete(context.Background(), request)
+        totalPromptTokens += response.PromptTokens
+        totalCompletionTokens += response.CompletionTokens
+        if err != nil {
+            var llmRequestException *exception.LLMRequestException
+            if errors.As(err, &llmRequestException) && !llmRequestException.Transient {
+                ai.log.Error("Error generating AI reply due to permanent error: ", err)
+                return response, backoff.Permanent(err) // Permanent error, no retry
+            }
+            // rate limiting, server errors or network errors (retry these errors)
+            ai.log.Error("Error generating AI reply. Retrying: ", err)
+            return response, err
         }
+        return response, nil
+    }
+
+    backoffPolicy := backoff.NewExponentialBackOff()
+    backoffPolicy.InitialInterval = 1 * time.Millisecond
+    response, err := backoff.RetryNotifyWithData(operation, backoffPolicy, func(err error, duration time.Duration) {
+        ai.log.Error("Retrying due to error: ", err, ". Next attempt in ", duration)
+    })
+    if totalPromptTokens > 0 || totalCompletionTokens > 0 {
+        ai.recordUsage(config.Model, totalPromptTokens, totalCompletionTokens, businessId, userId)
+    }
+    if err != nil {
+        ai.log.Errorf("Generating AI reply failed: %s", err)
+        return nil, err
     }
+    ai.log.Infof("AI reply with model %s used %d input tokens and %d output tokens", config.Model, totalPromptTokens, totalCompletionTokens)
 
-    // signature prompt
-    if user.SignatureEnabled {
-        if stringUtil.IsEmptyStringPtr(user.Signature) {
-            ai.log.Errorf("Signature is empty for user %s but signature is enabled", user.UserId)
-        } else {
-            signaturePrompt = fmt.Sprintf(util.SignaturePrompt, *user.Signature)
+    var replies []string
+    for _, completion := range response.Completions {
+        if !completion.Finished {
+            ai.log.Errorf("Discarding unfinished AI reply: %s", jsonUtil.AnyToJson(completion))
+            continue
         }
+        replies = append(replies, completion.Content)
+    }
+    if len(replies) == 0 {
+        if len(response.Completions) == 0 {
+            return nil, errors.New("no AI reply generated")
+        }
+        return nil, errors.New(response.Completions[0].Content)
     }
 
-    return fmt.Sprintf(util.AiReplyPromptFormat, businessPrompt, emojiPrompt, serviceRecommendationPrompt, keywordsPrompt, signaturePrompt)
+    return replies, nil
+}
+
+// revisionPrompt is the instruction for AI to revise its previous reply
+func revisionPrompt(revision enum.ReplyRevision) string {
+    switch revision {
+    case enum.ReplyRevisionShorter:
+        return util.ShorterReplyRevisionPrompt
+    case enum.ReplyRevisionWarmer:
+        return util.WarmerReplyRevisionPrompt
+    default:
+        return util.MoreFormalReplyRevisionPrompt
+    }
 }
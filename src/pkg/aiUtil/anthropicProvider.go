@@ -0,0 +1,147 @@
+package aiUtil
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "go.uber.org/zap"
+    "io"
+    "net/http"
+    "strings"
+    "time"
+)
+
+const (
+    defaultAnthropicBaseUrl = "https://api.anthropic.com/v1"
+    defaultAnthropicModel   = "claude-3-5-sonnet-20240620"
+    anthropicApiVersion     = "2023-06-01"
+    anthropicRequestTimeout = 60 * time.Second
+    // anthropicMaxTemperature is the highest temperature accepted by the Anthropic API
+    anthropicMaxTemperature = 1.0
+)
+
+// AnthropicProvider completes conversations with the Anthropic Messages API.
+// The API returns one completion per request, so completions are requested one at a time.
+type AnthropicProvider struct {
+    apiKey     string
+    baseUrl    string
+    httpClient *http.Client
+    log        *zap.SugaredLogger
+}
+
+// NewAnthropicProvider creates a provider of Anthropic. baseUrl overrides the API endpoint if not empty.
+func NewAnthropicProvider(apiKey string, baseUrl string, logger *zap.SugaredLogger) *AnthropicProvider {
+    if baseUrl == "" {
+        baseUrl = defaultAnthropicBaseUrl
+    }
+    return &AnthropicProvider{
+        apiKey:     apiKey,
+        baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+        httpClient: &http.Client{Timeout: anthropicRequestTimeout},
+        log:        logger,
+    }
+}
+
+type anthropicMessage struct {
+    Role    string `json:"role"`
+    Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+    Model       string             `json:"model"`
+    MaxTokens   int                `json:"max_tokens"`
+    Temperature float32            `json:"temperature"`
+    System      string             `json:"system,omitempty"`
+    Messages    []anthropicMessage `json:"messages"`
+}
+
+type anthropicResponse struct {
+    Content []struct {
+        Type string `json:"type"`
+        Text string `json:"text"`
+    } `json:"content"`
+    StopReason string `json:"stop_reason"`
+    Usage      struct {
+        InputTokens  int `json:"input_tokens"`
+        OutputTokens int `json:"output_tokens"`
+    } `json:"usage"`
+}
+
+func (p *AnthropicProvider) Complete(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+    return completeEach(ctx, request, p.complete)
+}
+
+func (p *AnthropicProvider) complete(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+    // system messages are a separate field of the Messages API
+    var systemPrompts []string
+    var messages []anthropicMessage
+    for _, message := range request.Messages {
+        if message.Role == LLMRoleSystem {
+            systemPrompts = append(systemPrompts, message.Content)
+            continue
+        }
+        messages = append(messages, anthropicMessage{Role: message.Role, Content: message.Content})
+    }
+
+    temperature := request.Temperature
+    if temperature > anthropicMaxTemperature {
+        temperature = anthropicMaxTemperature
+    }
+
+    jsonData, err := json.Marshal(anthropicRequest{
+        Model:       request.Model,
+        MaxTokens:   request.MaxTokens,
+        Temperature: temperature,
+        System:      strings.Join(systemPrompts, "\n"),
+        Messages:    messages,
+    })
+    if err != nil {
+        return LLMResponse{}, exception.NewPermanentLLMRequestException("error marshaling Anthropic request to JSON", 0, err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseUrl+"/messages", bytes.NewBuffer(jsonData))
+    if err != nil {
+        return LLMResponse{}, exception.NewPermanentLLMRequestException("error creating Anthropic request", 0, err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("x-api-key", p.apiKey)
+    req.Header.Set("anthropic-version", anthropicApiVersion)
+
+    resp, err := p.httpClient.Do(req)
+    if err != nil {
+        return LLMResponse{}, exception.NewTransientLLMRequestException("error sending Anthropic request", 0, err)
+    }
+    defer resp.Body.Close()
+
+    respBody, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return LLMResponse{}, exception.NewTransientLLMRequestException("error reading Anthropic response", resp.StatusCode, err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return LLMResponse{}, exception.NewLLMRequestException("Anthropic API error", resp.StatusCode, fmt.Errorf("%s", respBody))
+    }
+
+    var response anthropicResponse
+    err = json.Unmarshal(respBody, &response)
+    if err != nil {
+        return LLMResponse{}, exception.NewPermanentLLMRequestException(fmt.Sprintf("error parsing Anthropic response %s", respBody), resp.StatusCode, err)
+    }
+
+    var text strings.Builder
+    for _, content := range response.Content {
+        if content.Type == "text" {
+            text.WriteString(content.Text)
+        }
+    }
+
+    return LLMResponse{
+        Completions: []LLMCompletion{{
+            Content:  text.String(),
+            Finished: response.StopReason == "end_turn" || response.StopReason == "stop_sequence",
+        }},
+        PromptTokens:     response.Usage.InputTokens,
+        CompletionTokens: response.Usage.OutputTokens,
+    }, nil
+}
@@ -0,0 +1,30 @@
+package aiUtil
+
+import (
+    "context"
+    "fmt"
+)
+
+// FakeProvider returns deterministic completions without calling any LLM service, e.g., for local testing.
+// The i-th completion is "Fake reply i to: " followed by the last message of the conversation.
+type FakeProvider struct{}
+
+func NewFakeProvider() *FakeProvider {
+    return &FakeProvider{}
+}
+
+func (p *FakeProvider) Complete(_ context.Context, request LLMRequest) (LLMResponse, error) {
+    lastMessage := ""
+    if len(request.Messages) > 0 {
+        lastMessage = request.Messages[len(request.Messages)-1].Content
+    }
+
+    var response LLMResponse
+    for i := 0; i < request.N; i++ {
+        response.Completions = append(response.Completions, LLMCompletion{
+            Content:  fmt.Sprintf("Fake reply %d to: %s", i+1, lastMessage),
+            Finished: true,
+        })
+    }
+    return response, nil
+}
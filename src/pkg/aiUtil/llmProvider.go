@@ -0,0 +1,174 @@
+package aiUtil
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/ssmUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/sashabaranov/go-openai"
+    "go.uber.org/zap"
+    "os"
+    "strconv"
+)
+
+const (
+    LLMRoleSystem    = "system"
+    LLMRoleUser      = "user"
+    LLMRoleAssistant = "assistant"
+)
+
+// LLMMessage is a message of the conversation to complete
+type LLMMessage struct {
+    // Role is one of LLMRoleSystem, LLMRoleUser and LLMRoleAssistant
+    Role    string
+    Content string
+}
+
+// LLMRequest requests N alternative completions of the conversation
+type LLMRequest struct {
+    Messages    []LLMMessage
+    Model       string
+    Temperature float32
+    MaxTokens   int
+    N           int
+}
+
+type LLMCompletion struct {
+    Content string
+    // Finished is false if the completion was cut short, e.g., by the token limit or content filtering
+    Finished bool
+}
+
+type LLMResponse struct {
+    Completions      []LLMCompletion
+    PromptTokens     int
+    CompletionTokens int
+}
+
+// LLMProvider completes conversations with an LLM service.
+// Failed requests return *exception.LLMRequestException classifying whether the failure is transient. Retrying is up to the caller.
+type LLMProvider interface {
+    Complete(ctx context.Context, request LLMRequest) (LLMResponse, error)
+}
+
+// defaultLLMConfig is the LLM config of the stage if not configured by env vars
+func defaultLLMConfig(backend enum.LLMBackend) model.LLMConfig {
+    temperature := float32(1.12)
+    config := model.LLMConfig{
+        Model:       openai.GPT4o,
+        Temperature: &temperature,
+        MaxTokens:   512,
+    }
+    switch backend {
+    case enum.LLMBackendAnthropic:
+        config.Model = defaultAnthropicModel
+    case enum.LLMBackendLocal:
+        config.Model = defaultLocalModel
+    case enum.LLMBackendFake:
+        config.Model = "fake"
+    }
+    return config
+}
+
+// NewAiFromEnv creates Ai with the LLM provider and config of the stage configured by the LLM_* env vars.
+// OpenAI is used with gptApiKey if not configured. The API key of other backends, if any, is read from the SSM parameter named by LLM_API_KEY_PARAMETER_NAME.
+func NewAiFromEnv(awsConfig aws.Config, gptApiKey string, llmConfigDao *dao.LLMConfigDao, replyHistoryDao *dao.ReplyHistoryDao, autoReplyRuleDao *dao.AutoReplyRuleDao, aiUsageDao *dao.AiUsageDao, aiReplyCacheDao *dao.AiReplyCacheDao, logger *zap.SugaredLogger) (*Ai, error) {
+    backend := enum.LLMBackendOpenAi
+    if backendStr := os.Getenv(util.LLMBackendEnvKey); backendStr != "" {
+        var err error
+        backend, err = enum.ToLLMBackend(backendStr)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    apiKey := ""
+    if backend == enum.LLMBackendOpenAi {
+        apiKey = gptApiKey
+    }
+    if parameterName := os.Getenv(util.LLMApiKeyParameterNameEnvKey); parameterName != "" {
+        apiKey = ssmUtil.NewSsm(awsConfig, logger).GetSsmParameterValue(parameterName)
+    }
+
+    provider, err := NewLLMProvider(backend, apiKey, os.Getenv(util.LLMBaseUrlEnvKey), logger)
+    if err != nil {
+        return nil, err
+    }
+
+    config, err := llmConfigFromEnv(defaultLLMConfig(backend))
+    if err != nil {
+        return nil, err
+    }
+    logger.Infof("AI replies are generated by %s with model %s", backend, config.Model)
+
+    return NewAi(logger, provider, config, llmConfigDao, replyHistoryDao, autoReplyRuleDao, aiUsageDao, aiReplyCacheDao), nil
+}
+
+// NewLLMProvider creates the provider of the backend. baseUrl overrides the endpoint of the backend and is required for Azure OpenAI and local endpoints.
+func NewLLMProvider(backend enum.LLMBackend, apiKey string, baseUrl string, logger *zap.SugaredLogger) (LLMProvider, error) {
+    switch backend {
+    case enum.LLMBackendOpenAi:
+        return NewOpenAiProvider(apiKey, baseUrl, logger), nil
+    case enum.LLMBackendAzureOpenAi:
+        if baseUrl == "" {
+            return nil, fmt.Errorf("%s is required for %s", util.LLMBaseUrlEnvKey, backend)
+        }
+        return NewAzureOpenAiProvider(apiKey, baseUrl, logger), nil
+    case enum.LLMBackendAnthropic:
+        return NewAnthropicProvider(apiKey, baseUrl, logger), nil
+    case enum.LLMBackendLocal:
+        if baseUrl == "" {
+            return nil, fmt.Errorf("%s is required for %s", util.LLMBaseUrlEnvKey, backend)
+        }
+        return NewLocalProvider(apiKey, baseUrl, logger), nil
+    default:
+        return NewFakeProvider(), nil
+    }
+}
+
+// llmConfigFromEnv overrides the default config with the LLM_MODEL, LLM_TEMPERATURE and LLM_MAX_TOKENS env vars
+func llmConfigFromEnv(defaultConfig model.LLMConfig) (model.LLMConfig, error) {
+    var config model.LLMConfig
+    config.Model = os.Getenv(util.LLMModelEnvKey)
+
+    if temperatureStr := os.Getenv(util.LLMTemperatureEnvKey); temperatureStr != "" {
+        temperature, err := strconv.ParseFloat(temperatureStr, 32)
+        if err != nil {
+            return model.LLMConfig{}, fmt.Errorf("invalid %s '%s': %w", util.LLMTemperatureEnvKey, temperatureStr, err)
+        }
+        temperature32 := float32(temperature)
+        config.Temperature = &temperature32
+    }
+
+    if maxTokensStr := os.Getenv(util.LLMMaxTokensEnvKey); maxTokensStr != "" {
+        maxTokens, err := strconv.Atoi(maxTokensStr)
+        if err != nil || maxTokens <= 0 {
+            return model.LLMConfig{}, fmt.Errorf("invalid %s '%s'", util.LLMMaxTokensEnvKey, maxTokensStr)
+        }
+        config.MaxTokens = maxTokens
+    }
+
+    return defaultConfig.Override(config), nil
+}
+
+// completeEach completes the request one completion at a time, for providers that do not support multiple completions per request
+func completeEach(ctx context.Context, request LLMRequest, complete func(ctx context.Context, request LLMRequest) (LLMResponse, error)) (LLMResponse, error) {
+    n := request.N
+    request.N = 1
+
+    var merged LLMResponse
+    for i := 0; i < n; i++ {
+        response, err := complete(ctx, request)
+        merged.PromptTokens += response.PromptTokens
+        merged.CompletionTokens += response.CompletionTokens
+        if err != nil {
+            return merged, err
+        }
+        merged.Completions = append(merged.Completions, response.Completions...)
+    }
+    return merged, nil
+}
@@ -0,0 +1,155 @@
+package aiUtil
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "reflect"
+    "testing"
+)
+
+func TestFakeProviderComplete(t *testing.T) {
+    tests := []struct {
+        name    string
+        request LLMRequest
+        want    []LLMCompletion
+    }{
+        {
+            name: "completes the last message N times",
+            request: LLMRequest{
+                Messages: []LLMMessage{
+                    {Role: LLMRoleSystem, Content: "You reply to reviews"},
+                    {Role: LLMRoleUser, Content: "Great food"},
+                },
+                N: 2,
+            },
+            want: []LLMCompletion{
+                {Content: "Fake reply 1 to: Great food", Finished: true},
+                {Content: "Fake reply 2 to: Great food", Finished: true},
+            },
+        },
+        {
+            name:    "no messages",
+            request: LLMRequest{N: 1},
+            want: []LLMCompletion{
+                {Content: "Fake reply 1 to: ", Finished: true},
+            },
+        },
+        {
+            name: "no completions requested",
+            request: LLMRequest{
+                Messages: []LLMMessage{{Role: LLMRoleUser, Content: "Great food"}},
+                N:        0,
+            },
+            want: nil,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            response, err := NewFakeProvider().Complete(context.Background(), tt.request)
+            if err != nil {
+                t.Fatalf("Complete() error = %v", err)
+            }
+            if !reflect.DeepEqual(response.Completions, tt.want) {
+                t.Errorf("Complete() completions = %v, want %v", response.Completions, tt.want)
+            }
+        })
+    }
+}
+
+func TestCompleteEach(t *testing.T) {
+    errFailed := errors.New("failed")
+    request := LLMRequest{
+        Messages: []LLMMessage{{Role: LLMRoleUser, Content: "Great food"}},
+        N:        3,
+    }
+
+    tests := []struct {
+        name string
+        // failAt is the call failing, or 0 if none fails
+        failAt          int
+        wantCompletions int
+        wantTokens      int
+        wantErr         error
+    }{
+        {
+            name:            "merges one completion per call",
+            wantCompletions: 3,
+            wantTokens:      3,
+        },
+        {
+            name:            "stops at the failing call",
+            failAt:          2,
+            wantCompletions: 1,
+            wantTokens:      2,
+            wantErr:         errFailed,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            calls := 0
+            fake := NewFakeProvider()
+            response, err := completeEach(context.Background(), request, func(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+                calls++
+                if request.N != 1 {
+                    t.Errorf("request.N = %d, want 1", request.N)
+                }
+                if calls == tt.failAt {
+                    return LLMResponse{PromptTokens: 1}, errFailed
+                }
+                response, err := fake.Complete(ctx, request)
+                response.PromptTokens = 1
+                return response, err
+            })
+
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("completeEach() error = %v, want %v", err, tt.wantErr)
+            }
+            if len(response.Completions) != tt.wantCompletions {
+                t.Errorf("completeEach() completions = %d, want %d", len(response.Completions), tt.wantCompletions)
+            }
+            if response.PromptTokens != tt.wantTokens {
+                t.Errorf("completeEach() prompt tokens = %d, want %d", response.PromptTokens, tt.wantTokens)
+            }
+        })
+    }
+}
+
+func TestNewLLMProvider(t *testing.T) {
+    tests := []struct {
+        name     string
+        backend  enum.LLMBackend
+        wantFake bool
+        wantErr  bool
+    }{
+        {
+            name:     "fake backend",
+            backend:  enum.LLMBackendFake,
+            wantFake: true,
+        },
+        {
+            name:    "Azure OpenAI without base URL",
+            backend: enum.LLMBackendAzureOpenAi,
+            wantErr: true,
+        },
+        {
+            name:    "local without base URL",
+            backend: enum.LLMBackendLocal,
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            provider, err := NewLLMProvider(tt.backend, "", "", nil)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("NewLLMProvider() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if _, isFake := provider.(*FakeProvider); isFake != tt.wantFake {
+                t.Errorf("NewLLMProvider() provider = %T, want fake %v", provider, tt.wantFake)
+            }
+        })
+    }
+}
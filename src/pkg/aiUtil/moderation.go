@@ -0,0 +1,39 @@
+package aiUtil
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "strings"
+)
+
+// Moderate asks AI whether the reply of the business can be published with the LLM config of the stage.
+// Returns the reason to block the reply, or empty if the reply can be published. Usage is recorded for the business only.
+func (ai *Ai) Moderate(_ context.Context, businessId bid.BusinessId, reply string) (string, error) {
+    messages := []LLMMessage{
+        {
+            Role:    LLMRoleSystem,
+            Content: util.ReplyModerationPrompt,
+        },
+        {
+            Role:    LLMRoleUser,
+            Content: reply,
+        },
+    }
+
+    answers, err := ai.complete(ai.config, messages, 1, businessId, "")
+    if err != nil {
+        return "", err
+    }
+
+    answer := strings.TrimSpace(answers[0])
+    reason, blocked := strings.CutPrefix(answer, util.ReplyModerationBlockedPrefix)
+    if !blocked {
+        if answer != util.ReplyModerationApproved {
+            ai.log.Warnf("Unexpected moderation answer '%s'. Treating the reply as approved", answer)
+        }
+        return "", nil
+    }
+    ai.log.Infof("AI moderation blocked reply '%s': %s", reply, reason)
+    return "回覆未通過內容審查：" + strings.TrimSuffix(strings.TrimSpace(reason), "。") + "。", nil
+}
@@ -0,0 +1,116 @@
+package aiUtil
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/sashabaranov/go-openai"
+    "go.uber.org/zap"
+)
+
+// defaultLocalModel is the model of local endpoints if not configured
+const defaultLocalModel = "llama3"
+
+// OpenAiProvider completes conversations with the OpenAI chat completion API, or an API compatible with it
+type OpenAiProvider struct {
+    client *openai.Client
+    // supportsN is whether the API supports multiple completions per request
+    supportsN bool
+    log       *zap.SugaredLogger
+}
+
+// NewOpenAiProvider creates a provider of OpenAI. baseUrl overrides the API endpoint if not empty.
+func NewOpenAiProvider(apiKey string, baseUrl string, logger *zap.SugaredLogger) *OpenAiProvider {
+    config := openai.DefaultConfig(apiKey)
+    if baseUrl != "" {
+        config.BaseURL = baseUrl
+    }
+    return &OpenAiProvider{
+        client:    openai.NewClientWithConfig(config),
+        supportsN: true,
+        log:       logger,
+    }
+}
+
+// NewAzureOpenAiProvider creates a provider of the Azure OpenAI resource at endpoint, e.g., "https://RESOURCE_NAME.openai.azure.com/".
+// The model of requests is the deployment name.
+func NewAzureOpenAiProvider(apiKey string, endpoint string, logger *zap.SugaredLogger) *OpenAiProvider {
+    config := openai.DefaultAzureConfig(apiKey, endpoint)
+    config.AzureModelMapperFunc = func(model string) string {
+        return model
+    }
+    return &OpenAiProvider{
+        client:    openai.NewClientWithConfig(config),
+        supportsN: true,
+        log:       logger,
+    }
+}
+
+// NewLocalProvider creates a provider of a local OpenAI-compatible endpoint, e.g., "http://localhost:11434/v1" for Ollama.
+// Local endpoints commonly ignore the number of completions, so completions are requested one at a time.
+func NewLocalProvider(apiKey string, baseUrl string, logger *zap.SugaredLogger) *OpenAiProvider {
+    config := openai.DefaultConfig(apiKey)
+    config.BaseURL = baseUrl
+    return &OpenAiProvider{
+        client:    openai.NewClientWithConfig(config),
+        supportsN: false,
+        log:       logger,
+    }
+}
+
+func (p *OpenAiProvider) Complete(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+    if request.N > 1 && !p.supportsN {
+        return completeEach(ctx, request, p.complete)
+    }
+    return p.complete(ctx, request)
+}
+
+func (p *OpenAiProvider) complete(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+    messages := make([]openai.ChatCompletionMessage, 0, len(request.Messages))
+    for _, message := range request.Messages {
+        messages = append(messages, openai.ChatCompletionMessage{
+            Role:    message.Role,
+            Content: message.Content,
+        })
+    }
+
+    response, err := p.client.CreateChatCompletion(
+        ctx,
+        openai.ChatCompletionRequest{
+            Temperature: request.Temperature,
+            MaxTokens:   request.MaxTokens,
+            Model:       request.Model,
+            N:           request.N,
+            Messages:    messages,
+        },
+    )
+    if err != nil {
+        return LLMResponse{}, toLLMRequestException(err)
+    }
+
+    // response format: https://platform.openai.com/docs/guides/gpt/completions-response-format
+    llmResponse := LLMResponse{
+        PromptTokens:     response.Usage.PromptTokens,
+        CompletionTokens: response.Usage.CompletionTokens,
+    }
+    for _, choice := range response.Choices {
+        llmResponse.Completions = append(llmResponse.Completions, LLMCompletion{
+            Content:  choice.Message.Content,
+            Finished: choice.FinishReason == openai.FinishReasonStop,
+        })
+    }
+    return llmResponse, nil
+}
+
+// toLLMRequestException classifies errors of the OpenAI client. Errors without a response, e.g., network errors, are transient.
+func toLLMRequestException(err error) *exception.LLMRequestException {
+    apiError := &openai.APIError{}
+    if errors.As(err, &apiError) {
+        return exception.NewLLMRequestException("OpenAI API error", apiError.HTTPStatusCode, err)
+    }
+    requestError := &openai.RequestError{}
+    if errors.As(err, &requestError) {
+        return exception.NewLLMRequestException("OpenAI request error", requestError.HTTPStatusCode, err)
+    }
+    return exception.NewTransientLLMRequestException("OpenAI request failed", 0, err)
+}
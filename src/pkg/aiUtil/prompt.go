@@ -0,0 +1,205 @@
+package aiUtil
+
+import (
+    "embed"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    model2 "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/languageUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "io/fs"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "text/template"
+)
+
+// DefaultPromptTemplateName is the prompt template of businesses without one configured
+const DefaultPromptTemplateName = "default"
+
+// promptTemplateFiles are named "{name}.v{version}.tmpl". A published version must not be edited, so that the version saved with AI replies identifies the prompt they were generated with.
+//
+//go:embed prompt/*.tmpl
+var promptTemplateFiles embed.FS
+
+// promptTemplates are the versions of each prompt template, latest first
+var promptTemplates = mustLoadPromptTemplates()
+
+// PromptTemplate is a version of a named system prompt for AI replies, rendered with PromptInput
+type PromptTemplate struct {
+    Name     string
+    Version  int
+    template *template.Template
+}
+
+// Id identifies the template version, e.g., "default@v1"
+func (t PromptTemplate) Id() string {
+    return fmt.Sprintf("%s@v%d", t.Name, t.Version)
+}
+
+// Render renders the prompt for the input
+func (t PromptTemplate) Render(input PromptInput) (string, error) {
+    var prompt strings.Builder
+    err := t.template.Execute(&prompt, input)
+    if err != nil {
+        return "", fmt.Errorf("error rendering prompt template %s: %w", t.Id(), err)
+    }
+    return prompt.String(), nil
+}
+
+// PromptInput is the data available to prompt templates
+type PromptInput struct {
+    Business model2.Business
+    // User is the user whose AI reply settings (emoji, signature, service recommendation) apply
+    User model2.User
+    // ReviewerName is the display name of the reviewer
+    ReviewerName string
+    // Review is the review text. Empty if the review has no text.
+    Review string
+    // Rating is the star rating of the review
+    Rating int
+    // Language is the language of the review as ISO 639-1 code. Empty if unknown.
+    Language string
+    // ReplyLanguageMode is the reply language setting of the business. See ReplyLanguage.
+    ReplyLanguageMode enum.ReplyLanguageMode
+    // PastReplies are recent replies of users of the business to other reviews, most recent first.
+    // They are given to AI as earlier turns of the conversation, so templates only need to mention them.
+    PastReplies []model.PastReply
+}
+
+// BusinessDescription returns the business description, or empty if not set
+func (i PromptInput) BusinessDescription() string {
+    return stringOf(i.Business.BusinessDescription)
+}
+
+// ServiceRecommendation returns the service to recommend, or empty if not set
+func (i PromptInput) ServiceRecommendation() string {
+    return stringOf(i.User.ServiceRecommendation)
+}
+
+// ReplyLanguage returns the language to reply in by the reply language mode of the business, e.g., "Japanese".
+// Replies are in Taiwanese mandarin if the language of the review is Chinese or unknown.
+func (i PromptInput) ReplyLanguage() string {
+    chinese := languageUtil.Name(languageUtil.Chinese)
+    reviewLanguage := languageUtil.Name(i.Language)
+    if reviewLanguage == "" || i.Language == languageUtil.Chinese {
+        return chinese
+    }
+
+    switch i.ReplyLanguageMode {
+    case enum.ReplyLanguageModeReviewer:
+        return reviewLanguage
+    case enum.ReplyLanguageModeBilingual:
+        return fmt.Sprintf("%s, followed by the same reply in %s", reviewLanguage, chinese)
+    default:
+        return chinese
+    }
+}
+
+// Keywords returns the keywords to mention, or empty if keywords are disabled
+func (i PromptInput) Keywords() string {
+    if !i.Business.KeywordEnabled {
+        return ""
+    }
+    return stringOf(i.Business.Keywords)
+}
+
+// Signature returns the signature to sign off with, or empty if signature is disabled
+func (i PromptInput) Signature() string {
+    if !i.User.SignatureEnabled {
+        return ""
+    }
+    return stringOf(i.User.Signature)
+}
+
+// LookupPromptTemplate finds the prompt template by ID, e.g., "nailSalon@v1", or the latest version by name, e.g., "nailSalon".
+// An empty ID is the latest version of DefaultPromptTemplateName.
+func LookupPromptTemplate(id string) (PromptTemplate, bool) {
+    if id == "" {
+        id = DefaultPromptTemplateName
+    }
+
+    name, versionStr, hasVersion := strings.Cut(id, "@v")
+    versions := promptTemplates[name]
+    if len(versions) == 0 {
+        return PromptTemplate{}, false
+    }
+    if !hasVersion {
+        return versions[0], true
+    }
+
+    version, err := strconv.Atoi(versionStr)
+    if err != nil {
+        return PromptTemplate{}, false
+    }
+    for _, t := range versions {
+        if t.Version == version {
+            return t, true
+        }
+    }
+    return PromptTemplate{}, false
+}
+
+// PromptTemplateNames returns the names of all prompt templates, e.g., to list the vertical presets
+func PromptTemplateNames() []string {
+    var names []string
+    for name := range promptTemplates {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func mustLoadPromptTemplates() map[string][]PromptTemplate {
+    files, err := fs.Glob(promptTemplateFiles, "prompt/*.tmpl")
+    if err != nil {
+        panic(err)
+    }
+
+    templates := map[string][]PromptTemplate{}
+    for _, file := range files {
+        name, version, err := parsePromptTemplateFileName(path.Base(file))
+        if err != nil {
+            panic(err)
+        }
+        content, err := promptTemplateFiles.ReadFile(file)
+        if err != nil {
+            panic(err)
+        }
+        t, err := template.New(file).Option("missingkey=error").Parse(string(content))
+        if err != nil {
+            panic(fmt.Errorf("error parsing prompt template %s: %w", file, err))
+        }
+        templates[name] = append(templates[name], PromptTemplate{Name: name, Version: version, template: t})
+    }
+
+    for _, versions := range templates {
+        sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+    }
+    if len(templates[DefaultPromptTemplateName]) == 0 {
+        panic(fmt.Errorf("missing prompt template %s", DefaultPromptTemplateName))
+    }
+    return templates
+}
+
+// parsePromptTemplateFileName parses "{name}.v{version}.tmpl"
+func parsePromptTemplateFileName(fileName string) (string, int, error) {
+    name, versionStr, ok := strings.Cut(strings.TrimSuffix(fileName, ".tmpl"), ".v")
+    if !ok {
+        return "", 0, fmt.Errorf("prompt template file name '%s' is not in the format of {name}.v{version}.tmpl", fileName)
+    }
+    version, err := strconv.Atoi(versionStr)
+    if err != nil || version <= 0 {
+        return "", 0, fmt.Errorf("invalid version of prompt template file '%s'", fileName)
+    }
+    return name, version, nil
+}
+
+func stringOf(s *string) string {
+    if stringUtil.IsEmptyStringPtr(s) {
+        return ""
+    }
+    return *s
+}
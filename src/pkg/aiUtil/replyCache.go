@@ -0,0 +1,123 @@
+package aiUtil
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "strings"
+    "unicode"
+)
+
+// AiReplyCacheMaxReviewLength is the maximum length of normalized review text, in characters, whose AI replies are reused for identical reviews.
+// Longer reviews are rarely identical and deserve a reply of their own.
+const AiReplyCacheMaxReviewLength = 12
+
+// aiReplyCacheWriteAttempts is how many times the replies are added to a cache entry written concurrently
+const aiReplyCacheWriteAttempts = 3
+
+// aiReplySettings are the settings that change the prompt of a review besides the review itself
+type aiReplySettings struct {
+    Config                       model.LLMConfig `json:"config"`
+    BusinessDescription          string          `json:"businessDescription"`
+    Keywords                     string          `json:"keywords"`
+    Signature                    string          `json:"signature"`
+    EmojiEnabled                 bool            `json:"emojiEnabled"`
+    ServiceRecommendationEnabled bool            `json:"serviceRecommendationEnabled"`
+    ServiceRecommendation        string          `json:"serviceRecommendation"`
+    ReplyLanguage                string          `json:"replyLanguage"`
+}
+
+// aiReplyCacheKey identifies the review text, rating, prompt template and settings of the input.
+// The past replies given as examples are not part of the key, as they change with every reply to the business. Replies vary by being picked at random instead.
+// Returns false if replies to the review are not reused as the review is too long.
+func aiReplyCacheKey(input PromptInput, promptTemplate PromptTemplate, config model.LLMConfig) (string, bool) {
+    review := normalizeReview(input.Review)
+    if len([]rune(review)) > AiReplyCacheMaxReviewLength {
+        return "", false
+    }
+
+    key := struct {
+        Review         string `json:"review"`
+        Rating         int    `json:"rating"`
+        PromptTemplate string `json:"promptTemplate"`
+        SettingsHash   string `json:"settingsHash"`
+    }{
+        Review:         review,
+        Rating:         input.Rating,
+        PromptTemplate: promptTemplate.Id(),
+        SettingsHash: sha256Of(aiReplySettings{
+            Config:                       config,
+            BusinessDescription:          input.BusinessDescription(),
+            Keywords:                     input.Keywords(),
+            Signature:                    input.Signature(),
+            EmojiEnabled:                 input.User.EmojiEnabled,
+            ServiceRecommendationEnabled: input.User.ServiceRecommendationEnabled,
+            ServiceRecommendation:        input.ServiceRecommendation(),
+            ReplyLanguage:                input.ReplyLanguage(),
+        }),
+    }
+    return sha256Of(key), true
+}
+
+// normalizeReview lowercases the review and drops spaces, punctuation and symbols including emojis, e.g., "很棒!! 👍" to "很棒"
+func normalizeReview(review string) string {
+    return strings.Map(func(r rune) rune {
+        if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+            return -1
+        }
+        return unicode.ToLower(r)
+    }, review)
+}
+
+func sha256Of(v interface{}) string {
+    b, _ := json.Marshal(v)
+    sum := sha256.Sum256(b)
+    return hex.EncodeToString(sum[:])
+}
+
+// cachedReplies picks n cached replies to the review. Returns false if there are not enough to vary from the replies of earlier requests.
+// Failing to read the cache is a miss.
+func (ai *Ai) cachedReplies(businessId string, cacheKey string, reviewerName string, n int) ([]string, bool) {
+    entry, err := ai.aiReplyCacheDao.GetAiReplyCacheEntry(context.Background(), businessId, cacheKey)
+    if err != nil {
+        ai.log.Warnf("Error getting cached AI replies of business '%s'. Generating AI replies: %v", businessId, err)
+        return nil, false
+    }
+    if entry == nil {
+        return nil, false
+    }
+    return entry.PickReplies(n, reviewerName)
+}
+
+// cacheReplies adds the replies to the cached replies of identical reviews. Failing to write the cache does not fail the request.
+// The entry is read again and the replies added again if it was written concurrently, so that the replies of neither request are lost.
+func (ai *Ai) cacheReplies(businessId string, cacheKey string, promptTemplateId string, reviewerName string, replies []string) {
+    ctx := context.Background()
+    for attempt := 1; attempt <= aiReplyCacheWriteAttempts; attempt++ {
+        entry, err := ai.aiReplyCacheDao.GetAiReplyCacheEntry(ctx, businessId, cacheKey)
+        if err != nil {
+            ai.log.Warnf("Error getting cached AI replies of business '%s'. Not caching AI replies: %v", businessId, err)
+            return
+        }
+        if entry == nil {
+            newEntry := model.NewAiReplyCacheEntry(businessId, cacheKey, promptTemplateId)
+            entry = &newEntry
+        }
+
+        namingReplies := entry.AddReplies(replies, reviewerName)
+        if namingReplies > 0 && attempt == 1 {
+            ai.log.Infof("Not caching %d AI replies of business '%s' naming the reviewer", namingReplies, businessId)
+        }
+        written, err := ai.aiReplyCacheDao.PutAiReplyCacheEntry(ctx, *entry)
+        if err != nil {
+            ai.log.Warnf("Error caching AI replies of business '%s': %v", businessId, err)
+            return
+        }
+        if written {
+            return
+        }
+    }
+    ai.log.Warnf("AI replies of business '%s' were not cached, as the cache entry kept being written concurrently", businessId)
+}
@@ -0,0 +1,138 @@
+package aiUtil
+
+import (
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "testing"
+)
+
+func TestNormalizeReview(t *testing.T) {
+    tests := []struct {
+        name   string
+        review string
+        want   string
+    }{
+        {name: "punctuation and emoji", review: "很棒!! 👍", want: "很棒"},
+        {name: "full-width punctuation", review: "好吃。", want: "好吃"},
+        {name: "case and spaces", review: "Great Food", want: "greatfood"},
+        {name: "symbols", review: "A+ ★★★", want: "a"},
+        {name: "digits are kept", review: "10/10", want: "1010"},
+        {name: "only spaces and punctuation", review: " !? ", want: ""},
+        {name: "empty", review: "", want: ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := normalizeReview(tt.review); got != tt.want {
+                t.Errorf("normalizeReview(%q) = %q, want %q", tt.review, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestAiReplyCacheKey(t *testing.T) {
+    baseInput := PromptInput{Review: "Good!", Rating: 5}
+    baseTemplate := PromptTemplate{Name: DefaultPromptTemplateName, Version: 1}
+    baseConfig := model.LLMConfig{Model: "gpt-4o", MaxTokens: 512}
+
+    baseKey, ok := aiReplyCacheKey(baseInput, baseTemplate, baseConfig)
+    if !ok {
+        t.Fatalf("aiReplyCacheKey() of short review ok = false")
+    }
+
+    pastReview := "Nice"
+    tests := []struct {
+        name        string
+        input       func(input *PromptInput)
+        template    PromptTemplate
+        config      model.LLMConfig
+        wantCached  bool
+        wantSameKey bool
+    }{
+        {
+            name:        "same review with different spacing, case and punctuation",
+            input:       func(input *PromptInput) { input.Review = " good 👍" },
+            template:    baseTemplate,
+            config:      baseConfig,
+            wantCached:  true,
+            wantSameKey: true,
+        },
+        {
+            name:        "reviewer name is not part of the key",
+            input:       func(input *PromptInput) { input.ReviewerName = "Amy" },
+            template:    baseTemplate,
+            config:      baseConfig,
+            wantCached:  true,
+            wantSameKey: true,
+        },
+        {
+            name:       "different review",
+            input:      func(input *PromptInput) { input.Review = "Bad" },
+            template:   baseTemplate,
+            config:     baseConfig,
+            wantCached: true,
+        },
+        {
+            name:       "different rating",
+            input:      func(input *PromptInput) { input.Rating = 4 },
+            template:   baseTemplate,
+            config:     baseConfig,
+            wantCached: true,
+        },
+        {
+            name: "past replies are not part of the key",
+            input: func(input *PromptInput) {
+                input.PastReplies = []model.PastReply{{ReviewerName: "Ben", NumberRating: 5, Review: &pastReview, Reply: "Thank you!"}}
+            },
+            template:    baseTemplate,
+            config:      baseConfig,
+            wantCached:  true,
+            wantSameKey: true,
+        },
+        {
+            name:       "different prompt template version",
+            input:      func(input *PromptInput) {},
+            template:   PromptTemplate{Name: DefaultPromptTemplateName, Version: 2},
+            config:     baseConfig,
+            wantCached: true,
+        },
+        {
+            name:       "different model",
+            input:      func(input *PromptInput) {},
+            template:   baseTemplate,
+            config:     model.LLMConfig{Model: "gpt-4o-mini", MaxTokens: 512},
+            wantCached: true,
+        },
+        {
+            name:       "review at the maximum length",
+            input:      func(input *PromptInput) { input.Review = "很棒很棒很棒很棒很棒很棒!!" },
+            template:   baseTemplate,
+            config:     baseConfig,
+            wantCached: true,
+        },
+        {
+            name:       "review over the maximum length",
+            input:      func(input *PromptInput) { input.Review = "很棒很棒很棒很棒很棒很棒很" },
+            template:   baseTemplate,
+            config:     baseConfig,
+            wantCached: false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            input := baseInput
+            tt.input(&input)
+
+            key, ok := aiReplyCacheKey(input, tt.template, tt.config)
+            if ok != tt.wantCached {
+                t.Fatalf("aiReplyCacheKey() ok = %v, want %v", ok, tt.wantCached)
+            }
+            if !ok {
+                return
+            }
+            if (key == baseKey) != tt.wantSameKey {
+                t.Errorf("aiReplyCacheKey() same key as base = %v, want %v", key == baseKey, tt.wantSameKey)
+            }
+        })
+    }
+}
@@ -0,0 +1,58 @@
+package aiUtil
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    model2 "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+)
+
+// PastReplyExampleCount is the number of past replies of a business given to AI as examples of its voice
+const PastReplyExampleCount = 3
+
+// pastRepliesOf gets the recent replies of users of the business to reviews other than the review.
+// Returns none if they cannot be read, since they only guide the style of the reply.
+func (ai *Ai) pastRepliesOf(review model2.Review, business model2.Business) []model.PastReply {
+    if ai.replyHistoryDao == nil {
+        return nil
+    }
+    pastReplies, err := ai.replyHistoryDao.ListRecentReplies(context.Background(), business.BusinessId, review.VendorReviewId, PastReplyExampleCount)
+    if err != nil {
+        ai.log.Warnf("Error getting past replies of business '%s'. Generating AI reply without examples: %v", business.BusinessId, err)
+        return nil
+    }
+    return pastReplies
+}
+
+// exampleMessages presents the past replies as earlier turns of the conversation, oldest first
+func exampleMessages(pastReplies []model.PastReply) []LLMMessage {
+    var messages []LLMMessage
+    for i := len(pastReplies) - 1; i >= 0; i-- {
+        pastReply := pastReplies[i]
+        review := ""
+        if !stringUtil.IsEmptyStringPtr(pastReply.Review) {
+            review = *pastReply.Review
+        }
+        messages = append(messages,
+            LLMMessage{
+                Role:    LLMRoleUser,
+                Content: reviewMessage(pastReply.ReviewerName, pastReply.NumberRating, review),
+            },
+            LLMMessage{
+                Role:    LLMRoleAssistant,
+                Content: pastReply.Reply,
+            },
+        )
+    }
+    return messages
+}
+
+// reviewMessage presents the review to AI with the reviewer name and star rating, e.g., "Reviewer: 王小明\nRating: 2/5\nReview: 等太久"
+func reviewMessage(reviewerName string, rating int, review string) string {
+    if stringUtil.IsEmptyString(review) {
+        review = util.NoTextReviewAiPrompt
+    }
+    return fmt.Sprintf(util.ReviewAiPromptFormat, reviewerName, rating, review)
+}
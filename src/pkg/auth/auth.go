@@ -14,6 +14,7 @@ import (
     "github.com/IntelliLead/CoreDataAccess/model"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
     enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
     "go.uber.org/zap"
 )
 
@@ -25,8 +26,9 @@ func ValidateUserAuthOrRequestAuthTst(
     handlerName enum2.HandlerName,
     log *zap.SugaredLogger,
     authRedirectUrl string,
+    stateSigner *oauthState.Signer,
 ) (bool, *model.User, error) {
-    return ValidateUserAuthOrRequestAuth("TST", userId, userDao, line, handlerName, log, authRedirectUrl)
+    return ValidateUserAuthOrRequestAuth("TST", userId, userDao, line, handlerName, log, authRedirectUrl, stateSigner)
 }
 
 // ValidateUserAuthOrRequestAuth checks if the user has completed oauth.
@@ -43,12 +45,13 @@ func ValidateUserAuthOrRequestAuth(
     handlerName enum2.HandlerName,
     log *zap.SugaredLogger,
     authRedirectUrl string,
+    stateSigner *oauthState.Signer,
 ) (bool, *model.User, error) {
-    hasUserCompletedOauth, user, err := ValidateUserAuth(userId, userDao, line, handlerName, log)
+    authState, user, err := ValidateUserAuth(userId, userDao, line, handlerName, log)
     if err != nil {
         var userDoesNotExistException *exception.UserDoesNotExistException
         if errors.As(err, &userDoesNotExistException) {
-            err = requestAuth(replyToken, userId, line, log, authRedirectUrl)
+            err = RequestAuth(replyToken, userId, line, log, authRedirectUrl, stateSigner)
             if err != nil {
                 metric.EmitLambdaMetric(enum.Metric5xxError, handlerName.String(), 1)
             }
@@ -59,32 +62,46 @@ func ValidateUserAuthOrRequestAuth(
         return false, nil, err
     }
 
-    if !hasUserCompletedOauth {
+    switch authState {
+    case enum2.UserAuthStateNotAuthed:
         log.Info("User ", userId, " has not completed OAUTH. Sending auth request.")
-        err = requestAuth(replyToken, userId, line, log, authRedirectUrl)
+        err = RequestAuth(replyToken, userId, line, log, authRedirectUrl, stateSigner)
         if err != nil {
             metric.EmitLambdaMetric(enum.Metric5xxError, handlerName.String(), 1)
         }
-    } else {
+    case enum2.UserAuthStateNeedsReauth:
+        log.Info("Google token of user ", userId, " was revoked or purged. Sending re-auth request.")
+        err = RequestReauth(replyToken, userId, line, log, authRedirectUrl, stateSigner)
+        if err != nil {
+            metric.EmitLambdaMetric(enum.Metric5xxError, handlerName.String(), 1)
+        }
+    default:
         log.Info("User ", userId, " has completed OAUTH.")
     }
 
-    return hasUserCompletedOauth, &user, nil
+    return authState == enum2.UserAuthStateAuthed, &user, nil
 }
 
-func requestAuth(
+// RequestAuth replies the Google OAuth request to the user, or sends it if there is no reply token in testing
+func RequestAuth(
     replyToken string,
     userId string,
     line *lineUtil.LineUtil,
     log *zap.SugaredLogger,
     authRedirectUrl string,
+    stateSigner *oauthState.Signer,
 ) error {
+    state, err := stateSigner.Sign(userId)
+    if err != nil {
+        log.Errorf("Error signing OAuth state of user %s: %s", userId, err)
+        return err
+    }
+
     // when testing in local, there is no replyToken, send to user instead of replying
-    var err error
     if replyToken == "TST" {
-        err = line.SendAuthRequest(userId, authRedirectUrl)
+        err = line.SendAuthRequest(userId, state, authRedirectUrl)
     } else {
-        err = line.ReplyAuthRequest(replyToken, userId, authRedirectUrl)
+        err = line.ReplyAuthRequest(replyToken, state, authRedirectUrl)
     }
     if err != nil {
         log.Errorf("Error replying auth request: %s", err)
@@ -95,6 +112,37 @@ func requestAuth(
     return nil
 }
 
+// RequestReauth replies the Google OAuth request to the user whose Google token was revoked or purged, telling the user so,
+// or sends it if there is no reply token in testing
+func RequestReauth(
+    replyToken string,
+    userId string,
+    line *lineUtil.LineUtil,
+    log *zap.SugaredLogger,
+    authRedirectUrl string,
+    stateSigner *oauthState.Signer,
+) error {
+    state, err := stateSigner.Sign(userId)
+    if err != nil {
+        log.Errorf("Error signing OAuth state of user %s: %s", userId, err)
+        return err
+    }
+
+    // when testing in local, there is no replyToken, send to user instead of replying
+    if replyToken == "TST" {
+        err = line.SendReauthRequest(userId, state, authRedirectUrl)
+    } else {
+        err = line.ReplyReauthRequest(replyToken, state, authRedirectUrl)
+    }
+    if err != nil {
+        log.Errorf("Error replying re-auth request: %s", err)
+        return err
+    }
+    log.Info("Sent re-auth request to user ", userId)
+
+    return nil
+}
+
 // TODO: [INT-91] remove this check after LINE user info backfilling is done
 func backfillLineUserInfo(user *model.User, userDao *ddbDao.UserDao, line *lineUtil.LineUtil, handlerName enum2.HandlerName, log *zap.SugaredLogger) {
     if stringUtil.IsEmptyString(user.LineUsername) || stringUtil.IsEmptyString(user.LineProfilePictureUrl) || stringUtil.IsEmptyString(user.Language) {
@@ -137,34 +185,40 @@ func backfillLineUserInfo(user *model.User, userDao *ddbDao.UserDao, line *lineU
 }
 
 // ValidateUserAuth checks if the user has completed oauth.
-// Returns: hasUserAuthed, user, business, error
-// if hasUserAuthed is true, user and business will not be nil
-// If user has not completed oauth, hasUserAuthed will be false, and business may be nil
-// If user is not found, hasUserAuthed will be false, and user will also be nil
+// Returns: authState, user, error
+// If user has not completed oauth, authState will be enum2.UserAuthStateNotAuthed
+// If user completed oauth but the Google token was revoked or purged since, authState will be enum2.UserAuthStateNeedsReauth
+// If user is not found, error will be exception.UserDoesNotExistException
 func ValidateUserAuth(
     userId string,
     userDao *ddbDao.UserDao,
     line *lineUtil.LineUtil,
     handlerName enum2.HandlerName,
-    logger *zap.SugaredLogger) (bool, model.User, error) {
+    logger *zap.SugaredLogger) (enum2.UserAuthState, model.User, error) {
     userPtr, err := userDao.GetUser(userId)
     if err != nil {
         logger.Error("Error getting user: ", err)
-        return false, model.User{}, err
+        return enum2.UserAuthStateNotAuthed, model.User{}, err
     }
 
     if userPtr == nil {
-        return false, model.User{}, exception.NewUserDoesNotExistException(fmt.Sprintf("User with id %s does not exist", userId), nil)
+        return enum2.UserAuthStateNotAuthed, model.User{}, exception.NewUserDoesNotExistException(fmt.Sprintf("User with id %s does not exist", userId), nil)
     }
 
     backfillLineUserInfo(userPtr, userDao, line, handlerName, logger)
 
     user := *userPtr
 
-    // either condition works, checking both for robustness
-    if len(user.BusinessIds) == 0 || stringUtil.IsEmptyString(user.Google.RefreshToken) {
-        return false, user, nil
+    if len(user.BusinessIds) == 0 {
+        return enum2.UserAuthStateNotAuthed, user, nil
+    }
+    if stringUtil.IsEmptyString(user.Google.RefreshToken) {
+        // tokens are cleared without the other Google metadata when revoked or purged
+        if !stringUtil.IsEmptyString(user.Google.BusinessAccountId) {
+            return enum2.UserAuthStateNeedsReauth, user, nil
+        }
+        return enum2.UserAuthStateNotAuthed, user, nil
     }
 
-    return true, user, nil
+    return enum2.UserAuthStateAuthed, user, nil
 }
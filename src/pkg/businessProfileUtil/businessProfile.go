@@ -0,0 +1,244 @@
+package businessProfileUtil
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "go.uber.org/zap"
+    "golang.org/x/oauth2"
+    "golang.org/x/oauth2/google"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ErrNotFound is returned when the requested resource does not exist, e.g., the review was removed
+var ErrNotFound = errors.New("resource not found")
+
+// ErrTokenRevoked is returned when Google rejects the refresh token with invalid_grant, e.g., the user revoked access in their Google account.
+// The token cannot be used anymore until the user completes OAuth again.
+var ErrTokenRevoked = errors.New("Google refresh token is revoked or expired")
+
+const DefaultBaseUrl = "https://mybusiness.googleapis.com/v4"
+const businessManageScope = "https://www.googleapis.com/auth/business.manage"
+const requestTimeout = 30 * time.Second
+const listReviewsPageSize = 50
+
+// BusinessProfile calls the Google Business Profile API on behalf of a user with the user's stored OAuth token
+type BusinessProfile struct {
+    oauthConfig oauth2.Config
+    baseUrl     string
+    log         *zap.SugaredLogger
+}
+
+// NewBusinessProfile creates a Business Profile API client.
+// The API base URL and OAuth token URL can be overridden by env vars, e.g., to test against a local HTTP stub.
+func NewBusinessProfile(clientId string, clientSecret string, logger *zap.SugaredLogger) *BusinessProfile {
+    endpoint := google.Endpoint
+    if tokenUrl := os.Getenv(util.GoogleOauthTokenUrlEnvKey); tokenUrl != "" {
+        endpoint.TokenURL = tokenUrl
+    }
+
+    baseUrl := DefaultBaseUrl
+    if baseUrlOverride := os.Getenv(util.GoogleBusinessProfileBaseUrlEnvKey); baseUrlOverride != "" {
+        baseUrl = strings.TrimSuffix(baseUrlOverride, "/")
+    }
+
+    return &BusinessProfile{
+        oauthConfig: oauth2.Config{
+            ClientID:     clientId,
+            ClientSecret: clientSecret,
+            Endpoint:     endpoint,
+            Scopes:       []string{businessManageScope},
+        },
+        baseUrl: baseUrl,
+        log:     logger,
+    }
+}
+
+// UpdateReply creates or updates the reply of the review
+// reviewName is the review resource name in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID"
+// Returns the token used for the call, which differs from the given token if the access token was refreshed.
+func (b *BusinessProfile) UpdateReply(ctx context.Context, token oauth2.Token, reviewName string, comment string) (oauth2.Token, error) {
+    jsonData, err := json.Marshal(model.ReviewReply{Comment: comment})
+    if err != nil {
+        b.log.Errorf("error marshaling review reply to JSON: %v", err)
+        return token, err
+    }
+
+    _, usedToken, err := b.send(ctx, token, http.MethodPut, reviewName+"/reply", jsonData)
+    if err != nil {
+        b.log.Errorf("error updating reply for review '%s': %v", reviewName, err)
+        return usedToken, err
+    }
+
+    return usedToken, nil
+}
+
+// GetReview gets the review by its resource name
+// Returns the token used for the call, which differs from the given token if the access token was refreshed.
+func (b *BusinessProfile) GetReview(ctx context.Context, token oauth2.Token, reviewName string) (model.Review, oauth2.Token, error) {
+    respBody, usedToken, err := b.send(ctx, token, http.MethodGet, reviewName, nil)
+    if err != nil {
+        b.log.Errorf("error getting review '%s': %v", reviewName, err)
+        return model.Review{}, usedToken, err
+    }
+
+    var review model.Review
+    err = json.Unmarshal(respBody, &review)
+    if err != nil {
+        b.log.Errorf("error parsing review '%s' from response %s: %v", reviewName, respBody, err)
+        return model.Review{}, usedToken, err
+    }
+
+    return review, usedToken, nil
+}
+
+// ListReviews lists a page of reviews of the location, most recently updated first
+// locationName is the location resource name in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID"
+// Returns the token used for the call, which differs from the given token if the access token was refreshed.
+func (b *BusinessProfile) ListReviews(ctx context.Context, token oauth2.Token, locationName string, pageToken string) (model.ListReviewsResponse, oauth2.Token, error) {
+    query := url.Values{}
+    query.Set("pageSize", strconv.Itoa(listReviewsPageSize))
+    query.Set("orderBy", "updateTime desc")
+    if pageToken != "" {
+        query.Set("pageToken", pageToken)
+    }
+
+    respBody, usedToken, err := b.send(ctx, token, http.MethodGet, locationName+"/reviews?"+query.Encode(), nil)
+    if err != nil {
+        b.log.Errorf("error listing reviews of location '%s': %v", locationName, err)
+        return model.ListReviewsResponse{}, usedToken, err
+    }
+
+    var response model.ListReviewsResponse
+    err = json.Unmarshal(respBody, &response)
+    if err != nil {
+        b.log.Errorf("error parsing reviews of location '%s' from response %s: %v", locationName, respBody, err)
+        return model.ListReviewsResponse{}, usedToken, err
+    }
+
+    return response, usedToken, nil
+}
+
+// ListReviewsUpdatedSince lists all reviews of the location updated at or after since, most recently updated first
+// Returns the token used for the calls, which differs from the given token if the access token was refreshed.
+func (b *BusinessProfile) ListReviewsUpdatedSince(ctx context.Context, token oauth2.Token, locationName string, since time.Time) ([]model.Review, oauth2.Token, error) {
+    var reviews []model.Review
+    pageToken := ""
+    for {
+        response, usedToken, err := b.ListReviews(ctx, token, locationName, pageToken)
+        token = usedToken
+        if err != nil {
+            return reviews, token, err
+        }
+
+        for _, review := range response.Reviews {
+            updateTime, err := time.Parse(time.RFC3339, review.UpdateTime)
+            if err != nil {
+                b.log.Warnf("error parsing update time '%s' of review '%s'. Including the review: %v", review.UpdateTime, review.Name, err)
+            } else if updateTime.Before(since) {
+                // reviews are ordered by update time, so the remaining reviews are older
+                return reviews, token, nil
+            }
+            reviews = append(reviews, review)
+        }
+
+        if response.NextPageToken == "" {
+            return reviews, token, nil
+        }
+        pageToken = response.NextPageToken
+    }
+}
+
+// RefreshToken refreshes the access token with the refresh token, regardless of whether the access token has expired.
+// Returns ErrTokenRevoked if the refresh token is revoked.
+func (b *BusinessProfile) RefreshToken(ctx context.Context, token oauth2.Token) (oauth2.Token, error) {
+    ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+    defer cancel()
+
+    // a token without access token is always refreshed
+    refreshedToken, err := b.oauthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+    if err != nil {
+        if isInvalidGrant(err) {
+            return token, fmt.Errorf("error refreshing token: %w: %v", ErrTokenRevoked, err)
+        }
+        return token, fmt.Errorf("error refreshing token: %w", err)
+    }
+
+    // Google does not return the refresh token on refresh
+    if refreshedToken.RefreshToken == "" {
+        refreshedToken.RefreshToken = token.RefreshToken
+    }
+    return *refreshedToken, nil
+}
+
+// isInvalidGrant returns true if the error is Google rejecting the refresh token
+func isInvalidGrant(err error) bool {
+    var retrieveError *oauth2.RetrieveError
+    return errors.As(err, &retrieveError) && retrieveError.ErrorCode == "invalid_grant"
+}
+
+// send calls the API at the resource path on behalf of the token owner and returns the response body of a 200 response.
+// Failed requests return exception.GoogleRequestException, which indicates whether the failure is transient, except for ErrNotFound and ErrTokenRevoked.
+func (b *BusinessProfile) send(ctx context.Context, token oauth2.Token, method string, path string, jsonData []byte) ([]byte, oauth2.Token, error) {
+    ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+    defer cancel()
+
+    tokenSource := b.oauthConfig.TokenSource(ctx, &token)
+
+    var body io.Reader
+    if jsonData != nil {
+        body = bytes.NewBuffer(jsonData)
+    }
+
+    requestUrl := fmt.Sprintf("%s/%s", b.baseUrl, strings.TrimPrefix(path, "/"))
+    req, err := http.NewRequestWithContext(ctx, method, requestUrl, body)
+    if err != nil {
+        return nil, token, fmt.Errorf("error creating HTTP request: %w", err)
+    }
+    if jsonData != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+
+    resp, err := oauth2.NewClient(ctx, tokenSource).Do(req)
+    if err != nil {
+        if isInvalidGrant(err) {
+            return nil, token, fmt.Errorf("error refreshing token for %s request to '%s': %w: %v", method, path, ErrTokenRevoked, err)
+        }
+        return nil, token, exception.NewTransientGoogleRequestException(fmt.Sprintf("error sending %s request to '%s'", method, path), 0, "", err)
+    }
+    defer resp.Body.Close()
+
+    respBody, err := io.ReadAll(resp.Body)
+    if err != nil {
+        b.log.Warnf("error reading response body of %s request to '%s': %v", method, path, err)
+    }
+
+    if resp.StatusCode == http.StatusNotFound {
+        return nil, token, fmt.Errorf("%s request to '%s' failed: %w", method, path, ErrNotFound)
+    }
+    if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+        return nil, token, exception.NewTransientGoogleRequestException(fmt.Sprintf("%s request to '%s' failed", method, path), resp.StatusCode, string(respBody), fmt.Errorf("status %s", resp.Status))
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, token, exception.NewPermanentGoogleRequestException(fmt.Sprintf("%s request to '%s' failed", method, path), resp.StatusCode, string(respBody), fmt.Errorf("status %s", resp.Status))
+    }
+
+    // the token source only refreshes the token if it has expired, so this does not make another call
+    usedToken, err := tokenSource.Token()
+    if err != nil {
+        return respBody, token, nil
+    }
+
+    return respBody, *usedToken, nil
+}
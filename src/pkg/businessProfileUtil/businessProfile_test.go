@@ -0,0 +1,133 @@
+package businessProfileUtil
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "go.uber.org/zap"
+    "golang.org/x/oauth2"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestBusinessProfileUpdateReplyErrors(t *testing.T) {
+    tests := []struct {
+        name           string
+        statusCode     int
+        wantErr        bool
+        wantNotFound   bool
+        wantTransient  bool
+        wantStatusCode int
+    }{
+        {
+            name:       "success",
+            statusCode: http.StatusOK,
+        },
+        {
+            name:         "review removed",
+            statusCode:   http.StatusNotFound,
+            wantErr:      true,
+            wantNotFound: true,
+        },
+        {
+            name:           "rate limited",
+            statusCode:     http.StatusTooManyRequests,
+            wantErr:        true,
+            wantTransient:  true,
+            wantStatusCode: http.StatusTooManyRequests,
+        },
+        {
+            name:           "server error",
+            statusCode:     http.StatusInternalServerError,
+            wantErr:        true,
+            wantTransient:  true,
+            wantStatusCode: http.StatusInternalServerError,
+        },
+        {
+            name:           "service unavailable",
+            statusCode:     http.StatusServiceUnavailable,
+            wantErr:        true,
+            wantTransient:  true,
+            wantStatusCode: http.StatusServiceUnavailable,
+        },
+        {
+            name:           "bad request",
+            statusCode:     http.StatusBadRequest,
+            wantErr:        true,
+            wantTransient:  false,
+            wantStatusCode: http.StatusBadRequest,
+        },
+        {
+            name:           "permission denied",
+            statusCode:     http.StatusForbidden,
+            wantErr:        true,
+            wantTransient:  false,
+            wantStatusCode: http.StatusForbidden,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(tt.statusCode)
+                _, _ = w.Write([]byte(`{}`))
+            }))
+            defer server.Close()
+
+            _, err := newTestBusinessProfile(t, server.URL).UpdateReply(context.Background(), validToken(), "accounts/1/locations/2/reviews/3", "Thank you!")
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("UpdateReply() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err == nil {
+                return
+            }
+            if errors.Is(err, ErrNotFound) != tt.wantNotFound {
+                t.Errorf("UpdateReply() error = %v, want not found %v", err, tt.wantNotFound)
+            }
+            if tt.wantNotFound {
+                return
+            }
+
+            var googleRequestException exception.GoogleRequestException
+            if !errors.As(err, &googleRequestException) {
+                t.Fatalf("UpdateReply() error = %v, want GoogleRequestException", err)
+            }
+            if googleRequestException.Transient != tt.wantTransient {
+                t.Errorf("UpdateReply() transient = %v, want %v", googleRequestException.Transient, tt.wantTransient)
+            }
+            if googleRequestException.StatusCode != tt.wantStatusCode {
+                t.Errorf("UpdateReply() status code = %d, want %d", googleRequestException.StatusCode, tt.wantStatusCode)
+            }
+        })
+    }
+}
+
+func TestBusinessProfileUpdateReplyWithoutResponse(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    unreachableUrl := server.URL
+    server.Close()
+
+    _, err := newTestBusinessProfile(t, unreachableUrl).UpdateReply(context.Background(), validToken(), "accounts/1/locations/2/reviews/3", "Thank you!")
+
+    var googleRequestException exception.GoogleRequestException
+    if !errors.As(err, &googleRequestException) {
+        t.Fatalf("UpdateReply() error = %v, want GoogleRequestException", err)
+    }
+    if !googleRequestException.Transient {
+        t.Errorf("UpdateReply() transient = false, want network errors to be transient")
+    }
+}
+
+func newTestBusinessProfile(t *testing.T, baseUrl string) *BusinessProfile {
+    t.Helper()
+    t.Setenv(util.GoogleBusinessProfileBaseUrlEnvKey, baseUrl)
+    return NewBusinessProfile("client-id", "client-secret", zap.NewNop().Sugar())
+}
+
+// validToken returns a token whose access token is not refreshed
+func validToken() oauth2.Token {
+    return oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token", Expiry: time.Now().Add(time.Hour)}
+}
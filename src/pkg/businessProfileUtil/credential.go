@@ -0,0 +1,74 @@
+package businessProfileUtil
+
+import (
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "go.uber.org/zap"
+    "golang.org/x/oauth2"
+)
+
+// ErrNoGoogleCredentials is returned when no user of the business has Google credentials
+var ErrNoGoogleCredentials = errors.New("no user of the business has Google credentials")
+
+// FindCredentialOwner finds a user of the business with stored Google credentials, preferring the given user.
+// Returns ErrNoGoogleCredentials if no user of the business has Google credentials.
+func FindCredentialOwner(business model.Business, preferredUserId string, userDao *ddbDao.UserDao) (model.User, error) {
+    userIds := business.UserIds
+    if stringUtil.StringInSlice(preferredUserId, userIds) {
+        userIds = append([]string{preferredUserId}, stringUtil.RemoveStringFromSlice(userIds, preferredUserId)...)
+    }
+
+    var errs []error
+    for _, userId := range userIds {
+        userPtr, err := userDao.GetUser(userId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error getting user '%s': %w", userId, err))
+            continue
+        }
+        if userPtr != nil && HasGoogleCredentials(*userPtr) {
+            return *userPtr, nil
+        }
+    }
+
+    if len(errs) > 0 {
+        return model.User{}, errors.Join(errs...)
+    }
+    return model.User{}, ErrNoGoogleCredentials
+}
+
+// HasGoogleCredentials returns true if the user has a stored Google refresh token.
+// Users who completed OAuth have no refresh token once it is revoked or purged, until they complete OAuth again.
+func HasGoogleCredentials(user model.User) bool {
+    return !stringUtil.IsEmptyString(user.Google.RefreshToken)
+}
+
+// TokenOf returns the stored Google OAuth token of the user
+func TokenOf(user model.User) oauth2.Token {
+    return oauth2.Token{
+        AccessToken:  user.Google.AccessToken,
+        TokenType:    "Bearer",
+        RefreshToken: user.Google.RefreshToken,
+        Expiry:       user.Google.AccessTokenExpireAt,
+    }
+}
+
+// PersistRefreshedToken stores the used token of the user if it was refreshed, so that the next call does not need to refresh again.
+// Failures are only logged since the stored refresh token is still valid.
+func PersistRefreshedToken(user model.User, storedToken oauth2.Token, usedToken oauth2.Token, userDao *ddbDao.UserDao, log *zap.SugaredLogger) {
+    if usedToken.AccessToken == storedToken.AccessToken {
+        return
+    }
+
+    _, err := userDao.UpdateAttributes(user.UserId, []dbModel.AttributeAction{
+        {Action: enum.ActionUpdate, Name: "google.accessToken", Value: usedToken.AccessToken},
+        {Action: enum.ActionUpdate, Name: "google.accessTokenExpireAt", Value: usedToken.Expiry},
+    })
+    if err != nil {
+        log.Warnf("Error persisting refreshed Google access token for user '%s'. Proceeding: %v", user.UserId, err)
+    }
+}
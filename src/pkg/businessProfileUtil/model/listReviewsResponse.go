@@ -0,0 +1,10 @@
+package model
+
+// ListReviewsResponse is the response of listing reviews of a location in Google Business Profile API
+// https://developers.google.com/my-business/reference/rest/v4/accounts.locations.reviews/list#response-body
+type ListReviewsResponse struct {
+    Reviews          []Review `json:"reviews"`
+    AverageRating    float64  `json:"averageRating"`
+    TotalReviewCount int      `json:"totalReviewCount"`
+    NextPageToken    string   `json:"nextPageToken,omitempty"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import "fmt"
+
+// Review is a review of a location in Google Business Profile API
+// https://developers.google.com/my-business/reference/rest/v4/accounts.locations.reviews#Review
+type Review struct {
+    // Name is the resource name in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID"
+    Name        string       `json:"name"`
+    ReviewId    string       `json:"reviewId"`
+    Reviewer    Reviewer     `json:"reviewer"`
+    StarRating  StarRating   `json:"starRating"`
+    Comment     string       `json:"comment,omitempty"`
+    CreateTime  string       `json:"createTime"`
+    UpdateTime  string       `json:"updateTime"`
+    ReviewReply *ReviewReply `json:"reviewReply,omitempty"`
+}
+
+type Reviewer struct {
+    ProfilePhotoUrl string `json:"profilePhotoUrl,omitempty"`
+    DisplayName     string `json:"displayName,omitempty"`
+    IsAnonymous     bool   `json:"isAnonymous,omitempty"`
+}
+
+type StarRating string
+
+const (
+    StarRatingOne   StarRating = "ONE"
+    StarRatingTwo   StarRating = "TWO"
+    StarRatingThree StarRating = "THREE"
+    StarRatingFour  StarRating = "FOUR"
+    StarRatingFive  StarRating = "FIVE"
+)
+
+// Number returns the number of stars of the rating
+func (s StarRating) Number() (int, error) {
+    switch s {
+    case StarRatingOne:
+        return 1, nil
+    case StarRatingTwo:
+        return 2, nil
+    case StarRatingThree:
+        return 3, nil
+    case StarRatingFour:
+        return 4, nil
+    case StarRatingFive:
+        return 5, nil
+    default:
+        return 0, fmt.Errorf("unspecified star rating '%s'", s)
+    }
+}
@@ -0,0 +1,8 @@
+package model
+
+// ReviewReply is the reply of a review in Google Business Profile API
+// https://developers.google.com/my-business/reference/rest/v4/accounts.locations.reviews#ReviewReply
+type ReviewReply struct {
+    Comment    string `json:"comment"`
+    UpdateTime string `json:"updateTime,omitempty"`
+}
@@ -0,0 +1,95 @@
+package dao
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "strconv"
+    "time"
+)
+
+// AiReplyCacheDao stores the AI replies reused for identical reviews, keyed on business ID and cache key
+type AiReplyCacheDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewAiReplyCacheDao(client *dynamodb.Client, logger *zap.SugaredLogger) *AiReplyCacheDao {
+    return &AiReplyCacheDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetAiReplyCacheEntry gets the cache entry. Returns nil if not found or expired.
+func (d *AiReplyCacheDao) GetAiReplyCacheEntry(ctx context.Context, businessId string, cacheKey string) (*model.AiReplyCacheEntry, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName: aws.String(AiReplyCacheTableName),
+        Key: map[string]types.AttributeValue{
+            "businessId": &types.AttributeValueMemberS{Value: businessId},
+            "cacheKey":   &types.AttributeValueMemberS{Value: cacheKey},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error getting AI reply cache entry '%s' of business '%s': %v", cacheKey, businessId, err)
+        return nil, err
+    }
+    if output.Item == nil {
+        return nil, nil
+    }
+
+    var entry model.AiReplyCacheEntry
+    err = attributevalue.UnmarshalMap(output.Item, &entry)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling AI reply cache entry '%s' of business '%s': %v", cacheKey, businessId, err)
+        return nil, err
+    }
+    // TTL removes expired items eventually rather than immediately
+    if !time.Now().Before(entry.ExpireAt) {
+        return nil, nil
+    }
+    return &entry, nil
+}
+
+// PutAiReplyCacheEntry puts the cache entry with its version incremented, unless the entry was written since it was read at its version.
+// An expired entry not yet removed by TTL is overwritten as if it did not exist.
+// Returns false if the entry was written concurrently, in which case it should be read again.
+func (d *AiReplyCacheDao) PutAiReplyCacheEntry(ctx context.Context, entry model.AiReplyCacheEntry) (bool, error) {
+    readVersion := entry.Version
+    entry.Version++
+    item, err := attributevalue.MarshalMap(entry)
+    if err != nil {
+        return false, err
+    }
+
+    // a new entry may replace entries cached before versioning, which have no version
+    conditionExpression := "attribute_not_exists(version) OR expireAt <= :now"
+    expressionAttributeValues := map[string]types.AttributeValue{
+        ":now": unixTimeAttributeValue(time.Now()),
+    }
+    if readVersion > 0 {
+        conditionExpression = "version = :version OR expireAt <= :now"
+        expressionAttributeValues[":version"] = &types.AttributeValueMemberN{Value: strconv.Itoa(readVersion)}
+    }
+    _, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName:                 aws.String(AiReplyCacheTableName),
+        Item:                      item,
+        ConditionExpression:       aws.String(conditionExpression),
+        ExpressionAttributeValues: expressionAttributeValues,
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            d.log.Infof("AI reply cache entry '%s' of business '%s' was written concurrently", entry.CacheKey, entry.BusinessId)
+            return false, nil
+        }
+        d.log.Errorf("Error putting AI reply cache entry '%s' of business '%s': %v", entry.CacheKey, entry.BusinessId, err)
+        return false, err
+    }
+    return true, nil
+}
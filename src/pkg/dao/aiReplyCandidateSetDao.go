@@ -0,0 +1,72 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "time"
+)
+
+// AiReplyCandidateSetDao stores the AI reply candidates shown on AI reply cards, keyed on business ID and candidate set ID
+type AiReplyCandidateSetDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewAiReplyCandidateSetDao(client *dynamodb.Client, logger *zap.SugaredLogger) *AiReplyCandidateSetDao {
+    return &AiReplyCandidateSetDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetAiReplyCandidateSet gets the candidate set. Returns nil if not found or expired.
+func (d *AiReplyCandidateSetDao) GetAiReplyCandidateSet(ctx context.Context, businessId string, candidateSetId string) (*model.AiReplyCandidateSet, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName: aws.String(AiReplyCandidateSetTableName),
+        Key: map[string]types.AttributeValue{
+            "businessId":     &types.AttributeValueMemberS{Value: businessId},
+            "candidateSetId": &types.AttributeValueMemberS{Value: candidateSetId},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error getting AI reply candidate set '%s' of business '%s': %v", candidateSetId, businessId, err)
+        return nil, err
+    }
+    if output.Item == nil {
+        return nil, nil
+    }
+
+    var candidateSet model.AiReplyCandidateSet
+    err = attributevalue.UnmarshalMap(output.Item, &candidateSet)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling AI reply candidate set '%s' of business '%s': %v", candidateSetId, businessId, err)
+        return nil, err
+    }
+    // TTL removes expired items eventually rather than immediately
+    if !time.Now().Before(candidateSet.ExpireAt) {
+        return nil, nil
+    }
+    return &candidateSet, nil
+}
+
+func (d *AiReplyCandidateSetDao) PutAiReplyCandidateSet(ctx context.Context, candidateSet model.AiReplyCandidateSet) error {
+    item, err := attributevalue.MarshalMap(candidateSet)
+    if err != nil {
+        return err
+    }
+
+    _, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName: aws.String(AiReplyCandidateSetTableName),
+        Item:      item,
+    })
+    if err != nil {
+        d.log.Errorf("Error putting AI reply candidate set of review '%s' of business '%s': %v", candidateSet.ReviewId, candidateSet.BusinessId, err)
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,155 @@
+package dao
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "strconv"
+    "time"
+)
+
+// AiReplyDraftDao stores AI auto replies awaiting approval, keyed on business ID and review ID
+type AiReplyDraftDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewAiReplyDraftDao(client *dynamodb.Client, logger *zap.SugaredLogger) *AiReplyDraftDao {
+    return &AiReplyDraftDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetAiReplyDraft gets the draft of the review. Returns nil if not found or removed by TTL.
+func (d *AiReplyDraftDao) GetAiReplyDraft(ctx context.Context, businessId string, reviewId string) (*model.AiReplyDraft, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName:      aws.String(AiReplyDraftTableName),
+        Key:            aiReplyDraftKey(businessId, reviewId),
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        d.log.Errorf("Error getting AI reply draft of review '%s' of business '%s': %v", reviewId, businessId, err)
+        return nil, err
+    }
+    if output.Item == nil {
+        return nil, nil
+    }
+
+    var draft model.AiReplyDraft
+    err = attributevalue.UnmarshalMap(output.Item, &draft)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling AI reply draft of review '%s' of business '%s': %v", reviewId, businessId, err)
+        return nil, err
+    }
+    return &draft, nil
+}
+
+// PutAiReplyDraft creates the draft of the review, or replaces the pending draft of the review, e.g., when regenerated.
+// Returns *exception.AiReplyDraftNotPendingException if the existing draft has been approved.
+func (d *AiReplyDraftDao) PutAiReplyDraft(ctx context.Context, draft model.AiReplyDraft) error {
+    item, err := attributevalue.MarshalMap(draft)
+    if err != nil {
+        return err
+    }
+
+    _, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName:           aws.String(AiReplyDraftTableName),
+        Item:                item,
+        ConditionExpression: aws.String("attribute_not_exists(businessId) OR #status = :pending"),
+        ExpressionAttributeNames: map[string]string{
+            "#status": "status",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":pending": aiReplyDraftStatusAttributeValue(enum.AiReplyDraftStatusPending),
+        },
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            return exception.NewAiReplyDraftNotPendingException(fmt.Sprintf("AI reply draft of review '%s' has been approved", draft.ReviewId), err)
+        }
+        d.log.Errorf("Error putting AI reply draft of review '%s' of business '%s': %v", draft.ReviewId, draft.BusinessId, err)
+        return err
+    }
+    return nil
+}
+
+// ApproveAiReplyDraft marks the pending and unexpired draft approved by the user and returns the approved draft.
+// Returns *exception.AiReplyDraftNotPendingException if the draft does not exist, has been approved or has expired.
+func (d *AiReplyDraftDao) ApproveAiReplyDraft(ctx context.Context, businessId string, reviewId string, userId string) (model.AiReplyDraft, error) {
+    now := time.Now()
+    output, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(AiReplyDraftTableName),
+        Key:                 aiReplyDraftKey(businessId, reviewId),
+        UpdateExpression:    aws.String("SET #status = :approved, approvedBy = :approvedBy, lastUpdated = :now"),
+        ConditionExpression: aws.String("#status = :pending AND expireAt > :now"),
+        ExpressionAttributeNames: map[string]string{
+            "#status": "status",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":approved":   aiReplyDraftStatusAttributeValue(enum.AiReplyDraftStatusApproved),
+            ":pending":    aiReplyDraftStatusAttributeValue(enum.AiReplyDraftStatusPending),
+            ":approvedBy": &types.AttributeValueMemberS{Value: userId},
+            ":now":        unixTimeAttributeValue(now),
+        },
+        ReturnValues: types.ReturnValueAllNew,
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            return model.AiReplyDraft{}, exception.NewAiReplyDraftNotPendingException(fmt.Sprintf("AI reply draft of review '%s' is not pending", reviewId), err)
+        }
+        d.log.Errorf("Error approving AI reply draft of review '%s' of business '%s': %v", reviewId, businessId, err)
+        return model.AiReplyDraft{}, err
+    }
+
+    var draft model.AiReplyDraft
+    err = attributevalue.UnmarshalMap(output.Attributes, &draft)
+    if err != nil {
+        return model.AiReplyDraft{}, err
+    }
+    return draft, nil
+}
+
+// RevertAiReplyDraftApproval marks the approved draft pending again, e.g., when publishing the draft failed, so that it can be approved again
+func (d *AiReplyDraftDao) RevertAiReplyDraftApproval(ctx context.Context, businessId string, reviewId string) error {
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(AiReplyDraftTableName),
+        Key:                 aiReplyDraftKey(businessId, reviewId),
+        UpdateExpression:    aws.String("SET #status = :pending, lastUpdated = :now REMOVE approvedBy"),
+        ConditionExpression: aws.String("attribute_exists(businessId)"),
+        ExpressionAttributeNames: map[string]string{
+            "#status": "status",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":pending": aiReplyDraftStatusAttributeValue(enum.AiReplyDraftStatusPending),
+            ":now":     unixTimeAttributeValue(time.Now()),
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error reverting approval of AI reply draft of review '%s' of business '%s': %v", reviewId, businessId, err)
+        return err
+    }
+    return nil
+}
+
+func aiReplyDraftKey(businessId string, reviewId string) map[string]types.AttributeValue {
+    return map[string]types.AttributeValue{
+        "businessId": &types.AttributeValueMemberS{Value: businessId},
+        "reviewId":   &types.AttributeValueMemberS{Value: reviewId},
+    }
+}
+
+// aiReplyDraftStatusAttributeValue matches the encoding of enum.AiReplyDraftStatus in model.AiReplyDraft
+func aiReplyDraftStatusAttributeValue(status enum.AiReplyDraftStatus) types.AttributeValue {
+    return &types.AttributeValueMemberN{Value: strconv.Itoa(int(status))}
+}
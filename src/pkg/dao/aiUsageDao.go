@@ -0,0 +1,151 @@
+package dao
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "time"
+)
+
+// PlanTierAttributeName is the attribute of the business item storing its plan tier
+const PlanTierAttributeName = "planTier"
+
+// AiUsageDao stores the AI usage of businesses and users by month and by day, keyed on period and subject, and reads the plan tier of businesses
+type AiUsageDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewAiUsageDao(client *dynamodb.Client, logger *zap.SugaredLogger) *AiUsageDao {
+    return &AiUsageDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// RecordUsage adds the usage at the time to the monthly and daily usage of the business and, if userId is not empty, of the user
+func (d *AiUsageDao) RecordUsage(ctx context.Context, businessId bid.BusinessId, userId string, promptTokens int, completionTokens int, costUsd float64, at time.Time) error {
+    subjects := []string{model.BusinessAiUsageSubject(businessId.String())}
+    if userId != "" {
+        subjects = append(subjects, model.UserAiUsageSubject(userId))
+    }
+
+    for _, subject := range subjects {
+        err := d.addUsage(ctx, model.MonthlyAiUsagePeriod(at), subject, promptTokens, completionTokens, costUsd, nil)
+        if err != nil {
+            return err
+        }
+        expireAt := at.Add(model.AiUsageDailyTtl)
+        err = d.addUsage(ctx, model.DailyAiUsagePeriod(at), subject, promptTokens, completionTokens, costUsd, &expireAt)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// addUsage atomically adds the usage to the usage of the subject in the period, creating it if it does not exist
+func (d *AiUsageDao) addUsage(ctx context.Context, period string, subject string, promptTokens int, completionTokens int, costUsd float64, expireAt *time.Time) error {
+    updateExpression := "ADD requests :one, promptTokens :promptTokens, completionTokens :completionTokens, estimatedCostUsd :cost"
+    values := map[string]types.AttributeValue{
+        ":one":              &types.AttributeValueMemberN{Value: "1"},
+        ":promptTokens":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", promptTokens)},
+        ":completionTokens": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", completionTokens)},
+        ":cost":             &types.AttributeValueMemberN{Value: fmt.Sprintf("%f", costUsd)},
+    }
+    if expireAt != nil {
+        updateExpression += " SET expireAt = if_not_exists(expireAt, :expireAt)"
+        values[":expireAt"] = unixTimeAttributeValue(*expireAt)
+    }
+
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName: aws.String(AiUsageTableName),
+        Key: map[string]types.AttributeValue{
+            "period":  &types.AttributeValueMemberS{Value: period},
+            "subject": &types.AttributeValueMemberS{Value: subject},
+        },
+        UpdateExpression:          aws.String(updateExpression),
+        ExpressionAttributeValues: values,
+    })
+    if err != nil {
+        d.log.Errorf("Error adding AI usage of '%s' in %s: %v", subject, period, err)
+        return err
+    }
+    return nil
+}
+
+// GetUsage gets the usage of the subject in the period. Returns empty usage if there is none.
+func (d *AiUsageDao) GetUsage(ctx context.Context, period string, subject string) (model.AiUsage, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName: aws.String(AiUsageTableName),
+        Key: map[string]types.AttributeValue{
+            "period":  &types.AttributeValueMemberS{Value: period},
+            "subject": &types.AttributeValueMemberS{Value: subject},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error getting AI usage of '%s' in %s: %v", subject, period, err)
+        return model.AiUsage{}, err
+    }
+    if output.Item == nil {
+        return model.AiUsage{Period: period, Subject: subject}, nil
+    }
+
+    var usage model.AiUsage
+    err = attributevalue.UnmarshalMap(output.Item, &usage)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling AI usage of '%s' in %s: %v", subject, period, err)
+        return model.AiUsage{}, err
+    }
+    return usage, nil
+}
+
+// ListUsage lists the usage of all businesses and users in the period
+func (d *AiUsageDao) ListUsage(ctx context.Context, period string) ([]model.AiUsage, error) {
+    paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+        TableName:              aws.String(AiUsageTableName),
+        KeyConditionExpression: aws.String("#period = :period"),
+        ExpressionAttributeNames: map[string]string{
+            "#period": "period",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":period": &types.AttributeValueMemberS{Value: period},
+        },
+    })
+
+    var usages []model.AiUsage
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            d.log.Errorf("Error querying AI usage in %s: %v", period, err)
+            return usages, err
+        }
+
+        var items []model.AiUsage
+        err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+        if err != nil {
+            d.log.Errorf("Error unmarshalling AI usage in %s: %v", period, err)
+            return usages, err
+        }
+        usages = append(usages, items...)
+    }
+    return usages, nil
+}
+
+// GetPlanTier gets the plan tier of the business. Defaults to enum.PlanTierFree.
+func (d *AiUsageDao) GetPlanTier(ctx context.Context, businessId bid.BusinessId) (enum.PlanTier, error) {
+    tier := enum.PlanTierFree
+    _, err := getBusinessAttribute(ctx, d.client, businessId, PlanTierAttributeName, &tier)
+    if err != nil {
+        d.log.Errorf("Error getting plan tier of business '%s': %v", businessId, err)
+        return enum.PlanTierFree, err
+    }
+    return tier, nil
+}
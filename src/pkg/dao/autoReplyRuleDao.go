@@ -0,0 +1,198 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
+    enum2 "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+)
+
+// AutoReplyRulesAttributeName is the attribute of the business item storing its auto reply rules
+const AutoReplyRulesAttributeName = "autoReplyRules"
+
+// AiAutoReplyModeAttributeName is the attribute of the business item storing its AI auto reply mode
+const AiAutoReplyModeAttributeName = "aiAutoReplyMode"
+
+// ReplyLanguageModeAttributeName is the attribute of the business item storing the language of its AI replies
+const ReplyLanguageModeAttributeName = "replyLanguageMode"
+
+// LocalizedQuickReplyMessagesAttributeName is the attribute of the business item storing its quick reply messages by review language
+const LocalizedQuickReplyMessagesAttributeName = "localizedQuickReplyMessages"
+
+// AutoReplyRuleDao stores the auto reply rules and other auto reply settings of businesses on their business items
+type AutoReplyRuleDao struct {
+    client      *dynamodb.Client
+    businessDao *ddbDao.BusinessDao
+    log         *zap.SugaredLogger
+}
+
+func NewAutoReplyRuleDao(client *dynamodb.Client, businessDao *ddbDao.BusinessDao, logger *zap.SugaredLogger) *AutoReplyRuleDao {
+    return &AutoReplyRuleDao{
+        client:      client,
+        businessDao: businessDao,
+        log:         logger,
+    }
+}
+
+// GetAutoReplyRules gets the auto reply rules of the business.
+// A business without stored rules has the legacy rule derived from its auto quick reply toggle.
+func (d *AutoReplyRuleDao) GetAutoReplyRules(ctx context.Context, business model.Business) ([]model2.AutoReplyRule, error) {
+    var rules []model2.AutoReplyRule
+    found, err := getBusinessAttribute(ctx, d.client, business.BusinessId, AutoReplyRulesAttributeName, &rules)
+    if err != nil {
+        d.log.Errorf("Error getting auto reply rules of business '%s': %v", business.BusinessId, err)
+        return nil, err
+    }
+    if !found {
+        return []model2.AutoReplyRule{model2.NewLegacyAutoReplyRule(business.AutoQuickReplyEnabled)}, nil
+    }
+    return rules, nil
+}
+
+// UpdateAutoReplyRules stores the auto reply rules of the business in evaluation order and returns the updated business
+func (d *AutoReplyRuleDao) UpdateAutoReplyRules(businessId bid.BusinessId, rules []model2.AutoReplyRule, updatedBy string) (model.Business, error) {
+    if rules == nil {
+        // store an empty list rather than null, so that the business does not fall back to the legacy rule
+        rules = []model2.AutoReplyRule{}
+    }
+
+    action, err := dbModel.NewAttributeAction(enum2.ActionUpdate, AutoReplyRulesAttributeName, rules)
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    business, err := d.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{action}, updatedBy)
+    if err != nil {
+        d.log.Errorf("Error updating auto reply rules of business '%s': %v", businessId, err)
+        return model.Business{}, err
+    }
+    return business, nil
+}
+
+// GetAiAutoReplyMode gets the AI auto reply mode of the business. Defaults to enum.AiAutoReplyModeOff.
+func (d *AutoReplyRuleDao) GetAiAutoReplyMode(ctx context.Context, businessId bid.BusinessId) (enum.AiAutoReplyMode, error) {
+    mode := enum.AiAutoReplyModeOff
+    _, err := getBusinessAttribute(ctx, d.client, businessId, AiAutoReplyModeAttributeName, &mode)
+    if err != nil {
+        d.log.Errorf("Error getting AI auto reply mode of business '%s': %v", businessId, err)
+        return enum.AiAutoReplyModeOff, err
+    }
+    return mode, nil
+}
+
+// UpdateAiAutoReplyMode stores the AI auto reply mode of the business and returns the updated business
+func (d *AutoReplyRuleDao) UpdateAiAutoReplyMode(businessId bid.BusinessId, mode enum.AiAutoReplyMode, updatedBy string) (model.Business, error) {
+    action, err := dbModel.NewAttributeAction(enum2.ActionUpdate, AiAutoReplyModeAttributeName, mode)
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    business, err := d.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{action}, updatedBy)
+    if err != nil {
+        d.log.Errorf("Error updating AI auto reply mode of business '%s' to %s: %v", businessId, mode, err)
+        return model.Business{}, err
+    }
+    return business, nil
+}
+
+// GetReplyLanguageMode gets the reply language mode of the business. Defaults to enum.ReplyLanguageModeChinese.
+func (d *AutoReplyRuleDao) GetReplyLanguageMode(ctx context.Context, businessId bid.BusinessId) (enum.ReplyLanguageMode, error) {
+    mode := enum.ReplyLanguageModeChinese
+    _, err := getBusinessAttribute(ctx, d.client, businessId, ReplyLanguageModeAttributeName, &mode)
+    if err != nil {
+        d.log.Errorf("Error getting reply language mode of business '%s': %v", businessId, err)
+        return enum.ReplyLanguageModeChinese, err
+    }
+    return mode, nil
+}
+
+// UpdateReplyLanguageMode stores the reply language mode of the business and returns the updated business
+func (d *AutoReplyRuleDao) UpdateReplyLanguageMode(businessId bid.BusinessId, mode enum.ReplyLanguageMode, updatedBy string) (model.Business, error) {
+    action, err := dbModel.NewAttributeAction(enum2.ActionUpdate, ReplyLanguageModeAttributeName, mode)
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    business, err := d.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{action}, updatedBy)
+    if err != nil {
+        d.log.Errorf("Error updating reply language mode of business '%s' to %s: %v", businessId, mode, err)
+        return model.Business{}, err
+    }
+    return business, nil
+}
+
+// GetLocalizedQuickReplyMessages gets the quick reply messages of the business by review language, as ISO 639-1 code.
+// The quick reply message of the business applies to languages without one.
+func (d *AutoReplyRuleDao) GetLocalizedQuickReplyMessages(ctx context.Context, businessId bid.BusinessId) (map[string]string, error) {
+    messages := map[string]string{}
+    _, err := getBusinessAttribute(ctx, d.client, businessId, LocalizedQuickReplyMessagesAttributeName, &messages)
+    if err != nil {
+        d.log.Errorf("Error getting localized quick reply messages of business '%s': %v", businessId, err)
+        return nil, err
+    }
+    return messages, nil
+}
+
+// UpdateLocalizedQuickReplyMessage stores the quick reply message of the business for reviews in the language and returns the updated business.
+// An empty message removes the message of the language.
+func (d *AutoReplyRuleDao) UpdateLocalizedQuickReplyMessage(ctx context.Context, businessId bid.BusinessId, language string, message string, updatedBy string) (model.Business, error) {
+    messages, err := d.GetLocalizedQuickReplyMessages(ctx, businessId)
+    if err != nil {
+        return model.Business{}, err
+    }
+    if message == "" {
+        delete(messages, language)
+    } else {
+        messages[language] = message
+    }
+
+    action, err := dbModel.NewAttributeAction(enum2.ActionUpdate, LocalizedQuickReplyMessagesAttributeName, messages)
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    business, err := d.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{action}, updatedBy)
+    if err != nil {
+        d.log.Errorf("Error updating quick reply message in '%s' of business '%s': %v", language, businessId, err)
+        return model.Business{}, err
+    }
+    return business, nil
+}
+
+// getBusinessAttribute unmarshals the attribute of the business item into out.
+// Returns false if the business item does not have the attribute.
+func getBusinessAttribute(ctx context.Context, client *dynamodb.Client, businessId bid.BusinessId, attributeName string, out interface{}) (bool, error) {
+    output, err := client.Query(ctx, &dynamodb.QueryInput{
+        TableName:              aws.String(BusinessTableName),
+        KeyConditionExpression: aws.String("businessId = :businessId"),
+        ProjectionExpression:   aws.String("#attribute"),
+        ExpressionAttributeNames: map[string]string{
+            "#attribute": attributeName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":businessId": &types.AttributeValueMemberS{Value: businessId.String()},
+        },
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        return false, err
+    }
+
+    for _, item := range output.Items {
+        value, ok := item[attributeName]
+        if !ok {
+            continue
+        }
+        return true, attributevalue.Unmarshal(value, out)
+    }
+    return false, nil
+}
@@ -0,0 +1,66 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "go.uber.org/zap"
+)
+
+// BusinessIdDao lists the IDs of all businesses in the Business table, e.g., for jobs that run for every business
+type BusinessIdDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewBusinessIdDao(client *dynamodb.Client, logger *zap.SugaredLogger) *BusinessIdDao {
+    return &BusinessIdDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// ListBusinessIds scans the Business table for the IDs of all businesses
+func (d *BusinessIdDao) ListBusinessIds(ctx context.Context) ([]bid.BusinessId, error) {
+    paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+        TableName:            aws.String(BusinessTableName),
+        ProjectionExpression: aws.String("businessId"),
+    })
+
+    seen := map[string]bool{}
+    var businessIds []bid.BusinessId
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            d.log.Errorf("Error scanning business IDs: %v", err)
+            return businessIds, err
+        }
+
+        var items []struct {
+            BusinessId string `dynamodbav:"businessId"`
+        }
+        err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+        if err != nil {
+            d.log.Errorf("Error unmarshalling business IDs: %v", err)
+            return businessIds, err
+        }
+
+        for _, item := range items {
+            if seen[item.BusinessId] {
+                continue
+            }
+            seen[item.BusinessId] = true
+
+            businessId, err := bid.NewBusinessId(item.BusinessId)
+            if err != nil {
+                d.log.Warnf("Skipping invalid business ID '%s': %v", item.BusinessId, err)
+                continue
+            }
+            businessIds = append(businessIds, businessId)
+        }
+    }
+
+    return businessIds, nil
+}
@@ -0,0 +1,103 @@
+package dao
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+)
+
+// BusinessUserDao removes users from businesses and businesses from users.
+// ddbDao.BusinessDao and ddbDao.UserDao can append to the userIds and businessIds string sets but not delete from them.
+type BusinessUserDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewBusinessUserDao(client *dynamodb.Client, logger *zap.SugaredLogger) *BusinessUserDao {
+    return &BusinessUserDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// RemoveUserFromBusiness removes the user from the userIds of the business. Removing a user the business does not have is a no-op.
+func (d *BusinessUserDao) RemoveUserFromBusiness(ctx context.Context, businessId bid.BusinessId, userId string) error {
+    err := d.deleteFromStringSet(ctx, BusinessTableName, "businessId", businessId.String(), "userIds", userId)
+    if err != nil {
+        d.log.Errorf("Error removing user '%s' from business '%s': %v", userId, businessId, err)
+        return err
+    }
+    return nil
+}
+
+// RemoveBusinessFromUser removes the business from the businessIds of the user. Removing a business the user does not have is a no-op.
+func (d *BusinessUserDao) RemoveBusinessFromUser(ctx context.Context, userId string, businessId bid.BusinessId) error {
+    err := d.deleteFromStringSet(ctx, UserTableName, "userId", userId, "businessIds", businessId.String())
+    if err != nil {
+        d.log.Errorf("Error removing business '%s' from user '%s': %v", businessId, userId, err)
+        return err
+    }
+    return nil
+}
+
+// deleteFromStringSet deletes the value from the string set attribute of the item with the partition key
+func (d *BusinessUserDao) deleteFromStringSet(ctx context.Context, tableName string, partitionKeyName string, partitionKey string, attributeName string, value string) error {
+    key, err := getItemKey(ctx, d.client, tableName, partitionKeyName, partitionKey)
+    if err != nil {
+        return err
+    }
+
+    _, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:        aws.String(tableName),
+        Key:              key,
+        UpdateExpression: aws.String("DELETE #attribute :values"),
+        ExpressionAttributeNames: map[string]string{
+            "#attribute": attributeName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":values": &types.AttributeValueMemberSS{Value: []string{value}},
+        },
+    })
+    return err
+}
+
+// getItemKey gets the full key of the item with the partition key in the Business or User table.
+// The item is looked up by its partition key only, as its uniqueId sort key is managed by CoreDataAccess.
+func getItemKey(ctx context.Context, client *dynamodb.Client, tableName string, partitionKeyName string, partitionKey string) (map[string]types.AttributeValue, error) {
+    output, err := client.Query(ctx, &dynamodb.QueryInput{
+        TableName:              aws.String(tableName),
+        KeyConditionExpression: aws.String("#pk = :pk"),
+        ProjectionExpression:   aws.String("#pk, uniqueId"),
+        ExpressionAttributeNames: map[string]string{
+            "#pk": partitionKeyName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":pk": &types.AttributeValueMemberS{Value: partitionKey},
+        },
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(output.Items) == 0 {
+        return nil, fmt.Errorf("%s '%s' not found in %s table", partitionKeyName, partitionKey, tableName)
+    }
+    return output.Items[0], nil
+}
+
+// updateUserItem applies the update to the item of the user in the User table
+func updateUserItem(ctx context.Context, client *dynamodb.Client, userId string, input *dynamodb.UpdateItemInput) error {
+    key, err := getItemKey(ctx, client, UserTableName, "userId", userId)
+    if err != nil {
+        return err
+    }
+
+    input.TableName = aws.String(UserTableName)
+    input.Key = key
+    _, err = client.UpdateItem(ctx, input)
+    return err
+}
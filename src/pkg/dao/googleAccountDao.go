@@ -0,0 +1,78 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
+    enum2 "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "go.uber.org/zap"
+)
+
+// GoogleBusinessAccountIdAttributeName is the attribute of the business item storing the Google business account its location is under
+const GoogleBusinessAccountIdAttributeName = "googleBusinessAccountId"
+
+// GoogleBusinessAccountIdsAttributeName is the attribute of the user item storing all Google business accounts of the user.
+// model.Google.BusinessAccountId holds only the first of them.
+const GoogleBusinessAccountIdsAttributeName = "google.businessAccountIds"
+
+// GoogleAccountDao stores which Google business accounts businesses are under and users have, for users with more than one account
+type GoogleAccountDao struct {
+    client      *dynamodb.Client
+    businessDao *ddbDao.BusinessDao
+    userDao     *ddbDao.UserDao
+    log         *zap.SugaredLogger
+}
+
+func NewGoogleAccountDao(client *dynamodb.Client, businessDao *ddbDao.BusinessDao, userDao *ddbDao.UserDao, logger *zap.SugaredLogger) *GoogleAccountDao {
+    return &GoogleAccountDao{
+        client:      client,
+        businessDao: businessDao,
+        userDao:     userDao,
+        log:         logger,
+    }
+}
+
+// GetBusinessAccountId gets the Google business account the business is under. Returns empty if not recorded, e.g., for businesses connected before accounts were recorded.
+func (d *GoogleAccountDao) GetBusinessAccountId(ctx context.Context, businessId bid.BusinessId) (string, error) {
+    accountId := ""
+    _, err := getBusinessAttribute(ctx, d.client, businessId, GoogleBusinessAccountIdAttributeName, &accountId)
+    if err != nil {
+        d.log.Errorf("Error getting Google business account ID of business '%s': %v", businessId, err)
+        return "", err
+    }
+    return accountId, nil
+}
+
+// UpdateBusinessAccountId records the Google business account the business is under and returns the updated business
+func (d *GoogleAccountDao) UpdateBusinessAccountId(businessId bid.BusinessId, accountId string, updatedBy string) (model.Business, error) {
+    action, err := dbModel.NewAttributeAction(enum2.ActionUpdate, GoogleBusinessAccountIdAttributeName, accountId)
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    business, err := d.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{action}, updatedBy)
+    if err != nil {
+        d.log.Errorf("Error updating Google business account ID of business '%s': %v", businessId, err)
+        return model.Business{}, err
+    }
+    return business, nil
+}
+
+// UpdateUserAccountIds records all Google business accounts of the user and returns the updated user.
+// The user must have Google metadata already, as the accounts are stored under it.
+func (d *GoogleAccountDao) UpdateUserAccountIds(userId string, accountIds []string) (model.User, error) {
+    action, err := dbModel.NewAttributeAction(enum2.ActionUpdate, GoogleBusinessAccountIdsAttributeName, accountIds)
+    if err != nil {
+        return model.User{}, err
+    }
+
+    user, err := d.userDao.UpdateAttributes(userId, []dbModel.AttributeAction{action})
+    if err != nil {
+        d.log.Errorf("Error updating Google business account IDs of user '%s': %v", userId, err)
+        return model.User{}, err
+    }
+    return user, nil
+}
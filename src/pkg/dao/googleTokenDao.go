@@ -0,0 +1,137 @@
+package dao
+
+import (
+    "context"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "time"
+)
+
+// GoogleTokenStoredAtAttributeName is the attribute of the user item storing when the Google refresh token of the user was stored, in unix seconds.
+// Users without it have no refresh token. Only users with it are in googleTokenStoredAtIndexName.
+const GoogleTokenStoredAtAttributeName = "googleTokenStoredAt"
+
+// googleTokenStoredAtIndexName is the sparse index of the User table with the users who have a Google refresh token
+const googleTokenStoredAtIndexName = "googleTokenStoredAt-gsi"
+
+// GoogleTokenDao finds users with stored Google tokens and clears the tokens once they cannot be used anymore.
+// Refreshed access tokens are stored with businessProfileUtil.PersistRefreshedToken.
+type GoogleTokenDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewGoogleTokenDao(client *dynamodb.Client, logger *zap.SugaredLogger) *GoogleTokenDao {
+    return &GoogleTokenDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// ListUserIdsWithGoogleTokens scans the index of users with a Google refresh token for their IDs
+func (d *GoogleTokenDao) ListUserIdsWithGoogleTokens(ctx context.Context) ([]string, error) {
+    userIds, err := scanUserIds(ctx, d.client, &dynamodb.ScanInput{
+        IndexName: aws.String(googleTokenStoredAtIndexName),
+    })
+    if err != nil {
+        d.log.Errorf("Error scanning users with Google tokens: %v", err)
+        return userIds, err
+    }
+    return userIds, nil
+}
+
+// MarkTokenStored records that the Google refresh token of the user was stored, so that the user is found by ListUserIdsWithGoogleTokens
+func (d *GoogleTokenDao) MarkTokenStored(ctx context.Context, userId string, storedAt time.Time) error {
+    err := updateUserItem(ctx, d.client, userId, &dynamodb.UpdateItemInput{
+        UpdateExpression: aws.String("SET #googleTokenStoredAt = :googleTokenStoredAt"),
+        ExpressionAttributeNames: map[string]string{
+            "#googleTokenStoredAt": GoogleTokenStoredAtAttributeName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":googleTokenStoredAt": unixTimeAttributeValue(storedAt),
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error marking Google token of user '%s' stored: %v", userId, err)
+        return err
+    }
+    return nil
+}
+
+// MarkStoredTokens marks the users whose Google refresh token was stored before MarkTokenStored existed. It scans the whole User table, so it is run once.
+// Returns the number of users marked.
+func (d *GoogleTokenDao) MarkStoredTokens(ctx context.Context) (int, error) {
+    userIds, err := scanUserIds(ctx, d.client, &dynamodb.ScanInput{
+        FilterExpression: aws.String("attribute_exists(#google.#refreshToken) AND attribute_not_exists(#googleTokenStoredAt)"),
+        ExpressionAttributeNames: map[string]string{
+            "#google":              "google",
+            "#refreshToken":        "refreshToken",
+            "#googleTokenStoredAt": GoogleTokenStoredAtAttributeName,
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error scanning users with unmarked Google tokens: %v", err)
+        return 0, err
+    }
+
+    marked := 0
+    for _, userId := range userIds {
+        err = d.MarkTokenStored(ctx, userId, time.Now())
+        if err != nil {
+            return marked, err
+        }
+        marked++
+    }
+    return marked, nil
+}
+
+// ClearTokens removes the Google access and refresh tokens of the user.
+// The other Google metadata is kept, so that the user is known to have completed OAuth and is asked to complete it again.
+func (d *GoogleTokenDao) ClearTokens(ctx context.Context, userId string) error {
+    err := updateUserItem(ctx, d.client, userId, &dynamodb.UpdateItemInput{
+        UpdateExpression: aws.String("REMOVE #google.#accessToken, #google.#accessTokenExpireAt, #google.#refreshToken, #googleTokenStoredAt"),
+        ExpressionAttributeNames: map[string]string{
+            "#google":              "google",
+            "#accessToken":         "accessToken",
+            "#accessTokenExpireAt": "accessTokenExpireAt",
+            "#refreshToken":        "refreshToken",
+            "#googleTokenStoredAt": GoogleTokenStoredAtAttributeName,
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error clearing Google tokens of user '%s': %v", userId, err)
+        return err
+    }
+    return nil
+}
+
+// scanUserIds scans the User table, or the index of the input, for the IDs of the users matching the filter of the input
+func scanUserIds(ctx context.Context, client *dynamodb.Client, input *dynamodb.ScanInput) ([]string, error) {
+    input.TableName = aws.String(UserTableName)
+    input.ProjectionExpression = aws.String("userId")
+    paginator := dynamodb.NewScanPaginator(client, input)
+
+    var userIds []string
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            return userIds, err
+        }
+
+        var items []struct {
+            UserId string `dynamodbav:"userId"`
+        }
+        err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+        if err != nil {
+            return userIds, err
+        }
+        for _, item := range items {
+            userIds = append(userIds, item.UserId)
+        }
+    }
+
+    return userIds, nil
+}
@@ -0,0 +1,121 @@
+package dao
+
+import (
+    "context"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "strconv"
+    "time"
+)
+
+// UnfollowedAtAttributeName is the attribute of the user item storing when the user unfollowed (blocked) the LINE Official Account, in unix seconds.
+// Users without it are following. Only users with it are in unfollowedAtIndexName.
+const UnfollowedAtAttributeName = "unfollowedAt"
+
+// unfollowedAtIndexName is the sparse index of the User table with the users who unfollowed
+const unfollowedAtIndexName = "unfollowedAt-gsi"
+
+// LineFollowDao marks users who unfollowed the LINE Official Account as inactive, and finds those whose Google tokens are to be purged after a grace period
+type LineFollowDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewLineFollowDao(client *dynamodb.Client, logger *zap.SugaredLogger) *LineFollowDao {
+    return &LineFollowDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetUnfollowedAt gets when the user unfollowed. Returns false if the user is following or does not exist.
+func (d *LineFollowDao) GetUnfollowedAt(ctx context.Context, userId string) (time.Time, bool, error) {
+    output, err := d.client.Query(ctx, &dynamodb.QueryInput{
+        TableName:              aws.String(UserTableName),
+        KeyConditionExpression: aws.String("userId = :userId"),
+        ProjectionExpression:   aws.String("#unfollowedAt"),
+        ExpressionAttributeNames: map[string]string{
+            "#unfollowedAt": UnfollowedAtAttributeName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":userId": &types.AttributeValueMemberS{Value: userId},
+        },
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        d.log.Errorf("Error getting unfollow time of user '%s': %v", userId, err)
+        return time.Time{}, false, err
+    }
+    if len(output.Items) == 0 {
+        return time.Time{}, false, nil
+    }
+
+    var item struct {
+        UnfollowedAt *int64 `dynamodbav:"unfollowedAt"`
+    }
+    err = attributevalue.UnmarshalMap(output.Items[0], &item)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling unfollow time of user '%s': %v", userId, err)
+        return time.Time{}, false, err
+    }
+    if item.UnfollowedAt == nil {
+        return time.Time{}, false, nil
+    }
+    return time.Unix(*item.UnfollowedAt, 0), true, nil
+}
+
+// MarkUnfollowed marks the user inactive as of when the user unfollowed
+func (d *LineFollowDao) MarkUnfollowed(ctx context.Context, userId string, unfollowedAt time.Time) error {
+    err := updateUserItem(ctx, d.client, userId, &dynamodb.UpdateItemInput{
+        UpdateExpression: aws.String("SET #unfollowedAt = :unfollowedAt"),
+        ExpressionAttributeNames: map[string]string{
+            "#unfollowedAt": UnfollowedAtAttributeName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":unfollowedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(unfollowedAt.Unix(), 10)},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error marking user '%s' unfollowed: %v", userId, err)
+        return err
+    }
+    return nil
+}
+
+// MarkRefollowed marks the user active again
+func (d *LineFollowDao) MarkRefollowed(ctx context.Context, userId string) error {
+    err := updateUserItem(ctx, d.client, userId, &dynamodb.UpdateItemInput{
+        UpdateExpression: aws.String("REMOVE #unfollowedAt"),
+        ExpressionAttributeNames: map[string]string{
+            "#unfollowedAt": UnfollowedAtAttributeName,
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error marking user '%s' refollowed: %v", userId, err)
+        return err
+    }
+    return nil
+}
+
+// ListUnfollowedUserIdsWithGoogleTokens scans the index of unfollowed users for the IDs of users who unfollowed before the cutoff and still have Google tokens
+func (d *LineFollowDao) ListUnfollowedUserIdsWithGoogleTokens(ctx context.Context, unfollowedBefore time.Time) ([]string, error) {
+    userIds, err := scanUserIds(ctx, d.client, &dynamodb.ScanInput{
+        IndexName:        aws.String(unfollowedAtIndexName),
+        FilterExpression: aws.String("#unfollowedAt < :cutoff AND attribute_exists(#googleTokenStoredAt)"),
+        ExpressionAttributeNames: map[string]string{
+            "#unfollowedAt":        UnfollowedAtAttributeName,
+            "#googleTokenStoredAt": GoogleTokenStoredAtAttributeName,
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":cutoff": &types.AttributeValueMemberN{Value: strconv.FormatInt(unfollowedBefore.Unix(), 10)},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error scanning unfollowed users: %v", err)
+        return userIds, err
+    }
+    return userIds, nil
+}
@@ -0,0 +1,36 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "go.uber.org/zap"
+)
+
+// LLMConfigAttributeName is the attribute of the business item storing the LLM config overriding that of the stage
+const LLMConfigAttributeName = "llmConfig"
+
+// LLMConfigDao reads the LLM config of businesses from their business items
+type LLMConfigDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewLLMConfigDao(client *dynamodb.Client, logger *zap.SugaredLogger) *LLMConfigDao {
+    return &LLMConfigDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetLLMConfig gets the LLM config of the business. A business without LLM config has an empty config, i.e., the config of the stage.
+func (d *LLMConfigDao) GetLLMConfig(ctx context.Context, businessId bid.BusinessId) (model.LLMConfig, error) {
+    var config model.LLMConfig
+    _, err := getBusinessAttribute(ctx, d.client, businessId, LLMConfigAttributeName, &config)
+    if err != nil {
+        d.log.Errorf("Error getting LLM config of business '%s': %v", businessId, err)
+        return model.LLMConfig{}, err
+    }
+    return config, nil
+}
@@ -0,0 +1,107 @@
+package dao
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+)
+
+// LocationSelectionDao stores the Google business locations discovered in the latest OAuth of each user, keyed on user ID
+type LocationSelectionDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewLocationSelectionDao(client *dynamodb.Client, logger *zap.SugaredLogger) *LocationSelectionDao {
+    return &LocationSelectionDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetLocationSelection gets the location selection of the user. Returns nil if the user has not completed OAuth since locations are selected.
+func (d *LocationSelectionDao) GetLocationSelection(ctx context.Context, userId string) (*model.LocationSelection, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName: aws.String(LocationSelectionTableName),
+        Key: map[string]types.AttributeValue{
+            "userId": &types.AttributeValueMemberS{Value: userId},
+        },
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        d.log.Errorf("Error getting location selection of user '%s': %v", userId, err)
+        return nil, err
+    }
+    if output.Item == nil {
+        return nil, nil
+    }
+
+    var selection model.LocationSelection
+    err = attributevalue.UnmarshalMap(output.Item, &selection)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling location selection of user '%s': %v", userId, err)
+        return nil, err
+    }
+    return &selection, nil
+}
+
+// PutLocationSelection replaces the location selection of the user
+func (d *LocationSelectionDao) PutLocationSelection(ctx context.Context, selection model.LocationSelection) error {
+    item, err := attributevalue.MarshalMap(selection)
+    if err != nil {
+        return err
+    }
+
+    _, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName: aws.String(LocationSelectionTableName),
+        Item:      item,
+    })
+    if err != nil {
+        d.log.Errorf("Error putting location selection of user '%s': %v", selection.UserId, err)
+        return err
+    }
+    return nil
+}
+
+// RemoveGoogle removes the Google metadata kept for the user, once the user is created with it or unfollows.
+// The location selection no longer expires without it.
+func (d *LocationSelectionDao) RemoveGoogle(ctx context.Context, userId string) error {
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName: aws.String(LocationSelectionTableName),
+        Key: map[string]types.AttributeValue{
+            "userId": &types.AttributeValueMemberS{Value: userId},
+        },
+        UpdateExpression:    aws.String("REMOVE google, expireAt"),
+        ConditionExpression: aws.String("attribute_exists(userId)"),
+    })
+    var conditionalCheckFailedException *types.ConditionalCheckFailedException
+    if errors.As(err, &conditionalCheckFailedException) {
+        // the user has no location selection
+        return nil
+    }
+    if err != nil {
+        d.log.Errorf("Error removing Google metadata from location selection of user '%s': %v", userId, err)
+        return err
+    }
+    return nil
+}
+
+// DeleteLocationSelection deletes the location selection of the user, along with the Google metadata kept in it
+func (d *LocationSelectionDao) DeleteLocationSelection(ctx context.Context, userId string) error {
+    _, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+        TableName: aws.String(LocationSelectionTableName),
+        Key: map[string]types.AttributeValue{
+            "userId": &types.AttributeValueMemberS{Value: userId},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error deleting location selection of user '%s': %v", userId, err)
+        return err
+    }
+    return nil
+}
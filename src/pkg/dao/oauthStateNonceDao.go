@@ -0,0 +1,48 @@
+package dao
+
+import (
+    "context"
+    "errors"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "time"
+)
+
+// OAuthStateNonceDao records the nonces of OAuth states used to complete OAuth, so that each state is used only once
+type OAuthStateNonceDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewOAuthStateNonceDao(client *dynamodb.Client, logger *zap.SugaredLogger) *OAuthStateNonceDao {
+    return &OAuthStateNonceDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// UseNonce records the nonce as used by the user. Returns false if it has been used already.
+// The record expires with the state, after which the state is rejected as expired anyway.
+func (d *OAuthStateNonceDao) UseNonce(ctx context.Context, nonce string, userId string, expireAt time.Time) (bool, error) {
+    _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName: aws.String(OAuthStateNonceTableName),
+        Item: map[string]types.AttributeValue{
+            "nonce":    &types.AttributeValueMemberS{Value: nonce},
+            "userId":   &types.AttributeValueMemberS{Value: userId},
+            "usedAt":   unixTimeAttributeValue(time.Now()),
+            "expireAt": unixTimeAttributeValue(expireAt),
+        },
+        ConditionExpression: aws.String("attribute_not_exists(nonce)"),
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            return false, nil
+        }
+        d.log.Errorf("Error using OAuth state nonce '%s' of user '%s': %v", nonce, userId, err)
+        return false, err
+    }
+    return true, nil
+}
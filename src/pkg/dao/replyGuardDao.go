@@ -0,0 +1,63 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
+    enum2 "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "go.uber.org/zap"
+)
+
+// AllowedContactsAttributeName is the attribute of the business item storing the phone numbers and emails allowed in its replies
+const AllowedContactsAttributeName = "allowedContacts"
+
+// ReplyGuardDao stores the reply guard settings of businesses on their business items
+type ReplyGuardDao struct {
+    client      *dynamodb.Client
+    businessDao *ddbDao.BusinessDao
+    log         *zap.SugaredLogger
+}
+
+func NewReplyGuardDao(client *dynamodb.Client, businessDao *ddbDao.BusinessDao, logger *zap.SugaredLogger) *ReplyGuardDao {
+    return &ReplyGuardDao{
+        client:      client,
+        businessDao: businessDao,
+        log:         logger,
+    }
+}
+
+// GetAllowedContacts gets the phone numbers and emails the business allows in its replies. Empty if none is allowed.
+func (d *ReplyGuardDao) GetAllowedContacts(ctx context.Context, businessId bid.BusinessId) ([]string, error) {
+    var contacts []string
+    _, err := getBusinessAttribute(ctx, d.client, businessId, AllowedContactsAttributeName, &contacts)
+    if err != nil {
+        d.log.Errorf("Error getting allowed contacts of business '%s': %v", businessId, err)
+        return nil, err
+    }
+    return contacts, nil
+}
+
+// UpdateAllowedContacts stores the phone numbers and emails the business allows in its replies and returns the updated business.
+// Empty contacts removes the attribute.
+func (d *ReplyGuardDao) UpdateAllowedContacts(businessId bid.BusinessId, contacts []string, updatedBy string) (model.Business, error) {
+    var action dbModel.AttributeAction
+    var err error
+    if len(contacts) == 0 {
+        action, err = dbModel.NewAttributeAction(enum2.ActionRemove, AllowedContactsAttributeName, nil)
+    } else {
+        action, err = dbModel.NewAttributeAction(enum2.ActionUpdate, AllowedContactsAttributeName, contacts)
+    }
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    business, err := d.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{action}, updatedBy)
+    if err != nil {
+        d.log.Errorf("Error updating allowed contacts of business '%s': %v", businessId, err)
+        return model.Business{}, err
+    }
+    return business, nil
+}
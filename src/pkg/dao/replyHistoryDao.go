@@ -0,0 +1,72 @@
+package dao
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+)
+
+// lastRepliedIndexName is the index of the Review table sorted by when reviews were replied. Only replied reviews are in the index.
+const lastRepliedIndexName = "lastReplied-lsi"
+
+// replyHistoryScanLimit bounds the replied reviews evaluated for past replies, so that businesses mostly auto replied do not page through all their reviews
+const replyHistoryScanLimit = 20
+
+// ReplyHistoryDao reads the replies published to reviews of businesses
+type ReplyHistoryDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewReplyHistoryDao(client *dynamodb.Client, logger *zap.SugaredLogger) *ReplyHistoryDao {
+    return &ReplyHistoryDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// ListRecentReplies lists up to limit of the most recent replies published by users of the business, most recent first.
+// Auto replies are skipped since no user chose them, as is the review with excludeVendorReviewId, e.g., the review being replied.
+func (d *ReplyHistoryDao) ListRecentReplies(ctx context.Context, businessId bid.BusinessId, excludeVendorReviewId string, limit int) ([]model.PastReply, error) {
+    // reviews are partitioned by business ID under the legacy "userId" partition key
+    output, err := d.client.Query(ctx, &dynamodb.QueryInput{
+        TableName:              aws.String(ReviewTableName),
+        IndexName:              aws.String(lastRepliedIndexName),
+        KeyConditionExpression: aws.String("#pk = :businessId"),
+        FilterExpression:       aws.String("attribute_exists(reply) AND (attribute_not_exists(repliedBy) OR repliedBy <> :autoReply) AND vendorReviewId <> :excluded AND (attribute_not_exists(removed) OR removed = :false)"),
+        ProjectionExpression:   aws.String("reviewerName, numberRating, review, reply, repliedBy"),
+        ExpressionAttributeNames: map[string]string{
+            "#pk": "userId",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":businessId": &types.AttributeValueMemberS{Value: businessId.String()},
+            ":autoReply":  &types.AttributeValueMemberS{Value: util.AutoReplyUserId},
+            ":excluded":   &types.AttributeValueMemberS{Value: excludeVendorReviewId},
+            ":false":      &types.AttributeValueMemberBOOL{Value: false},
+        },
+        ScanIndexForward: aws.Bool(false),
+        Limit:            aws.Int32(replyHistoryScanLimit),
+    })
+    if err != nil {
+        d.log.Errorf("Error querying recent replies of business '%s': %v", businessId, err)
+        return nil, err
+    }
+
+    var replies []model.PastReply
+    err = attributevalue.UnmarshalListOfMaps(output.Items, &replies)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling recent replies of business '%s': %v", businessId, err)
+        return nil, err
+    }
+
+    if len(replies) > limit {
+        replies = replies[:limit]
+    }
+    return replies, nil
+}
@@ -0,0 +1,220 @@
+package dao
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "time"
+)
+
+// ReviewEventDao stores the processing progress of new review events, keyed on vendor event ID
+type ReviewEventDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewReviewEventDao(client *dynamodb.Client, logger *zap.SugaredLogger) *ReviewEventDao {
+    return &ReviewEventDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// GetReviewEvent gets the review event. Returns nil if not found.
+func (d *ReviewEventDao) GetReviewEvent(ctx context.Context, vendorEventId string) (*model.ReviewEvent, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName:      aws.String(ReviewEventTableName),
+        Key:            reviewEventKey(vendorEventId),
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        d.log.Errorf("Error getting review event '%s': %v", vendorEventId, err)
+        return nil, err
+    }
+    if output.Item == nil {
+        return nil, nil
+    }
+
+    var reviewEvent model.ReviewEvent
+    err = attributevalue.UnmarshalMap(output.Item, &reviewEvent)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling review event '%s': %v", vendorEventId, err)
+        return nil, err
+    }
+    return &reviewEvent, nil
+}
+
+// CreateReviewEvent creates the review event, which holds the lease of the creating delivery.
+// Returns *exception.ReviewEventAlreadyExistException if the event already exists, e.g., it is being processed concurrently.
+func (d *ReviewEventDao) CreateReviewEvent(ctx context.Context, reviewEvent model.ReviewEvent) error {
+    item, err := attributevalue.MarshalMap(reviewEvent)
+    if err != nil {
+        return err
+    }
+
+    _, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName:           aws.String(ReviewEventTableName),
+        Item:                item,
+        ConditionExpression: aws.String("attribute_not_exists(vendorEventId)"),
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            return exception.NewReviewEventAlreadyExistException(fmt.Sprintf("Review event '%s' already exists", reviewEvent.VendorEventId), err)
+        }
+        d.log.Errorf("Error creating review event '%s': %v", reviewEvent.VendorEventId, err)
+        return err
+    }
+    return nil
+}
+
+// ReserveReviewId records the review ID of the event unless one is already reserved, and returns the reserved review ID
+func (d *ReviewEventDao) ReserveReviewId(ctx context.Context, vendorEventId string, reviewId string) (string, error) {
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(ReviewEventTableName),
+        Key:                 reviewEventKey(vendorEventId),
+        UpdateExpression:    aws.String("SET reviewId = :reviewId, lastUpdated = :lastUpdated"),
+        ConditionExpression: aws.String("attribute_exists(vendorEventId) AND attribute_not_exists(reviewId)"),
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":reviewId":    &types.AttributeValueMemberS{Value: reviewId},
+            ":lastUpdated": unixTimeAttributeValue(time.Now()),
+        },
+    })
+    if err == nil {
+        return reviewId, nil
+    }
+
+    var conditionalCheckFailedException *types.ConditionalCheckFailedException
+    if !errors.As(err, &conditionalCheckFailedException) {
+        d.log.Errorf("Error reserving review ID '%s' for review event '%s': %v", reviewId, vendorEventId, err)
+        return "", err
+    }
+
+    // reserved concurrently
+    reviewEvent, err := d.GetReviewEvent(ctx, vendorEventId)
+    if err != nil {
+        return "", err
+    }
+    if reviewEvent == nil || reviewEvent.ReviewId == "" {
+        return "", fmt.Errorf("review event '%s' does not exist", vendorEventId)
+    }
+    return reviewEvent.ReviewId, nil
+}
+
+// CompleteStep marks the step of the review event completed
+func (d *ReviewEventDao) CompleteStep(ctx context.Context, vendorEventId string, step enum.ReviewEventStep) error {
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(ReviewEventTableName),
+        Key:                 reviewEventKey(vendorEventId),
+        UpdateExpression:    aws.String("SET #step = :done, lastUpdated = :lastUpdated"),
+        ConditionExpression: aws.String("attribute_exists(vendorEventId)"),
+        ExpressionAttributeNames: map[string]string{
+            "#step": step.String(),
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":done":        &types.AttributeValueMemberBOOL{Value: true},
+            ":lastUpdated": unixTimeAttributeValue(time.Now()),
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error completing step '%s' of review event '%s': %v", step, vendorEventId, err)
+        return err
+    }
+    return nil
+}
+
+// AcquireLease leases the review event to the caller until leaseUntil, unless another delivery holds an unexpired lease.
+// Returns the review event as of acquiring the lease, or nil if the lease is held by another delivery.
+func (d *ReviewEventDao) AcquireLease(ctx context.Context, vendorEventId string, leaseUntil time.Time) (*model.ReviewEvent, error) {
+    now := time.Now()
+    output, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(ReviewEventTableName),
+        Key:                 reviewEventKey(vendorEventId),
+        UpdateExpression:    aws.String("SET leaseUntil = :leaseUntil, lastUpdated = :now"),
+        ConditionExpression: aws.String("attribute_exists(vendorEventId) AND (attribute_not_exists(leaseUntil) OR leaseUntil < :now)"),
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":leaseUntil": unixTimeAttributeValue(leaseUntil),
+            ":now":        unixTimeAttributeValue(now),
+        },
+        ReturnValues: types.ReturnValueAllNew,
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            return nil, nil
+        }
+        d.log.Errorf("Error acquiring lease of review event '%s': %v", vendorEventId, err)
+        return nil, err
+    }
+
+    var reviewEvent model.ReviewEvent
+    err = attributevalue.UnmarshalMap(output.Attributes, &reviewEvent)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling review event '%s': %v", vendorEventId, err)
+        return nil, err
+    }
+    return &reviewEvent, nil
+}
+
+// ReleaseLease releases the lease of the review event held until leaseUntil.
+// A lease taken over by another delivery after it expired is left untouched.
+func (d *ReviewEventDao) ReleaseLease(ctx context.Context, vendorEventId string, leaseUntil time.Time) error {
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(ReviewEventTableName),
+        Key:                 reviewEventKey(vendorEventId),
+        UpdateExpression:    aws.String("REMOVE leaseUntil SET lastUpdated = :lastUpdated"),
+        ConditionExpression: aws.String("leaseUntil = :leaseUntil"),
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":leaseUntil":  unixTimeAttributeValue(leaseUntil),
+            ":lastUpdated": unixTimeAttributeValue(time.Now()),
+        },
+    })
+    if err != nil {
+        var conditionalCheckFailedException *types.ConditionalCheckFailedException
+        if errors.As(err, &conditionalCheckFailedException) {
+            d.log.Warnf("Lease of review event '%s' expired and was taken over before it was released", vendorEventId)
+            return nil
+        }
+        d.log.Errorf("Error releasing lease of review event '%s': %v", vendorEventId, err)
+        return err
+    }
+    return nil
+}
+
+// RecordAiPromptTemplate records the ID of the prompt template of the AI auto reply published for the review event
+func (d *ReviewEventDao) RecordAiPromptTemplate(ctx context.Context, vendorEventId string, promptTemplate string) error {
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:           aws.String(ReviewEventTableName),
+        Key:                 reviewEventKey(vendorEventId),
+        UpdateExpression:    aws.String("SET aiPromptTemplate = :promptTemplate, lastUpdated = :lastUpdated"),
+        ConditionExpression: aws.String("attribute_exists(vendorEventId)"),
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":promptTemplate": &types.AttributeValueMemberS{Value: promptTemplate},
+            ":lastUpdated":    unixTimeAttributeValue(time.Now()),
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error recording prompt template '%s' of review event '%s': %v", promptTemplate, vendorEventId, err)
+        return err
+    }
+    return nil
+}
+
+func reviewEventKey(vendorEventId string) map[string]types.AttributeValue {
+    return map[string]types.AttributeValue{
+        "vendorEventId": &types.AttributeValueMemberS{Value: vendorEventId},
+    }
+}
+
+// unixTimeAttributeValue matches the `unixtime` encoding of model.ReviewEvent
+func unixTimeAttributeValue(t time.Time) types.AttributeValue {
+    return &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", t.Unix())}
+}
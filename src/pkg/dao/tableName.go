@@ -0,0 +1,16 @@
+package dao
+
+// table names as defined in cdk/src/config/ddbTable.ts
+const (
+    ReviewTableName              = "Review"
+    UserTableName                = "User"
+    BusinessTableName            = "Business"
+    ReviewEventTableName         = "ReviewEvent"
+    AiReplyDraftTableName        = "AiReplyDraft"
+    AiReplyCandidateSetTableName = "AiReplyCandidateSet"
+    AiUsageTableName             = "AiUsage"
+    AiReplyCacheTableName        = "AiReplyCache"
+    OAuthStateNonceTableName     = "OAuthStateNonce"
+    LocationSelectionTableName   = "LocationSelection"
+    VendorReviewIdTableName      = "VendorReviewId"
+)
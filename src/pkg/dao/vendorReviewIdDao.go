@@ -0,0 +1,337 @@
+package dao
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+    "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+    "go.uber.org/zap"
+    "strings"
+    "time"
+)
+
+// reviewContentAttributes are the attributes of a review that the reviewer can change
+var reviewContentAttributes = []string{"review", "numberRating", "reviewLastUpdated", "lastUpdated"}
+
+// ReviewLanguageAttributeName is the attribute of the review item storing the language of the review text detected at intake
+const ReviewLanguageAttributeName = "language"
+
+// StoredReview is a stored review with the key of its item
+type StoredReview struct {
+    Review  model.Review
+    Removed bool
+    // Language is the language of the review as ISO 639-1 code. Empty if unknown.
+    Language string
+    key      map[string]types.AttributeValue
+}
+
+// VendorReviewIdDao looks up stored reviews by their vendor review IDs, e.g., to reconcile reviews against Google
+type VendorReviewIdDao struct {
+    client *dynamodb.Client
+    log    *zap.SugaredLogger
+}
+
+func NewVendorReviewIdDao(client *dynamodb.Client, logger *zap.SugaredLogger) *VendorReviewIdDao {
+    return &VendorReviewIdDao{
+        client: client,
+        log:    logger,
+    }
+}
+
+// ListVendorReviewIds queries the Review table for the vendor review IDs of all reviews of the business
+func (d *VendorReviewIdDao) ListVendorReviewIds(ctx context.Context, businessId bid.BusinessId) ([]string, error) {
+    // reviews are partitioned by business ID under the legacy "userId" partition key
+    paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+        TableName:              aws.String(ReviewTableName),
+        KeyConditionExpression: aws.String("#pk = :businessId"),
+        ProjectionExpression:   aws.String("vendorReviewId"),
+        ExpressionAttributeNames: map[string]string{
+            "#pk": "userId",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":businessId": &types.AttributeValueMemberS{Value: businessId.String()},
+        },
+    })
+
+    var vendorReviewIds []string
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            d.log.Errorf("Error querying vendor review IDs of business '%s': %v", businessId, err)
+            return vendorReviewIds, err
+        }
+
+        var items []struct {
+            VendorReviewId string `dynamodbav:"vendorReviewId"`
+        }
+        err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+        if err != nil {
+            d.log.Errorf("Error unmarshalling vendor review IDs of business '%s': %v", businessId, err)
+            return vendorReviewIds, err
+        }
+
+        for _, item := range items {
+            if item.VendorReviewId != "" {
+                vendorReviewIds = append(vendorReviewIds, item.VendorReviewId)
+            }
+        }
+    }
+
+    return vendorReviewIds, nil
+}
+
+// PutVendorReviewId maps the review ID of the vendor review ID of the stored review to the key of its item, so that FindReviewByVendorReviewId finds it.
+// It is called after the review is stored. Returns the stored review.
+func (d *VendorReviewIdDao) PutVendorReviewId(ctx context.Context, review model.Review) (StoredReview, error) {
+    item, err := attributevalue.MarshalMap(review)
+    if err != nil {
+        return StoredReview{}, err
+    }
+    key := map[string]types.AttributeValue{
+        "userId":   item["userId"],
+        "uniqueId": item["uniqueId"],
+    }
+    if key["userId"] == nil || key["uniqueId"] == nil {
+        return StoredReview{}, fmt.Errorf("review '%s' has no item key", review.VendorReviewId)
+    }
+
+    err = d.putVendorReviewId(ctx, review.BusinessId, review.VendorReviewId, key)
+    if err != nil {
+        return StoredReview{}, err
+    }
+    return StoredReview{Review: review, key: key}, nil
+}
+
+// IndexVendorReviewIds maps the vendor review IDs of all stored reviews of the business to their items.
+// It is run once for reviews stored before PutVendorReviewId was. Returns the number of reviews mapped.
+func (d *VendorReviewIdDao) IndexVendorReviewIds(ctx context.Context, businessId bid.BusinessId) (int, error) {
+    paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+        TableName:              aws.String(ReviewTableName),
+        KeyConditionExpression: aws.String("#pk = :businessId"),
+        ProjectionExpression:   aws.String("#pk, uniqueId, vendorReviewId"),
+        ExpressionAttributeNames: map[string]string{
+            "#pk": "userId",
+        },
+        ExpressionAttributeValues: map[string]types.AttributeValue{
+            ":businessId": &types.AttributeValueMemberS{Value: businessId.String()},
+        },
+    })
+
+    count := 0
+    for paginator.HasMorePages() {
+        page, err := paginator.NextPage(ctx)
+        if err != nil {
+            d.log.Errorf("Error querying reviews of business '%s': %v", businessId, err)
+            return count, err
+        }
+
+        for _, item := range page.Items {
+            vendorReviewId, ok := item["vendorReviewId"].(*types.AttributeValueMemberS)
+            if !ok || vendorReviewId.Value == "" {
+                continue
+            }
+            err = d.putVendorReviewId(ctx, businessId.String(), vendorReviewId.Value, map[string]types.AttributeValue{
+                "userId":   item["userId"],
+                "uniqueId": item["uniqueId"],
+            })
+            if err != nil {
+                return count, err
+            }
+            count++
+        }
+    }
+
+    return count, nil
+}
+
+func (d *VendorReviewIdDao) putVendorReviewId(ctx context.Context, businessId string, vendorReviewId string, key map[string]types.AttributeValue) error {
+    _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+        TableName: aws.String(VendorReviewIdTableName),
+        Item: map[string]types.AttributeValue{
+            "businessId": &types.AttributeValueMemberS{Value: businessId},
+            "reviewId":   &types.AttributeValueMemberS{Value: reviewIdOf(vendorReviewId)},
+            "reviewKey":  &types.AttributeValueMemberM{Value: key},
+        },
+    })
+    if err != nil {
+        d.log.Errorf("Error putting vendor review ID '%s' of business '%s': %v", vendorReviewId, businessId, err)
+        return err
+    }
+    return nil
+}
+
+// FindReviewByVendorReviewId finds the stored review of the business with the vendor review ID. Returns nil if not found.
+// Reviews are matched by the trailing review ID since the account ID of the vendor review ID depends on the account the review is fetched with.
+func (d *VendorReviewIdDao) FindReviewByVendorReviewId(ctx context.Context, businessId bid.BusinessId, vendorReviewId string) (*StoredReview, error) {
+    output, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName: aws.String(VendorReviewIdTableName),
+        Key: map[string]types.AttributeValue{
+            "businessId": &types.AttributeValueMemberS{Value: businessId.String()},
+            "reviewId":   &types.AttributeValueMemberS{Value: reviewIdOf(vendorReviewId)},
+        },
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        d.log.Errorf("Error getting vendor review ID '%s' of business '%s': %v", vendorReviewId, businessId, err)
+        return nil, err
+    }
+    if output.Item == nil {
+        return nil, nil
+    }
+    reviewKey, ok := output.Item["reviewKey"].(*types.AttributeValueMemberM)
+    if !ok {
+        return nil, fmt.Errorf("vendor review ID '%s' of business '%s' has no review key", vendorReviewId, businessId)
+    }
+
+    reviewOutput, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+        TableName:      aws.String(ReviewTableName),
+        Key:            reviewKey.Value,
+        ConsistentRead: aws.Bool(true),
+    })
+    if err != nil {
+        d.log.Errorf("Error getting review '%s' of business '%s': %v", vendorReviewId, businessId, err)
+        return nil, err
+    }
+    if reviewOutput.Item == nil {
+        d.log.Warnf("Review '%s' of business '%s' mapped by its vendor review ID does not exist", vendorReviewId, businessId)
+        return nil, nil
+    }
+
+    var storedReview StoredReview
+    err = attributevalue.UnmarshalMap(reviewOutput.Item, &storedReview.Review)
+    if err != nil {
+        d.log.Errorf("Error unmarshalling review '%s' of business '%s': %v", vendorReviewId, businessId, err)
+        return nil, err
+    }
+    var flags struct {
+        Removed  bool   `dynamodbav:"removed"`
+        Language string `dynamodbav:"language"`
+    }
+    err = attributevalue.UnmarshalMap(reviewOutput.Item, &flags)
+    if err != nil {
+        return nil, err
+    }
+    storedReview.Removed = flags.Removed
+    storedReview.Language = flags.Language
+    storedReview.key = reviewKey.Value
+    return &storedReview, nil
+}
+
+// UpdateReviewContent overwrites the content of the stored review, i.e., review text, rating and timestamps, with those of the updated review,
+// and the language of the review with that of the updated review text
+func (d *VendorReviewIdDao) UpdateReviewContent(ctx context.Context, storedReview StoredReview, updatedReview model.Review, language string) error {
+    item, err := attributevalue.MarshalMap(updatedReview)
+    if err != nil {
+        return err
+    }
+
+    var setExpressions, removeExpressions []string
+    names := map[string]string{}
+    values := map[string]types.AttributeValue{}
+    for i, attribute := range reviewContentAttributes {
+        namePlaceholder := fmt.Sprintf("#a%d", i)
+        names[namePlaceholder] = attribute
+
+        value, ok := item[attribute]
+        if _, isNull := value.(*types.AttributeValueMemberNULL); !ok || isNull {
+            // e.g., the reviewer removed the review text
+            removeExpressions = append(removeExpressions, namePlaceholder)
+            continue
+        }
+        valuePlaceholder := fmt.Sprintf(":v%d", i)
+        values[valuePlaceholder] = value
+        setExpressions = append(setExpressions, fmt.Sprintf("%s = %s", namePlaceholder, valuePlaceholder))
+    }
+
+    names["#language"] = ReviewLanguageAttributeName
+    if language == "" {
+        removeExpressions = append(removeExpressions, "#language")
+    } else {
+        values[":language"] = &types.AttributeValueMemberS{Value: language}
+        setExpressions = append(setExpressions, "#language = :language")
+    }
+
+    var clauses []string
+    if len(setExpressions) > 0 {
+        clauses = append(clauses, "SET "+strings.Join(setExpressions, ", "))
+    }
+    if len(removeExpressions) > 0 {
+        clauses = append(clauses, "REMOVE "+strings.Join(removeExpressions, ", "))
+    }
+    updateExpression := strings.Join(clauses, " ")
+
+    return d.update(ctx, storedReview, updateExpression, names, values)
+}
+
+// UpdateReviewLanguage stores the language of the stored review, as ISO 639-1 code. An empty language removes it.
+func (d *VendorReviewIdDao) UpdateReviewLanguage(ctx context.Context, storedReview StoredReview, language string) error {
+    if language == "" {
+        return d.update(ctx, storedReview, "REMOVE #language", map[string]string{"#language": ReviewLanguageAttributeName}, nil)
+    }
+    return d.update(ctx, storedReview, "SET #language = :language",
+        map[string]string{
+            "#language": ReviewLanguageAttributeName,
+        },
+        map[string]types.AttributeValue{
+            ":language": &types.AttributeValueMemberS{Value: language},
+        })
+}
+
+// MarkReviewRemoved marks the stored review as removed by the reviewer or Google
+func (d *VendorReviewIdDao) MarkReviewRemoved(ctx context.Context, storedReview StoredReview, removedAt time.Time) error {
+    removedAtValue, err := attributevalue.Marshal(removedAt)
+    if err != nil {
+        return err
+    }
+
+    return d.update(ctx, storedReview, "SET #removed = :removed, #removedAt = :removedAt",
+        map[string]string{
+            "#removed":   "removed",
+            "#removedAt": "removedAt",
+        },
+        map[string]types.AttributeValue{
+            ":removed":   &types.AttributeValueMemberBOOL{Value: true},
+            ":removedAt": removedAtValue,
+        })
+}
+
+func (d *VendorReviewIdDao) update(
+    ctx context.Context,
+    storedReview StoredReview,
+    updateExpression string,
+    names map[string]string,
+    values map[string]types.AttributeValue) error {
+    if storedReview.key == nil {
+        return errors.New("review was not found by VendorReviewIdDao")
+    }
+
+    names["#sk"] = "uniqueId"
+    if len(values) == 0 {
+        // DynamoDB rejects empty expression attribute values
+        values = nil
+    }
+    _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+        TableName:                 aws.String(ReviewTableName),
+        Key:                       storedReview.key,
+        UpdateExpression:          aws.String(updateExpression),
+        ConditionExpression:       aws.String("attribute_exists(#sk)"),
+        ExpressionAttributeNames:  names,
+        ExpressionAttributeValues: values,
+    })
+    if err != nil {
+        d.log.Errorf("Error updating review '%s' with '%s': %v", storedReview.Review.VendorReviewId, updateExpression, err)
+        return err
+    }
+
+    return nil
+}
+
+// reviewIdOf returns the trailing review ID of the vendor review ID, e.g., "BUSINESS_REVIEW_ID" of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID"
+func reviewIdOf(vendorReviewId string) string {
+    return vendorReviewId[strings.LastIndex(vendorReviewId, "/")+1:]
+}
@@ -0,0 +1,25 @@
+package exception
+
+import (
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+)
+
+// AiQuotaExceededException is returned when a business has used up the monthly AI quota of its plan tier
+type AiQuotaExceededException struct {
+    Context  string
+    PlanTier enum.PlanTier
+    QuotaUsd float64
+}
+
+func NewAiQuotaExceededException(message string, planTier enum.PlanTier, quotaUsd float64) *AiQuotaExceededException {
+    return &AiQuotaExceededException{
+        Context:  message,
+        PlanTier: planTier,
+        QuotaUsd: quotaUsd,
+    }
+}
+
+func (e *AiQuotaExceededException) Error() string {
+    return fmt.Sprintf("AiQuotaExceededException: %s: monthly quota of plan %s is $%.2f", e.Context, e.PlanTier, e.QuotaUsd)
+}
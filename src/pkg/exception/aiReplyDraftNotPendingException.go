@@ -0,0 +1,19 @@
+package exception
+
+import "fmt"
+
+type AiReplyDraftNotPendingException struct {
+    Context string
+    Err     error
+}
+
+func NewAiReplyDraftNotPendingException(message string, err error) *AiReplyDraftNotPendingException {
+    return &AiReplyDraftNotPendingException{
+        Context: message,
+        Err:     err,
+    }
+}
+
+func (e *AiReplyDraftNotPendingException) Error() string {
+    return fmt.Sprintf("AiReplyDraftNotPendingException: %s: %v", e.Context, e.Err)
+}
@@ -0,0 +1,19 @@
+package exception
+
+import "fmt"
+
+type BusinessNotFoundException struct {
+    Context string
+    Err     error
+}
+
+func NewBusinessNotFoundException(message string, err error) *BusinessNotFoundException {
+    return &BusinessNotFoundException{
+        Context: message,
+        Err:     err,
+    }
+}
+
+func (e *BusinessNotFoundException) Error() string {
+    return fmt.Sprintf("BusinessNotFoundException: %s: %v", e.Context, e.Err)
+}
@@ -0,0 +1,45 @@
+package exception
+
+import "fmt"
+
+// GoogleRequestException is returned when a request to the Google Business Profile API fails.
+// Transient failures (e.g., 429, 5xx, network errors, timeouts) may succeed if retried later; permanent failures will not.
+type GoogleRequestException struct {
+    Context      string
+    Transient    bool
+    StatusCode   int // 0 if no response was received
+    ResponseBody string
+    Err          error
+}
+
+func NewTransientGoogleRequestException(message string, statusCode int, responseBody string, err error) GoogleRequestException {
+    return GoogleRequestException{
+        Context:      message,
+        Transient:    true,
+        StatusCode:   statusCode,
+        ResponseBody: responseBody,
+        Err:          err,
+    }
+}
+
+func NewPermanentGoogleRequestException(message string, statusCode int, responseBody string, err error) GoogleRequestException {
+    return GoogleRequestException{
+        Context:      message,
+        Transient:    false,
+        StatusCode:   statusCode,
+        ResponseBody: responseBody,
+        Err:          err,
+    }
+}
+
+func (e GoogleRequestException) Error() string {
+    kind := "permanent"
+    if e.Transient {
+        kind = "transient"
+    }
+    return fmt.Sprintf("GoogleRequestException (%s): %s: status code %d: response body '%s': %v", kind, e.Context, e.StatusCode, e.ResponseBody, e.Err)
+}
+
+func (e GoogleRequestException) Unwrap() error {
+    return e.Err
+}
@@ -0,0 +1,19 @@
+package exception
+
+import "fmt"
+
+type InvalidAutoReplyRuleException struct {
+    Context string
+    Err     error
+}
+
+func NewInvalidAutoReplyRuleException(message string, err error) *InvalidAutoReplyRuleException {
+    return &InvalidAutoReplyRuleException{
+        Context: message,
+        Err:     err,
+    }
+}
+
+func (e *InvalidAutoReplyRuleException) Error() string {
+    return fmt.Sprintf("InvalidAutoReplyRuleException: %s: %v", e.Context, e.Err)
+}
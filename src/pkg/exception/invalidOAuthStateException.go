@@ -0,0 +1,21 @@
+package exception
+
+import "fmt"
+
+// InvalidOAuthStateException is returned when the state of a Google OAuth callback is malformed, tampered with or expired.
+// Only the user ID of an expired state can be trusted.
+type InvalidOAuthStateException struct {
+    Context string
+    Expired bool
+}
+
+func NewInvalidOAuthStateException(message string, expired bool) InvalidOAuthStateException {
+    return InvalidOAuthStateException{
+        Context: message,
+        Expired: expired,
+    }
+}
+
+func (e InvalidOAuthStateException) Error() string {
+    return fmt.Sprintf("InvalidOAuthStateException: %s", e.Context)
+}
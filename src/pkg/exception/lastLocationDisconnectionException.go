@@ -0,0 +1,18 @@
+package exception
+
+import "fmt"
+
+// LastLocationDisconnectionException is returned when the user disconnects the only location connected to the user
+type LastLocationDisconnectionException struct {
+    Context string
+}
+
+func NewLastLocationDisconnectionException(message string) *LastLocationDisconnectionException {
+    return &LastLocationDisconnectionException{
+        Context: message,
+    }
+}
+
+func (e *LastLocationDisconnectionException) Error() string {
+    return fmt.Sprintf("LastLocationDisconnectionException: %s", e.Context)
+}
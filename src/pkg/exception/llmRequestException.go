@@ -0,0 +1,50 @@
+package exception
+
+import "fmt"
+
+// LLMRequestException is returned when a request to an LLM provider fails.
+// Transient failures (e.g., 429, 5xx, network errors) may succeed if retried; permanent failures will not.
+type LLMRequestException struct {
+    Context    string
+    Transient  bool
+    StatusCode int // 0 if no response was received
+    Err        error
+}
+
+func NewTransientLLMRequestException(message string, statusCode int, err error) *LLMRequestException {
+    return &LLMRequestException{
+        Context:    message,
+        Transient:  true,
+        StatusCode: statusCode,
+        Err:        err,
+    }
+}
+
+func NewPermanentLLMRequestException(message string, statusCode int, err error) *LLMRequestException {
+    return &LLMRequestException{
+        Context:    message,
+        Transient:  false,
+        StatusCode: statusCode,
+        Err:        err,
+    }
+}
+
+// NewLLMRequestException classifies the failure by the status code: 429 and 5xx are transient
+func NewLLMRequestException(message string, statusCode int, err error) *LLMRequestException {
+    if statusCode == 429 || statusCode >= 500 {
+        return NewTransientLLMRequestException(message, statusCode, err)
+    }
+    return NewPermanentLLMRequestException(message, statusCode, err)
+}
+
+func (e *LLMRequestException) Error() string {
+    kind := "permanent"
+    if e.Transient {
+        kind = "transient"
+    }
+    return fmt.Sprintf("LLMRequestException (%s): %s: status code %d: %v", kind, e.Context, e.StatusCode, e.Err)
+}
+
+func (e *LLMRequestException) Unwrap() error {
+    return e.Err
+}
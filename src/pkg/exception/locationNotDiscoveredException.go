@@ -0,0 +1,18 @@
+package exception
+
+import "fmt"
+
+// LocationNotDiscoveredException is returned when the user connects a location not discovered in the latest OAuth of the user
+type LocationNotDiscoveredException struct {
+    Context string
+}
+
+func NewLocationNotDiscoveredException(message string) *LocationNotDiscoveredException {
+    return &LocationNotDiscoveredException{
+        Context: message,
+    }
+}
+
+func (e *LocationNotDiscoveredException) Error() string {
+    return fmt.Sprintf("LocationNotDiscoveredException: %s", e.Context)
+}
@@ -0,0 +1,21 @@
+package exception
+
+import "fmt"
+
+// ReplyBlockedException is returned when a reply is blocked from being published by the reply guard.
+// Reason is shown to LINE users, e.g., "回覆含有電話號碼。"
+type ReplyBlockedException struct {
+    Context string
+    Reason  string
+}
+
+func NewReplyBlockedException(message string, reason string) ReplyBlockedException {
+    return ReplyBlockedException{
+        Context: message,
+        Reason:  reason,
+    }
+}
+
+func (e ReplyBlockedException) Error() string {
+    return fmt.Sprintf("ReplyBlockedException: %s: %s", e.Context, e.Reason)
+}
@@ -0,0 +1,19 @@
+package exception
+
+import "fmt"
+
+type ReviewEventAlreadyExistException struct {
+    Context string
+    Err     error
+}
+
+func NewReviewEventAlreadyExistException(message string, err error) *ReviewEventAlreadyExistException {
+    return &ReviewEventAlreadyExistException{
+        Context: message,
+        Err:     err,
+    }
+}
+
+func (e *ReviewEventAlreadyExistException) Error() string {
+    return fmt.Sprintf("ReviewEventAlreadyExistException: %s: %v", e.Context, e.Err)
+}
@@ -0,0 +1,45 @@
+package exception
+
+import "fmt"
+
+// ZapierRequestException is returned when a request to a Zapier webhook fails.
+// Transient failures (e.g., 429, 5xx, network errors) may succeed if retried later; permanent failures will not.
+type ZapierRequestException struct {
+    Context      string
+    Transient    bool
+    StatusCode   int // 0 if no response was received
+    ResponseBody string
+    Err          error
+}
+
+func NewTransientZapierRequestException(message string, statusCode int, responseBody string, err error) ZapierRequestException {
+    return ZapierRequestException{
+        Context:      message,
+        Transient:    true,
+        StatusCode:   statusCode,
+        ResponseBody: responseBody,
+        Err:          err,
+    }
+}
+
+func NewPermanentZapierRequestException(message string, statusCode int, responseBody string, err error) ZapierRequestException {
+    return ZapierRequestException{
+        Context:      message,
+        Transient:    false,
+        StatusCode:   statusCode,
+        ResponseBody: responseBody,
+        Err:          err,
+    }
+}
+
+func (e ZapierRequestException) Error() string {
+    kind := "permanent"
+    if e.Transient {
+        kind = "transient"
+    }
+    return fmt.Sprintf("ZapierRequestException (%s): %s: status code %d: response body '%s': %v", kind, e.Context, e.StatusCode, e.ResponseBody, e.Err)
+}
+
+func (e ZapierRequestException) Unwrap() error {
+    return e.Err
+}
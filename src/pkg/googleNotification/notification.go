@@ -0,0 +1,70 @@
+package googleNotification
+
+import (
+    "crypto/subtle"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+)
+
+// PushRequest is the body of a Pub/Sub push request
+// https://cloud.google.com/pubsub/docs/push#receive_push
+type PushRequest struct {
+    Message      PushMessage `json:"message"`
+    Subscription string      `json:"subscription"`
+}
+
+type PushMessage struct {
+    // Data is the base64 encoded notification
+    Data        string            `json:"data"`
+    Attributes  map[string]string `json:"attributes,omitempty"`
+    MessageId   string            `json:"messageId"`
+    PublishTime string            `json:"publishTime"`
+}
+
+type NotificationType string
+
+const (
+    NotificationTypeNewReview     NotificationType = "NEW_REVIEW"
+    NotificationTypeUpdatedReview NotificationType = "UPDATED_REVIEW"
+)
+
+// Notification is the Google Business Profile notification published to Pub/Sub
+// https://developers.google.com/my-business/reference/rest/v4/accounts/updateNotifications#NotificationType
+type Notification struct {
+    Type NotificationType `json:"type"`
+    // Location is the location resource name in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID"
+    Location string `json:"location"`
+    // Review is the review resource name in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID"
+    Review string `json:"review,omitempty"`
+}
+
+// ParsePushRequest parses the Pub/Sub push request body and decodes the notification in its message
+func ParsePushRequest(body string) (PushRequest, Notification, error) {
+    var pushRequest PushRequest
+    err := json.Unmarshal([]byte(body), &pushRequest)
+    if err != nil {
+        return PushRequest{}, Notification{}, fmt.Errorf("error parsing push request: %w", err)
+    }
+
+    data, err := base64.StdEncoding.DecodeString(pushRequest.Message.Data)
+    if err != nil {
+        return pushRequest, Notification{}, fmt.Errorf("error decoding data of message '%s': %w", pushRequest.Message.MessageId, err)
+    }
+
+    var notification Notification
+    err = json.Unmarshal(data, &notification)
+    if err != nil {
+        return pushRequest, Notification{}, fmt.Errorf("error parsing notification of message '%s': %w", pushRequest.Message.MessageId, err)
+    }
+
+    return pushRequest, notification, nil
+}
+
+// VerifyPushToken checks the token of the push request against the verification token configured in the push subscription endpoint
+func VerifyPushToken(expectedToken string, token string) bool {
+    if expectedToken == "" {
+        return false
+    }
+    return subtle.ConstantTimeCompare([]byte(expectedToken), []byte(token)) == 1
+}
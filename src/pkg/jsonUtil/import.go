@@ -18,18 +18,21 @@ type QuickReplySettingsLineFlexTemplateJsons struct {
 
 type AiReplyLineFlexTemplateJsons struct {
     AiReplyResult                []byte
+    AiReplyDraft                 []byte
     AiReplySettings              []byte
     AiReplySettingsMultiBusiness []byte
 }
 
 type AuthLineFlexTemplateJsons struct {
-    AuthRequest []byte
+    AuthRequest       []byte
+    LocationSelection []byte
 }
 
 type NotificationLineFlexTemplateJsons struct {
     AiReplySettingsUpdated    []byte
     QuickReplySettingsUpdated []byte
     ReviewReplied             []byte
+    ReviewChanged             []byte
 }
 
 //go:embed json/lineFlexTemplate/*
@@ -78,6 +81,10 @@ func LoadAiReplyLineFlexTemplateJsons() AiReplyLineFlexTemplateJsons {
     if err != nil {
         log.Fatal("Error reading aiReplyResult.json: ", err)
     }
+    aiReplyDraft, err := embeddedFileSystem.ReadFile("json/lineFlexTemplate/aiReply/aiReplyDraft.json")
+    if err != nil {
+        log.Fatal("Error reading aiReplyDraft.json: ", err)
+    }
     aiReplySettings, err := embeddedFileSystem.ReadFile("json/lineFlexTemplate/aiReply/aiReplySettings.json")
     if err != nil {
         log.Fatal("Error reading aiReplySettings.json: ", err)
@@ -89,6 +96,7 @@ func LoadAiReplyLineFlexTemplateJsons() AiReplyLineFlexTemplateJsons {
 
     return AiReplyLineFlexTemplateJsons{
         aiReplyResult,
+        aiReplyDraft,
         aiReplySettings,
         aiReplySettingsMultiBusiness,
     }
@@ -99,9 +107,14 @@ func LoadAuthLineFlexTemplateJsons() AuthLineFlexTemplateJsons {
     if err != nil {
         log.Fatal("Error reading authRequest.json: ", err)
     }
+    locationSelection, err := embeddedFileSystem.ReadFile("json/lineFlexTemplate/auth/locationSelection.json")
+    if err != nil {
+        log.Fatal("Error reading locationSelection.json: ", err)
+    }
 
     return AuthLineFlexTemplateJsons{
         authRequest,
+        locationSelection,
     }
 }
 
@@ -119,9 +132,15 @@ func LoadNotificationLineFlexTemplateJsons() NotificationLineFlexTemplateJsons {
         log.Fatal("Error reading quickReplySettingsUpdated.json: ", err)
     }
 
+    reviewChanged, err := embeddedFileSystem.ReadFile("json/lineFlexTemplate/notification/reviewChanged.json")
+    if err != nil {
+        log.Fatal("Error reading reviewChanged.json: ", err)
+    }
+
     return NotificationLineFlexTemplateJsons{
         aiReplySettingsUpdated,
         quickReplySettingsUpdated,
         reviewReplied,
+        reviewChanged,
     }
 }
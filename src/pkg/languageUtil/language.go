@@ -0,0 +1,92 @@
+package languageUtil
+
+import (
+    "unicode"
+)
+
+// Languages are identified by ISO 639-1 codes
+const (
+    Chinese  = "zh"
+    Japanese = "ja"
+    Korean   = "ko"
+    English  = "en"
+)
+
+// Languages are the languages Detect tells apart
+var Languages = []string{Chinese, English, Japanese, Korean}
+
+// IsSupported returns whether the language is one of Languages
+func IsSupported(language string) bool {
+    for _, l := range Languages {
+        if l == language {
+            return true
+        }
+    }
+    return false
+}
+
+// Name is the English name of the language given to AI, e.g., "Japanese". Chinese is Taiwanese mandarin, which replies are written in by default.
+// Returns an empty string if the language is not supported.
+func Name(language string) string {
+    switch language {
+    case Chinese:
+        return "Taiwanese mandarin"
+    case English:
+        return "English"
+    case Japanese:
+        return "Japanese"
+    case Korean:
+        return "Korean"
+    default:
+        return ""
+    }
+}
+
+// DisplayName is the name of the language shown to LINE users, e.g., "日文". Returns the language code if the language is not supported.
+func DisplayName(language string) string {
+    switch language {
+    case Chinese:
+        return "中文"
+    case English:
+        return "英文"
+    case Japanese:
+        return "日文"
+    case Korean:
+        return "韓文"
+    default:
+        return language
+    }
+}
+
+// Detect guesses the language of the text from the scripts of its letters.
+// Returns an empty string if the text has no letters.
+// Kana or Hangul decide Japanese or Korean, as Japanese and Korean texts may also contain Han characters.
+func Detect(text string) string {
+    han, kana, hangul, latin := 0, 0, 0, 0
+    for _, r := range text {
+        switch {
+        case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+            kana++
+        case unicode.Is(unicode.Hangul, r):
+            hangul++
+        case unicode.Is(unicode.Han, r):
+            han++
+        case unicode.Is(unicode.Latin, r):
+            latin++
+        }
+    }
+
+    switch {
+    case kana > 0 && kana*5 >= han:
+        return Japanese
+    case hangul > 0 && hangul >= han:
+        return Korean
+    case han > 0 && han*2 >= latin:
+        // a few Han characters outweigh Latin words, e.g., "服務很好 good"
+        return Chinese
+    case latin > 0:
+        return English
+    default:
+        return ""
+    }
+}
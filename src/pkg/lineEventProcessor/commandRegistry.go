@@ -0,0 +1,142 @@
+package lineEventProcessor
+
+import (
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/line/line-bot-sdk-go/v7/linebot"
+    "strconv"
+    "strings"
+)
+
+// CommandHandler handles a text command message matched to a registered command
+type CommandHandler func(request CommandRequest) (events.LambdaFunctionURLResponse, error)
+
+// Command registers a text command, e.g., "/keywords/0 美甲"
+type Command struct {
+    // Name is the canonical name of the command, used in fill-in texts and help text
+    Name string
+    // Aliases are the alternative names of the command in all supported languages, e.g., "k", "關鍵字"
+    Aliases []string
+    // Description describes the command in help text
+    Description string
+    // Arg is the argument schema of the command. nil if the command takes no argument.
+    Arg *CommandArg
+    // RequiresAuth retrieves the user and requests auth if the user has not completed auth.
+    // CommandRequest.User is empty if the command does not require auth.
+    RequiresAuth bool
+    // TakesBusinessIndex resolves the 2nd command segment as the index of the user's business, e.g., "/keywords/0".
+    // Implies RequiresAuth.
+    TakesBusinessIndex bool
+    Handler            CommandHandler
+}
+
+// CommandArg describes the free text argument following the command
+type CommandArg struct {
+    // Name is the display name of the argument in help text
+    Name string
+    // Required rejects the command if the argument is empty. Optional arguments are typically cleared when empty.
+    Required bool
+}
+
+// CommandRequest is a text command message matched to a registered command
+type CommandRequest struct {
+    Event       *linebot.Event
+    TextMessage *linebot.TextMessage
+    UserId      string
+    // User is empty if the command does not require auth
+    User model.User
+    // BusinessId is empty if the command does not take a business index
+    BusinessId bid.BusinessId
+    Message    CommandMessage
+}
+
+// Usage returns the format of the command, e.g., "/keywords/{店家編號} {關鍵字}"
+func (c Command) Usage() string {
+    usage := "/" + c.Name
+    if c.TakesBusinessIndex {
+        usage += "/{店家編號}"
+    }
+    if c.Arg != nil {
+        usage += fmt.Sprintf(" {%s}", c.Arg.Name)
+    }
+    return usage
+}
+
+// CommandRegistry looks up registered commands by any of their names
+type CommandRegistry struct {
+    commands []Command
+    byName   map[string]int
+}
+
+func NewCommandRegistry() *CommandRegistry {
+    return &CommandRegistry{
+        byName: map[string]int{},
+    }
+}
+
+// Register registers a command. Panics on invalid or conflicting registrations, as commands are registered at start up.
+func (r *CommandRegistry) Register(command Command) *CommandRegistry {
+    if command.Name == "" || command.Handler == nil {
+        panic(fmt.Sprintf("command '%s' must have a name and a handler", command.Name))
+    }
+    if command.TakesBusinessIndex && !command.RequiresAuth {
+        panic(fmt.Sprintf("command '%s' takes business index and must require auth", command.Name))
+    }
+
+    for _, name := range append([]string{command.Name}, command.Aliases...) {
+        if _, exists := r.byName[name]; exists {
+            panic(fmt.Sprintf("command name '%s' is already registered", name))
+        }
+        r.byName[name] = len(r.commands)
+    }
+    r.commands = append(r.commands, command)
+
+    return r
+}
+
+// Lookup returns the command registered under the name or alias
+func (r *CommandRegistry) Lookup(name string) (Command, bool) {
+    index, ok := r.byName[name]
+    if !ok {
+        return Command{}, false
+    }
+    return r.commands[index], true
+}
+
+// Commands returns all registered commands in registration order
+func (r *CommandRegistry) Commands() []Command {
+    return r.commands
+}
+
+// HelpText lists the usage and description of all registered commands
+func (r *CommandRegistry) HelpText() string {
+    var sb strings.Builder
+    sb.WriteString("指令列表：")
+    for _, command := range r.commands {
+        sb.WriteString(fmt.Sprintf("\n%s：%s", command.Usage(), command.Description))
+        if len(command.Aliases) > 0 {
+            sb.WriteString(fmt.Sprintf("（亦可使用 %s）", strings.Join(command.Aliases, "、")))
+        }
+    }
+    return sb.String()
+}
+
+// ParseBusinessIndex resolves the business index in the 2nd command segment to the user's business ID
+// e.g., "/keywords/0" -> user's 1st business ID
+func ParseBusinessIndex(cmd CommandMessage, user model.User) (bid.BusinessId, error) {
+    if len(cmd.Command) < 2 {
+        return "", fmt.Errorf("command '%s' does not have business index", strings.Join(cmd.Command, "/"))
+    }
+
+    businessIdIndex, err := strconv.Atoi(cmd.Command[1])
+    if err != nil {
+        return "", fmt.Errorf("business index '%s' is not a number: %w", cmd.Command[1], err)
+    }
+    if businessIdIndex < 0 || businessIdIndex >= len(user.BusinessIds) {
+        return "", fmt.Errorf("business index %d is out of range for user with %d businesses", businessIdIndex, len(user.BusinessIds))
+    }
+
+    return user.GetBusinessIdFromIndex(businessIdIndex)
+}
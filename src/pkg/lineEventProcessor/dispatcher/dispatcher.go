@@ -0,0 +1,221 @@
+package dispatcher
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/line/line-bot-sdk-go/v7/linebot"
+    "go.uber.org/zap"
+)
+
+// EventProcessor processes a single LINE event sent by userId
+type EventProcessor func(event *linebot.Event, userId string) (events.LambdaFunctionURLResponse, error)
+
+// Dispatcher dispatches every LINE event of a webhook call to the processor registered for its event type.
+// Events are processed one at a time and in order, so that a failure of one event does not drop the rest of the batch.
+type Dispatcher struct {
+    processors map[linebot.EventType]EventProcessor
+    log        *zap.SugaredLogger
+}
+
+func NewDispatcher(logger *zap.SugaredLogger) *Dispatcher {
+    return &Dispatcher{
+        processors: map[linebot.EventType]EventProcessor{},
+        log:        logger,
+    }
+}
+
+// Register registers the processor for the event type. Registering the same event type twice replaces the processor.
+func (d *Dispatcher) Register(eventType linebot.EventType, processor EventProcessor) *Dispatcher {
+    d.processors[eventType] = processor
+    return d
+}
+
+// Dispatch processes all LINE events and collects the result of each of them
+func (d *Dispatcher) Dispatch(lineEvents []*linebot.Event) BatchResult {
+    results := make([]EventResult, 0, len(lineEvents))
+    for _, event := range lineEvents {
+        result := d.dispatchEvent(event)
+        emitEventMetric(result)
+        results = append(results, result)
+    }
+
+    return BatchResult{Results: results}
+}
+
+func (d *Dispatcher) dispatchEvent(event *linebot.Event) (result EventResult) {
+    result = EventResult{
+        WebhookEventId: event.WebhookEventID,
+        EventType:      event.Type,
+    }
+
+    d.log.Infof("Processing event: %s\n", jsonUtil.AnyToJson(event))
+
+    if event.Source == nil || !lineUtil.IsEventFromUser(event) {
+        d.log.Info("Event is not from user. No action taken: ", jsonUtil.AnyToJson(event))
+        result.Skipped = true
+        result.Response = events.LambdaFunctionURLResponse{
+            StatusCode: 200,
+            Body:       `{"message": "Event is not from user. No action taken."}`,
+        }
+        return result
+    }
+    userId := event.Source.UserID
+
+    processor, ok := d.processors[event.Type]
+    if !ok {
+        d.log.Info("Unhandled event type: ", event.Type)
+        result.Skipped = true
+        result.Response = events.LambdaFunctionURLResponse{
+            StatusCode: 200,
+            Body:       fmt.Sprintf(`{"message": "Unhandled event type '%s'. No action taken."}`, event.Type),
+        }
+        return result
+    }
+
+    // a panic in one event must not prevent the remaining events from being processed
+    defer func() {
+        if r := recover(); r != nil {
+            d.log.Errorf("Recovered from panic while processing %s event '%s': %v", event.Type, event.WebhookEventID, r)
+            result.Err = fmt.Errorf("panic while processing %s event '%s': %v", event.Type, event.WebhookEventID, r)
+            result.Response = events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Panic while processing event: %v"}`, r),
+            }
+        }
+    }()
+
+    d.log.Infof("Received %s event", event.Type)
+    result.Response, result.Err = processor(event, userId)
+    if result.Err != nil {
+        d.log.Errorf("Error processing %s event '%s' from user '%s': %v", event.Type, event.WebhookEventID, userId, result.Err)
+    }
+
+    return result
+}
+
+func emitEventMetric(result EventResult) {
+    var eventMetric enum.LineEventMetric
+    switch {
+    case result.Skipped:
+        eventMetric = enum.MetricLineEventSkipped
+    case result.Failed():
+        eventMetric = enum.MetricLineEventFailed
+    default:
+        eventMetric = enum.MetricLineEventSucceeded
+    }
+
+    metric.EmitMetricWithNamespace(eventMetric.MetricName(result.EventType), 1.0, util.LineEventsMetricNamespace)
+}
+
+// EventResult is the outcome of processing a single LINE event
+type EventResult struct {
+    WebhookEventId string
+    EventType      linebot.EventType
+    Skipped        bool
+    Response       events.LambdaFunctionURLResponse
+    Err            error
+}
+
+// Failed returns true if the event processing returned an error or a non-2xx response
+func (r EventResult) Failed() bool {
+    return r.Err != nil || r.Response.StatusCode >= 300
+}
+
+// BatchResult is the outcome of processing all LINE events of a webhook call
+type BatchResult struct {
+    Results []EventResult
+}
+
+func (b BatchResult) FailedCount() int {
+    count := 0
+    for _, result := range b.Results {
+        if result.Failed() {
+            count++
+        }
+    }
+    return count
+}
+
+// Err joins the errors of all failed events. Returns nil if no event returned an error.
+func (b BatchResult) Err() error {
+    var errs []error
+    for _, result := range b.Results {
+        if result.Err != nil {
+            errs = append(errs, fmt.Errorf("%s event '%s': %w", result.EventType, result.WebhookEventId, result.Err))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+type eventResultSummary struct {
+    WebhookEventId string          `json:"webhookEventId"`
+    EventType      string          `json:"type"`
+    StatusCode     int             `json:"statusCode"`
+    Skipped        bool            `json:"skipped,omitempty"`
+    Body           json.RawMessage `json:"body,omitempty"`
+    Error          string          `json:"error,omitempty"`
+}
+
+type batchResultSummary struct {
+    Message   string               `json:"message"`
+    Total     int                  `json:"total"`
+    Failed    int                  `json:"failed"`
+    Processed []eventResultSummary `json:"results"`
+}
+
+// ToResponse summarizes all event results into a single response.
+// The status code is always 200: LINE redelivers the whole batch on a non-2xx response, which would process the succeeded events again.
+// Failed events are reported in the summary, logs and metrics instead.
+func (b BatchResult) ToResponse() events.LambdaFunctionURLResponse {
+    summary := batchResultSummary{
+        Total:     len(b.Results),
+        Failed:    b.FailedCount(),
+        Processed: make([]eventResultSummary, 0, len(b.Results)),
+    }
+
+    for _, result := range b.Results {
+        eventSummary := eventResultSummary{
+            WebhookEventId: result.WebhookEventId,
+            EventType:      string(result.EventType),
+            StatusCode:     result.Response.StatusCode,
+            Skipped:        result.Skipped,
+        }
+        // response bodies are JSON by convention, but embed them as string if they are not
+        if json.Valid([]byte(result.Response.Body)) {
+            eventSummary.Body = json.RawMessage(result.Response.Body)
+        } else if result.Response.Body != "" {
+            eventSummary.Body, _ = json.Marshal(result.Response.Body)
+        }
+        if result.Err != nil {
+            eventSummary.Error = result.Err.Error()
+        }
+        summary.Processed = append(summary.Processed, eventSummary)
+    }
+
+    if summary.Failed == 0 {
+        summary.Message = fmt.Sprintf("Successfully handled %d LINE events", summary.Total)
+    } else {
+        summary.Message = fmt.Sprintf("Failed to handle %d of %d LINE events", summary.Failed, summary.Total)
+    }
+
+    body, err := json.Marshal(summary)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 200,
+            Body:       fmt.Sprintf(`{"message": "%s. Failed to summarize LINE event results: %s"}`, summary.Message, err),
+        }
+    }
+
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Headers:    map[string]string{"Content-Type": "application/json"},
+        Body:       string(body),
+    }
+}
@@ -0,0 +1,187 @@
+package dispatcher
+
+import (
+    "encoding/json"
+    "errors"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/line/line-bot-sdk-go/v7/linebot"
+    "go.uber.org/zap"
+    "testing"
+)
+
+func okProcessor(*linebot.Event, string) (events.LambdaFunctionURLResponse, error) {
+    return events.LambdaFunctionURLResponse{StatusCode: 200, Body: `{"message": "ok"}`}, nil
+}
+
+func failingProcessor(*linebot.Event, string) (events.LambdaFunctionURLResponse, error) {
+    return events.LambdaFunctionURLResponse{StatusCode: 500, Body: `{"error": "failed"}`}, errors.New("failed")
+}
+
+func panickingProcessor(*linebot.Event, string) (events.LambdaFunctionURLResponse, error) {
+    panic("nil map")
+}
+
+func newEvent(webhookEventId string, eventType linebot.EventType, sourceType linebot.EventSourceType) *linebot.Event {
+    return &linebot.Event{
+        WebhookEventID: webhookEventId,
+        Type:           eventType,
+        Source:         &linebot.EventSource{Type: sourceType, UserID: "U1234567890abcdef"},
+    }
+}
+
+func TestDispatcherDispatch(t *testing.T) {
+    tests := []struct {
+        name        string
+        processors  map[linebot.EventType]EventProcessor
+        events      []*linebot.Event
+        wantFailed  []bool
+        wantSkipped []bool
+    }{
+        {
+            name:       "all events succeed",
+            processors: map[linebot.EventType]EventProcessor{linebot.EventTypeMessage: okProcessor},
+            events: []*linebot.Event{
+                newEvent("1", linebot.EventTypeMessage, linebot.EventSourceTypeUser),
+                newEvent("2", linebot.EventTypeMessage, linebot.EventSourceTypeUser),
+            },
+            wantFailed:  []bool{false, false},
+            wantSkipped: []bool{false, false},
+        },
+        {
+            name: "failed event does not stop the rest of the batch",
+            processors: map[linebot.EventType]EventProcessor{
+                linebot.EventTypeMessage:  okProcessor,
+                linebot.EventTypePostback: failingProcessor,
+            },
+            events: []*linebot.Event{
+                newEvent("1", linebot.EventTypePostback, linebot.EventSourceTypeUser),
+                newEvent("2", linebot.EventTypeMessage, linebot.EventSourceTypeUser),
+            },
+            wantFailed:  []bool{true, false},
+            wantSkipped: []bool{false, false},
+        },
+        {
+            name: "panicking event is recovered as failed",
+            processors: map[linebot.EventType]EventProcessor{
+                linebot.EventTypeMessage:  okProcessor,
+                linebot.EventTypePostback: panickingProcessor,
+            },
+            events: []*linebot.Event{
+                newEvent("1", linebot.EventTypeMessage, linebot.EventSourceTypeUser),
+                newEvent("2", linebot.EventTypePostback, linebot.EventSourceTypeUser),
+                newEvent("3", linebot.EventTypeMessage, linebot.EventSourceTypeUser),
+            },
+            wantFailed:  []bool{false, true, false},
+            wantSkipped: []bool{false, false, false},
+        },
+        {
+            name:       "events not from user and unhandled event types are skipped",
+            processors: map[linebot.EventType]EventProcessor{linebot.EventTypeMessage: panickingProcessor},
+            events: []*linebot.Event{
+                newEvent("1", linebot.EventTypeMessage, linebot.EventSourceTypeGroup),
+                newEvent("2", linebot.EventTypeBeacon, linebot.EventSourceTypeUser),
+                {WebhookEventID: "3", Type: linebot.EventTypeMessage},
+            },
+            wantFailed:  []bool{false, false, false},
+            wantSkipped: []bool{true, true, true},
+        },
+        {
+            name:       "empty batch",
+            processors: map[linebot.EventType]EventProcessor{},
+            events:     []*linebot.Event{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            d := NewDispatcher(zap.NewNop().Sugar())
+            for eventType, processor := range tt.processors {
+                d.Register(eventType, processor)
+            }
+
+            batchResult := d.Dispatch(tt.events)
+            if len(batchResult.Results) != len(tt.events) {
+                t.Fatalf("Dispatch() results = %d, want %d", len(batchResult.Results), len(tt.events))
+            }
+            for i, result := range batchResult.Results {
+                if result.WebhookEventId != tt.events[i].WebhookEventID {
+                    t.Errorf("Dispatch() result %d webhook event ID = %s, want %s", i, result.WebhookEventId, tt.events[i].WebhookEventID)
+                }
+                if result.Failed() != tt.wantFailed[i] {
+                    t.Errorf("Dispatch() result %d failed = %v, want %v", i, result.Failed(), tt.wantFailed[i])
+                }
+                if result.Skipped != tt.wantSkipped[i] {
+                    t.Errorf("Dispatch() result %d skipped = %v, want %v", i, result.Skipped, tt.wantSkipped[i])
+                }
+            }
+        })
+    }
+}
+
+func TestBatchResultToResponse(t *testing.T) {
+    succeeded := EventResult{WebhookEventId: "1", EventType: linebot.EventTypeMessage, Response: events.LambdaFunctionURLResponse{StatusCode: 200, Body: `{"message": "ok"}`}}
+    skipped := EventResult{WebhookEventId: "2", EventType: linebot.EventTypeBeacon, Skipped: true, Response: events.LambdaFunctionURLResponse{StatusCode: 200}}
+    rejected := EventResult{WebhookEventId: "3", EventType: linebot.EventTypePostback, Response: events.LambdaFunctionURLResponse{StatusCode: 400, Body: "not JSON"}}
+    failed := EventResult{WebhookEventId: "4", EventType: linebot.EventTypePostback, Response: events.LambdaFunctionURLResponse{StatusCode: 200}, Err: errors.New("failed")}
+
+    tests := []struct {
+        name            string
+        results         []EventResult
+        wantFailed      int
+        wantStatusCodes []int
+    }{
+        {
+            name:            "all events succeed",
+            results:         []EventResult{succeeded, skipped},
+            wantFailed:      0,
+            wantStatusCodes: []int{200, 200},
+        },
+        {
+            name:            "event rejected with error status code",
+            results:         []EventResult{succeeded, rejected},
+            wantFailed:      1,
+            wantStatusCodes: []int{200, 400},
+        },
+        {
+            name:            "event error without error status code",
+            results:         []EventResult{succeeded, rejected, failed},
+            wantFailed:      2,
+            wantStatusCodes: []int{200, 400, 200},
+        },
+        {
+            name:            "empty batch",
+            results:         []EventResult{},
+            wantFailed:      0,
+            wantStatusCodes: []int{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            response := BatchResult{Results: tt.results}.ToResponse()
+            // failed events must not fail the batch, or LINE redelivers the events that succeeded
+            if response.StatusCode != 200 {
+                t.Errorf("ToResponse() status code = %d, want 200", response.StatusCode)
+            }
+
+            var summary batchResultSummary
+            if err := json.Unmarshal([]byte(response.Body), &summary); err != nil {
+                t.Fatalf("ToResponse() body '%s' is not a batch summary: %v", response.Body, err)
+            }
+            if summary.Total != len(tt.results) || summary.Failed != tt.wantFailed {
+                t.Errorf("ToResponse() summary total = %d, failed = %d, want %d, %d", summary.Total, summary.Failed, len(tt.results), tt.wantFailed)
+            }
+            if len(summary.Processed) != len(tt.results) {
+                t.Fatalf("ToResponse() summary results = %d, want %d", len(summary.Processed), len(tt.results))
+            }
+            for i, eventSummary := range summary.Processed {
+                if eventSummary.StatusCode != tt.wantStatusCodes[i] {
+                    t.Errorf("ToResponse() result %d status code = %d, want %d", i, eventSummary.StatusCode, tt.wantStatusCodes[i])
+                }
+                if (eventSummary.Error != "") != (tt.results[i].Err != nil) {
+                    t.Errorf("ToResponse() result %d error = %q, want error %v", i, eventSummary.Error, tt.results[i].Err)
+                }
+            }
+        })
+    }
+}
@@ -1,13 +1,21 @@
 package lineEventProcessor
 
 import (
+    "context"
+    "errors"
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/metric"
     enum2 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
+    enum3 "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
+    "github.com/IntelliLead/CoreDataAccess/model"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/auth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/slackUtil"
     "github.com/aws/aws-lambda-go/events"
     "github.com/line/line-bot-sdk-go/v7/linebot"
@@ -15,11 +23,14 @@ import (
 )
 
 func ProcessFollowEvent(event *linebot.Event,
+    businessDao *ddbDao.BusinessDao,
     userDao *ddbDao.UserDao,
+    lineFollowDao *dao.LineFollowDao,
     slack *slackUtil.Slack,
     line *lineUtil.LineUtil,
     log *zap.SugaredLogger,
     authRedirectUrl string,
+    stateSigner *oauthState.Signer,
 ) (events.LambdaFunctionURLResponse, error) {
 
     if lineUtil.IsEventFromUser(event) == false {
@@ -29,18 +40,43 @@ func ProcessFollowEvent(event *linebot.Event,
 
     userId := event.Source.UserID
 
-    // notify Slack channel
-    err := slack.SendNewUserFollowedMessage(userId, event.Timestamp)
+    // restore the user who unfollowed before
+    unfollowedAt, hasUnfollowed, err := lineFollowDao.GetUnfollowedAt(context.Background(), userId)
     if err != nil {
-        log.Error("Error sending Slack message:", err)
-        metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1.0)
-
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to get unfollow time of user: %s"}`, err),
+        }, err
     }
+    if hasUnfollowed {
+        user, err := restoreUnfollowedUser(userId, businessDao, userDao, lineFollowDao, log)
+        if err != nil {
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Failed to restore unfollowed user: %s"}`, err),
+            }, err
+        }
+
+        err = slack.SendUserRefollowedMessage(user, unfollowedAt, event.Timestamp)
+        if err != nil {
+            log.Error("Error sending Slack message:", err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1.0)
+        }
+    } else {
+        // notify Slack channel
+        err = slack.SendNewUserFollowedMessage(userId, event.Timestamp)
+        if err != nil {
+            log.Error("Error sending Slack message:", err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1.0)
 
-    log.Info("Successfully notified Slack channel of new user follow event")
+        }
 
+        log.Info("Successfully notified Slack channel of new user follow event")
+    }
+
+    // users whose Google tokens were purged after unfollowing, or revoked, are asked to complete OAuth again
     var hasUserAuthed bool
-    hasUserAuthed, _, err = auth.ValidateUserAuthOrRequestAuth(event.ReplyToken, userId, userDao, line, enum.HandlerNameLineEventsHandler, log, authRedirectUrl)
+    hasUserAuthed, _, err = auth.ValidateUserAuthOrRequestAuth(event.ReplyToken, userId, userDao, line, enum.HandlerNameLineEventsHandler, log, authRedirectUrl, stateSigner)
     if err != nil {
         return events.LambdaFunctionURLResponse{
             StatusCode: 500,
@@ -58,3 +94,59 @@ func ProcessFollowEvent(event *linebot.Event,
 
     return events.LambdaFunctionURLResponse{Body: `{"message": "Successfully handled Follow event"}`, StatusCode: 200}, nil
 }
+
+// restoreUnfollowedUser adds the user back to the userIds of their businesses and marks the user active again.
+// The user is marked active even if some businesses fail to restore, so that the Google tokens of the user are not purged.
+func restoreUnfollowedUser(userId string,
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    lineFollowDao *dao.LineFollowDao,
+    log *zap.SugaredLogger,
+) (model.User, error) {
+    userPtr, err := userDao.GetUser(userId)
+    if err != nil {
+        log.Errorf("Error getting user '%s': %s", userId, err)
+        return model.User{}, err
+    }
+    if userPtr == nil {
+        return model.User{}, fmt.Errorf("unfollowed user '%s' does not exist", userId)
+    }
+    user := *userPtr
+
+    var errs []error
+    for _, businessId := range user.BusinessIds {
+        businessPtr, err := businessDao.GetBusiness(businessId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error getting business '%s': %w", businessId, err))
+            continue
+        }
+        if businessPtr == nil {
+            log.Warnf("Business '%s' of user '%s' does not exist. Skipping.", businessId, userId)
+            continue
+        }
+        if stringUtil.StringInSlice(userId, businessPtr.UserIds) {
+            continue
+        }
+
+        userIdAppendAction, err := dbModel.NewAttributeAction(enum3.ActionAppendStringSet, "userIds", []string{userId})
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error building user id append action: %w", err))
+            continue
+        }
+        _, err = businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{userIdAppendAction}, userId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error adding user to business '%s': %w", businessId, err))
+        }
+    }
+
+    err = lineFollowDao.MarkRefollowed(context.Background(), userId)
+    if err != nil {
+        errs = append(errs, err)
+    }
+
+    if len(errs) > 0 {
+        return user, errors.Join(errs...)
+    }
+    log.Infof("Restored unfollowed user '%s' to %d businesses", userId, len(user.BusinessIds))
+    return user, nil
+}
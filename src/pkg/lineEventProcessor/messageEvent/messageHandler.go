@@ -1,13 +1,20 @@
 package messageEvent
 
 import (
+    "context"
+    "fmt"
     "github.com/IntelliLead/CoreCommonUtil/stringUtil"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
     "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
     "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
     "github.com/IntelliLead/CoreDataAccess/model"
     "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
     "go.uber.org/zap"
+    "strconv"
+    "strings"
 )
 
 func buildQuickReplyUpdateAttributeActions(quickReplyMessage string) ([]dbModel.AttributeAction, error) {
@@ -31,16 +38,37 @@ func buildQuickReplyUpdateAttributeActions(quickReplyMessage string) ([]dbModel.
     }
 }
 
+// parseAllowedContacts splits the phone numbers and emails of the allowed contacts command, separated by commas or new lines
+func parseAllowedContacts(arg string) []string {
+    var contacts []string
+    for _, contact := range strings.FieldsFunc(arg, func(r rune) bool { return r == ',' || r == '，' || r == '、' || r == '\n' }) {
+        contact = strings.TrimSpace(contact)
+        if contact != "" {
+            contacts = append(contacts, contact)
+        }
+    }
+    return contacts
+}
+
+// cutQuickReplyMessageLanguage splits the leading language token of the quick reply message command, e.g., "語言:en Thank you!", into the lowercased language and the rest of the message.
+// Returns false if the message does not start with a language token.
+func cutQuickReplyMessageLanguage(quickReplyMessage string) (string, string, bool) {
+    token, rest, _ := strings.Cut(strings.TrimSpace(quickReplyMessage), " ")
+    name, language, hasValue := strings.Cut(strings.Replace(token, "：", ":", 1), ":")
+    if !hasValue || (name != "語言" && name != "lang") {
+        return "", quickReplyMessage, false
+    }
+    return strings.ToLower(language), strings.TrimSpace(rest), true
+}
+
 // handleUpdateQuickReplyMessage handles the update of the quick reply message
-// returns:
-// 1. bool: whether the quick reply is enabled
-// 2. string: quick reply message
-// 3. error
+// Clearing the quick reply message disables the auto reply rules replying with it.
 func handleUpdateQuickReplyMessage(
     businessId bid.BusinessId,
     quickReplyMessage string,
     updatedByUserId string,
     businessDao *ddbDao.BusinessDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
     log *zap.SugaredLogger) (model.Business, error) {
     actions, err := buildQuickReplyUpdateAttributeActions(quickReplyMessage)
     if err != nil {
@@ -48,6 +76,17 @@ func handleUpdateQuickReplyMessage(
         return model.Business{}, err
     }
 
+    if stringUtil.IsEmptyString(quickReplyMessage) {
+        disableRulesAction, err := buildDisableQuickReplyMessageRulesAttributeAction(businessId, businessDao, autoReplyRuleDao)
+        if err != nil {
+            log.Errorf("Error building auto reply rules update attribute action for business '%s': %v", businessId, err)
+            return model.Business{}, err
+        }
+        if disableRulesAction != nil {
+            actions = append(actions, *disableRulesAction)
+        }
+    }
+
     business, err := businessDao.UpdateAttributes(businessId, actions, updatedByUserId)
     if err != nil {
         log.Errorf("Error updating quick reply message '%s' for business '%s': %v", quickReplyMessage, businessId, err)
@@ -57,6 +96,99 @@ func handleUpdateQuickReplyMessage(
     return business, nil
 }
 
+// buildDisableQuickReplyMessageRulesAttributeAction builds the action disabling the stored auto reply rules that reply with the quick reply message.
+// Returns nil if no rule needs to be disabled.
+func buildDisableQuickReplyMessageRulesAttributeAction(
+    businessId bid.BusinessId,
+    businessDao *ddbDao.BusinessDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao) (*dbModel.AttributeAction, error) {
+    businessPtr, err := businessDao.GetBusiness(businessId)
+    if err != nil {
+        return nil, err
+    }
+    if businessPtr == nil {
+        return nil, fmt.Errorf("business not found for businessId: %s", businessId)
+    }
+
+    rules, err := autoReplyRuleDao.GetAutoReplyRules(context.Background(), *businessPtr)
+    if err != nil {
+        return nil, err
+    }
+
+    changed := false
+    for i, rule := range rules {
+        // the legacy rule is disabled with autoQuickReplyEnabled
+        if !rule.Legacy && rule.Enabled && rule.RequiresQuickReplyMessage() {
+            rules[i].Enabled = false
+            changed = true
+        }
+    }
+    if !changed {
+        return nil, nil
+    }
+
+    action, err := dbModel.NewAttributeAction(enum.ActionUpdate, dao.AutoReplyRulesAttributeName, rules)
+    if err != nil {
+        return nil, err
+    }
+    return &action, nil
+}
+
+// handleUpdateAutoReplyRule adds the auto reply rule parsed from the command argument to the end of the rules of the business,
+// or deletes a rule if the argument is "刪除 {規則編號}".
+// Returns *exception.InvalidAutoReplyRuleException if the argument is invalid.
+func handleUpdateAutoReplyRule(
+    businessId bid.BusinessId,
+    arg string,
+    updatedByUserId string,
+    businessDao *ddbDao.BusinessDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
+    log *zap.SugaredLogger) (model.Business, error) {
+    businessPtr, err := businessDao.GetBusiness(businessId)
+    if err != nil {
+        log.Errorf("Error getting business '%s' during handling auto reply rule update: %v", businessId, err)
+        return model.Business{}, err
+    }
+    if businessPtr == nil {
+        return model.Business{}, fmt.Errorf("business not found for businessId: %s", businessId)
+    }
+    business := *businessPtr
+
+    rules, err := autoReplyRuleDao.GetAutoReplyRules(context.Background(), business)
+    if err != nil {
+        return model.Business{}, err
+    }
+    for i := range rules {
+        // the legacy rule is stored as a regular rule from now on
+        rules[i].Legacy = false
+    }
+
+    verb, ruleNumberStr, _ := strings.Cut(arg, " ")
+    if verb == "刪除" || verb == "delete" {
+        ruleNumber, err := strconv.Atoi(strings.TrimSpace(ruleNumberStr))
+        if err != nil || ruleNumber < 1 || ruleNumber > len(rules) {
+            return business, exception.NewInvalidAutoReplyRuleException(fmt.Sprintf("Rule number '%s' is out of range of %d rules", ruleNumberStr, len(rules)), err)
+        }
+        rules = append(rules[:ruleNumber-1], rules[ruleNumber:]...)
+    } else {
+        rule, err := model2.ParseAutoReplyRule(arg)
+        if err != nil {
+            return business, exception.NewInvalidAutoReplyRuleException(fmt.Sprintf("Invalid auto reply rule '%s'", arg), err)
+        }
+        if rule.RequiresQuickReplyMessage() && stringUtil.IsEmptyStringPtr(business.QuickReplyMessage) {
+            return business, exception.NewAutoQuickReplyConditionNotMetException("Please fill in quick reply message before adding auto reply rules replying with it")
+        }
+        rules = append(rules, rule)
+    }
+
+    updatedBusiness, err := autoReplyRuleDao.UpdateAutoReplyRules(businessId, rules, updatedByUserId)
+    if err != nil {
+        return model.Business{}, err
+    }
+
+    return updatedBusiness, nil
+}
+
 // handleBusinessDescriptionUpdate handles the update of the business description.
 // returns:
 // 1. updated user (if there is no operation on user, it will be the same as the input)
@@ -1,36 +1,130 @@
 package messageEvent
 
 import (
+    "context"
+    "errors"
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/metric"
     enum2 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
     "github.com/IntelliLead/CoreCommonUtil/stringUtil"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
     "github.com/IntelliLead/CoreDataAccess/model"
-    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/auth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/languageUtil"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/locationConnector"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/aws/aws-lambda-go/events"
     "github.com/line/line-bot-sdk-go/v7/linebot"
     "go.uber.org/zap"
-    "strconv"
+    "strings"
 )
 
-func shouldAuth(message string) bool {
-    if lineEventProcessor.IsReviewReplyMessage(message) {
-        return true
-    }
+type messageProcessor struct {
+    businessDao      *ddbDao.BusinessDao
+    userDao          *ddbDao.UserDao
+    reviewDao        *ddbDao.ReviewDao
+    autoReplyRuleDao *dao.AutoReplyRuleDao
+    replyGuardDao    *dao.ReplyGuardDao
+    connector        *locationConnector.LocationConnector
+    line             *lineUtil.LineUtil
+    log              *zap.SugaredLogger
+    authRedirectUrl  string
+    stateSigner      *oauthState.Signer
+}
 
-    cmd, err := lineEventProcessor.ParseCommandMessage(message)
-    if err != nil {
-        return false
-    }
+// commandRegistry registers all text commands. Adding a command only requires registering it here.
+func (p messageProcessor) commandRegistry() *lineEventProcessor.CommandRegistry {
+    return lineEventProcessor.NewCommandRegistry().
+        Register(lineEventProcessor.Command{
+            Name:        "help",
+            Aliases:     []string{"h", "Help", "幫助", "協助"},
+            Description: "查看指令說明",
+            Handler:     p.handleHelp,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:               util.UpdateQuickReplyMessageCmd,
+            Description:        "更新快速回覆訊息，留空即清除；以「語言:en」開頭則更新該語言評論的快速回覆訊息",
+            Arg:                &lineEventProcessor.CommandArg{Name: "快速回覆訊息"},
+            RequiresAuth:       true,
+            TakesBusinessIndex: true,
+            Handler:            p.handleUpdateQuickReplyMessageCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:               util.UpdateAutoReplyRuleCmd,
+            Aliases:            []string{"自動回覆規則"},
+            Description:        "新增自動回覆規則，例如「4-5 有文字 AI」；「刪除 {規則編號}」即刪除規則",
+            Arg:                &lineEventProcessor.CommandArg{Name: "規則", Required: true},
+            RequiresAuth:       true,
+            TakesBusinessIndex: true,
+            Handler:            p.handleUpdateAutoReplyRuleCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:               util.UpdateAllowedContactsCmd,
+            Aliases:            []string{"聯絡方式"},
+            Description:        "更新允許在回覆中公開的電話或 Email，以逗號分隔，留空即清除",
+            Arg:                &lineEventProcessor.CommandArg{Name: "電話或 Email"},
+            RequiresAuth:       true,
+            TakesBusinessIndex: true,
+            Handler:            p.handleUpdateAllowedContactsCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:         util.ManageLocationsCmd,
+            Aliases:      []string{"商家"},
+            Description:  "連結或取消連結 Google 商家",
+            RequiresAuth: true,
+            Handler:      p.handleManageLocationsCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:        util.ReauthCmd,
+            Aliases:     []string{"授權"},
+            Description: "重新授權 Google，例如授權失效時",
+            Handler:     p.handleReauthCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:               util.UpdateBusinessDescriptionMessageCmd,
+            Description:        "更新主要業務，留空即清除",
+            Arg:                &lineEventProcessor.CommandArg{Name: "主要業務"},
+            RequiresAuth:       true,
+            TakesBusinessIndex: true,
+            Handler:            p.handleUpdateBusinessDescriptionCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:               util.UpdateKeywordsMessageCmd,
+            Aliases:            []string{"k", "關鍵字"},
+            Description:        "更新關鍵字，留空即清除",
+            Arg:                &lineEventProcessor.CommandArg{Name: "關鍵字"},
+            RequiresAuth:       true,
+            TakesBusinessIndex: true,
+            Handler:            p.handleUpdateKeywordsCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:         util.UpdateSignatureMessageCmd,
+            Aliases:      []string{"s", "簽名"},
+            Description:  "更新簽名，留空即清除",
+            Arg:          &lineEventProcessor.CommandArg{Name: "簽名"},
+            RequiresAuth: true,
+            Handler:      p.handleUpdateSignatureCommand,
+        }).
+        Register(lineEventProcessor.Command{
+            Name:         util.UpdateRecommendationMessageCmd,
+            Aliases:      []string{"r", "推薦"},
+            Description:  "更新推薦業務，留空即清除",
+            Arg:          &lineEventProcessor.CommandArg{Name: "推薦業務"},
+            RequiresAuth: true,
+            Handler:      p.handleUpdateServiceRecommendationCommand,
+        })
+}
 
-    firstCmdArg := cmd.Command[0]
-    return firstCmdArg != "h" && firstCmdArg != "Help" && firstCmdArg != "help" && firstCmdArg != "幫助"
+// CommandHelpText lists all text commands, generated from the command registrations
+func CommandHelpText() string {
+    return messageProcessor{}.commandRegistry().HelpText()
 }
 
 // ProcessMessageEvent processes a message event from LINE
@@ -41,10 +135,27 @@ func ProcessMessageEvent(
     businessDao *ddbDao.BusinessDao,
     userDao *ddbDao.UserDao,
     reviewDao *ddbDao.ReviewDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
+    replyGuardDao *dao.ReplyGuardDao,
+    connector *locationConnector.LocationConnector,
     line *lineUtil.LineUtil,
+    publisher replyPublisher.ReplyPublisher,
     log *zap.SugaredLogger,
     authRedirectUrl string,
+    stateSigner *oauthState.Signer,
 ) (events.LambdaFunctionURLResponse, error) {
+    p := messageProcessor{
+        businessDao:      businessDao,
+        userDao:          userDao,
+        reviewDao:        reviewDao,
+        autoReplyRuleDao: autoReplyRuleDao,
+        replyGuardDao:    replyGuardDao,
+        connector:        connector,
+        line:             line,
+        log:              log,
+        authRedirectUrl:  authRedirectUrl,
+        stateSigner:      stateSigner,
+    }
 
     // --------------------------------
     // validate is text message from user
@@ -69,58 +180,23 @@ func ProcessMessageEvent(
 
     if !isTextMessageFromUser {
         log.Info("Message from user is not a text message.")
-
-        err := line.ReplyUnknownResponseReply(event.ReplyToken)
-        if err != nil {
-            log.Error("Error executing ReplyUnknownResponseReply: ", err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Error executing ReplyUnknownResponseReply: %s"}`, err),
-            }, err
-        }
-
-        return events.LambdaFunctionURLResponse{
-            StatusCode: 200,
-            Body:       `{"message": "Message from user is not a text message."}`,
-        }, nil
+        return p.replyUnknownMessage(event)
     }
 
     lineTextMessage := event.Message.(*linebot.TextMessage)
     message := lineTextMessage.Text
     log.Infof("Received text message from user '%s': %s", userId, message)
 
-    // --------------------------------
-    // auth if required
-    // --------------------------------
-    // Auth retrieves user object, so user can be empty if event does not require auth
-    var user model.User
-    // WARN: ensure event handlers that require auth are added to shouldAuth() list
-    if shouldAuth(message) {
-        log.Infof("Event requires auth. Validating user auth for user '%s'", userId)
-
-        var hasUserAuthed bool
-        hasUserAuthed, userPtr, err := auth.ValidateUserAuthOrRequestAuth(event.ReplyToken, userId, userDao, line, enum.HandlerNameLineEventsHandler, log, authRedirectUrl)
-        if err != nil {
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to validate user auth: %s"}`, err),
-            }, err
-        }
-        if userPtr == nil || !hasUserAuthed {
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 200,
-                Body:       `{"message": "User has not authenticated. Requested authentication."}`,
-            }, nil
-        }
-
-        user = *userPtr
-    }
-
     // --------------------------------
     // process review reply request
     // --------------------------------
     if lineEventProcessor.IsReviewReplyMessage(message) {
-        return ProcessReviewReplyMessage(user, event, reviewDao, businessDao, userDao, line, log)
+        user, response, err := p.authenticate(event, userId)
+        if user == nil {
+            return response, err
+        }
+
+        return ProcessReviewReplyMessage(*user, event, reviewDao, businessDao, userDao, line, publisher, log)
     }
 
     // --------------------------------
@@ -135,313 +211,578 @@ func ProcessMessageEvent(
         }, err
     }
 
+    command, ok := p.commandRegistry().Lookup(cmd.Command[0])
+    if !ok {
+        log.Infof("Unknown command '%s' from user '%s'", cmd.Command[0], userId)
+        return p.replyUnknownMessage(event)
+    }
+
+    request := lineEventProcessor.CommandRequest{
+        Event:       event,
+        TextMessage: lineTextMessage,
+        UserId:      userId,
+        Message:     cmd,
+    }
+
     // --------------------------------
-    // prepare businessId for command requests that include businessId index
-    // only for commands that require auth (or else user obj does not exist)
+    // auth if required
     // --------------------------------
-    var businessId bid.BusinessId
-    if shouldAuth(message) && cmd.Command[0] == util.UpdateQuickReplyMessageCmd || cmd.Command[0] == util.UpdateBusinessDescriptionMessageCmd || cmd.Command[0] == util.UpdateKeywordsMessageCmd {
-        if len(cmd.Command) < 2 {
-            log.Errorf("Error parsing command message '%s' from user '%s': %v", message, userId, err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to parse command message: %s"}`, err),
-            }, fmt.Errorf("command message '%s' does not have enough arguments", message)
+    if command.RequiresAuth || command.TakesBusinessIndex {
+        user, response, err := p.authenticate(event, userId)
+        if user == nil {
+            return response, err
         }
+        request.User = *user
+    }
 
-        // convert 2nd cmd arg to index
-        businessIdIndex, err := strconv.Atoi(cmd.Command[1])
-        if err != nil || businessIdIndex < 0 || businessIdIndex >= len(user.BusinessIds) {
-            log.Errorf("Error parsing command message '%s' from user '%s': %v", message, userId, err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to parse command message: %s"}`, err),
-            }, err
-        }
-        businessId, err = user.GetBusinessIdFromIndex(businessIdIndex)
+    // --------------------------------
+    // prepare businessId for commands that include businessId index
+    // --------------------------------
+    if command.TakesBusinessIndex {
+        request.BusinessId, err = lineEventProcessor.ParseBusinessIndex(cmd, request.User)
         if err != nil {
-            log.Errorf("Error getting business id from index '%d' for user '%s': %v", businessIdIndex, userId, err)
+            log.Errorf("Error parsing business index of command message '%s' from user '%s': %v", message, userId, err)
             return events.LambdaFunctionURLResponse{
                 StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to get business id from index: %s"}`, err),
+                Body:       fmt.Sprintf(`{"error": "Failed to parse command message: %s"}`, err),
             }, err
         }
     }
 
     // --------------------------------
-    // Handle message commands
+    // validate argument
     // --------------------------------
-    switch cmd.Command[0] {
-    case "h", "Help", "help", "幫助", "協助":
-        err = line.ReplyHelpMessage(event.ReplyToken)
+    if command.Arg != nil && command.Arg.Required && stringUtil.IsEmptyString(cmd.Arg) {
+        log.Infof("Command '%s' from user '%s' is missing required argument '%s'", command.Name, userId, command.Arg.Name)
+
+        err = line.Base.ReplyText(event.ReplyToken, fmt.Sprintf("請輸入%s。指令格式：%s", command.Arg.Name, command.Usage()))
         if err != nil {
+            log.Errorf("Error replying command usage to user '%s': %v", userId, err)
             return events.LambdaFunctionURLResponse{
                 StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to reply help message: %s"}`, err),
+                Body:       fmt.Sprintf(`{"error": "Failed to reply command usage: %s"}`, err),
             }, err
         }
 
-        log.Infof("Successfully processed help request to user '%s'", userId)
         return events.LambdaFunctionURLResponse{
+            StatusCode: 400,
+            Body:       fmt.Sprintf(`{"error": "Command '%s' is missing required argument '%s'"}`, command.Name, command.Arg.Name),
+        }, nil
+    }
+
+    return command.Handler(request)
+}
+
+// authenticate validates user auth, and requests auth if the user has not completed auth.
+// The user is nil if the event should not be processed further, in which case the response and error are to be returned.
+func (p messageProcessor) authenticate(event *linebot.Event, userId string) (*model.User, events.LambdaFunctionURLResponse, error) {
+    p.log.Infof("Event requires auth. Validating user auth for user '%s'", userId)
+
+    hasUserAuthed, userPtr, err := auth.ValidateUserAuthOrRequestAuth(event.ReplyToken, userId, p.userDao, p.line, enum.HandlerNameLineEventsHandler, p.log, p.authRedirectUrl, p.stateSigner)
+    if err != nil {
+        return nil, events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to validate user auth: %s"}`, err),
+        }, err
+    }
+    if userPtr == nil || !hasUserAuthed {
+        return nil, events.LambdaFunctionURLResponse{
             StatusCode: 200,
-            Body:       `{"message": "Successfully processed help request"}`,
+            Body:       `{"message": "User has not authenticated. Requested authentication."}`,
         }, nil
+    }
 
-    case util.UpdateQuickReplyMessageCmd:
-        // validate message does not contain LINE emojis
-        // --------------------------------
-        if HasLineEmoji(lineTextMessage) {
-            err = line.NotifyUserCannotUseLineEmoji(event.ReplyToken)
-            if err != nil {
-                log.Errorf("Error notifying user '%s' that LINE Emoji is not yet supported for quick reply: %v", userId, err)
-                return events.LambdaFunctionURLResponse{
-                    StatusCode: 500,
-                    Body:       fmt.Sprintf(`{"error": "Failed to notify user of LINE Emoji not yet supported: %s"}`, err),
-                }, err
-            }
+    return userPtr, events.LambdaFunctionURLResponse{}, nil
+}
 
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 200,
-                Body:       `{"message": "Notified LINE Emoji not yet supported"}`,
-            }, nil
-        }
+// replyUnknownMessage handles unknown messages from user
+func (p messageProcessor) replyUnknownMessage(event *linebot.Event) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.ReplyUnknownResponseReply(event.ReplyToken)
+    if err != nil {
+        p.log.Error("Error executing ReplyUnknownResponseReply: ", err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error executing ReplyUnknownResponseReply: %s"}`, err),
+        }, err
+    }
 
-        quickReplyMessage := cmd.Arg
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Text message from user is not handled."}`,
+    }, nil
+}
 
-        business, err := handleUpdateQuickReplyMessage(businessId, quickReplyMessage, user.UserId, businessDao, log)
-        if err != nil {
-            log.Errorf("Error updating quick reply message '%s' for user '%s': %v", quickReplyMessage, userId, err)
-            notifyErr := line.NotifyUserUpdateFailed(event.ReplyToken, "快速回覆訊息")
-            if notifyErr != nil {
-                log.Errorf("Failed to notify user of update quick reply message failed: %v", err)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully notified user of update quick reply message failed")
-            }
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to update quick reply message: %s"}`, err),
-            }, err
-        }
+func (p messageProcessor) handleHelp(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.ReplyHelpMessage(request.Event.ReplyToken, p.commandRegistry().HelpText())
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to reply help message: %s"}`, err),
+        }, err
+    }
 
-        // notify all other users of update (skip notifying self)
-        err = line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
-        if err != nil {
-            log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
-        }
+    p.log.Infof("Successfully processed help request to user '%s'", request.UserId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed help request"}`,
+    }, nil
+}
 
-        err = line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, businessDao)
+// handleReauthCommand replies the auth request, so that users disconnected from Google can complete OAuth again
+func (p messageProcessor) handleReauthCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    err := auth.RequestAuth(request.Event.ReplyToken, request.UserId, p.line, p.log, p.authRedirectUrl, p.stateSigner)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to reply auth request: %s"}`, err),
+        }, err
+    }
+
+    p.log.Infof("Successfully replied auth request to user '%s'", request.UserId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully replied auth request"}`,
+    }, nil
+}
+
+// handleManageLocationsCommand replies the locations discovered in the latest OAuth of the user to connect or disconnect
+func (p messageProcessor) handleManageLocationsCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    selection, err := p.connector.GetLocationSelection(userId)
+    if err != nil {
+        var locationNotDiscoveredException *exception.LocationNotDiscoveredException
+        if errors.As(err, &locationNotDiscoveredException) {
+            // users who completed OAuth before locations are selected have no location selection
+            p.log.Infof("User '%s' has no discovered locations. Sending auth request.", userId)
+            err = auth.RequestAuth(event.ReplyToken, userId, p.line, p.log, p.authRedirectUrl, p.stateSigner)
+        }
         if err != nil {
-            log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
+            p.log.Errorf("Error getting location selection of user '%s': %v", userId, err)
             return events.LambdaFunctionURLResponse{
                 StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
+                Body:       fmt.Sprintf(`{"error": "Failed to get location selection: %s"}`, err),
             }, err
         }
-
-        log.Infof("Successfully processed update quick reply message request for user '%s'", userId)
         return events.LambdaFunctionURLResponse{
             StatusCode: 200,
-            Body:       `{"message": "Successfully processed update quick reply message request"}`,
+            Body:       `{"message": "User has no discovered locations. Prompted auth."}`,
         }, nil
+    }
 
-    case util.UpdateBusinessDescriptionMessageCmd:
-        businessDescription := cmd.Arg
-        user, business, err := handleBusinessDescriptionUpdate(businessId, businessDescription, user, userDao, businessDao, log)
+    err = p.line.ReplyLocationSelection(event.ReplyToken, selection.Locations, request.User.BusinessIds)
+    if err != nil {
+        p.log.Errorf("Error replying location selection to user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to reply location selection: %s"}`, err),
+        }, err
+    }
+
+    p.log.Infof("Successfully replied location selection to user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully replied location selection"}`,
+    }, nil
+}
+
+func (p messageProcessor) handleUpdateQuickReplyMessageCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+
+    // validate message does not contain LINE emojis
+    // --------------------------------
+    if HasLineEmoji(request.TextMessage) {
+        err := p.line.NotifyUserCannotUseLineEmoji(event.ReplyToken)
         if err != nil {
-            log.Errorf("Error updating business description '%s' for user '%s': %v", businessDescription, userId, err)
-            notifyErr := line.NotifyUserUpdateFailed(event.ReplyToken, "主要業務")
-            if notifyErr != nil {
-                log.Errorf("Failed to notify user of update business description failed: %v", err)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully notified user of update business description failed")
-            }
+            p.log.Errorf("Error notifying user '%s' that LINE Emoji is not yet supported for quick reply: %v", userId, err)
             return events.LambdaFunctionURLResponse{
                 StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to update business description: %s"}`, err),
+                Body:       fmt.Sprintf(`{"error": "Failed to notify user of LINE Emoji not yet supported: %s"}`, err),
             }, err
         }
 
-        // notify all other users of toggle (skip notifying self)
-        err = line.NotifyAiReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
-        if err != nil {
-            log.Errorf("Error notifying other users of AI reply settings update for user '%s': %v", userId, err)
-        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 200,
+            Body:       `{"message": "Notified LINE Emoji not yet supported"}`,
+        }, nil
+    }
 
-        err = line.ShowAiReplySettings(event.ReplyToken, user, business, businessDao)
-        if err != nil {
-            log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
-
-            notifyErr := line.Base.ReplyText(event.ReplyToken, "主要業務更新成功，但顯示設定失敗，請稍後再試")
-            if notifyErr != nil {
-                errMsg := fmt.Sprintf(`{"error": "Failed to reply user of update business description success: %s"}`, notifyErr)
-                log.Error(errMsg)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully replied user of update business description success but show settings failed")
-            }
+    quickReplyMessage := request.Message.Arg
+
+    // a leading language token updates the quick reply message of reviews in the language
+    language, localizedMessage, isLocalized := cutQuickReplyMessageLanguage(quickReplyMessage)
+    if isLocalized && !languageUtil.IsSupported(language) {
+        p.log.Warnf("Rejected quick reply message update in unsupported language '%s' for user '%s'", language, userId)
+        rejectionMessage := fmt.Sprintf("不支援的語言「%s」，目前支援：%s", language, strings.Join(languageUtil.Languages, "、"))
+        replyUserErr := p.line.Base.ReplyText(event.ReplyToken, rejectionMessage)
+        if replyUserErr != nil {
+            p.log.Errorf("Error replying rejected quick reply message update to user '%s': %v", userId, replyUserErr)
             return events.LambdaFunctionURLResponse{
                 StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings: %s"}`, err),
-            }, err
+                Body:       fmt.Sprintf(`{"error": "Failed to reply rejected quick reply message update: %s"}`, replyUserErr),
+            }, replyUserErr
         }
 
-        log.Infof("Successfully processed update business description request for user '%s'", userId)
         return events.LambdaFunctionURLResponse{
             StatusCode: 200,
-            Body:       `{"message": "Successfully processed update business description request"}`,
+            Body:       `{"message": "Rejected quick reply message update"}`,
         }, nil
+    }
 
-    case "k", util.UpdateKeywordsMessageCmd, "關鍵字":
-        // process update quick reply message request
-        keywords := cmd.Arg
+    var business model.Business
+    var err error
+    if isLocalized {
+        business, err = p.autoReplyRuleDao.UpdateLocalizedQuickReplyMessage(context.Background(), request.BusinessId, language, localizedMessage, user.UserId)
+    } else {
+        business, err = handleUpdateQuickReplyMessage(request.BusinessId, quickReplyMessage, user.UserId, p.businessDao, p.autoReplyRuleDao, p.log)
+    }
+    if err != nil {
+        p.log.Errorf("Error updating quick reply message '%s' for user '%s': %v", quickReplyMessage, userId, err)
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "快速回覆訊息")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update quick reply message failed: %v", err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update quick reply message failed")
+        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update quick reply message: %s"}`, err),
+        }, err
+    }
 
-        updatedBusiness, err := handleUpdateKeywords(businessId, user.UserId, keywords, businessDao, log)
-        if err != nil {
-            log.Errorf("Error updating keywords '%s' for user '%s': %v", keywords, userId, err)
-
-            notifyErr := line.NotifyUserUpdateFailed(event.ReplyToken, "關鍵字")
-            if notifyErr != nil {
-                log.Errorf("Failed to notify user of update keywords failed: %v", notifyErr)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully notified user of update keywords failed")
+    // notify all other users of update (skip notifying self)
+    err = p.line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
+    }
+
+    err = p.line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
+        }, err
+    }
+
+    p.log.Infof("Successfully processed update quick reply message request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update quick reply message request"}`,
+    }, nil
+}
+
+func (p messageProcessor) handleUpdateAutoReplyRuleCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+
+    arg := request.Message.Arg
+    business, err := handleUpdateAutoReplyRule(request.BusinessId, arg, user.UserId, p.businessDao, p.autoReplyRuleDao, p.log)
+    if err != nil {
+        var invalidAutoReplyRuleException *exception.InvalidAutoReplyRuleException
+        var autoQuickReplyConditionNotMetException *exception.AutoQuickReplyConditionNotMetException
+        var rejectionMessage string
+        switch {
+        case errors.As(err, &invalidAutoReplyRuleException):
+            rejectionMessage = fmt.Sprintf("無法辨識自動回覆規則「%s」。\n%s", arg, util.AutoReplyRuleUsageMessage)
+        case errors.As(err, &autoQuickReplyConditionNotMetException):
+            rejectionMessage = "請先填寫快速回覆訊息，才能新增以快速回覆訊息回覆的規則"
+        }
+        if rejectionMessage != "" {
+            p.log.Warnf("Rejected auto reply rule update '%s' for user '%s': %v", arg, userId, err)
+            replyUserErr := p.line.Base.ReplyText(event.ReplyToken, rejectionMessage)
+            if replyUserErr != nil {
+                p.log.Errorf("Error replying rejected auto reply rule update to user '%s': %v", userId, replyUserErr)
+                return events.LambdaFunctionURLResponse{
+                    StatusCode: 500,
+                    Body:       fmt.Sprintf(`{"error": "Failed to reply rejected auto reply rule update: %s"}`, replyUserErr),
+                }, replyUserErr
             }
+
             return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to update keywords: %s"}`, err),
-            }, err
+                StatusCode: 200,
+                Body:       `{"message": "Rejected auto reply rule update"}`,
+            }, nil
         }
 
-        // notify all other users of toggle (skip notifying self)
-        err = line.NotifyAiReplySettingsUpdated(stringUtil.RemoveStringFromSlice(updatedBusiness.UserIds, userId), user.LineUsername, updatedBusiness.BusinessName)
-        if err != nil {
-            log.Errorf("Error notifying other users of AI reply settings update for user '%s': %v", userId, err)
+        p.log.Errorf("Error updating auto reply rules with '%s' for user '%s': %v", arg, userId, err)
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "自動回覆規則")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update auto reply rules failed: %v", notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update auto reply rules failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update auto reply rules: %s"}`, err),
+        }, err
+    }
 
-        err = line.ShowAiReplySettings(event.ReplyToken, user, updatedBusiness, businessDao)
-        if err != nil {
-            log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
-
-            notifyErr := line.Base.ReplyText(event.ReplyToken, "關鍵字更新成功，但顯示設定失敗，請稍後再試")
-            if notifyErr != nil {
-                log.Errorf("Failed to reply user of update keywords success: %v", notifyErr)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully replied user of update keywords success but show settings failed")
-            }
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings : %s"}`, err),
-            }, err
+    // notify all other users of update (skip notifying self)
+    err = p.line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
+    }
+
+    err = p.line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
+        }, err
+    }
+
+    p.log.Infof("Successfully processed update auto reply rule request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update auto reply rule request"}`,
+    }, nil
+}
+
+func (p messageProcessor) handleUpdateAllowedContactsCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    contacts := parseAllowedContacts(request.Message.Arg)
+
+    _, err := p.replyGuardDao.UpdateAllowedContacts(request.BusinessId, contacts, userId)
+    if err != nil {
+        p.log.Errorf("Error updating allowed contacts '%s' for user '%s': %v", contacts, userId, err)
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "允許公開的聯絡方式")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update allowed contacts failed: %v", notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update allowed contacts failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update allowed contacts: %s"}`, err),
+        }, err
+    }
 
-        log.Infof("Successfully processed update keywords request for user '%s'", userId)
+    text := "已清除允許公開的聯絡方式，回覆中的電話及 Email 將無法發布。"
+    if len(contacts) > 0 {
+        text = "已更新允許在回覆中公開的聯絡方式：" + strings.Join(contacts, "、")
+    }
+    err = p.line.Base.ReplyText(event.ReplyToken, text)
+    if err != nil {
+        p.log.Errorf("Error replying allowed contacts updated to user '%s': %v", userId, err)
         return events.LambdaFunctionURLResponse{
-            StatusCode: 200,
-            Body:       `{"message": "Successfully processed update keywords request"}`,
-        }, nil
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to reply allowed contacts updated: %s"}`, err),
+        }, err
+    }
 
-    case "s", util.UpdateSignatureMessageCmd, "簽名":
-        signature := cmd.Arg
+    p.log.Infof("Successfully processed update allowed contacts request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update allowed contacts request"}`,
+    }, nil
+}
 
-        updatedUser, err := handleUpdateSignature(user, signature, userDao, log)
-        if err != nil {
-            log.Errorf("Error updating signature '%s' for user '%s': %v", signature, userId, err)
-            notifyErr := line.NotifyUserUpdateFailed(event.ReplyToken, "簽名")
-            if notifyErr != nil {
-                log.Errorf("Failed to notify user of update signature failed: %v", err)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully notified user of update signature failed")
-            }
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to update signature: %s"}`, err),
-            }, err
+func (p messageProcessor) handleUpdateBusinessDescriptionCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    businessDescription := request.Message.Arg
+    user, business, err := handleBusinessDescriptionUpdate(request.BusinessId, businessDescription, request.User, p.userDao, p.businessDao, p.log)
+    if err != nil {
+        p.log.Errorf("Error updating business description '%s' for user '%s': %v", businessDescription, userId, err)
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "主要業務")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update business description failed: %v", err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update business description failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update business description: %s"}`, err),
+        }, err
+    }
 
-        err = line.ShowAiReplySettingsByUser(event.ReplyToken, updatedUser, businessDao)
-        if err != nil {
-            log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
-
-            notifyErr := line.Base.ReplyText(event.ReplyToken, "簽名更新成功，但顯示設定失敗，請稍後再試")
-            if notifyErr != nil {
-                log.Error("Failed to reply user of update signature success: ", notifyErr)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully replied user of update signature success but show settings failed")
-            }
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings: %s"}`, err),
-            }, err
+    // notify all other users of toggle (skip notifying self)
+    err = p.line.NotifyAiReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of AI reply settings update for user '%s': %v", userId, err)
+    }
+
+    err = p.line.ShowAiReplySettings(event.ReplyToken, user, business, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
+
+        notifyErr := p.line.Base.ReplyText(event.ReplyToken, "主要業務更新成功，但顯示設定失敗，請稍後再試")
+        if notifyErr != nil {
+            errMsg := fmt.Sprintf(`{"error": "Failed to reply user of update business description success: %s"}`, notifyErr)
+            p.log.Error(errMsg)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully replied user of update business description success but show settings failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings: %s"}`, err),
+        }, err
+    }
+
+    p.log.Infof("Successfully processed update business description request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update business description request"}`,
+    }, nil
+}
+
+func (p messageProcessor) handleUpdateKeywordsCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
 
-        log.Infof("Successfully processed update signature request for user '%s'", userId)
+    keywords := request.Message.Arg
+
+    updatedBusiness, err := handleUpdateKeywords(request.BusinessId, user.UserId, keywords, p.businessDao, p.log)
+    if err != nil {
+        p.log.Errorf("Error updating keywords '%s' for user '%s': %v", keywords, userId, err)
+
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "關鍵字")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update keywords failed: %v", notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update keywords failed")
+        }
         return events.LambdaFunctionURLResponse{
-            StatusCode: 200,
-            Body:       `{"message": "Successfully processed update signature request"}`,
-        }, nil
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update keywords: %s"}`, err),
+        }, err
+    }
 
-    case "r", util.UpdateRecommendationMessageCmd, "推薦":
-        serviceRecommendation := cmd.Arg
+    // notify all other users of toggle (skip notifying self)
+    err = p.line.NotifyAiReplySettingsUpdated(stringUtil.RemoveStringFromSlice(updatedBusiness.UserIds, userId), user.LineUsername, updatedBusiness.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of AI reply settings update for user '%s': %v", userId, err)
+    }
 
-        updatedUser, err := handleUpdateServiceRecommendation(user.UserId, serviceRecommendation, userDao)
-        if err != nil {
-            log.Errorf("Error updating service recommendation '%s' for user '%s': %v", serviceRecommendation, userId, err)
-
-            notifyErr := line.NotifyUserUpdateFailed(event.ReplyToken, "推薦業務")
-            if notifyErr != nil {
-                log.Errorf("Failed to notify user of update service recommendation failed: %v", err)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully notified user of update service recommendation failed")
-            }
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to update service recommendation: %s"}`, err),
-            }, err
+    err = p.line.ShowAiReplySettings(event.ReplyToken, user, updatedBusiness, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
+
+        notifyErr := p.line.Base.ReplyText(event.ReplyToken, "關鍵字更新成功，但顯示設定失敗，請稍後再試")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to reply user of update keywords success: %v", notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully replied user of update keywords success but show settings failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings : %s"}`, err),
+        }, err
+    }
 
-        err = line.ShowAiReplySettingsByUser(event.ReplyToken, updatedUser, businessDao)
-        if err != nil {
-            log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
-
-            notifyErr := line.Base.ReplyText(event.ReplyToken, "推薦更新成功，但顯示設定失敗，請稍後再試")
-            if notifyErr != nil {
-                log.Error("Failed to reply user of update service recommendation success: ", notifyErr)
-                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-            } else {
-                log.Info("Successfully replied user of update service recommendation success but show settings failed")
-            }
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings: %s"}`, err),
-            }, err
+    p.log.Infof("Successfully processed update keywords request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update keywords request"}`,
+    }, nil
+}
+
+func (p messageProcessor) handleUpdateSignatureCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    signature := request.Message.Arg
+
+    updatedUser, err := handleUpdateSignature(request.User, signature, p.userDao, p.log)
+    if err != nil {
+        p.log.Errorf("Error updating signature '%s' for user '%s': %v", signature, userId, err)
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "簽名")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update signature failed: %v", err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update signature failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update signature: %s"}`, err),
+        }, err
+    }
 
-        log.Infof("Successfully processed update service recommendation request for user '%s'", userId)
+    err = p.line.ShowAiReplySettingsByUser(event.ReplyToken, updatedUser, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
+
+        notifyErr := p.line.Base.ReplyText(event.ReplyToken, "簽名更新成功，但顯示設定失敗，請稍後再試")
+        if notifyErr != nil {
+            p.log.Error("Failed to reply user of update signature success: ", notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully replied user of update signature success but show settings failed")
+        }
         return events.LambdaFunctionURLResponse{
-            StatusCode: 200,
-            Body:       `{"message": "Successfully processed update service recommendation request"}`,
-        }, nil
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings: %s"}`, err),
+        }, err
+    }
 
-    default:
-        // handle unknown messages from user
-        err = line.ReplyUnknownResponseReply(event.ReplyToken)
-        if err != nil {
-            log.Error("Error executing ReplyUnknownResponseReply: ", err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Error executing ReplyUnknownResponseReply: %s"}`, err),
-            }, err
+    p.log.Infof("Successfully processed update signature request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update signature request"}`,
+    }, nil
+}
+
+func (p messageProcessor) handleUpdateServiceRecommendationCommand(request lineEventProcessor.CommandRequest) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    serviceRecommendation := request.Message.Arg
+
+    updatedUser, err := handleUpdateServiceRecommendation(request.User.UserId, serviceRecommendation, p.userDao)
+    if err != nil {
+        p.log.Errorf("Error updating service recommendation '%s' for user '%s': %v", serviceRecommendation, userId, err)
+
+        notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "推薦業務")
+        if notifyErr != nil {
+            p.log.Errorf("Failed to notify user of update service recommendation failed: %v", err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update service recommendation failed")
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to update service recommendation: %s"}`, err),
+        }, err
+    }
 
+    err = p.line.ShowAiReplySettingsByUser(event.ReplyToken, updatedUser, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error showing AI reply settings for user '%s': %v", userId, err)
+
+        notifyErr := p.line.Base.ReplyText(event.ReplyToken, "推薦更新成功，但顯示設定失敗，請稍後再試")
+        if notifyErr != nil {
+            p.log.Error("Failed to reply user of update service recommendation success: ", notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully replied user of update service recommendation success but show settings failed")
+        }
         return events.LambdaFunctionURLResponse{
-            StatusCode: 200,
-            Body:       `{"message": "Text message from user is not handled."}`,
-        }, nil
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show AI reply settings: %s"}`, err),
+        }, err
     }
+
+    p.log.Infof("Successfully processed update service recommendation request for user '%s'", userId)
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Successfully processed update service recommendation request"}`,
+    }, nil
 }
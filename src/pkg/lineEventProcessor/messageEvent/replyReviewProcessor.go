@@ -1,15 +1,18 @@
 package messageEvent
 
 import (
+    "errors"
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
     "github.com/IntelliLead/CoreCommonUtil/metric"
     "github.com/IntelliLead/CoreCommonUtil/metric/enum"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
     "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
     enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
     "github.com/aws/aws-lambda-go/events"
     "github.com/line/line-bot-sdk-go/v7/linebot"
     "go.uber.org/zap"
@@ -23,6 +26,7 @@ func ProcessReviewReplyMessage(
     businessDao *ddbDao.BusinessDao,
     userDao *ddbDao.UserDao,
     line *lineUtil.LineUtil,
+    publisher replyPublisher.ReplyPublisher,
     log *zap.SugaredLogger) (events.LambdaFunctionURLResponse, error) {
 
     textMessage := event.Message.(*linebot.TextMessage)
@@ -119,7 +123,23 @@ func ProcessReviewReplyMessage(
     // --------------------------------
     // process reply message
     // --------------------------------
-    err = lineEventProcessor.ReplyReview(user.UserId, reply.Message, review, reviewDao, log)
+    err = lineEventProcessor.ReplyReview(user.UserId, reply.Message, false, review, publisher, reviewDao, log)
+    var replyBlockedException exception.ReplyBlockedException
+    if errors.As(err, &replyBlockedException) {
+        notifyUserErr := line.ReplyUserReplyFailedWithReason(event.ReplyToken, review.ReviewerName, replyBlockedException.Reason)
+        if notifyUserErr != nil {
+            log.Errorf("Error notifying user '%s' reply blocked for review '%s': %v", user.UserId, review.ReviewId.String(), notifyUserErr)
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Failed to notify reply blocked for user '%s' : %v"}`, user.UserId, notifyUserErr),
+            }, notifyUserErr
+        }
+
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 200,
+            Body:       `{"message": "Notified reply blocked"}`,
+        }, nil
+    }
     if err != nil {
         log.Errorf("Error handling replying '%s' to review '%s' for user '%s' business '%s': %v", jsonUtil.AnyToJson(reply.Message), review.ReviewId.String(), user.UserId, businessId, err)
 
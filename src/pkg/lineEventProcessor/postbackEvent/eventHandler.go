@@ -1,6 +1,7 @@
 package postbackEvent
 
 import (
+    "context"
     "errors"
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/stringUtil"
@@ -11,9 +12,15 @@ import (
     "github.com/IntelliLead/CoreDataAccess/model/type/bid"
     "github.com/IntelliLead/CoreDataAccess/model/type/rid"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
     "go.uber.org/zap"
+    "time"
 )
 
 // handleAutoQuickReplyToggle handles the auto quick reply toggle postback event
@@ -54,6 +61,49 @@ func handleAutoQuickReplyToggle(
     return updatedBusiness, nil
 }
 
+// handleAutoReplyRuleToggle toggles the auto reply rule at ruleIndex of the business.
+// The legacy rule of a business without stored rules is toggled through autoQuickReplyEnabled.
+func handleAutoReplyRuleToggle(
+    user model.User,
+    businessId bid.BusinessId,
+    ruleIndex int,
+    businessDao *ddbDao.BusinessDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
+    log *zap.SugaredLogger,
+) (model.Business, error) {
+    businessPtr, err := businessDao.GetBusiness(businessId)
+    if err != nil {
+        log.Errorf("Error getting business by businessId '%s' during handling auto reply rule toggle: %s", businessId, err)
+        return model.Business{}, err
+    }
+    if businessPtr == nil {
+        errStr := fmt.Sprintf("Business not found for businessId: %s", businessId)
+        log.Error(errStr)
+        return model.Business{}, errors.New(errStr)
+    }
+    business := *businessPtr
+
+    rules, err := autoReplyRuleDao.GetAutoReplyRules(context.Background(), business)
+    if err != nil {
+        return business, err
+    }
+    if ruleIndex >= len(rules) {
+        return business, fmt.Errorf("rule index %d is out of range of %d auto reply rules of business '%s'", ruleIndex, len(rules), businessId)
+    }
+
+    rule := rules[ruleIndex]
+    if rule.Legacy {
+        return handleAutoQuickReplyToggle(user, businessId, businessDao, log)
+    }
+
+    if !rule.Enabled && rule.RequiresQuickReplyMessage() && stringUtil.IsEmptyStringPtr(business.QuickReplyMessage) {
+        return business, exception.NewAutoQuickReplyConditionNotMetException("Please fill in quick reply message before enabling auto reply rules replying with it")
+    }
+
+    rules[ruleIndex].Enabled = !rule.Enabled
+    return autoReplyRuleDao.UpdateAutoReplyRules(businessId, rules, user.UserId)
+}
+
 func handleGenerateAiReply(
     replyToken string,
     user model.User,
@@ -62,38 +112,20 @@ func handleGenerateAiReply(
     businessDao *ddbDao.BusinessDao,
     userDao *ddbDao.UserDao,
     reviewDao *ddbDao.ReviewDao,
+    aiReplyCandidateSetDao *dao.AiReplyCandidateSetDao,
     line *lineUtil.LineUtil,
     log *zap.SugaredLogger,
-    gptApiKey string,
+    ai *aiUtil.Ai,
 ) error {
     userId := user.UserId
 
     // --------------------
     // Get business and review and perform validation
     // --------------------
-    businessPtr, err := businessDao.GetBusiness(businessId)
-    if err != nil {
-        log.Errorf("Error getting business by businessId '%s' during handling generate AI reply: %s", businessId, err)
-        return err
-    }
-    if businessPtr == nil {
-        errStr := fmt.Sprintf("Business not found for businessId: %s", businessId)
-        log.Error(errStr)
-        return errors.New(errStr)
-    }
-    business := *businessPtr
-
-    reviewPtr, err := reviewDao.GetReview(businessId.String(), reviewId)
+    business, review, err := getBusinessAndReview(businessId, reviewId, businessDao, reviewDao, log)
     if err != nil {
-        log.Errorf("Error getting review by businessId '%s' reviewId '%s' during handling generate AI reply: %s", businessId, reviewId.String(), err)
         return err
     }
-    if reviewPtr == nil {
-        errStr := fmt.Sprintf("Review not found for businessId: %s ; UserReviewId: %s", businessId, reviewId)
-        log.Error(errStr)
-        return errors.New(errStr)
-    }
-    review := *reviewPtr
     if stringUtil.IsEmptyStringPtr(review.Review) {
         errStr := fmt.Sprintf("Review is empty. Cannot generate AI reply. userId: %s ; UserReviewId: %s", userId, reviewId)
         log.Error(errStr)
@@ -112,12 +144,84 @@ func handleGenerateAiReply(
     // --------------------
     // invoke gpt4
     // --------------------
-    aiReply, err := aiUtil.NewAi(log, gptApiKey).GenerateReply(*review.Review, business, user)
+    aiReplies, promptTemplate, err := ai.GenerateReplies(review, business, user)
     if err != nil {
         log.Errorf("Error invoking GPT to generate AI reply: %v", err)
         return err
     }
 
+    return sendAiReplyCandidates(aiReplies, promptTemplate, user, business, review, userDao, aiReplyCandidateSetDao, line, log)
+}
+
+// handleReviseAiReplyCandidate revises the AI reply candidate following the revision and sends the revised candidates to the users of the business.
+// Returns a non-empty rejection to show the user if the candidate has expired.
+func handleReviseAiReplyCandidate(
+    replyToken string,
+    user model.User,
+    businessId bid.BusinessId,
+    candidateSetId string,
+    candidateIndex int,
+    revision enum2.ReplyRevision,
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    reviewDao *ddbDao.ReviewDao,
+    aiReplyCandidateSetDao *dao.AiReplyCandidateSetDao,
+    line *lineUtil.LineUtil,
+    log *zap.SugaredLogger,
+    ai *aiUtil.Ai,
+) (string, error) {
+    candidateSet, previousReply, rejection, err := getAiReplyCandidate(businessId, candidateSetId, candidateIndex, aiReplyCandidateSetDao)
+    if err != nil || rejection != "" {
+        return rejection, err
+    }
+
+    reviewId, err := rid.NewReviewId(candidateSet.ReviewId)
+    if err != nil {
+        return "", err
+    }
+    business, review, err := getBusinessAndReview(businessId, reviewId, businessDao, reviewDao, log)
+    if err != nil {
+        return "", err
+    }
+    if stringUtil.IsEmptyStringPtr(review.Review) {
+        errStr := fmt.Sprintf("Review is empty. Cannot revise AI reply. userId: %s ; UserReviewId: %s", user.UserId, reviewId)
+        log.Error(errStr)
+        return "", errors.New(errStr)
+    }
+
+    err = line.NotifyUserAiReplyGenerationInProgress(replyToken)
+    if err != nil {
+        log.Errorf("Error notifying user '%s' that AI is generating reply: %v", user.UserId, err)
+        return "", err
+    }
+
+    aiReplies, promptTemplate, err := ai.ReviseReply(review, previousReply, revision, business, user)
+    if err != nil {
+        log.Errorf("Error invoking GPT to revise AI reply to be %s: %v", revision, err)
+        return "", err
+    }
+
+    return "", sendAiReplyCandidates(aiReplies, promptTemplate, user, business, review, userDao, aiReplyCandidateSetDao, line, log)
+}
+
+// sendAiReplyCandidates stores the AI reply candidates generated for the user and sends them to the users of the business
+func sendAiReplyCandidates(
+    aiReplies []string,
+    promptTemplate string,
+    user model.User,
+    business model.Business,
+    review model.Review,
+    userDao *ddbDao.UserDao,
+    aiReplyCandidateSetDao *dao.AiReplyCandidateSetDao,
+    line *lineUtil.LineUtil,
+    log *zap.SugaredLogger,
+) error {
+    candidateSet := model2.NewAiReplyCandidateSet(review.BusinessId, review.ReviewId.String(), aiReplies, promptTemplate, user.UserId)
+    err := aiReplyCandidateSetDao.PutAiReplyCandidateSet(context.Background(), candidateSet)
+    if err != nil {
+        return err
+    }
+
     // --------------------
     // create AI generated result card
     // --------------------
@@ -125,15 +229,169 @@ func handleGenerateAiReply(
     if stringUtil.IsEmptyString(generateAuthorName) {
         generateAuthorName = "您的同仁"
     }
-    err = line.SendAiGeneratedReply(aiReply, review, generateAuthorName, business, user, userDao)
+    err = line.SendAiGeneratedReply(candidateSet, review, generateAuthorName, business, user, userDao)
     if err != nil {
-        log.Errorf("Error sending AI generated reply to user '%s': %v", userId, err)
+        log.Errorf("Error sending AI generated reply to user '%s': %v", user.UserId, err)
         return err
     }
 
     return nil
 }
 
+// getAiReplyCandidate gets the candidate set and its reply at candidateIndex.
+// Returns a non-empty rejection to show the user if the candidate set has expired.
+func getAiReplyCandidate(
+    businessId bid.BusinessId,
+    candidateSetId string,
+    candidateIndex int,
+    aiReplyCandidateSetDao *dao.AiReplyCandidateSetDao,
+) (model2.AiReplyCandidateSet, string, string, error) {
+    candidateSetPtr, err := aiReplyCandidateSetDao.GetAiReplyCandidateSet(context.Background(), businessId.String(), candidateSetId)
+    if err != nil {
+        return model2.AiReplyCandidateSet{}, "", "", err
+    }
+    if candidateSetPtr == nil {
+        return model2.AiReplyCandidateSet{}, "", "此 AI 回覆已失效，請重新生成或手動回覆。", nil
+    }
+
+    reply, ok := candidateSetPtr.Reply(candidateIndex)
+    if !ok {
+        return model2.AiReplyCandidateSet{}, "", "", fmt.Errorf("candidate index %d is out of range of %d candidates in AI reply candidate set '%s'", candidateIndex, len(candidateSetPtr.Replies), candidateSetId)
+    }
+    return *candidateSetPtr, reply, "", nil
+}
+
+// getBusinessAndReview gets the business and its review
+func getBusinessAndReview(
+    businessId bid.BusinessId,
+    reviewId rid.ReviewId,
+    businessDao *ddbDao.BusinessDao,
+    reviewDao *ddbDao.ReviewDao,
+    log *zap.SugaredLogger,
+) (model.Business, model.Review, error) {
+    businessPtr, err := businessDao.GetBusiness(businessId)
+    if err != nil {
+        log.Errorf("Error getting business by businessId '%s': %s", businessId, err)
+        return model.Business{}, model.Review{}, err
+    }
+    if businessPtr == nil {
+        errStr := fmt.Sprintf("Business not found for businessId: %s", businessId)
+        log.Error(errStr)
+        return model.Business{}, model.Review{}, errors.New(errStr)
+    }
+
+    reviewPtr, err := reviewDao.GetReview(businessId.String(), reviewId)
+    if err != nil {
+        log.Errorf("Error getting review by businessId '%s' reviewId '%s': %s", businessId, reviewId.String(), err)
+        return model.Business{}, model.Review{}, err
+    }
+    if reviewPtr == nil {
+        errStr := fmt.Sprintf("Review not found for businessId: %s ; UserReviewId: %s", businessId, reviewId)
+        log.Error(errStr)
+        return model.Business{}, model.Review{}, errors.New(errStr)
+    }
+
+    return *businessPtr, *reviewPtr, nil
+}
+
+// handleApproveAiReplyDraft publishes the AI reply draft of the review approved by the user and returns the published reply.
+// Returns a non-empty rejection to show the user if the draft can no longer be approved, e.g., it has expired.
+// The approval is reverted if publishing fails, so that the draft can be approved again.
+func handleApproveAiReplyDraft(
+    user model.User,
+    review model.Review,
+    aiReplyDraftDao *dao.AiReplyDraftDao,
+    reviewDao *ddbDao.ReviewDao,
+    publisher replyPublisher.ReplyPublisher,
+    log *zap.SugaredLogger,
+) (string, string, error) {
+    ctx := context.Background()
+    reviewId := review.ReviewId.String()
+
+    draft, err := aiReplyDraftDao.GetAiReplyDraft(ctx, review.BusinessId, reviewId)
+    if err != nil {
+        return "", "", err
+    }
+    if draft == nil || draft.IsExpired(time.Now()) {
+        return "", "此 AI 回覆草稿已失效，請重新生成或手動回覆。", nil
+    }
+    if draft.Status == enum2.AiReplyDraftStatusApproved {
+        return "", "此 AI 回覆草稿已核准發布。", nil
+    }
+
+    approvedDraft, err := aiReplyDraftDao.ApproveAiReplyDraft(ctx, review.BusinessId, reviewId, user.UserId)
+    if err != nil {
+        var aiReplyDraftNotPendingException *exception.AiReplyDraftNotPendingException
+        if errors.As(err, &aiReplyDraftNotPendingException) {
+            // approved by another user or expired in the meantime
+            return "", "此 AI 回覆草稿已核准發布或已失效。", nil
+        }
+        return "", "", err
+    }
+
+    err = lineEventProcessor.ReplyReview(user.UserId, approvedDraft.Reply, true, review, publisher, reviewDao, log)
+    if err != nil {
+        revertErr := aiReplyDraftDao.RevertAiReplyDraftApproval(ctx, review.BusinessId, reviewId)
+        if revertErr != nil {
+            log.Errorf("Error reverting approval of AI reply draft of review '%s' after publishing failed: %v", reviewId, revertErr)
+        }
+        return "", "", err
+    }
+
+    return approvedDraft.Reply, "", nil
+}
+
+// handleRegenerateAiReplyDraft replaces the AI reply draft of the review with a newly generated reply and sends it to the users of the business.
+// Returns a non-empty rejection to show the user if the draft has been approved.
+func handleRegenerateAiReplyDraft(
+    replyToken string,
+    user model.User,
+    business model.Business,
+    review model.Review,
+    userDao *ddbDao.UserDao,
+    aiReplyDraftDao *dao.AiReplyDraftDao,
+    line *lineUtil.LineUtil,
+    log *zap.SugaredLogger,
+    ai *aiUtil.Ai,
+) (string, error) {
+    ctx := context.Background()
+
+    draft, err := aiReplyDraftDao.GetAiReplyDraft(ctx, review.BusinessId, review.ReviewId.String())
+    if err != nil {
+        return "", err
+    }
+    if draft != nil && draft.Status == enum2.AiReplyDraftStatusApproved {
+        return "此 AI 回覆草稿已核准發布。", nil
+    }
+
+    err = line.NotifyUserAiReplyGenerationInProgress(replyToken)
+    if err != nil {
+        log.Errorf("Error notifying user '%s' that AI is generating reply: %v", user.UserId, err)
+        return "", err
+    }
+
+    // the user asked for another reply, so do not reuse replies to identical reviews
+    aiReply, promptTemplate, err := ai.RegenerateReply(review, business, user)
+    if err != nil {
+        log.Errorf("Error invoking GPT to regenerate AI reply draft: %v", err)
+        return "", err
+    }
+
+    newDraft := model2.NewAiReplyDraft(review.BusinessId, review.ReviewId.String(), aiReply, promptTemplate)
+    err = aiReplyDraftDao.PutAiReplyDraft(ctx, newDraft)
+    if err != nil {
+        return "", err
+    }
+
+    err = line.SendAiReplyDraft(newDraft, review, business, userDao)
+    if err != nil {
+        log.Errorf("Error sending regenerated AI reply draft of review '%s' to users of business '%s': %v", review.ReviewId.String(), business.BusinessId, err)
+        return "", err
+    }
+
+    return "", nil
+}
+
 // handleEmojiToggle handles the emoji toggle postback event
 // replyToken is only used when there is an error
 func handleEmojiToggle(
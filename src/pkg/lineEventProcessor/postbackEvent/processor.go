@@ -11,488 +11,1073 @@ import (
     "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
     enum3 "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
     "github.com/IntelliLead/CoreDataAccess/model"
-    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
     "github.com/IntelliLead/CoreDataAccess/model/type/rid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/auth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor/messageEvent"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor/postbackRouter"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/locationConnector"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/aws/aws-lambda-go/events"
     "github.com/line/line-bot-sdk-go/v7/linebot"
     "go.uber.org/zap"
 )
 
+const (
+    aiReplyToggleEmoji                 = "Emoji"
+    aiReplyToggleSignature             = "Signature"
+    aiReplyToggleKeyword               = "Keyword"
+    aiReplyToggleServiceRecommendation = "ServiceRecommendation"
+
+    featureParam           = "feature"
+    ruleIndexParam         = "ruleIndex"
+    aiAutoReplyModeParam   = "aiAutoReplyMode"
+    replyLanguageModeParam = "replyLanguageMode"
+    candidateSetIdParam    = "candidateSetId"
+    candidateIndexParam    = "candidateIndex"
+    revisionParam          = "revision"
+)
+
+type postbackProcessor struct {
+    businessDao            *ddbDao.BusinessDao
+    userDao                *ddbDao.UserDao
+    reviewDao              *ddbDao.ReviewDao
+    autoReplyRuleDao       *dao.AutoReplyRuleDao
+    aiReplyDraftDao        *dao.AiReplyDraftDao
+    aiReplyCandidateSetDao *dao.AiReplyCandidateSetDao
+    connector              *locationConnector.LocationConnector
+    line                   *lineUtil.LineUtil
+    publisher              replyPublisher.ReplyPublisher
+    log                    *zap.SugaredLogger
+    ai                     *aiUtil.Ai
+}
+
 func ProcessPostbackEvent(
     event *linebot.Event,
     userId string,
     businessDao *ddbDao.BusinessDao,
     userDao *ddbDao.UserDao,
     reviewDao *ddbDao.ReviewDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
+    aiReplyDraftDao *dao.AiReplyDraftDao,
+    aiReplyCandidateSetDao *dao.AiReplyCandidateSetDao,
+    connector *locationConnector.LocationConnector,
     line *lineUtil.LineUtil,
+    publisher replyPublisher.ReplyPublisher,
     log *zap.SugaredLogger,
     authRedirectUrl string,
-    gptApiKey string,
+    stateSigner *oauthState.Signer,
+    ai *aiUtil.Ai,
 ) (events.LambdaFunctionURLResponse, error) {
+    p := postbackProcessor{
+        businessDao:            businessDao,
+        userDao:                userDao,
+        reviewDao:              reviewDao,
+        autoReplyRuleDao:       autoReplyRuleDao,
+        aiReplyDraftDao:        aiReplyDraftDao,
+        aiReplyCandidateSetDao: aiReplyCandidateSetDao,
+        connector:              connector,
+        line:                   line,
+        publisher:              publisher,
+        log:                    log,
+        ai:                     ai,
+    }
+
+    router := postbackRouter.NewRouter(
+        func(replyToken string, userId string) (bool, *model.User, error) {
+            return auth.ValidateUserAuthOrRequestAuth(replyToken, userId, userDao, line, enum.HandlerNameLineEventsHandler, log, authRedirectUrl, stateSigner)
+        },
+        func(event linebot.Event) events.LambdaFunctionURLResponse {
+            return returnUnhandledPostback(log, event)
+        },
+        log,
+    )
+    for _, route := range p.routes() {
+        router.Handle(route)
+    }
+
+    return router.Dispatch(event, userId)
+}
+
+// routes lists all handled postback data. Postback data matching none of the routes is unhandled.
+func (p postbackProcessor) routes() []postbackRouter.Route {
+    return []postbackRouter.Route{
+        // NewReview
+        {
+            Pattern:                   "/NewReview/GenerateAiReply/{businessId}/{reviewId}",
+            Handler:                   p.handleGenerateAiReply,
+            RequiresBusinessOwnership: true,
+        },
+        {
+            Pattern:      "/NewReview/QuickReply",
+            Handler:      p.logOnly("User is editing quick reply message before replying"),
+            RequiresAuth: true,
+        },
+        {
+            Pattern:      "/NewReview/Reply",
+            Handler:      p.logOnly("User is editing hand-written reply message before replying"),
+            RequiresAuth: true,
+        },
+
+        // AiReply
+        {
+            Pattern:                   "/AiReply/GenerateAiReply/{businessId}/{reviewId}",
+            Handler:                   p.handleGenerateAiReply,
+            RequiresBusinessOwnership: true,
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/Toggle/{feature}",
+            Handler:                   p.handleAiReplyToggle,
+            RequiresBusinessOwnership: true,
+            ParamParsers: map[string]postbackRouter.ParamParser{
+                featureParam: postbackRouter.OneOf(aiReplyToggleEmoji, aiReplyToggleSignature, aiReplyToggleKeyword, aiReplyToggleServiceRecommendation),
+            },
+        },
+        {
+            Pattern: "/AiReply/{businessId}/EditBusinessDescription",
+            Handler: p.logOnly("User is editing business description"),
+        },
+        {
+            Pattern: "/AiReply/{businessId}/EditSignature",
+            Handler: p.logOnly("User is editing signature"),
+        },
+        {
+            Pattern: "/AiReply/{businessId}/EditKeywords",
+            Handler: p.logOnly("User is editing keywords"),
+        },
+        {
+            Pattern: "/AiReply/{businessId}/EditServiceRecommendations",
+            Handler: p.logOnly("User is editing service recommendations"),
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/EditReply",
+            Handler:                   p.logOnly("User is editing AI generated reply"),
+            RequiresBusinessOwnership: true,
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/Candidates/{candidateSetId}/{candidateIndex}/Publish",
+            Handler:                   p.handlePublishAiReplyCandidate,
+            RequiresBusinessOwnership: true,
+            ParamParsers: map[string]postbackRouter.ParamParser{
+                candidateIndexParam: postbackRouter.NonNegativeInt,
+            },
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/Candidates/{candidateSetId}/{candidateIndex}/Revise/{revision}",
+            Handler:                   p.handleReviseAiReplyCandidate,
+            RequiresBusinessOwnership: true,
+            ParamParsers: map[string]postbackRouter.ParamParser{
+                candidateIndexParam: postbackRouter.NonNegativeInt,
+                revisionParam: func(raw string) (interface{}, error) {
+                    return enum.ToReplyRevision(raw)
+                },
+            },
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/Draft/{reviewId}/Approve",
+            Handler:                   p.handleApproveAiReplyDraft,
+            RequiresBusinessOwnership: true,
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/Draft/{reviewId}/Regenerate",
+            Handler:                   p.handleRegenerateAiReplyDraft,
+            RequiresBusinessOwnership: true,
+        },
+        {
+            Pattern:                   "/AiReply/{businessId}/UpdateActiveBusiness",
+            Handler:                   p.handleAiReplyUpdateActiveBusiness,
+            RequiresBusinessOwnership: true,
+        },
+
+        // RichMenu
+        {
+            Pattern:      "/RichMenu/QuickReplySettings",
+            Handler:      p.handleRichMenuQuickReplySettings,
+            RequiresAuth: true,
+        },
+        {
+            Pattern:      "/RichMenu/AiReplySettings",
+            Handler:      p.handleRichMenuAiReplySettings,
+            RequiresAuth: true,
+        },
+        {
+            Pattern: "/RichMenu/Help",
+            Handler: p.handleRichMenuHelp,
+        },
+
+        // QuickReply
+        {
+            // sent by quick reply settings cards rendered before auto reply rules
+            Pattern:                   "/QuickReply/{businessId}/Toggle/AutoReply",
+            Handler:                   p.handleQuickReplyAutoReplyToggle,
+            RequiresBusinessOwnership: true,
+        },
+        {
+            Pattern:                   "/QuickReply/{businessId}/Rule/{ruleIndex}/Toggle",
+            Handler:                   p.handleQuickReplyAutoReplyToggle,
+            RequiresBusinessOwnership: true,
+            ParamParsers: map[string]postbackRouter.ParamParser{
+                ruleIndexParam: postbackRouter.NonNegativeInt,
+            },
+        },
+        {
+            Pattern: "/QuickReply/{businessId}/EditAutoReplyRules",
+            Handler: p.logOnly("User is editing auto reply rules"),
+        },
+        {
+            Pattern:                   "/QuickReply/{businessId}/AiAutoReplyMode/{aiAutoReplyMode}",
+            Handler:                   p.handleQuickReplyAiAutoReplyModeUpdate,
+            RequiresBusinessOwnership: true,
+            ParamParsers: map[string]postbackRouter.ParamParser{
+                aiAutoReplyModeParam: func(raw string) (interface{}, error) {
+                    return enum.ToAiAutoReplyMode(raw)
+                },
+            },
+        },
+        {
+            Pattern:                   "/QuickReply/{businessId}/ReplyLanguageMode/{replyLanguageMode}",
+            Handler:                   p.handleQuickReplyReplyLanguageModeUpdate,
+            RequiresBusinessOwnership: true,
+            ParamParsers: map[string]postbackRouter.ParamParser{
+                replyLanguageModeParam: func(raw string) (interface{}, error) {
+                    return enum.ToReplyLanguageMode(raw)
+                },
+            },
+        },
+        {
+            Pattern: "/QuickReply/{businessId}/EditLocalizedQuickReplyMessage",
+            Handler: p.logOnly("User is editing localized quick reply message"),
+        },
+        {
+            Pattern: "/QuickReply/{businessId}/EditQuickReplyMessage",
+            Handler: p.logOnly("User is editing quick reply message"),
+        },
+        {
+            Pattern:                   "/QuickReply/{businessId}/UpdateActiveBusiness",
+            Handler:                   p.handleQuickReplyUpdateActiveBusiness,
+            RequiresBusinessOwnership: true,
+        },
+
+        // Location
+        {
+            // the user is created on connecting the first location, so connecting does not require auth
+            Pattern: "/Location/{businessId}/Connect",
+            Handler: p.handleLocationConnect,
+        },
+        {
+            Pattern: "/Location/{businessId}/Skip",
+            Handler: p.handleLocationSkip,
+        },
+        {
+            Pattern:                   "/Location/{businessId}/Disconnect",
+            Handler:                   p.handleLocationDisconnect,
+            RequiresBusinessOwnership: true,
+        },
+
+        // Notification
+        {
+            Pattern: "/Notification/Replied/Reply",
+            Handler: p.logOnly("User is editing reply message to be resent"),
+        },
+        {
+            Pattern: "/Notification/Changed/Reply",
+            Handler: p.logOnly("User is editing reply message to changed review"),
+        },
+    }
+}
+
+// logOnly handles postback events that require no action, e.g., the user opened a text input prefilled by the postback action
+func (p postbackProcessor) logOnly(description string) postbackRouter.Handler {
+    return func(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+        p.log.Infof("%s postback event received. %s", request.Event.Postback.Data, description)
+        return p.handled(request), nil
+    }
+}
+
+func (p postbackProcessor) handled(request postbackRouter.Request) events.LambdaFunctionURLResponse {
+    p.log.Infof("Successfully handled Postback event from user '%s': %s", request.UserId, jsonUtil.AnyToJson(request.Event.Postback.Data))
+
+    return events.LambdaFunctionURLResponse{Body: `{"message": "Successfully handled Postback event"}`, StatusCode: 200}
+}
+
+// handleGenerateAiReply handles /[NewReview|AiReply]/GenerateAiReply/{BUSINESS_ID}/{REVIEW_ID}
+func (p postbackProcessor) handleGenerateAiReply(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
 
-    dataSlice, err := lineEventProcessor.ParsePostBackData(event.Postback.Data)
+    err := handleGenerateAiReply(event.ReplyToken, request.User, request.Params.BusinessId(), request.Params.ReviewId(), p.businessDao, p.userDao, p.reviewDao, p.aiReplyCandidateSetDao, p.line, p.log, p.ai)
+    var aiQuotaExceededException *exception.AiQuotaExceededException
+    if errors.As(err, &aiQuotaExceededException) {
+        return p.aiQuotaExceeded(request, aiQuotaExceededException)
+    }
     if err != nil {
-        log.Errorf("Error parsing postback data '%s': %s", event.Postback.Data, err)
+        p.log.Errorf("Error handling %s: %s", event.Postback.Data, err)
+
+        notifyErr := p.line.NotifyUserAiReplyGenerationFailed(userId)
+        if notifyErr != nil {
+            p.log.Errorf("Error notifying user '%s' that AI reply generation failed: %v", userId, err)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Infof("Successfully notified user '%s' that AI reply generation failed", userId)
+        }
         return events.LambdaFunctionURLResponse{
             StatusCode: 500,
-            Body:       fmt.Sprintf(`{"error": "Error parsing postback data '%s': %s"}`, event.Postback.Data, err),
+            Body:       fmt.Sprintf(`{"error": "Error handling %s: %s"}`, event.Postback.Data, err),
         }, err
     }
 
-    if len(dataSlice) < 2 {
-        return returnUnhandledPostback(log, *event), nil
+    return p.handled(request), nil
+}
+
+// handlePublishAiReplyCandidate handles /AiReply/{BUSINESS_ID}/Candidates/{CANDIDATE_SET_ID}/{CANDIDATE_INDEX}/Publish
+func (p postbackProcessor) handlePublishAiReplyCandidate(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+    businessId := request.Params.BusinessId()
+
+    candidateSet, reply, rejection, err := getAiReplyCandidate(businessId, request.Params.String(candidateSetIdParam), request.Params.Int(candidateIndexParam), p.aiReplyCandidateSetDao)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error getting AI reply candidate: %s"}`, err),
+        }, err
+    }
+    if rejection != "" {
+        p.log.Warnf("Rejected publishing AI reply candidate %s for user '%s': %s", event.Postback.Data, userId, rejection)
+        return p.replyRejection(request, rejection)
     }
 
-    // user is empty if event does not require auth
-    // WARN: ensure event handlers that require auth are added to shouldAuth() list
-    var user model.User
-    if shouldAuth(dataSlice) {
-        log.Infof("Event requires auth. Validating user '%s' auth...", userId)
+    reviewId, err := rid.NewReviewId(candidateSet.ReviewId)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Invalid review ID of AI reply candidate set: %s"}`, err),
+        }, err
+    }
+    business, review, err := getBusinessAndReview(businessId, reviewId, p.businessDao, p.reviewDao, p.log)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error getting business and review: %s"}`, err),
+        }, err
+    }
 
-        var hasUserCompletedAuth bool
-        var err error
-        hasUserCompletedAuth, userPtr, err := auth.ValidateUserAuthOrRequestAuth(event.ReplyToken, userId, userDao, line, enum.HandlerNameLineEventsHandler, log, authRedirectUrl)
-        if err != nil {
-            log.Errorf("Error validating user '%s' auth: %s", userId, err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf("Error validating user '%s' auth: %s", userId, err),
-            }, err
+    err = lineEventProcessor.ReplyReview(userId, reply, true, review, p.publisher, p.reviewDao, p.log)
+    var replyBlockedException exception.ReplyBlockedException
+    if errors.As(err, &replyBlockedException) {
+        return p.replyBlocked(request, review.ReviewerName, replyBlockedException.Reason)
+    }
+    if err != nil {
+        p.log.Errorf("Error publishing AI reply candidate to review '%s' for user '%s': %v", reviewId.String(), userId, err)
+
+        notifyUserErr := p.line.ReplyUserReplyFailed(event.ReplyToken, review.ReviewerName, false)
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user '%s' reply failed for review '%s': %v", userId, reviewId.String(), notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
         }
-        if !hasUserCompletedAuth {
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 200,
-                Body:       `{"message": "User has not completed auth. Prompted auth."}`,
-            }, nil
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error publishing AI reply candidate: %s"}`, err),
+        }, err
+    }
+
+    err = p.line.NotifyReviewReplied(event.ReplyToken, review, reply, business, user, p.userDao)
+    if err != nil {
+        p.log.Errorf("Error sending review reply notification to users of business '%s' for review '%s': %v", business.BusinessId, reviewId.String(), err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to send review reply notification: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleReviseAiReplyCandidate handles /AiReply/{BUSINESS_ID}/Candidates/{CANDIDATE_SET_ID}/{CANDIDATE_INDEX}/Revise/{REVISION}
+func (p postbackProcessor) handleReviseAiReplyCandidate(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    revisionParamValue, _ := request.Params.Get(revisionParam)
+    revision := revisionParamValue.(enum.ReplyRevision)
+
+    rejection, err := handleReviseAiReplyCandidate(event.ReplyToken, request.User, request.Params.BusinessId(), request.Params.String(candidateSetIdParam), request.Params.Int(candidateIndexParam), revision,
+        p.businessDao, p.userDao, p.reviewDao, p.aiReplyCandidateSetDao, p.line, p.log, p.ai)
+    var aiQuotaExceededException *exception.AiQuotaExceededException
+    if errors.As(err, &aiQuotaExceededException) {
+        return p.aiQuotaExceeded(request, aiQuotaExceededException)
+    }
+    if err != nil {
+        p.log.Errorf("Error handling %s: %s", event.Postback.Data, err)
+
+        notifyErr := p.line.NotifyUserAiReplyGenerationFailed(userId)
+        if notifyErr != nil {
+            p.log.Errorf("Error notifying user '%s' that AI reply generation failed: %v", userId, notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
         }
-        user = *userPtr
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error handling %s: %s"}`, event.Postback.Data, err),
+        }, err
+    }
+    if rejection != "" {
+        p.log.Warnf("Rejected revising AI reply candidate %s for user '%s': %s", event.Postback.Data, userId, rejection)
+        return p.replyRejection(request, rejection)
+    }
+
+    return p.handled(request), nil
+}
+
+// handleApproveAiReplyDraft handles /AiReply/{BUSINESS_ID}/Draft/{REVIEW_ID}/Approve
+func (p postbackProcessor) handleApproveAiReplyDraft(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+
+    business, review, err := getBusinessAndReview(request.Params.BusinessId(), request.Params.ReviewId(), p.businessDao, p.reviewDao, p.log)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error getting business and review: %s"}`, err),
+        }, err
+    }
 
-        log.Debugf("Retrieved user: %s", jsonUtil.AnyToJson(user))
+    reply, rejection, err := handleApproveAiReplyDraft(user, review, p.aiReplyDraftDao, p.reviewDao, p.publisher, p.log)
+    var replyBlockedException exception.ReplyBlockedException
+    if errors.As(err, &replyBlockedException) {
+        return p.replyBlocked(request, review.ReviewerName, replyBlockedException.Reason)
     }
+    if err != nil {
+        p.log.Errorf("Error approving AI reply draft of review '%s' for user '%s': %v", review.ReviewId.String(), userId, err)
 
-    if (dataSlice[0] == "NewReview" || dataSlice[0] == "AiReply") && dataSlice[1] == "GenerateAiReply" {
-        // /[NewReview|AiReply]/GenerateAiReply/{BUSINESS_ID}/{REVIEW_ID}
-        businessId, err := bid.NewBusinessId(dataSlice[2])
-        if err != nil {
-            log.Errorf("Error parsing businessId '%s' during handling generate AI reply: %s", dataSlice[2], err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Error parsing businessId '%s' during handling generate AI reply: %s"}`, dataSlice[2], err),
-            }, err
+        notifyUserErr := p.line.ReplyUserReplyFailed(event.ReplyToken, review.ReviewerName, false)
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user '%s' reply failed for review '%s': %v", userId, review.ReviewId.String(), notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
         }
-        reviewId, err := rid.NewReviewId(dataSlice[3])
-        if err != nil {
-            log.Errorf("Error parsing reviewId '%s' during handling generate AI reply: %s", dataSlice[2], err)
-            return events.LambdaFunctionURLResponse{
-                StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Error parsing reviewId '%s' during handling generate AI reply: %s"}`, dataSlice[2], err),
-            }, err
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error approving AI reply draft: %s"}`, err),
+        }, err
+    }
+    if rejection != "" {
+        p.log.Warnf("Rejected approving AI reply draft of review '%s' for user '%s': %s", review.ReviewId.String(), userId, rejection)
+        return p.replyRejection(request, rejection)
+    }
+
+    err = p.line.NotifyReviewReplied(event.ReplyToken, review, reply, business, user, p.userDao)
+    if err != nil {
+        p.log.Errorf("Error sending review reply notification to users of business '%s' for review '%s': %v", business.BusinessId, review.ReviewId.String(), err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to send review reply notification: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleRegenerateAiReplyDraft handles /AiReply/{BUSINESS_ID}/Draft/{REVIEW_ID}/Regenerate
+func (p postbackProcessor) handleRegenerateAiReplyDraft(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+
+    business, review, err := getBusinessAndReview(request.Params.BusinessId(), request.Params.ReviewId(), p.businessDao, p.reviewDao, p.log)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error getting business and review: %s"}`, err),
+        }, err
+    }
+
+    rejection, err := handleRegenerateAiReplyDraft(event.ReplyToken, request.User, business, review, p.userDao, p.aiReplyDraftDao, p.line, p.log, p.ai)
+    var aiQuotaExceededException *exception.AiQuotaExceededException
+    if errors.As(err, &aiQuotaExceededException) {
+        return p.aiQuotaExceeded(request, aiQuotaExceededException)
+    }
+    if err != nil {
+        p.log.Errorf("Error handling %s: %s", event.Postback.Data, err)
+
+        notifyErr := p.line.NotifyUserAiReplyGenerationFailed(userId)
+        if notifyErr != nil {
+            p.log.Errorf("Error notifying user '%s' that AI reply generation failed: %v", userId, notifyErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
         }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error handling %s: %s"}`, event.Postback.Data, err),
+        }, err
+    }
+    if rejection != "" {
+        p.log.Warnf("Rejected regenerating AI reply draft of review '%s' for user '%s': %s", review.ReviewId.String(), userId, rejection)
+        return p.replyRejection(request, rejection)
+    }
+
+    return p.handled(request), nil
+}
+
+// replyRejection replies the reason the postback action cannot be performed to the user
+func (p postbackProcessor) replyRejection(request postbackRouter.Request, rejection string) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.Base.ReplyText(request.Event.ReplyToken, rejection)
+    if err != nil {
+        p.log.Errorf("Error replying rejection '%s' to user '%s': %v", rejection, request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error replying rejection: %s"}`, err),
+        }, err
+    }
+
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Rejected Postback event"}`,
+    }, nil
+}
+
+// replyBlocked replies to the user that the reply to the review is blocked by the reply guard with the reason
+func (p postbackProcessor) replyBlocked(request postbackRouter.Request, reviewerName string, reason string) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.ReplyUserReplyFailedWithReason(request.Event.ReplyToken, reviewerName, reason)
+    if err != nil {
+        p.log.Errorf("Error replying reply blocked '%s' to user '%s': %v", reason, request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error replying reply blocked: %s"}`, err),
+        }, err
+    }
+
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Notified reply blocked"}`,
+    }, nil
+}
+
+// aiQuotaExceeded notifies the user that the business has used up its monthly AI quota.
+// The reply token has been used to tell the user AI is generating, so the notification is pushed.
+func (p postbackProcessor) aiQuotaExceeded(request postbackRouter.Request, e *exception.AiQuotaExceededException) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.NotifyUserAiQuotaExceeded(request.UserId, e.PlanTier, e.QuotaUsd)
+    if err != nil {
+        p.log.Errorf("Error notifying user '%s' that AI quota is exceeded: %v", request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error notifying AI quota exceeded: %s"}`, err),
+        }, err
+    }
+
+    return events.LambdaFunctionURLResponse{
+        StatusCode: 200,
+        Body:       `{"message": "Notified AI quota exceeded"}`,
+    }, nil
+}
+
+// handleAiReplyToggle handles /AiReply/{BUSINESS_ID}/Toggle/{FEATURE}
+func (p postbackProcessor) handleAiReplyToggle(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+    businessId := request.Params.BusinessId()
+
+    // Any toggle will require displaying the AI reply settings, which requires retrieving and/or updating business. Therefore, we will retrieve business here.
+    businessPtr, err := p.businessDao.GetBusiness(businessId)
+    if err != nil {
+        p.log.Errorf("Error getting business by businessId '%s'", businessId)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error getting business by businessId '%s'"}`, businessId),
+        }, err
+    }
+    if businessPtr == nil {
+        errStr := fmt.Sprintf("Business not found for businessId: %s", businessId)
+        p.log.Error(errStr)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Business not found for businessId: %s"}`, businessId),
+        }, errors.New(errStr)
+    }
+    business := *businessPtr
 
-        err = handleGenerateAiReply(event.ReplyToken, user, businessId, reviewId, businessDao, userDao, reviewDao, line, log, gptApiKey)
+    switch request.Params.String(featureParam) {
+    case aiReplyToggleEmoji:
+        user, err = handleEmojiToggle(user, p.userDao, p.log)
         if err != nil {
-            log.Errorf("Error handling /%s/GenerateAiReply: %s", dataSlice[0], err)
+            p.log.Errorf("Error handling emoji toggle: %s", err)
 
-            notifyErr := line.NotifyUserAiReplyGenerationFailed(userId)
+            notifyErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "Emoji AI 回覆")
             if notifyErr != nil {
-                log.Errorf("Error notifying user '%s' that AI reply generation failed: %v", userId, err)
+                p.log.Errorf("Error notifying user '%s' of update emoji enabled failed: %v", userId, err)
                 metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
             } else {
-                log.Infof("Successfully notified user '%s' that AI reply generation failed", userId)
+                p.log.Info("Successfully notified user of update emoji enabled failed")
             }
             return events.LambdaFunctionURLResponse{
                 StatusCode: 500,
-                Body:       fmt.Sprintf(`{"error": "Error handling /%s/GenerateAiReply: %s"}`, dataSlice[0], err),
-            }, err
+                Body:       fmt.Sprintf(`{"error": "Error handling emoji toggle: %s"}`, err),
+            }, nil
         }
-    } else {
-        switch dataSlice[0] {
-        case "NewReview":
-            switch dataSlice[1] {
-            case "QuickReply":
-                log.Info("/NewReview/QuickReply postback event received. User is editing quick reply message before replying")
-            case "Reply":
-                log.Info("/NewReview/Reply postback event received. User is editing hand-written reply message before replying")
-            default:
-                return returnUnhandledPostback(log, *event), nil
-            }
-
-        case "AiReply":
-            switch {
-            case bid.IsValidBusinessId(dataSlice[1]):
-                businessId := bid.BusinessId(dataSlice[1])
-                // user not retrieved if event does not require auth
-                if shouldAuth(dataSlice) && !stringUtil.StringInSlice(businessId.String(), bid.BusinessIdsToStringSlice(user.BusinessIds)) {
-                    log.Errorf("Business ID '%s' does not belong to user '%s'", businessId, userId)
-                    return events.LambdaFunctionURLResponse{
-                        StatusCode: 500,
-                        Body:       fmt.Sprintf(`{"error": "Business ID '%s' does not belong to user '%s'"}`, businessId, userId),
-                    }, errors.New("business ID does not belong to user")
-                }
 
-                switch dataSlice[2] {
-                case "Toggle":
-                    // Any toggle will require displaying the AI reply settings, which requires retrieving and/or updating business. Therefore, we will retrieve business here.
-                    businessPtr, err := businessDao.GetBusiness(businessId)
-                    if err != nil {
-                        log.Errorf("Error getting business by businessId '%s'", businessId)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error getting business by businessId '%s'"}`, businessId),
-                        }, err
-                    }
-                    if businessPtr == nil {
-                        errStr := fmt.Sprintf("Business not found for businessId: %s", businessId)
-                        log.Error(errStr)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Business not found for businessId: %s"}`, businessId),
-                        }, errors.New(errStr)
-                    }
-                    business := *businessPtr
-
-                    switch dataSlice[3] {
-                    case "Emoji":
-                        user, err = handleEmojiToggle(user, userDao, log)
-                        if err != nil {
-                            log.Errorf("Error handling emoji toggle: %s", err)
-
-                            notifyErr := line.NotifyUserUpdateFailed(event.ReplyToken, "Emoji AI 回覆")
-                            if notifyErr != nil {
-                                log.Errorf("Error notifying user '%s' of update emoji enabled failed: %v", userId, err)
-                                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-                            } else {
-                                log.Info("Successfully notified user of update emoji enabled failed")
-                            }
-                            return events.LambdaFunctionURLResponse{
-                                StatusCode: 500,
-                                Body:       fmt.Sprintf(`{"error": "Error handling emoji toggle: %s"}`, err),
-                            }, nil
-                        }
-
-                    case "Signature":
-                        user, err = handleSignatureToggle(user, userDao, log)
-
-                        if err != nil {
-                            var signatureDoesNotExistException *exception.SignatureDoesNotExistException
-                            if errors.As(err, &signatureDoesNotExistException) {
-                                err = line.Base.ReplyText(event.ReplyToken, "請先填寫簽名，才能開啟簽名功能")
-                                if err != nil {
-                                    log.Errorf("Error replying signature settings prompt message to user '%s': %v", userId, err)
-                                    return events.LambdaFunctionURLResponse{
-                                        StatusCode: 200,
-                                        Body:       fmt.Sprintf(`{"error": "Error replying signature settings prompt message: %s"}`, err),
-                                    }, err
-                                }
-                            }
-
-                            log.Errorf("Error handling signature toggle: %s", err)
-                            return events.LambdaFunctionURLResponse{
-                                StatusCode: 500,
-                                Body:       fmt.Sprintf(`{"error": "Error handling signature toggle: %s"}`, err),
-                            }, err
-                        }
-
-                    case "Keyword":
-                        business, err = handleKeywordToggle(user, business, businessDao)
-                        if err != nil {
-                            log.Errorf("Error handling keyword toggle: %s", err)
-
-                            var keywordConditionNotMetException *exception.KeywordConditionNotMetException
-                            if errors.As(err, &keywordConditionNotMetException) {
-                                err = line.Base.ReplyText(event.ReplyToken, "請先填寫主要業務及關鍵字，才能開啟關鍵字回覆功能")
-                                if err != nil {
-                                    log.Errorf("Error replying keyword settings prompt message to user '%s': %v", userId, err)
-                                    return events.LambdaFunctionURLResponse{
-                                        StatusCode: 500,
-                                        Body:       fmt.Sprintf(`{"error": "Error replying keyword settings prompt message: %s"}`, err),
-                                    }, err
-                                }
-                            }
-
-                            return events.LambdaFunctionURLResponse{
-                                StatusCode: 500,
-                                Body:       fmt.Sprintf(`{"error": "Error handling keyword toggle: %s"}`, err),
-                            }, err
-                        }
-
-                        // notify all other users of toggle (skip notifying self)
-                        err = line.NotifyAiReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
-                        if err != nil {
-                            log.Errorf("Error notifying other users of AI reply settings update for user '%s': %v", userId, err)
-                        }
-
-                    case "ServiceRecommendation":
-                        user, err = handleServiceRecommendationToggle(user, business.BusinessDescription, userDao, log)
-                        if err != nil {
-                            var serviceRecommendationConditionNotMetException *exception.ServiceRecommendationConditionNotMetException
-                            if errors.As(err, &serviceRecommendationConditionNotMetException) {
-                                err = line.Base.ReplyText(event.ReplyToken, "請先填寫推薦業務或主要業務欄位，才能開啟推薦其他業務功能")
-                                if err != nil {
-                                    log.Errorf("Error replying service recommendation settings prompt message to user '%s': %v", userId, err)
-                                    return events.LambdaFunctionURLResponse{
-                                        StatusCode: 500,
-                                        Body:       fmt.Sprintf(`{"error": "Error replying service recommendation settings prompt message: %s"}`, err),
-                                    }, err
-                                }
-
-                                return events.LambdaFunctionURLResponse{
-                                    StatusCode: 200,
-                                    Body:       fmt.Sprintf(`{"Rejected enabling service recommendation feature": "Please fill in service recommendation before enabling service recommendation"}`),
-                                }, nil
-                            }
-
-                            log.Errorf("Error handling service recommendation toggle: %s", err)
-                            return events.LambdaFunctionURLResponse{
-                                StatusCode: 500,
-                                Body:       fmt.Sprintf(`{"error": "Error handling keyword toggle: %s"}`, err),
-                            }, err
-                        }
-                    }
-
-                    err = line.ShowAiReplySettings(event.ReplyToken, user, business, businessDao)
-                    if err != nil {
-                        log.Errorf("Error sending AI reply settings to user '%s': %v", userId, err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
-                        }, err
-                    }
-
-                case "EditBusinessDescription":
-                    log.Info("/AiReply/EditBusinessDescription postback event received. User is editing business description for ", businessId)
-
-                case "EditSignature":
-                    log.Info("/AiReply/EditSignature postback event received. User is editing signature")
-
-                case "EditKeywords":
-                    log.Info("/AiReply/EditKeywords postback event received. User is editing keywords")
-
-                case "EditServiceRecommendations":
-                    log.Info("/AiReply/EditServiceRecommendations postback event received. User is editing service recommendations for ", businessId)
-
-                case "EditReply":
-                    log.Info("/AiReply/EditReply postback event received. User is editing AI generated reply for ", businessId)
-
-                case "UpdateActiveBusiness":
-                    // /AiReply/{BUSINESS_ID}/UpdateActiveBusiness
-                    // update active business ID for user
-                    action, err := dbModel.NewAttributeAction(enum3.ActionUpdate, "activeBusinessId", businessId.String())
-                    if err != nil {
-                        log.Errorf("Error creating attribute action: %s", err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error creating attribute action: %s"}`, err),
-                        }, err
-                    }
-
-                    user, err = userDao.UpdateAttributes(userId, []dbModel.AttributeAction{action})
-                    if err != nil {
-                        log.Errorf("Error updating user '%s' active business ID to '%s': %s", userId, businessId, err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error updating user active business ID: %s"}`, err),
-                        }, err
-                    }
-
-                    err = line.ShowAiReplySettingsByUser(event.ReplyToken, user, businessDao)
-                    if err != nil {
-                        log.Errorf("Error sending AI reply settings to user '%s': %v", userId, err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
-                        }, err
-                    }
-
-                default:
-                    return returnUnhandledPostback(log, *event), nil
-                }
-            default:
-                return returnUnhandledPostback(log, *event), nil
-            }
+    case aiReplyToggleSignature:
+        user, err = handleSignatureToggle(user, p.userDao, p.log)
 
-        case "RichMenu":
-            switch dataSlice[1] {
-            case "QuickReplySettings":
-                err = line.ShowQuickReplySettings(
-                    event.ReplyToken, user, businessDao)
+        if err != nil {
+            var signatureDoesNotExistException *exception.SignatureDoesNotExistException
+            if errors.As(err, &signatureDoesNotExistException) {
+                err = p.line.Base.ReplyText(event.ReplyToken, "請先填寫簽名，才能開啟簽名功能")
                 if err != nil {
-                    log.Errorf("Error sending quick reply settings to user '%s': %v", userId, err)
+                    p.log.Errorf("Error replying signature settings prompt message to user '%s': %v", userId, err)
                     return events.LambdaFunctionURLResponse{
-                        StatusCode: 500,
-                        Body:       fmt.Sprintf(`{"error": "Error sending quick reply settings: %s"}`, err),
+                        StatusCode: 200,
+                        Body:       fmt.Sprintf(`{"error": "Error replying signature settings prompt message: %s"}`, err),
                     }, err
                 }
+            }
+
+            p.log.Errorf("Error handling signature toggle: %s", err)
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Error handling signature toggle: %s"}`, err),
+            }, err
+        }
+
+    case aiReplyToggleKeyword:
+        business, err = handleKeywordToggle(user, business, p.businessDao)
+        if err != nil {
+            p.log.Errorf("Error handling keyword toggle: %s", err)
 
-            case "AiReplySettings":
-                err = line.ShowAiReplySettingsByUser(event.ReplyToken, user, businessDao)
+            var keywordConditionNotMetException *exception.KeywordConditionNotMetException
+            if errors.As(err, &keywordConditionNotMetException) {
+                err = p.line.Base.ReplyText(event.ReplyToken, "請先填寫主要業務及關鍵字，才能開啟關鍵字回覆功能")
                 if err != nil {
-                    log.Errorf("Error sending AI reply settings to user '%s': %v", userId, err)
+                    p.log.Errorf("Error replying keyword settings prompt message to user '%s': %v", userId, err)
                     return events.LambdaFunctionURLResponse{
                         StatusCode: 500,
-                        Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
+                        Body:       fmt.Sprintf(`{"error": "Error replying keyword settings prompt message: %s"}`, err),
                     }, err
                 }
+            }
+
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Error handling keyword toggle: %s"}`, err),
+            }, err
+        }
 
-            case "Help":
-                err = line.ReplyHelpMessage(event.ReplyToken)
+        // notify all other users of toggle (skip notifying self)
+        err = p.line.NotifyAiReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+        if err != nil {
+            p.log.Errorf("Error notifying other users of AI reply settings update for user '%s': %v", userId, err)
+        }
+
+    case aiReplyToggleServiceRecommendation:
+        user, err = handleServiceRecommendationToggle(user, business.BusinessDescription, p.userDao, p.log)
+        if err != nil {
+            var serviceRecommendationConditionNotMetException *exception.ServiceRecommendationConditionNotMetException
+            if errors.As(err, &serviceRecommendationConditionNotMetException) {
+                err = p.line.Base.ReplyText(event.ReplyToken, "請先填寫推薦業務或主要業務欄位，才能開啟推薦其他業務功能")
                 if err != nil {
-                    log.Errorf("Error replying help message to user '%s': %v", userId, err)
+                    p.log.Errorf("Error replying service recommendation settings prompt message to user '%s': %v", userId, err)
                     return events.LambdaFunctionURLResponse{
                         StatusCode: 500,
-                        Body:       fmt.Sprintf(`{"error": "Error replying help message: %s"}`, err),
+                        Body:       fmt.Sprintf(`{"error": "Error replying service recommendation settings prompt message: %s"}`, err),
                     }, err
                 }
 
-            default:
-                return returnUnhandledPostback(log, *event), nil
+                return events.LambdaFunctionURLResponse{
+                    StatusCode: 200,
+                    Body:       fmt.Sprintf(`{"Rejected enabling service recommendation feature": "Please fill in service recommendation before enabling service recommendation"}`),
+                }, nil
             }
 
-        case "QuickReply":
-            switch {
-            case bid.IsValidBusinessId(dataSlice[1]):
-                businessId := bid.BusinessId(dataSlice[1])
+            p.log.Errorf("Error handling service recommendation toggle: %s", err)
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Error handling keyword toggle: %s"}`, err),
+            }, err
+        }
+    }
 
-                // validate businessId belongs to user
-                if shouldAuth(dataSlice) && !stringUtil.StringInSlice(businessId.String(), bid.BusinessIdsToStringSlice(user.BusinessIds)) {
-                    log.Errorf("Business ID '%s' does not belong to user '%s'", businessId, userId)
-                    log.Debugf("User's Business IDs: %s", jsonUtil.AnyToJson(user.BusinessIds))
+    err = p.line.ShowAiReplySettings(event.ReplyToken, user, business, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error sending AI reply settings to user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
+        }, err
+    }
 
-                    return events.LambdaFunctionURLResponse{
-                        StatusCode: 500,
-                        Body:       fmt.Sprintf(`{"error": "Business ID '%s' does not belong to user '%s'"}`, businessId, userId),
-                    }, errors.New("business ID does not belong to user")
-                }
+    return p.handled(request), nil
+}
 
-                switch dataSlice[2] {
-                case "Toggle":
-                    switch dataSlice[3] {
-                    case "AutoReply":
-                        business, err := handleAutoQuickReplyToggle(user, businessId, businessDao, log)
-                        if err != nil {
-                            var autoQuickReplyConditionNotMetException *exception.AutoQuickReplyConditionNotMetException
-                            if errors.As(err, &autoQuickReplyConditionNotMetException) {
-                                log.Warnf("Auto reply condition not met for user '%s': %v", userId, err)
-                                replyUserErr := line.Base.ReplyText(event.ReplyToken, "請先填寫快速回覆訊息，才能開啟自動回覆功能")
-                                if replyUserErr != nil {
-                                    log.Errorf("Error replying cannot enable auto quick reply prompt to user '%s': %v", userId, replyUserErr)
-
-                                    return events.LambdaFunctionURLResponse{
-                                        StatusCode: 500,
-                                        Body:       fmt.Sprintf(`{"error": "Error replying cannot enable auto quick reply prompt: %s"}`, replyUserErr),
-                                    }, replyUserErr
-                                }
-                                log.Warnf("Notified user '%s' to fill in quick reply message before enabling auto quick reply", userId)
-
-                                return events.LambdaFunctionURLResponse{
-                                    StatusCode: 200,
-                                    Body:       fmt.Sprintf(`{"Rejected enabling auto quick reply feature": "Please fill in quick reply message before enabling auto quick reply"}`),
-                                }, nil
-                            }
-
-                            log.Errorf("Error handling auto quick reply toggle for user '%s': %v", userId, err)
-                            notifyUserErr := line.NotifyUserUpdateFailed(event.ReplyToken, "自動回覆")
-                            if notifyUserErr != nil {
-                                log.Errorf("Error notifying user of updating auto quick reply enabled failed for user '%s': %v", userId, notifyUserErr)
-                                metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
-                            } else {
-                                log.Info("Successfully notified user of update auto quick reply enabled failed")
-                            }
-                            return events.LambdaFunctionURLResponse{
-                                StatusCode: 500,
-                                Body:       fmt.Sprintf(`{"error": "Error handling auto quick reply toggle: %s"}`, notifyUserErr),
-                            }, err
-                        }
-
-                        // notify all other users of toggle (skip notifying self)
-                        err = line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
-                        if err != nil {
-                            log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
-                        }
-
-                        err = line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, businessDao)
-                        if err != nil {
-                            log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
-                            return events.LambdaFunctionURLResponse{
-                                StatusCode: 500,
-                                Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
-                            }, err
-                        }
-                    default:
-                        return returnUnhandledPostback(log, *event), nil
-                    }
-
-                case "EditQuickReplyMessage":
-                    // /QuickReply/{BUSINESS_ID}/EditQuickReplyMessage
-                    log.Info("/QuickReply/EditQuickReplyMessage postback event received. User is editing quick reply message.")
-
-                case "UpdateActiveBusiness":
-                    // /QuickReply/{BUSINESS_ID}/UpdateActiveBusiness
-                    // update active business ID for user
-                    action, err := dbModel.NewAttributeAction(enum3.ActionUpdate, "activeBusinessId", businessId.String())
-                    if err != nil {
-                        log.Errorf("Error creating attribute action: %s", err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error creating attribute action: %s"}`, err),
-                        }, err
-                    }
-
-                    user, err = userDao.UpdateAttributes(userId, []dbModel.AttributeAction{action})
-                    if err != nil {
-                        log.Errorf("Error updating user '%s' active business ID to '%s': %s", userId, businessId, err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error updating user active business ID: %s"}`, err),
-                        }, err
-                    }
-
-                    err = line.ShowQuickReplySettings(event.ReplyToken, user, businessDao)
-                    if err != nil {
-                        log.Errorf("Error sending AI reply settings to user '%s': %v", userId, err)
-                        return events.LambdaFunctionURLResponse{
-                            StatusCode: 500,
-                            Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
-                        }, err
-                    }
-
-                default:
-                    return returnUnhandledPostback(log, *event), nil
-                }
+// handleAiReplyUpdateActiveBusiness handles /AiReply/{BUSINESS_ID}/UpdateActiveBusiness
+func (p postbackProcessor) handleAiReplyUpdateActiveBusiness(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    user, response, err := p.updateActiveBusiness(request)
+    if err != nil {
+        return response, err
+    }
 
-            default:
-                return returnUnhandledPostback(log, *event), nil
-            }
+    err = p.line.ShowAiReplySettingsByUser(request.Event.ReplyToken, user, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error sending AI reply settings to user '%s': %v", request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
+        }, err
+    }
 
-        case "Notification":
-            switch dataSlice[1] {
+    return p.handled(request), nil
+}
 
-            case "Replied":
-                switch dataSlice[2] {
-                case "Reply":
-                    // /Notification/Replied/Reply
-                    log.Info("/Notification/Replied/Reply postback event received. User is editing reply message to be resent.")
-                default:
-                    return returnUnhandledPostback(log, *event), nil
-                }
+// handleQuickReplyUpdateActiveBusiness handles /QuickReply/{BUSINESS_ID}/UpdateActiveBusiness
+func (p postbackProcessor) handleQuickReplyUpdateActiveBusiness(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    user, response, err := p.updateActiveBusiness(request)
+    if err != nil {
+        return response, err
+    }
+
+    err = p.line.ShowQuickReplySettings(request.Event.ReplyToken, user, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error sending quick reply settings to user '%s': %v", request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error sending quick reply settings: %s"}`, err),
+        }, err
+    }
 
-            default:
-                return returnUnhandledPostback(log, *event), nil
+    return p.handled(request), nil
+}
+
+// updateActiveBusiness updates the active business ID of the user to the {BUSINESS_ID} parameter
+func (p postbackProcessor) updateActiveBusiness(request postbackRouter.Request) (model.User, events.LambdaFunctionURLResponse, error) {
+    userId := request.UserId
+    businessId := request.Params.BusinessId()
+
+    action, err := dbModel.NewAttributeAction(enum3.ActionUpdate, "activeBusinessId", businessId.String())
+    if err != nil {
+        p.log.Errorf("Error creating attribute action: %s", err)
+        return model.User{}, events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error creating attribute action: %s"}`, err),
+        }, err
+    }
+
+    user, err := p.userDao.UpdateAttributes(userId, []dbModel.AttributeAction{action})
+    if err != nil {
+        p.log.Errorf("Error updating user '%s' active business ID to '%s': %s", userId, businessId, err)
+        return model.User{}, events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error updating user active business ID: %s"}`, err),
+        }, err
+    }
+
+    return user, events.LambdaFunctionURLResponse{}, nil
+}
+
+// handleRichMenuQuickReplySettings handles /RichMenu/QuickReplySettings
+func (p postbackProcessor) handleRichMenuQuickReplySettings(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.ShowQuickReplySettings(request.Event.ReplyToken, request.User, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error sending quick reply settings to user '%s': %v", request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error sending quick reply settings: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleRichMenuAiReplySettings handles /RichMenu/AiReplySettings
+func (p postbackProcessor) handleRichMenuAiReplySettings(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.ShowAiReplySettingsByUser(request.Event.ReplyToken, request.User, p.businessDao)
+    if err != nil {
+        p.log.Errorf("Error sending AI reply settings to user '%s': %v", request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error sending AI reply settings: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleRichMenuHelp handles /RichMenu/Help
+func (p postbackProcessor) handleRichMenuHelp(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    err := p.line.ReplyHelpMessage(request.Event.ReplyToken, messageEvent.CommandHelpText())
+    if err != nil {
+        p.log.Errorf("Error replying help message to user '%s': %v", request.UserId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error replying help message: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleQuickReplyAutoReplyToggle handles /QuickReply/{BUSINESS_ID}/Rule/{RULE_INDEX}/Toggle and /QuickReply/{BUSINESS_ID}/Toggle/AutoReply, which toggles the first rule
+func (p postbackProcessor) handleQuickReplyAutoReplyToggle(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+
+    business, err := handleAutoReplyRuleToggle(user, request.Params.BusinessId(), request.Params.Int(ruleIndexParam), p.businessDao, p.autoReplyRuleDao, p.log)
+    if err != nil {
+        var autoQuickReplyConditionNotMetException *exception.AutoQuickReplyConditionNotMetException
+        if errors.As(err, &autoQuickReplyConditionNotMetException) {
+            p.log.Warnf("Auto reply condition not met for user '%s': %v", userId, err)
+            replyUserErr := p.line.Base.ReplyText(event.ReplyToken, "請先填寫快速回覆訊息，才能開啟自動回覆功能")
+            if replyUserErr != nil {
+                p.log.Errorf("Error replying cannot enable auto quick reply prompt to user '%s': %v", userId, replyUserErr)
+
+                return events.LambdaFunctionURLResponse{
+                    StatusCode: 500,
+                    Body:       fmt.Sprintf(`{"error": "Error replying cannot enable auto quick reply prompt: %s"}`, replyUserErr),
+                }, replyUserErr
             }
+            p.log.Warnf("Notified user '%s' to fill in quick reply message before enabling auto quick reply", userId)
 
-        default:
-            return returnUnhandledPostback(log, *event), nil
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 200,
+                Body:       fmt.Sprintf(`{"Rejected enabling auto quick reply feature": "Please fill in quick reply message before enabling auto quick reply"}`),
+            }, nil
         }
+
+        p.log.Errorf("Error handling auto quick reply toggle for user '%s': %v", userId, err)
+        notifyUserErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "自動回覆")
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user of updating auto quick reply enabled failed for user '%s': %v", userId, notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        } else {
+            p.log.Info("Successfully notified user of update auto quick reply enabled failed")
+        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error handling auto quick reply toggle: %s"}`, err),
+        }, err
+    }
+
+    // notify all other users of toggle (skip notifying self)
+    err = p.line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
     }
 
-    log.Infof("Successfully handled Postback event from user '%s': %s", userId, jsonUtil.AnyToJson(event.Postback.Data))
+    err = p.line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
+        }, err
+    }
 
-    return events.LambdaFunctionURLResponse{Body: `{"message": "Successfully handled Postback event"}`, StatusCode: 200}, nil
+    return p.handled(request), nil
+}
+
+// handleQuickReplyAiAutoReplyModeUpdate handles /QuickReply/{BUSINESS_ID}/AiAutoReplyMode/{AI_AUTO_REPLY_MODE}
+func (p postbackProcessor) handleQuickReplyAiAutoReplyModeUpdate(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+
+    modeParam, _ := request.Params.Get(aiAutoReplyModeParam)
+    mode := modeParam.(enum.AiAutoReplyMode)
+
+    business, err := p.autoReplyRuleDao.UpdateAiAutoReplyMode(request.Params.BusinessId(), mode, userId)
+    if err != nil {
+        p.log.Errorf("Error updating AI auto reply mode to %s for user '%s': %v", mode, userId, err)
+        notifyUserErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "AI 自動回覆")
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user of updating AI auto reply mode failed for user '%s': %v", userId, notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error updating AI auto reply mode: %s"}`, err),
+        }, err
+    }
+
+    // notify all other users of update (skip notifying self)
+    err = p.line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
+    }
+
+    err = p.line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleQuickReplyReplyLanguageModeUpdate handles /QuickReply/{BUSINESS_ID}/ReplyLanguageMode/{REPLY_LANGUAGE_MODE}
+func (p postbackProcessor) handleQuickReplyReplyLanguageModeUpdate(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    user := request.User
+
+    modeParam, _ := request.Params.Get(replyLanguageModeParam)
+    mode := modeParam.(enum.ReplyLanguageMode)
+
+    business, err := p.autoReplyRuleDao.UpdateReplyLanguageMode(request.Params.BusinessId(), mode, userId)
+    if err != nil {
+        p.log.Errorf("Error updating reply language mode to %s for user '%s': %v", mode, userId, err)
+        notifyUserErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "回覆語言")
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user of updating reply language mode failed for user '%s': %v", userId, notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error updating reply language mode: %s"}`, err),
+        }, err
+    }
+
+    // notify all other users of update (skip notifying self)
+    err = p.line.NotifyQuickReplySettingsUpdated(stringUtil.RemoveStringFromSlice(business.UserIds, userId), user.LineUsername, business.BusinessName)
+    if err != nil {
+        p.log.Errorf("Error notifying other users of quick reply settings update for user '%s': %v", userId, err)
+    }
+
+    err = p.line.ShowQuickReplySettingsWithActiveBusiness(event.ReplyToken, user, business, p.businessDao, p.autoReplyRuleDao)
+    if err != nil {
+        p.log.Errorf("Error showing quick reply settings for user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to show quick reply settings: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleLocationConnect handles /Location/{BUSINESS_ID}/Connect
+func (p postbackProcessor) handleLocationConnect(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    businessId := request.Params.BusinessId()
+
+    _, business, err := p.connector.Connect(userId, businessId)
+    if err != nil {
+        var locationNotDiscoveredException *exception.LocationNotDiscoveredException
+        if errors.As(err, &locationNotDiscoveredException) {
+            p.log.Warnf("Location of business '%s' cannot be connected by user '%s': %v", businessId, userId, err)
+            return p.replyRejection(request, "找不到此商家。請重新授權 Google 後再選擇要連結的商家。")
+        }
+
+        p.log.Errorf("Error connecting business '%s' to user '%s': %v", businessId, userId, err)
+        notifyUserErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "商家連結")
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user '%s' of connecting business failed: %v", userId, notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error connecting business: %s"}`, err),
+        }, err
+    }
+
+    err = p.line.Base.ReplyText(event.ReplyToken, fmt.Sprintf("已連結「%s」，可以開始使用啦！", business.BusinessName))
+    if err != nil {
+        p.log.Errorf("Error replying business connected to user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error replying business connected: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleLocationSkip handles /Location/{BUSINESS_ID}/Skip. Skipped locations stay in the location selection to be connected later.
+func (p postbackProcessor) handleLocationSkip(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    userId := request.UserId
+    businessId := request.Params.BusinessId()
+
+    selection, err := p.connector.GetLocationSelection(userId)
+    if err != nil {
+        var locationNotDiscoveredException *exception.LocationNotDiscoveredException
+        if errors.As(err, &locationNotDiscoveredException) {
+            return p.replyRejection(request, "找不到此商家。請重新授權 Google 後再選擇要連結的商家。")
+        }
+        p.log.Errorf("Error getting location selection of user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error getting location selection: %s"}`, err),
+        }, err
+    }
+    location, ok := selection.FindLocation(businessId)
+    if !ok {
+        return p.replyRejection(request, "找不到此商家。請重新授權 Google 後再選擇要連結的商家。")
+    }
+
+    p.log.Infof("User '%s' skipped connecting business '%s'", userId, businessId)
+    err = p.line.Base.ReplyText(request.Event.ReplyToken, fmt.Sprintf("已略過「%s」。之後可輸入「/%s」連結。", location.Title, util.ManageLocationsCmd))
+    if err != nil {
+        p.log.Errorf("Error replying business skipped to user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error replying business skipped: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
+}
+
+// handleLocationDisconnect handles /Location/{BUSINESS_ID}/Disconnect
+func (p postbackProcessor) handleLocationDisconnect(request postbackRouter.Request) (events.LambdaFunctionURLResponse, error) {
+    event := request.Event
+    userId := request.UserId
+    businessId := request.Params.BusinessId()
+
+    _, err := p.connector.Disconnect(request.User, businessId)
+    if err != nil {
+        var lastLocationDisconnectionException *exception.LastLocationDisconnectionException
+        if errors.As(err, &lastLocationDisconnectionException) {
+            p.log.Warnf("User '%s' cannot disconnect the only business '%s'", userId, businessId)
+            return p.replyRejection(request, "這是您唯一連結的商家，無法取消連結。")
+        }
+
+        p.log.Errorf("Error disconnecting business '%s' from user '%s': %v", businessId, userId, err)
+        notifyUserErr := p.line.NotifyUserUpdateFailed(event.ReplyToken, "商家連結")
+        if notifyUserErr != nil {
+            p.log.Errorf("Error notifying user '%s' of disconnecting business failed: %v", userId, notifyUserErr)
+            metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1)
+        }
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error disconnecting business: %s"}`, err),
+        }, err
+    }
+
+    businessName := businessId.String()
+    businessPtr, err := p.businessDao.GetBusiness(businessId)
+    if err != nil {
+        p.log.Warnf("Error getting business '%s' for its name. Proceeding: %v", businessId, err)
+    } else if businessPtr != nil {
+        businessName = businessPtr.BusinessName
+    }
+
+    err = p.line.Base.ReplyText(event.ReplyToken, fmt.Sprintf("已取消連結「%s」，您將不再收到此商家的通知。", businessName))
+    if err != nil {
+        p.log.Errorf("Error replying business disconnected to user '%s': %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error replying business disconnected: %s"}`, err),
+        }, err
+    }
+
+    return p.handled(request), nil
 }
 
 func returnUnhandledPostback(log *zap.SugaredLogger, event linebot.Event) events.LambdaFunctionURLResponse {
@@ -502,13 +1087,3 @@ func returnUnhandledPostback(log *zap.SugaredLogger, event linebot.Event) events
         Body:       `{"message": "Postback event data is not in expected format. No action taken."}`,
     }
 }
-
-func shouldAuth(postbackEvent []string) bool {
-    return !(postbackEvent[0] == "RichMenu" && postbackEvent[1] == "Help") &&
-        !(postbackEvent[0] == "QuickReply" && postbackEvent[2] == "EditQuickReplyMessage") &&
-        !(postbackEvent[0] == "AiReply" && postbackEvent[2] == "EditBusinessDescription") &&
-        !(postbackEvent[0] == "AiReply" && postbackEvent[2] == "EditSignature") &&
-        !(postbackEvent[0] == "AiReply" && postbackEvent[2] == "EditKeywords") &&
-        !(postbackEvent[0] == "AiReply" && postbackEvent[2] == "EditServiceRecommendations") &&
-        !(postbackEvent[0] == "Notification" && postbackEvent[1] == "Replied" && postbackEvent[2] == "Reply")
-}
@@ -0,0 +1,81 @@
+package postbackRouter
+
+import (
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/CoreDataAccess/model/type/rid"
+    "strconv"
+)
+
+const (
+    BusinessIdParam = "businessId"
+    ReviewIdParam   = "reviewId"
+)
+
+// ParamParser parses and validates the raw value of a route parameter
+type ParamParser func(raw string) (interface{}, error)
+
+// DefaultParamParsers are applied to parameters of the same name in every route
+var DefaultParamParsers = map[string]ParamParser{
+    BusinessIdParam: func(raw string) (interface{}, error) {
+        return bid.NewBusinessId(raw)
+    },
+    ReviewIdParam: func(raw string) (interface{}, error) {
+        return rid.NewReviewId(raw)
+    },
+}
+
+// OneOf only accepts the given values
+func OneOf(values ...string) ParamParser {
+    return func(raw string) (interface{}, error) {
+        if !stringUtil.StringInSlice(raw, values) {
+            return nil, fmt.Errorf("'%s' is not one of %v", raw, values)
+        }
+        return raw, nil
+    }
+}
+
+// NonNegativeInt only accepts non-negative integers
+func NonNegativeInt(raw string) (interface{}, error) {
+    value, err := strconv.Atoi(raw)
+    if err != nil || value < 0 {
+        return nil, fmt.Errorf("'%s' is not a non-negative integer", raw)
+    }
+    return value, nil
+}
+
+// Params holds the parsed parameters of a matched route
+type Params struct {
+    values map[string]interface{}
+}
+
+// BusinessId returns the parsed {businessId} parameter. Empty if the route has no such parameter.
+func (p Params) BusinessId() bid.BusinessId {
+    businessId, _ := p.values[BusinessIdParam].(bid.BusinessId)
+    return businessId
+}
+
+// ReviewId returns the parsed {reviewId} parameter. Empty if the route has no such parameter.
+func (p Params) ReviewId() rid.ReviewId {
+    reviewId, _ := p.values[ReviewIdParam].(rid.ReviewId)
+    return reviewId
+}
+
+// String returns the parameter as string. Empty if the route has no such parameter or the parameter is not a string.
+func (p Params) String(name string) string {
+    value, _ := p.values[name].(string)
+    return value
+}
+
+// Int returns the parameter as int. Zero if the route has no such parameter or the parameter is not an int.
+func (p Params) Int(name string) int {
+    value, _ := p.values[name].(int)
+    return value
+}
+
+// Get returns the parsed parameter
+func (p Params) Get(name string) (interface{}, bool) {
+    value, ok := p.values[name]
+    return value, ok
+}
@@ -0,0 +1,228 @@
+package postbackRouter
+
+import (
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/line/line-bot-sdk-go/v7/linebot"
+    "go.uber.org/zap"
+    "strings"
+)
+
+// Handler handles a postback event matched to a route
+type Handler func(request Request) (events.LambdaFunctionURLResponse, error)
+
+// Authenticator validates whether the user has completed auth.
+// If the user has not, the authenticator is expected to prompt auth and return false.
+type Authenticator func(replyToken string, userId string) (bool, *model.User, error)
+
+// UnhandledHandler handles postback events that do not match any route
+type UnhandledHandler func(event linebot.Event) events.LambdaFunctionURLResponse
+
+// Route registers a postback data path pattern to its handler.
+// Pattern segments wrapped in braces are parameters, e.g., "/AiReply/{businessId}/Toggle/{feature}"
+type Route struct {
+    Pattern string
+    Handler Handler
+    // RequiresAuth retrieves the user and requests auth if the user has not completed auth.
+    // Request.User is empty if the route does not require auth.
+    RequiresAuth bool
+    // RequiresBusinessOwnership rejects the request if the {businessId} parameter does not belong to the user.
+    // Implies RequiresAuth.
+    RequiresBusinessOwnership bool
+    // ParamParsers parse and validate parameters in addition to DefaultParamParsers.
+    // A postback whose parameter fails to parse does not match the route.
+    ParamParsers map[string]ParamParser
+}
+
+// Request is a postback event matched to a route
+type Request struct {
+    Event  *linebot.Event
+    UserId string
+    User   model.User
+    Params Params
+}
+
+// Router dispatches postback events to the first registered route matching the postback data
+type Router struct {
+    routes       []compiledRoute
+    authenticate Authenticator
+    unhandled    UnhandledHandler
+    log          *zap.SugaredLogger
+}
+
+func NewRouter(authenticate Authenticator, unhandled UnhandledHandler, logger *zap.SugaredLogger) *Router {
+    return &Router{
+        authenticate: authenticate,
+        unhandled:    unhandled,
+        log:          logger,
+    }
+}
+
+// Handle registers a route. Panics on malformed patterns, as routes are registered at start up.
+func (r *Router) Handle(route Route) *Router {
+    compiled, err := compileRoute(route)
+    if err != nil {
+        panic(err)
+    }
+    r.routes = append(r.routes, compiled)
+    return r
+}
+
+// Dispatch routes the postback event to its handler.
+// Postback events that do not match any route are passed to the unhandled handler.
+func (r *Router) Dispatch(event *linebot.Event, userId string) (events.LambdaFunctionURLResponse, error) {
+    dataSlice, err := lineEventProcessor.ParsePostBackData(event.Postback.Data)
+    if err != nil {
+        r.log.Errorf("Error parsing postback data '%s': %s", event.Postback.Data, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Error parsing postback data '%s': %s"}`, event.Postback.Data, err),
+        }, err
+    }
+
+    route, params, ok := r.match(dataSlice)
+    if !ok {
+        return r.unhandled(*event), nil
+    }
+    r.log.Debugf("Postback data '%s' matched route '%s'", event.Postback.Data, route.Pattern)
+
+    request := Request{
+        Event:  event,
+        UserId: userId,
+        Params: params,
+    }
+
+    if route.RequiresAuth || route.RequiresBusinessOwnership {
+        r.log.Infof("Event requires auth. Validating user '%s' auth...", userId)
+
+        hasUserCompletedAuth, userPtr, err := r.authenticate(event.ReplyToken, userId)
+        if err != nil {
+            r.log.Errorf("Error validating user '%s' auth: %s", userId, err)
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Error validating user '%s' auth: %s"}`, userId, err),
+            }, err
+        }
+        if !hasUserCompletedAuth || userPtr == nil {
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 200,
+                Body:       `{"message": "User has not completed auth. Prompted auth."}`,
+            }, nil
+        }
+        request.User = *userPtr
+
+        r.log.Debugf("Retrieved user: %s", jsonUtil.AnyToJson(request.User))
+    }
+
+    if route.RequiresBusinessOwnership {
+        businessId := params.BusinessId()
+        if !stringUtil.StringInSlice(businessId.String(), bid.BusinessIdsToStringSlice(request.User.BusinessIds)) {
+            r.log.Errorf("Business ID '%s' does not belong to user '%s'", businessId, userId)
+            r.log.Debugf("User's Business IDs: %s", jsonUtil.AnyToJson(request.User.BusinessIds))
+
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Business ID '%s' does not belong to user '%s'"}`, businessId, userId),
+            }, errors.New("business ID does not belong to user")
+        }
+    }
+
+    return route.Handler(request)
+}
+
+func (r *Router) match(dataSlice []string) (compiledRoute, Params, bool) {
+    for _, route := range r.routes {
+        params, ok := route.match(dataSlice)
+        if ok {
+            return route, params, true
+        }
+    }
+    return compiledRoute{}, Params{}, false
+}
+
+type segment struct {
+    value   string
+    isParam bool
+}
+
+type compiledRoute struct {
+    Route
+    segments []segment
+}
+
+func compileRoute(route Route) (compiledRoute, error) {
+    if route.Handler == nil {
+        return compiledRoute{}, fmt.Errorf("route '%s' has no handler", route.Pattern)
+    }
+
+    dataSlice, err := lineEventProcessor.ParsePostBackData(route.Pattern)
+    if err != nil || !strings.HasPrefix(route.Pattern, "/") {
+        return compiledRoute{}, fmt.Errorf("route pattern '%s' must begin with '/'", route.Pattern)
+    }
+
+    hasBusinessIdParam := false
+    segments := make([]segment, len(dataSlice))
+    for i, s := range dataSlice {
+        if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+            name := s[1 : len(s)-1]
+            if name == "" {
+                return compiledRoute{}, fmt.Errorf("route pattern '%s' has an unnamed parameter", route.Pattern)
+            }
+            if name == BusinessIdParam {
+                hasBusinessIdParam = true
+            }
+            segments[i] = segment{value: name, isParam: true}
+        } else {
+            segments[i] = segment{value: s}
+        }
+    }
+
+    if route.RequiresBusinessOwnership && !hasBusinessIdParam {
+        return compiledRoute{}, fmt.Errorf("route '%s' requires business ownership but has no {%s} parameter", route.Pattern, BusinessIdParam)
+    }
+
+    return compiledRoute{Route: route, segments: segments}, nil
+}
+
+func (c compiledRoute) match(dataSlice []string) (Params, bool) {
+    if len(dataSlice) != len(c.segments) {
+        return Params{}, false
+    }
+
+    params := Params{values: map[string]interface{}{}}
+    for i, s := range c.segments {
+        if !s.isParam {
+            if s.value != dataSlice[i] {
+                return Params{}, false
+            }
+            continue
+        }
+
+        value, err := c.parseParam(s.value, dataSlice[i])
+        if err != nil {
+            return Params{}, false
+        }
+        params.values[s.value] = value
+    }
+
+    return params, true
+}
+
+func (c compiledRoute) parseParam(name string, raw string) (interface{}, error) {
+    if parser, ok := c.ParamParsers[name]; ok {
+        return parser(raw)
+    }
+    if parser, ok := DefaultParamParsers[name]; ok {
+        return parser(raw)
+    }
+    if raw == "" {
+        return nil, fmt.Errorf("parameter '%s' is empty", name)
+    }
+    return raw, nil
+}
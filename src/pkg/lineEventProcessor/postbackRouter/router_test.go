@@ -0,0 +1,219 @@
+package postbackRouter
+
+import (
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
+    "github.com/aws/aws-lambda-go/events"
+    "reflect"
+    "testing"
+)
+
+func noopHandler(Request) (events.LambdaFunctionURLResponse, error) {
+    return events.LambdaFunctionURLResponse{}, nil
+}
+
+func TestCompileRoute(t *testing.T) {
+    tests := []struct {
+        name    string
+        route   Route
+        wantErr bool
+    }{
+        {
+            name:  "static pattern",
+            route: Route{Pattern: "/RichMenu/Help", Handler: noopHandler},
+        },
+        {
+            name:  "pattern with parameters",
+            route: Route{Pattern: "/AiReply/{businessId}/Toggle/{feature}", Handler: noopHandler},
+        },
+        {
+            name:    "no handler",
+            route:   Route{Pattern: "/RichMenu/Help"},
+            wantErr: true,
+        },
+        {
+            name:    "pattern without leading slash",
+            route:   Route{Pattern: "RichMenu/Help", Handler: noopHandler},
+            wantErr: true,
+        },
+        {
+            name:    "unnamed parameter",
+            route:   Route{Pattern: "/AiReply/{}/Toggle", Handler: noopHandler},
+            wantErr: true,
+        },
+        {
+            name:    "business ownership without businessId parameter",
+            route:   Route{Pattern: "/RichMenu/QuickReplySettings", Handler: noopHandler, RequiresBusinessOwnership: true},
+            wantErr: true,
+        },
+        {
+            name:  "business ownership with businessId parameter",
+            route: Route{Pattern: "/QuickReply/{businessId}/Toggle/AutoReply", Handler: noopHandler, RequiresBusinessOwnership: true},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := compileRoute(tt.route)
+            if (err != nil) != tt.wantErr {
+                t.Errorf("compileRoute() error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestCompiledRouteMatch(t *testing.T) {
+    tests := []struct {
+        name       string
+        route      Route
+        data       string
+        wantMatch  bool
+        wantParams map[string]interface{}
+    }{
+        {
+            name:       "static pattern matches",
+            route:      Route{Pattern: "/RichMenu/Help"},
+            data:       "/RichMenu/Help",
+            wantMatch:  true,
+            wantParams: map[string]interface{}{},
+        },
+        {
+            name:      "static segment mismatch",
+            route:     Route{Pattern: "/RichMenu/Help"},
+            data:      "/RichMenu/AiReplySettings",
+            wantMatch: false,
+        },
+        {
+            name:      "fewer segments",
+            route:     Route{Pattern: "/AiReply/{candidateSetId}/Publish"},
+            data:      "/AiReply/set-1",
+            wantMatch: false,
+        },
+        {
+            name:      "more segments",
+            route:     Route{Pattern: "/AiReply/{candidateSetId}"},
+            data:      "/AiReply/set-1/Publish",
+            wantMatch: false,
+        },
+        {
+            name:       "parameter without parser is kept raw",
+            route:      Route{Pattern: "/AiReply/{candidateSetId}/Publish"},
+            data:       "/AiReply/set-1/Publish",
+            wantMatch:  true,
+            wantParams: map[string]interface{}{"candidateSetId": "set-1"},
+        },
+        {
+            name:      "empty parameter without parser",
+            route:     Route{Pattern: "/AiReply/{candidateSetId}/Publish"},
+            data:      "/AiReply//Publish",
+            wantMatch: false,
+        },
+        {
+            name: "parameter accepted by parser",
+            route: Route{
+                Pattern:      "/AiReply/Toggle/{feature}",
+                ParamParsers: map[string]ParamParser{"feature": OneOf("Emoji", "Signature")},
+            },
+            data:       "/AiReply/Toggle/Signature",
+            wantMatch:  true,
+            wantParams: map[string]interface{}{"feature": "Signature"},
+        },
+        {
+            name: "parameter rejected by parser",
+            route: Route{
+                Pattern:      "/AiReply/Toggle/{feature}",
+                ParamParsers: map[string]ParamParser{"feature": OneOf("Emoji", "Signature")},
+            },
+            data:      "/AiReply/Toggle/Keyword",
+            wantMatch: false,
+        },
+        {
+            name: "parameter parsed to int",
+            route: Route{
+                Pattern:      "/AiReply/Candidates/{candidateIndex}/Publish",
+                ParamParsers: map[string]ParamParser{"candidateIndex": NonNegativeInt},
+            },
+            data:       "/AiReply/Candidates/2/Publish",
+            wantMatch:  true,
+            wantParams: map[string]interface{}{"candidateIndex": 2},
+        },
+        {
+            name: "negative int rejected",
+            route: Route{
+                Pattern:      "/AiReply/Candidates/{candidateIndex}/Publish",
+                ParamParsers: map[string]ParamParser{"candidateIndex": NonNegativeInt},
+            },
+            data:      "/AiReply/Candidates/-1/Publish",
+            wantMatch: false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.route.Handler = noopHandler
+            compiled, err := compileRoute(tt.route)
+            if err != nil {
+                t.Fatalf("compileRoute() error = %v", err)
+            }
+
+            params, ok := compiled.match(splitData(t, tt.data))
+            if ok != tt.wantMatch {
+                t.Fatalf("match() ok = %v, want %v", ok, tt.wantMatch)
+            }
+            if ok && !reflect.DeepEqual(params.values, tt.wantParams) {
+                t.Errorf("match() params = %v, want %v", params.values, tt.wantParams)
+            }
+        })
+    }
+}
+
+func TestRouterMatchesFirstRegisteredRoute(t *testing.T) {
+    router := NewRouter(nil, nil, nil).
+        Handle(Route{Pattern: "/QuickReply/{ruleIndex}/Toggle", Handler: noopHandler, ParamParsers: map[string]ParamParser{"ruleIndex": NonNegativeInt}}).
+        Handle(Route{Pattern: "/QuickReply/{setting}/Toggle", Handler: noopHandler})
+
+    tests := []struct {
+        name        string
+        data        string
+        wantMatch   bool
+        wantPattern string
+    }{
+        {
+            name:        "matches both routes",
+            data:        "/QuickReply/3/Toggle",
+            wantMatch:   true,
+            wantPattern: "/QuickReply/{ruleIndex}/Toggle",
+        },
+        {
+            name:        "rejected by the parser of the first route",
+            data:        "/QuickReply/AutoReply/Toggle",
+            wantMatch:   true,
+            wantPattern: "/QuickReply/{setting}/Toggle",
+        },
+        {
+            name:      "matches no route",
+            data:      "/QuickReply/3/Delete",
+            wantMatch: false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            route, _, ok := router.match(splitData(t, tt.data))
+            if ok != tt.wantMatch {
+                t.Fatalf("match() ok = %v, want %v", ok, tt.wantMatch)
+            }
+            if ok && route.Pattern != tt.wantPattern {
+                t.Errorf("match() pattern = %s, want %s", route.Pattern, tt.wantPattern)
+            }
+        })
+    }
+}
+
+func splitData(t *testing.T, data string) []string {
+    t.Helper()
+    dataSlice, err := lineEventProcessor.ParsePostBackData(data)
+    if err != nil {
+        t.Fatalf("invalid postback data '%s': %v", data, err)
+    }
+    return dataSlice
+}
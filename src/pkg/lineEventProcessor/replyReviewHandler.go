@@ -1,45 +1,52 @@
 package lineEventProcessor
 
 import (
-    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "context"
+    "errors"
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
     "github.com/IntelliLead/CoreDataAccess/model"
-    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
-    "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil"
-    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
     "go.uber.org/zap"
     "time"
 )
 
+// ReplyReview publishes the reply to the review and records the reply in DDB.
+// The review is only recorded as replied after the reply is successfully published.
+// Returns exception.ReplyBlockedException if the reply guard blocks the reply.
 func ReplyReview(
     repliedByUserId string,
     replyMessage string,
+    aiGenerated bool,
     review model.Review,
+    publisher replyPublisher.ReplyPublisher,
     reviewDao *ddbDao.ReviewDao,
     log *zap.SugaredLogger) error {
-    if review.ZapierReplyWebhook == util.TestZapierReplyWebhook {
-        log.Infof("Skipping reply event to Zapier for review %s from user '%s' of business '%s' because it is a test webhook", replyMessage, repliedByUserId, review.BusinessId)
-    } else {
-        // post reply to zapier
-        // --------------------
-        zapier := zapierUtil.NewZapier(log)
-        zapierEvent := model2.ReplyToZapierEvent{
-            VendorReviewId: review.VendorReviewId,
-            Message:        replyMessage,
-        }
-
-        err := zapier.SendReplyEvent(review.ZapierReplyWebhook, zapierEvent)
-        if err != nil {
-            log.Errorf("Error sending reply event to Zapier for review %s from user '%s' of business '%s': %v", replyMessage, repliedByUserId, review.BusinessId, err)
+    backend, err := publisher.Publish(context.Background(), replyPublisher.PublishRequest{
+        RepliedByUserId: repliedByUserId,
+        Message:         replyMessage,
+        Review:          review,
+        AiGenerated:     aiGenerated,
+    })
+    if err != nil {
+        var replyBlockedException exception.ReplyBlockedException
+        if errors.As(err, &replyBlockedException) {
+            log.Warnf("Reply %s from user '%s' to review '%s' of business '%s' is blocked: %s", replyMessage, repliedByUserId, review.ReviewId.String(), review.BusinessId, replyBlockedException.Reason)
             return err
         }
 
-        log.Infof("Sent reply event '%s' to Zapier from user '%s' of business '%s'", jsonUtil.AnyToJson(zapierEvent), repliedByUserId, review.BusinessId)
+        log.Errorf("Error publishing reply %s through %s from user '%s' of business '%s': %v", replyMessage, backend, repliedByUserId, review.BusinessId, err)
+
+        if IsTransientReplyError(err) {
+            log.Warnf("Publishing reply to review '%s' failed transiently. Review is not recorded as replied so that the reply can be retried", review.ReviewId.String())
+        }
+        return err
     }
+    log.Infof("Published reply to review '%s' through %s from user '%s' of business '%s'", review.ReviewId.String(), backend, repliedByUserId, review.BusinessId)
 
     // update DDB
     // --------------------
-    err := reviewDao.UpdateReview(ddbDao.UpdateReviewInput{
+    err = reviewDao.UpdateReview(ddbDao.UpdateReviewInput{
         BusinessId:  review.BusinessId,
         ReviewId:    review.ReviewId,
         LastUpdated: time.Now(),
@@ -54,3 +61,13 @@ func ReplyReview(
 
     return nil
 }
+
+// IsTransientReplyError returns true if publishing the reply failed transiently, e.g., Zapier is down or Google is rate limiting, so that retrying may publish it
+func IsTransientReplyError(err error) bool {
+    var zapierRequestException exception.ZapierRequestException
+    if errors.As(err, &zapierRequestException) {
+        return zapierRequestException.Transient
+    }
+    var googleRequestException exception.GoogleRequestException
+    return errors.As(err, &googleRequestException) && googleRequestException.Transient
+}
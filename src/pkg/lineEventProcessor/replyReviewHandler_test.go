@@ -0,0 +1,70 @@
+package lineEventProcessor
+
+import (
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "testing"
+)
+
+func TestIsTransientReplyError(t *testing.T) {
+    tests := []struct {
+        name string
+        err  error
+        want bool
+    }{
+        {
+            name: "no error",
+            err:  nil,
+            want: false,
+        },
+        {
+            name: "Google rate limited",
+            err:  exception.NewTransientGoogleRequestException("PUT request failed", 429, "", errors.New("status 429 Too Many Requests")),
+            want: true,
+        },
+        {
+            name: "Google server error wrapped",
+            err:  fmt.Errorf("error publishing reply: %w", exception.NewTransientGoogleRequestException("PUT request failed", 503, "", errors.New("status 503 Service Unavailable"))),
+            want: true,
+        },
+        {
+            name: "Google timeout",
+            err:  exception.NewTransientGoogleRequestException("error sending PUT request", 0, "", errors.New("context deadline exceeded")),
+            want: true,
+        },
+        {
+            name: "Google bad request",
+            err:  exception.NewPermanentGoogleRequestException("PUT request failed", 400, "", errors.New("status 400 Bad Request")),
+            want: false,
+        },
+        {
+            name: "Zapier server error",
+            err:  exception.NewTransientZapierRequestException("received retryable status code", 500, "", errors.New("status 500 Internal Server Error")),
+            want: true,
+        },
+        {
+            name: "Zapier client error",
+            err:  exception.NewPermanentZapierRequestException("received non-OK status code", 404, "", errors.New("status 404 Not Found")),
+            want: false,
+        },
+        {
+            name: "reply blocked",
+            err:  exception.NewReplyBlockedException("reply blocked", "回覆內容為空白。"),
+            want: false,
+        },
+        {
+            name: "unclassified error",
+            err:  errors.New("business has no Google credentials"),
+            want: false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := IsTransientReplyError(tt.err); got != tt.want {
+                t.Errorf("IsTransientReplyError(%v) = %v, want %v", tt.err, got, tt.want)
+            }
+        })
+    }
+}
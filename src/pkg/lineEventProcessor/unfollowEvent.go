@@ -0,0 +1,122 @@
+package lineEventProcessor
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    enum2 "github.com/IntelliLead/CoreCommonUtil/metric/enum"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/slackUtil"
+    "github.com/aws/aws-lambda-go/events"
+    "github.com/line/line-bot-sdk-go/v7/linebot"
+    "go.uber.org/zap"
+)
+
+// ProcessUnfollowEvent offboards the user who unfollowed (blocked) the LINE Official Account.
+// The user is marked inactive and removed from the userIds of their businesses, so that nothing is pushed to the user anymore.
+// The businessIds of the user are kept to restore the user on follow. Google tokens are purged after a grace period by unfollowedUserPurgeHandler.
+func ProcessUnfollowEvent(event *linebot.Event,
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    businessUserDao *dao.BusinessUserDao,
+    lineFollowDao *dao.LineFollowDao,
+    locationSelectionDao *dao.LocationSelectionDao,
+    slack *slackUtil.Slack,
+    log *zap.SugaredLogger,
+) (events.LambdaFunctionURLResponse, error) {
+    ctx := context.Background()
+    userId := event.Source.UserID
+
+    userPtr, err := userDao.GetUser(userId)
+    if err != nil {
+        log.Errorf("Error getting user '%s': %s", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to get user: %s"}`, err),
+        }, err
+    }
+
+    if userPtr == nil {
+        // the Google metadata of a user who completed OAuth but connected no location yet is kept in the location selection only
+        err = locationSelectionDao.DeleteLocationSelection(ctx, userId)
+        if err != nil {
+            return events.LambdaFunctionURLResponse{
+                StatusCode: 500,
+                Body:       fmt.Sprintf(`{"error": "Failed to delete location selection: %s"}`, err),
+            }, err
+        }
+
+        notifyUnfollowed(userId, nil, nil, event, slack, log)
+        log.Info("Successfully handled Unfollow event for user without user record: ", userId)
+        return events.LambdaFunctionURLResponse{Body: `{"message": "Successfully handled Unfollow event"}`, StatusCode: 200}, nil
+    }
+    user := *userPtr
+
+    err = lineFollowDao.MarkUnfollowed(ctx, userId, event.Timestamp)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to mark user unfollowed: %s"}`, err),
+        }, err
+    }
+
+    // Google tokens of the user are purged from the user after the grace period, and are not to be kept in the location selection either
+    err = locationSelectionDao.RemoveGoogle(ctx, userId)
+    if err != nil {
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to remove Google metadata from location selection: %s"}`, err),
+        }, err
+    }
+
+    // remove the user from all businesses even if some fail, so that as few notifications as possible are pushed to the user
+    var businesses []model.Business
+    var errs []error
+    for _, businessId := range user.BusinessIds {
+        businessPtr, err := businessDao.GetBusiness(businessId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error getting business '%s': %w", businessId, err))
+            continue
+        }
+        if businessPtr == nil {
+            log.Warnf("Business '%s' of user '%s' does not exist. Skipping.", businessId, userId)
+            continue
+        }
+
+        err = businessUserDao.RemoveUserFromBusiness(ctx, businessId, userId)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error removing user from business '%s': %w", businessId, err))
+            continue
+        }
+        businesses = append(businesses, *businessPtr)
+    }
+
+    notifyUnfollowed(userId, &user, businesses, event, slack, log)
+
+    if len(errs) > 0 {
+        err = errors.Join(errs...)
+        log.Errorf("Error removing unfollowed user '%s' from businesses: %v", userId, err)
+        return events.LambdaFunctionURLResponse{
+            StatusCode: 500,
+            Body:       fmt.Sprintf(`{"error": "Failed to remove user from businesses: %s"}`, err),
+        }, err
+    }
+
+    log.Infof("Successfully handled Unfollow event for user '%s'. Removed from %d businesses.", userId, len(businesses))
+    return events.LambdaFunctionURLResponse{Body: `{"message": "Successfully handled Unfollow event"}`, StatusCode: 200}, nil
+}
+
+// notifyUnfollowed notifies Slack channel of the unfollow. Failures are only logged as the user is already offboarded.
+func notifyUnfollowed(userId string, user *model.User, businesses []model.Business, event *linebot.Event, slack *slackUtil.Slack, log *zap.SugaredLogger) {
+    err := slack.SendUserUnfollowedMessage(userId, user, businesses, event.Timestamp)
+    if err != nil {
+        log.Error("Error sending Slack message:", err)
+        metric.EmitLambdaMetric(enum2.Metric5xxError, enum.HandlerNameLineEventsHandler.String(), 1.0)
+        return
+    }
+    log.Info("Successfully notified Slack channel of user unfollow event")
+}
@@ -1,6 +1,7 @@
 package lineUtil
 
 import (
+    "context"
     "errors"
     "fmt"
     jsonUtil2 "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
@@ -11,7 +12,9 @@ import (
     "github.com/IntelliLead/CoreDataAccess/ddbDao"
     "github.com/IntelliLead/CoreDataAccess/model"
     "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/jsonUtil"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
     enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/aws/aws-lambda-go/events"
@@ -130,7 +133,7 @@ func (l LineUtil) SendNewReviewToUser(review model.Review, userId string) error
     return l.Base.SendFlexMessage(userId, linebot.NewFlexMessage("您有新的Google Map 評論！", flexMessage))
 }
 
-func (l LineUtil) ShowQuickReplySettings(replyToken string, user model.User, businessDao *ddbDao.BusinessDao) error {
+func (l LineUtil) ShowQuickReplySettings(replyToken string, user model.User, businessDao *ddbDao.BusinessDao, autoReplyRuleDao *dao.AutoReplyRuleDao) error {
     orderedBusinesses := make([]model.Business, len(user.BusinessIds))
     for i, id := range user.GetSortedBusinessIds() {
         b, err := businessDao.GetBusiness(id)
@@ -146,9 +149,9 @@ func (l LineUtil) ShowQuickReplySettings(replyToken string, user model.User, bus
     }
 
     if len(user.BusinessIds) > 1 {
-        return l.showQuickReplySettingsForMultiBusiness(replyToken, orderedBusinesses, user.ActiveBusinessId)
+        return l.showQuickReplySettingsForMultiBusiness(replyToken, orderedBusinesses, user.ActiveBusinessId, autoReplyRuleDao)
     } else {
-        return l.showQuickReplySettingsForSingleBusiness(replyToken, orderedBusinesses[0])
+        return l.showQuickReplySettingsForSingleBusiness(replyToken, orderedBusinesses[0], autoReplyRuleDao)
     }
 }
 
@@ -157,9 +160,10 @@ func (l LineUtil) ShowQuickReplySettingsWithActiveBusiness(
     user model.User,
     activeBusiness model.Business,
     businessDao *ddbDao.BusinessDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
 ) error {
     if len(user.BusinessIds) == 1 {
-        return l.showQuickReplySettingsForSingleBusiness(replyToken, activeBusiness)
+        return l.showQuickReplySettingsForSingleBusiness(replyToken, activeBusiness, autoReplyRuleDao)
     }
 
     orderedBusinesses := make([]model.Business, len(user.BusinessIds))
@@ -186,17 +190,31 @@ func (l LineUtil) ShowQuickReplySettingsWithActiveBusiness(
         metric.EmitLambdaMetric(enum.Metric5xxError, enum2.HandlerNameLineEventsHandler.String(), 1)
     }
 
-    return l.showQuickReplySettingsForMultiBusiness(replyToken, orderedBusinesses, activeBusiness.BusinessId)
+    return l.showQuickReplySettingsForMultiBusiness(replyToken, orderedBusinesses, activeBusiness.BusinessId, autoReplyRuleDao)
 }
 
 func (l LineUtil) showQuickReplySettingsForMultiBusiness(
     replyToken string,
     orderedBusinesses []model.Business,
     activeBusinessId bid.BusinessId,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
 ) error {
+    var settings autoReplySettings
+    for _, business := range orderedBusinesses {
+        if business.BusinessId != activeBusinessId {
+            continue
+        }
+        var err error
+        settings, err = getAutoReplySettings(business, autoReplyRuleDao)
+        if err != nil {
+            return err
+        }
+    }
+
     flexMessage, err := l.buildQuickReplySettingsFlexMessageForMultiBusiness(
         orderedBusinesses,
         activeBusinessId,
+        settings,
     )
     if err != nil {
         log.Error("Error building flex message in showQuickReplySettingsForMultiBusiness: ", err)
@@ -209,8 +227,13 @@ func (l LineUtil) showQuickReplySettingsForMultiBusiness(
     }
 }
 
-func (l LineUtil) showQuickReplySettingsForSingleBusiness(replyToken string, business model.Business) error {
-    flexMessage, err := l.buildQuickReplySettingsFlexMessage(business)
+func (l LineUtil) showQuickReplySettingsForSingleBusiness(replyToken string, business model.Business, autoReplyRuleDao *dao.AutoReplyRuleDao) error {
+    settings, err := getAutoReplySettings(business, autoReplyRuleDao)
+    if err != nil {
+        return err
+    }
+
+    flexMessage, err := l.buildQuickReplySettingsFlexMessage(business, settings)
     if err != nil {
         log.Error("Error building flex message in showQuickReplySettingsForSingleBusiness: ", err)
         return err
@@ -223,6 +246,51 @@ func (l LineUtil) showQuickReplySettingsForSingleBusiness(replyToken string, bus
     }
 }
 
+// autoReplySettings are the auto reply settings of a business shown in the quick reply settings
+type autoReplySettings struct {
+    rules             []model2.AutoReplyRule
+    aiAutoReplyMode   enum2.AiAutoReplyMode
+    replyLanguageMode enum2.ReplyLanguageMode
+    // localizedQuickReplyMessages are the quick reply messages by review language
+    localizedQuickReplyMessages map[string]string
+}
+
+// getAutoReplySettings gets the auto reply settings of the business shown in the quick reply settings
+func getAutoReplySettings(business model.Business, autoReplyRuleDao *dao.AutoReplyRuleDao) (autoReplySettings, error) {
+    ctx := context.Background()
+
+    rules, err := autoReplyRuleDao.GetAutoReplyRules(ctx, business)
+    if err != nil {
+        log.Errorf("Error getting auto reply rules of business '%s': %v", business.BusinessId, err)
+        return autoReplySettings{}, err
+    }
+
+    aiAutoReplyMode, err := autoReplyRuleDao.GetAiAutoReplyMode(ctx, business.BusinessId)
+    if err != nil {
+        log.Errorf("Error getting AI auto reply mode of business '%s': %v", business.BusinessId, err)
+        return autoReplySettings{}, err
+    }
+
+    replyLanguageMode, err := autoReplyRuleDao.GetReplyLanguageMode(ctx, business.BusinessId)
+    if err != nil {
+        log.Errorf("Error getting reply language mode of business '%s': %v", business.BusinessId, err)
+        return autoReplySettings{}, err
+    }
+
+    localizedQuickReplyMessages, err := autoReplyRuleDao.GetLocalizedQuickReplyMessages(ctx, business.BusinessId)
+    if err != nil {
+        log.Errorf("Error getting localized quick reply messages of business '%s': %v", business.BusinessId, err)
+        return autoReplySettings{}, err
+    }
+
+    return autoReplySettings{
+        rules:                       rules,
+        aiAutoReplyMode:             aiAutoReplyMode,
+        replyLanguageMode:           replyLanguageMode,
+        localizedQuickReplyMessages: localizedQuickReplyMessages,
+    }, nil
+}
+
 func (l LineUtil) ShowAiReplySettingsByUser(replyToken string, user model.User, businessDao *ddbDao.BusinessDao) error {
     businessId := user.ActiveBusinessId
     businessPtr, err := businessDao.GetBusiness(businessId)
@@ -299,7 +367,8 @@ func (l LineUtil) showAiReplySettingsForMultiBusiness(replyToken string, user mo
     }
 }
 
-func (l LineUtil) SendAiGeneratedReply(aiReply string, review model.Review, generateAuthorName string, business model.Business, user model.User, userDao *ddbDao.UserDao) error {
+// SendAiGeneratedReply sends the AI reply candidates of the review to all users of the business
+func (l LineUtil) SendAiGeneratedReply(candidateSet model2.AiReplyCandidateSet, review model.Review, generateAuthorName string, business model.Business, user model.User, userDao *ddbDao.UserDao) error {
     var returnErr error = nil
     // for each user of the business, retrieve businessId Index for the user, and send the message
     for _, userId := range business.UserIds {
@@ -337,7 +406,7 @@ func (l LineUtil) SendAiGeneratedReply(aiReply string, review model.Review, gene
             }
         }
 
-        flexMessage, err := l.buildAiGeneratedReplyFlexMessage(review, aiReply, generateAuthorName, business.BusinessId, businessIdIndex)
+        flexMessage, err := l.buildAiGeneratedReplyFlexMessage(review, candidateSet, generateAuthorName, business.BusinessId, businessIdIndex)
         if err != nil {
             log.Error("Error building flex message in SendAiGeneratedReply: ", err)
             returnErr = err
@@ -357,8 +426,52 @@ func (l LineUtil) SendAiGeneratedReply(aiReply string, review model.Review, gene
     return returnErr
 }
 
-func (l LineUtil) SendAuthRequest(userId string, authRedirectUrl string) error {
-    flexMessage, err := l.buildAuthRequestFlexMessage(userId, authRedirectUrl)
+// SendAiReplyDraft sends the AI reply draft of the review to all users of the business for approval
+func (l LineUtil) SendAiReplyDraft(draft model2.AiReplyDraft, review model.Review, business model.Business, userDao *ddbDao.UserDao) error {
+    var returnErr error = nil
+    for _, userId := range business.UserIds {
+        sendingUser, err := userDao.GetUser(userId)
+        if err != nil {
+            log.Errorf("Error getting user '%s' in SendAiReplyDraft: %v", userId, err)
+            return err
+        }
+        if sendingUser == nil {
+            errMsg := fmt.Sprintf("User '%s' not found in SendAiReplyDraft. Inconsistent userIds in business '%s'", userId, business.BusinessId)
+            log.Error(errMsg)
+            metric.EmitLambdaMetric(enum.Metric5xxError, enum2.HandlerNameLineEventsHandler.String(), 1)
+            returnErr = errors.New(errMsg)
+            continue
+        }
+        businessIdIndex, err := sendingUser.GetBusinessIdIndex(business.BusinessId)
+        if err != nil {
+            errMsg := fmt.Sprintf("Error getting businessIdIndex for business '%s' in user '%s' during SendAiReplyDraft: %v", business.BusinessId, sendingUser.UserId, err)
+            log.Error(errMsg)
+            metric.EmitLambdaMetric(enum.Metric5xxError, enum2.HandlerNameLineEventsHandler.String(), 1)
+            returnErr = errors.New(errMsg)
+            continue
+        }
+
+        flexMessage, err := l.buildAiReplyDraftFlexMessage(review, draft, business.BusinessId, businessIdIndex)
+        if err != nil {
+            log.Error("Error building flex message in SendAiReplyDraft: ", err)
+            return err
+        }
+
+        err = l.Base.SendFlexMessage(userId, linebot.NewFlexMessage("AI 回覆草稿待核准", flexMessage))
+        if err != nil {
+            log.Errorf("Error sending AI reply draft to LINE user %s in SendAiReplyDraft: %v", userId, err)
+            metric.EmitLambdaMetric(enum.Metric5xxError, enum2.HandlerNameLineEventsHandler.String(), 1)
+            returnErr = err
+            continue
+        }
+    }
+
+    return returnErr
+}
+
+// SendAuthRequest sends the Google OAuth request with the signed state of the user. See oauthState.Signer.
+func (l LineUtil) SendAuthRequest(userId string, state string, authRedirectUrl string) error {
+    flexMessage, err := l.buildAuthRequestFlexMessage(state, authRedirectUrl)
     if err != nil {
         log.Error("Error building flex message in RequestAuth: ", err)
         return err
@@ -367,8 +480,9 @@ func (l LineUtil) SendAuthRequest(userId string, authRedirectUrl string) error {
     return l.Base.SendFlexMessage(userId, linebot.NewFlexMessage("智引力請求訪問 Google 資料", flexMessage))
 }
 
-func (l LineUtil) ReplyAuthRequest(replyToken string, userId string, authRedirectUrl string) error {
-    flexMessage, err := l.buildAuthRequestFlexMessage(userId, authRedirectUrl)
+// ReplyAuthRequest replies the Google OAuth request with the signed state of the user. See oauthState.Signer.
+func (l LineUtil) ReplyAuthRequest(replyToken string, state string, authRedirectUrl string) error {
+    flexMessage, err := l.buildAuthRequestFlexMessage(state, authRedirectUrl)
     if err != nil {
         log.Error("Error building flex message in RequestAuth: ", err)
         return err
@@ -377,6 +491,65 @@ func (l LineUtil) ReplyAuthRequest(replyToken string, userId string, authRedirec
     return l.Base.ReplyFlexMessage(replyToken, linebot.NewFlexMessage("智引力請求訪問 Google 資料", flexMessage))
 }
 
+// SendReauthRequest notifies the user that their Google authorization is no longer valid and sends the Google OAuth request with the signed state of the user
+func (l LineUtil) SendReauthRequest(userId string, state string, authRedirectUrl string) error {
+    messages, err := l.buildReauthRequestMessages(state, authRedirectUrl)
+    if err != nil {
+        return err
+    }
+
+    _, err = l.Base.LineClient.PushMessage(userId, messages...).Do()
+    return err
+}
+
+// ReplyReauthRequest replies that the Google authorization of the user is no longer valid with the Google OAuth request with the signed state of the user
+func (l LineUtil) ReplyReauthRequest(replyToken string, state string, authRedirectUrl string) error {
+    messages, err := l.buildReauthRequestMessages(state, authRedirectUrl)
+    if err != nil {
+        return err
+    }
+
+    _, err = l.Base.LineClient.ReplyMessage(replyToken, messages...).Do()
+    return err
+}
+
+func (l LineUtil) buildReauthRequestMessages(state string, authRedirectUrl string) ([]linebot.SendingMessage, error) {
+    flexMessage, err := l.buildAuthRequestFlexMessage(state, authRedirectUrl)
+    if err != nil {
+        log.Error("Error building flex message in buildReauthRequestMessages: ", err)
+        return nil, err
+    }
+
+    return []linebot.SendingMessage{
+        linebot.NewTextMessage("您的 Google 授權已失效，評論回覆將無法發布至 Google。請重新授權以恢復連結。"),
+        linebot.NewFlexMessage("智引力請求訪問 Google 資料", flexMessage),
+    }, nil
+}
+
+// SendLocationSelection sends the discovered locations for the user to connect or skip, and to disconnect the connected ones
+func (l LineUtil) SendLocationSelection(userId string, locations []model2.DiscoveredLocation, connectedBusinessIds []bid.BusinessId) error {
+    messages, err := l.buildLocationSelectionFlexMessages(locations, connectedBusinessIds)
+    if err != nil {
+        log.Error("Error building flex messages in SendLocationSelection: ", err)
+        return err
+    }
+
+    _, err = l.Base.LineClient.PushMessage(userId, messages...).Do()
+    return err
+}
+
+// ReplyLocationSelection replies the discovered locations for the user to connect or skip, and to disconnect the connected ones
+func (l LineUtil) ReplyLocationSelection(replyToken string, locations []model2.DiscoveredLocation, connectedBusinessIds []bid.BusinessId) error {
+    messages, err := l.buildLocationSelectionFlexMessages(locations, connectedBusinessIds)
+    if err != nil {
+        log.Error("Error building flex messages in ReplyLocationSelection: ", err)
+        return err
+    }
+
+    _, err = l.Base.LineClient.ReplyMessage(replyToken, messages...).Do()
+    return err
+}
+
 func (l LineUtil) ReplyUserReplyFailed(replyToken string, reviewerName string, isAutoReply bool) error {
     return l.Base.ReplyText(replyToken, buildReplyFailedMessage(reviewerName, isAutoReply))
 }
@@ -393,6 +566,45 @@ func (l LineUtil) NotifyUsersReplyFailed(userIds []string, reviewerName string,
     return returnErr
 }
 
+// NotifyUsersAutoReplyBlocked notifies the users that the auto reply to the review is blocked by the reply guard with the reason
+func (l LineUtil) NotifyUsersAutoReplyBlocked(userIds []string, reviewerName string, reason string) error {
+    text := fmt.Sprintf("自動回覆 %s 的評論未發布。%s請確認後手動回覆。", reviewerName, reason)
+
+    var returnErr error = nil
+    for _, userId := range userIds {
+        err := l.Base.SendText(userId, text)
+        if err != nil {
+            log.Errorf("Error sending message to '%s' in NotifyUsersAutoReplyBlocked: %v", userId, err)
+            returnErr = err
+        }
+    }
+    return returnErr
+}
+
+// NotifyUserAiQuotaExceeded notifies the user that AI replies are unavailable until next month as the business has used up the monthly AI quota of its plan tier
+func (l LineUtil) NotifyUserAiQuotaExceeded(userId string, planTier enum2.PlanTier, quotaUsd float64) error {
+    return l.Base.SendText(userId, aiQuotaExceededText(planTier, quotaUsd))
+}
+
+// NotifyUsersAutoReplyAiQuotaExceeded notifies users of the business that the review is not auto replied with AI as the business has used up the monthly AI quota of its plan tier
+func (l LineUtil) NotifyUsersAutoReplyAiQuotaExceeded(userIds []string, reviewerName string, planTier enum2.PlanTier, quotaUsd float64) error {
+    text := fmt.Sprintf("未自動回覆 %s 的評論。%s", reviewerName, aiQuotaExceededText(planTier, quotaUsd))
+
+    var returnErr error = nil
+    for _, userId := range userIds {
+        err := l.Base.SendText(userId, text)
+        if err != nil {
+            log.Errorf("Error sending message to '%s' in NotifyUsersAutoReplyAiQuotaExceeded: %v", userId, err)
+            returnErr = err
+        }
+    }
+    return returnErr
+}
+
+func aiQuotaExceededText(planTier enum2.PlanTier, quotaUsd float64) string {
+    return fmt.Sprintf("本月 AI 用量已達%s上限（約 US$%.2f），AI 回覆將於下個月 1 日恢復。如需更多用量，請聯繫我們升級方案。", planTier.DisplayName(), quotaUsd)
+}
+
 // ReplyUserReplyFailedWithReason replies to the user that the reply failed with the reason
 // both the reviewerName and reason can be empty
 func (l LineUtil) ReplyUserReplyFailedWithReason(replyToken string, reviewerName string, reason string) error {
@@ -462,6 +674,73 @@ func (l LineUtil) NotifyReviewAutoReplied(
     return returnErr
 }
 
+// NotifyReviewChanged notifies all users of the business that the reviewer changed the review, showing both versions
+// param oldReview: the review before the change
+// param newReview: the review after the change
+// param business: the business that owns the review
+// param userDao: the userDao
+func (l LineUtil) NotifyReviewChanged(
+    oldReview model.Review,
+    newReview model.Review,
+    business model.Business,
+    userDao *ddbDao.UserDao,
+) error {
+    var returnErr error = nil
+    for _, userId := range business.UserIds {
+        userPtr, err := userDao.GetUser(userId)
+        if err != nil {
+            log.Errorf("Error getting user '%s' in NotifyReviewChanged: %v", userId, err)
+            return err
+        }
+        if userPtr == nil {
+            errMsg := fmt.Sprintf("User '%s' not found in NotifyReviewChanged. Inconsistent userIds in business '%s'", userId, business.BusinessId)
+            log.Error(errMsg)
+            returnErr = errors.New(errMsg)
+            continue
+        }
+        businessIdIndex, err := userPtr.GetBusinessIdIndex(business.BusinessId)
+        if err != nil {
+            errMsg := fmt.Sprintf("Error getting businessIdIndex for business '%s' in user '%s' during NotifyReviewChanged: %v", business.BusinessId, userId, err)
+            log.Error(errMsg)
+            returnErr = errors.New(errMsg)
+            continue
+        }
+
+        flexMessage, err := l.buildReviewChangedNotificationMessage(oldReview, newReview, business.BusinessName, businessIdIndex)
+        if err != nil {
+            log.Error("Error building flex message in NotifyReviewChanged: ", err)
+            return err
+        }
+
+        err = l.Base.SendFlexMessage(userId, linebot.NewFlexMessage("評論更新通知", flexMessage))
+        if err != nil {
+            log.Errorf("Error sending message to '%s' in NotifyReviewChanged: %v . Flex Message: %s", userId, err, jsonUtil2.AnyToJson(flexMessage))
+            returnErr = err
+            continue
+        }
+    }
+
+    return returnErr
+}
+
+// NotifyReviewRemoved notifies all users of the business that the review was removed from Google
+func (l LineUtil) NotifyReviewRemoved(review model.Review, business model.Business) error {
+    text := fmt.Sprintf("%s 的評論已從 Google 地圖移除。", review.ReviewerName)
+    if !stringUtil.IsEmptyStringPtr(review.Review) {
+        text += fmt.Sprintf("\n\n原評論：\n%s", *review.Review)
+    }
+
+    var returnErr error = nil
+    for _, userId := range business.UserIds {
+        err := l.Base.SendText(userId, text)
+        if err != nil {
+            log.Errorf("Error sending message to '%s' in NotifyReviewRemoved: %v", userId, err)
+            returnErr = err
+        }
+    }
+    return returnErr
+}
+
 // NotifyReviewReplied notifies all users of the business that owns the review that the review has been replied to
 // param replyToken: the reply token of the user who replied to the review
 // param replyTokenOwnerUserId: the userId of the user who replied to the review
@@ -557,8 +836,9 @@ func (l LineUtil) NotifyUserAiReplyGenerationFailed(userId string) error {
     return l.Base.SendText(userId, "AI 回覆生成失敗，請稍後再試。很抱歉為您造成不便。")
 }
 
-func (l LineUtil) ReplyHelpMessage(replyToken string) error {
-    return l.Base.ReplyText(replyToken, util.HelpMessage())
+// ReplyHelpMessage replies the help message, prefixed with the help text of all text commands
+func (l LineUtil) ReplyHelpMessage(replyToken string, commandHelpText string) error {
+    return l.Base.ReplyText(replyToken, commandHelpText+"\n\n"+util.HelpMessage())
 }
 
 func (l LineUtil) ReplyMoreMessage(replyToken string) error {
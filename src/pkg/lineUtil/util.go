@@ -2,6 +2,7 @@ package lineUtil
 
 import (
     "encoding/json"
+    "errors"
     "fmt"
     "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
     "github.com/IntelliLead/CoreCommonUtil/line"
@@ -10,10 +11,13 @@ import (
     util2 "github.com/IntelliLead/CoreCommonUtil/util"
     "github.com/IntelliLead/CoreDataAccess/model"
     "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/languageUtil"
     model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
     "github.com/line/line-bot-sdk-go/v7/linebot"
     "net/url"
+    "strings"
 )
 
 const CannotUseLineEmojiMessage = "暫不支援LINE Emoji，但是您可以考慮使用 Unicode emoji （比如👍🏻）。"
@@ -71,9 +75,11 @@ type message struct {
 // buildQuickReplySettingsFlexMessageForMultiBusiness builds a LINE flex message for quick reply settings for multi-business
 // orderedBusinesses must be sorted by businessIdIndex (i.e., the order as appear in sorted user.BusinessIds)
 // activeBusinessId must be in orderedBusinesses
+// rules are the auto reply rules of the active business
 func (l LineUtil) buildQuickReplySettingsFlexMessageForMultiBusiness(
     orderedBusinesses []model.Business,
     activeBusinessId bid.BusinessId,
+    settings autoReplySettings,
 ) (linebot.FlexContainer, error) {
     jsonMap, err := jsonUtil.JsonToMap(l.quickReplyJsons.QuickReplySettingsMultiBusiness)
     if err != nil {
@@ -134,23 +140,38 @@ func (l LineUtil) buildQuickReplySettingsFlexMessageForMultiBusiness(
     (map[string]interface{})["action"].
     (map[string]interface{})["fillInText"] = quickReplyFillInText
 
-    // update auto quick reply toggle
-    autoQuickReplyTogglePostbackData := fmt.Sprintf("/QuickReply/%s/Toggle/AutoReply", business.BusinessId)
-    // contents[0] -> body -> contents[3] -> contents[0] -> contents[1] -> url
-    jsonMap["contents"].([]interface{})[0].
+    // update auto reply rules
+    // contents[0] -> body -> contents[3]
+    err = fillAutoReplyRules(jsonMap["contents"].([]interface{})[0].
     (map[string]interface{})["body"].
     (map[string]interface{})["contents"].([]interface{})[3].
-    (map[string]interface{})["contents"].([]interface{})[0].
-    (map[string]interface{})["contents"].([]interface{})[1].
-    (map[string]interface{})["url"] = util.GetToggleUrl(business.AutoQuickReplyEnabled)
-    // contents[0] -> body -> contents[3] -> contents[0] -> contents[1] -> action -> data
-    jsonMap["contents"].([]interface{})[0].
+    (map[string]interface{}), settings.rules, business.BusinessId, activeBusinessIndex)
+    if err != nil {
+        log.Error("Error filling in auto reply rules: ", err)
+        return nil, err
+    }
+
+    // update AI auto reply mode
+    // contents[0] -> body -> contents[4]
+    err = fillAiAutoReplyMode(jsonMap["contents"].([]interface{})[0].
     (map[string]interface{})["body"].
-    (map[string]interface{})["contents"].([]interface{})[3].
-    (map[string]interface{})["contents"].([]interface{})[0].
-    (map[string]interface{})["contents"].([]interface{})[1].
-    (map[string]interface{})["action"].
-    (map[string]interface{})["data"] = autoQuickReplyTogglePostbackData
+    (map[string]interface{})["contents"].([]interface{})[4].
+    (map[string]interface{}), settings.aiAutoReplyMode, business.BusinessId)
+    if err != nil {
+        log.Error("Error filling in AI auto reply mode: ", err)
+        return nil, err
+    }
+
+    // update reply language
+    // contents[0] -> body -> contents[5]
+    err = fillReplyLanguage(jsonMap["contents"].([]interface{})[0].
+    (map[string]interface{})["body"].
+    (map[string]interface{})["contents"].([]interface{})[5].
+    (map[string]interface{}), settings, business.BusinessId, activeBusinessIndex)
+    if err != nil {
+        log.Error("Error filling in reply language: ", err)
+        return nil, err
+    }
 
     // update other business bubbles
     otherBusinessBubbleTemplate, err := util2.DeepCopy(jsonMap["contents"].([]interface{})[1])
@@ -188,7 +209,7 @@ func (l LineUtil) buildQuickReplySettingsFlexMessageForMultiBusiness(
 }
 
 // buildQuickReplySettingsFlexMessage builds a LINE flex message for quick reply settings
-func (l LineUtil) buildQuickReplySettingsFlexMessage(business model.Business) (linebot.FlexContainer, error) {
+func (l LineUtil) buildQuickReplySettingsFlexMessage(business model.Business, settings autoReplySettings) (linebot.FlexContainer, error) {
     jsonMap, err := jsonUtil.JsonToMap(l.quickReplyJsons.QuickReplySettings)
     if err != nil {
         log.Debug("Error unmarshalling QuickReplySettings JSON: ", err)
@@ -226,25 +247,172 @@ func (l LineUtil) buildQuickReplySettingsFlexMessage(business model.Business) (l
     (map[string]interface{})["action"].
     (map[string]interface{})["fillInText"] = quickReplyFillInText
 
-    // update auto quick reply toggle
-    autoQuickReplyTogglePostbackData := fmt.Sprintf("/QuickReply/%s/Toggle/AutoReply", business.BusinessId)
-    // body -> contents[3] -> contents[0] -> contents[1] -> url
-    jsonMap["body"].
+    // update auto reply rules
+    // body -> contents[3]
+    err = fillAutoReplyRules(jsonMap["body"].
     (map[string]interface{})["contents"].([]interface{})[3].
-    (map[string]interface{})["contents"].([]interface{})[0].
-    (map[string]interface{})["contents"].([]interface{})[1].
-    (map[string]interface{})["url"] = util.GetToggleUrl(business.AutoQuickReplyEnabled)
-    // body -> contents[3] -> contents[0] -> contents[1] -> action -> data
-    jsonMap["body"].
-    (map[string]interface{})["contents"].([]interface{})[3].
-    (map[string]interface{})["contents"].([]interface{})[0].
-    (map[string]interface{})["contents"].([]interface{})[1].
-    (map[string]interface{})["action"].
-    (map[string]interface{})["data"] = autoQuickReplyTogglePostbackData
+    (map[string]interface{}), settings.rules, business.BusinessId, businessIdIndex)
+    if err != nil {
+        log.Error("Error filling in auto reply rules: ", err)
+        return nil, err
+    }
+
+    // update AI auto reply mode
+    // body -> contents[4]
+    err = fillAiAutoReplyMode(jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[4].
+    (map[string]interface{}), settings.aiAutoReplyMode, business.BusinessId)
+    if err != nil {
+        log.Error("Error filling in AI auto reply mode: ", err)
+        return nil, err
+    }
+
+    // update reply language
+    // body -> contents[5]
+    err = fillReplyLanguage(jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[5].
+    (map[string]interface{}), settings, business.BusinessId, businessIdIndex)
+    if err != nil {
+        log.Error("Error filling in reply language: ", err)
+        return nil, err
+    }
 
     return line.JsonMapToLineFlexContainer(jsonMap)
 }
 
+// fillAutoReplyRules fills in the auto reply rules section of the quick reply settings with a toggle row per rule
+// section -> contents[1] is the list of rules, whose contents[0] is the row template
+// section -> contents[3] is the edit rules button
+func fillAutoReplyRules(section map[string]interface{}, rules []model2.AutoReplyRule, businessId bid.BusinessId, businessIdIndex int) error {
+    ruleList := section["contents"].([]interface{})[1].(map[string]interface{})
+    rowTemplate := ruleList["contents"].([]interface{})[0]
+
+    rows := make([]interface{}, 0, len(rules))
+    for i, rule := range rules {
+        row, err := util2.DeepCopy(rowTemplate)
+        if err != nil {
+            return err
+        }
+
+        // row -> contents[0] -> text
+        row.(map[string]interface{})["contents"].([]interface{})[0].
+        (map[string]interface{})["text"] = fmt.Sprintf("%d. %s", i+1, rule.Describe())
+        // row -> contents[1] -> url
+        row.(map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["url"] = util.GetToggleUrl(rule.Enabled)
+        // row -> contents[1] -> action -> data
+        row.(map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["action"].
+        (map[string]interface{})["data"] = fmt.Sprintf("/QuickReply/%s/Rule/%d/Toggle", businessId, i)
+
+        rows = append(rows, row)
+    }
+    if len(rows) == 0 {
+        rows = append(rows, map[string]interface{}{
+            "type":  "text",
+            "text":  "尚未設定自動回覆規則",
+            "size":  "sm",
+            "color": "#aaaaaa",
+        })
+    }
+    ruleList["contents"] = rows
+
+    // contents[3] -> action
+    editAction := section["contents"].([]interface{})[3].(map[string]interface{})["action"].(map[string]interface{})
+    editAction["data"] = fmt.Sprintf("/QuickReply/%s/EditAutoReplyRules", businessId)
+    editAction["fillInText"] = fmt.Sprintf("/%s/%d ", util.UpdateAutoReplyRuleCmd, businessIdIndex)
+
+    return nil
+}
+
+// fillAiAutoReplyMode fills in the AI auto reply mode section of the quick reply settings with a selectable row per mode
+// section -> contents[1] is the list of modes, whose contents[0] is the row template
+// section -> contents[2] is the hint
+func fillAiAutoReplyMode(section map[string]interface{}, aiAutoReplyMode enum.AiAutoReplyMode, businessId bid.BusinessId) error {
+    modeList := section["contents"].([]interface{})[1].(map[string]interface{})
+    rowTemplate := modeList["contents"].([]interface{})[0]
+
+    rows := make([]interface{}, 0, len(enum.AiAutoReplyModes))
+    for _, mode := range enum.AiAutoReplyModes {
+        row, err := util2.DeepCopy(rowTemplate)
+        if err != nil {
+            return err
+        }
+
+        // row -> contents[0] -> text
+        row.(map[string]interface{})["contents"].([]interface{})[0].
+        (map[string]interface{})["text"] = mode.DisplayName()
+        // row -> contents[1] -> url
+        row.(map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["url"] = util.GetToggleUrl(mode == aiAutoReplyMode)
+        // row -> contents[1] -> action -> data
+        row.(map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["action"].
+        (map[string]interface{})["data"] = fmt.Sprintf("/QuickReply/%s/AiAutoReplyMode/%s", businessId, mode.String())
+
+        rows = append(rows, row)
+    }
+    modeList["contents"] = rows
+
+    // contents[2] -> text
+    section["contents"].([]interface{})[2].(map[string]interface{})["text"] = fmt.Sprintf(
+        "開啟後，未符合任何規則的新評論將由 AI 生成回覆。選擇%s時，AI 回覆（包含規則產生的 AI 回覆）會以草稿傳送，經核准才會發布，草稿於 %d 小時後失效。",
+        enum.AiAutoReplyModeApproval.DisplayName(), int(model2.AiReplyDraftTtl.Hours()))
+
+    return nil
+}
+
+// fillReplyLanguage fills in the reply language section of the quick reply settings with a selectable row per reply language mode
+// section -> contents[1] is the list of modes, whose contents[0] is the row template
+// section -> contents[2] lists the localized quick reply messages
+// section -> contents[3] is the edit localized quick reply message button
+func fillReplyLanguage(section map[string]interface{}, settings autoReplySettings, businessId bid.BusinessId, businessIdIndex int) error {
+    modeList := section["contents"].([]interface{})[1].(map[string]interface{})
+    rowTemplate := modeList["contents"].([]interface{})[0]
+
+    rows := make([]interface{}, 0, len(enum.ReplyLanguageModes))
+    for _, mode := range enum.ReplyLanguageModes {
+        row, err := util2.DeepCopy(rowTemplate)
+        if err != nil {
+            return err
+        }
+
+        // row -> contents[0] -> text
+        row.(map[string]interface{})["contents"].([]interface{})[0].
+        (map[string]interface{})["text"] = mode.DisplayName()
+        // row -> contents[1] -> url
+        row.(map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["url"] = util.GetToggleUrl(mode == settings.replyLanguageMode)
+        // row -> contents[1] -> action -> data
+        row.(map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["action"].
+        (map[string]interface{})["data"] = fmt.Sprintf("/QuickReply/%s/ReplyLanguageMode/%s", businessId, mode.String())
+
+        rows = append(rows, row)
+    }
+    modeList["contents"] = rows
+
+    // contents[2] -> text
+    var languages []string
+    for _, language := range languageUtil.Languages {
+        if _, ok := settings.localizedQuickReplyMessages[language]; ok {
+            languages = append(languages, languageUtil.DisplayName(language))
+        }
+    }
+    localizedMessagesText := "AI 回覆使用的語言。其他語言的評論可設定專屬的快速回覆訊息，未設定則使用上方的快速回覆訊息。"
+    if len(languages) > 0 {
+        localizedMessagesText += "\n已設定：" + strings.Join(languages, "、")
+    }
+    section["contents"].([]interface{})[2].(map[string]interface{})["text"] = localizedMessagesText
+
+    // contents[3] -> action
+    editAction := section["contents"].([]interface{})[3].(map[string]interface{})["action"].(map[string]interface{})
+    editAction["data"] = fmt.Sprintf("/QuickReply/%s/EditLocalizedQuickReplyMessage", businessId)
+    editAction["fillInText"] = fmt.Sprintf("/%s/%d 語言:%s ", util.UpdateQuickReplyMessageCmd, businessIdIndex, languageUtil.English)
+
+    return nil
+}
+
 func (l LineUtil) buildReviewFlexMessage(review model.Review, quickReplyMessage string, businessId bid.BusinessId, businessIdIndex int, businessName *string) (linebot.FlexContainer, error) {
     // Convert the original JSON to a map[string]interface{}
     jsonMap, err := jsonUtil.JsonToMap(l.reviewMessageJsons.ReviewMessage)
@@ -440,13 +608,38 @@ func (l LineUtil) buildReviewFlexMessageForUnauthedUser(review model.Review) (li
     return line.JsonMapToLineFlexContainer(jsonMap)
 }
 
-func (l LineUtil) buildAiGeneratedReplyFlexMessage(review model.Review, aiReply string, generateAuthorName string, businessId bid.BusinessId, businessIdIndex int) (linebot.FlexContainer, error) {
+// buildAiGeneratedReplyFlexMessage builds a carousel with a bubble for each candidate of the candidate set
+func (l LineUtil) buildAiGeneratedReplyFlexMessage(review model.Review, candidateSet model2.AiReplyCandidateSet, generateAuthorName string, businessId bid.BusinessId, businessIdIndex int) (linebot.FlexContainer, error) {
+    var bubbles []interface{}
+    for index, aiReply := range candidateSet.Replies {
+        bubble, err := l.buildAiGeneratedReplyBubble(review, candidateSet, index, aiReply, generateAuthorName, businessId, businessIdIndex)
+        if err != nil {
+            return nil, err
+        }
+        bubbles = append(bubbles, bubble)
+    }
+
+    return line.JsonMapToLineFlexContainer(map[string]interface{}{
+        "type":     "carousel",
+        "contents": bubbles,
+    })
+}
+
+func (l LineUtil) buildAiGeneratedReplyBubble(review model.Review, candidateSet model2.AiReplyCandidateSet, index int, aiReply string, generateAuthorName string, businessId bid.BusinessId, businessIdIndex int) (map[string]interface{}, error) {
     jsonMap, err := jsonUtil.JsonToMap(l.aiReplyJsons.AiReplyResult)
     if err != nil {
         log.Debug("Error unmarshalling AiReplyResult JSON: ", err)
         return nil, err
     }
 
+    candidateData := fmt.Sprintf("/AiReply/%s/Candidates/%s/%d", businessId, candidateSet.CandidateSetId, index)
+
+    // update title
+    // body -> contents[0] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["text"] = fmt.Sprintf("AI 生成結果 %d/%d", index+1, len(candidateSet.Replies))
+
     // update reviewer name
     // body -> contents[2] -> contents[0] -> contents[1] -> text
     jsonMap["body"].
@@ -478,23 +671,119 @@ func (l LineUtil) buildAiGeneratedReplyFlexMessage(review model.Review, aiReply
     (map[string]interface{})["contents"].([]interface{})[1].
     (map[string]interface{})["text"] = generateAuthorName
 
-    // update 送出回覆 button
-    // footer -> contents[0] -> action -> fillInText
+    // update 直接送出 button
+    // footer -> contents[0] -> action -> data
     jsonMap["footer"].
     (map[string]interface{})["contents"].([]interface{})[0].
     (map[string]interface{})["action"].
+    (map[string]interface{})["data"] = candidateData + "/Publish"
+
+    // update 編輯後送出 button
+    // footer -> contents[1] -> action -> fillInText
+    jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["action"].
     (map[string]interface{})["fillInText"] = fmt.Sprintf("@%d|%s %s", businessIdIndex, review.ReviewId.String(), aiReply)
+    // footer -> contents[1] -> action -> data
+    jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["action"].
+    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/%s/EditReply", businessId)
+
+    // update revision buttons
+    // footer -> contents[2] -> contents[i] -> action
+    revisionButtons := jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[2].
+    (map[string]interface{})["contents"].([]interface{})
+    for i, revision := range enum.ReplyRevisions {
+        action := revisionButtons[i].(map[string]interface{})["action"].(map[string]interface{})
+        action["label"] = revision.DisplayName()
+        action["data"] = fmt.Sprintf("%s/Revise/%s", candidateData, revision.String())
+    }
+
+    // update 換一組 button
+    // footer -> contents[3] -> action -> data
+    jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[3].
+    (map[string]interface{})["action"].
+    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/GenerateAiReply/%s/%s", businessId, review.ReviewId.String())
+
+    return jsonMap, nil
+}
+
+func (l LineUtil) buildAiReplyDraftFlexMessage(review model.Review, draft model2.AiReplyDraft, businessId bid.BusinessId, businessIdIndex int) (linebot.FlexContainer, error) {
+    jsonMap, err := jsonUtil.JsonToMap(l.aiReplyJsons.AiReplyDraft)
+    if err != nil {
+        log.Debug("Error unmarshalling AiReplyDraft JSON: ", err)
+        return nil, err
+    }
+
+    readableExpireAt, err := timeUtil.UtcToReadableTwTimestamp(draft.ExpireAt)
+    if err != nil {
+        log.Error("Error converting draft expiry timestamp to readable format: ", err)
+        return nil, err
+    }
+
+    reviewDisplayed := "（無文字評論）"
+    if !stringUtil.IsEmptyStringPtr(review.Review) {
+        reviewDisplayed = *review.Review
+    }
+
+    // update expiry note
+    // body -> contents[1] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["text"] = fmt.Sprintf("此回覆經核准後才會發布，請於 %s 前核准", readableExpireAt)
+
+    // update reviewer name
+    // body -> contents[2] -> contents[0] -> contents[1] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[2].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["text"] = review.ReviewerName
+
+    // update review body
+    // body -> contents[2] -> contents[1] -> contents[1] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[2].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["text"] = reviewDisplayed
+
+    // update ai reply
+    // body -> contents[3] -> contents[0] -> contents[0] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[3].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["text"] = draft.Reply
+
+    // update 核准發布 button
     // footer -> contents[0] -> action -> data
     jsonMap["footer"].
     (map[string]interface{})["contents"].([]interface{})[0].
     (map[string]interface{})["action"].
-    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/%s/EditReply", businessId)
+    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/%s/Draft/%s/Approve", businessId, review.ReviewId.String())
 
+    // update 編輯 button
+    // footer -> contents[1] -> action -> fillInText
+    jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["action"].
+    (map[string]interface{})["fillInText"] = fmt.Sprintf("@%d|%s %s", businessIdIndex, review.ReviewId.String(), draft.Reply)
     // footer -> contents[1] -> action -> data
     jsonMap["footer"].
     (map[string]interface{})["contents"].([]interface{})[1].
     (map[string]interface{})["action"].
-    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/GenerateAiReply/%s/%s", businessId, review.ReviewId.String())
+    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/%s/EditReply", businessId)
+
+    // update 重新生成 button
+    // footer -> contents[2] -> action -> data
+    jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[2].
+    (map[string]interface{})["action"].
+    (map[string]interface{})["data"] = fmt.Sprintf("/AiReply/%s/Draft/%s/Regenerate", businessId, review.ReviewId.String())
 
     return line.JsonMapToLineFlexContainer(jsonMap)
 }
@@ -928,7 +1217,7 @@ func (l LineUtil) buildAiReplySettingsFlexMessageForSingleBusiness(user model.Us
     return line.JsonMapToLineFlexContainer(jsonMap)
 }
 
-func (l LineUtil) buildAuthRequestFlexMessage(userId string, authRedirectUrl string) (linebot.FlexContainer, error) {
+func (l LineUtil) buildAuthRequestFlexMessage(state string, authRedirectUrl string) (linebot.FlexContainer, error) {
     jsonMap, err := jsonUtil.JsonToMap(l.authJsons.AuthRequest)
     if err != nil {
         log.Debug("Error unmarshalling AuthRequest JSON: ", err)
@@ -943,7 +1232,7 @@ func (l LineUtil) buildAuthRequestFlexMessage(userId string, authRedirectUrl str
     (map[string]interface{})["uri"].(string)
 
     // replace the redirect_uri query parameter in the uri with authRedirectUrl
-    uri, err := finalizeAuthUri(oldUri, userId, authRedirectUrl)
+    uri, err := finalizeAuthUri(oldUri, state, authRedirectUrl)
     if err != nil {
         return nil, err
     }
@@ -958,7 +1247,105 @@ func (l LineUtil) buildAuthRequestFlexMessage(userId string, authRedirectUrl str
     return line.JsonMapToLineFlexContainer(jsonMap)
 }
 
-func finalizeAuthUri(uri string, userId string, authRedirectUrl string) (string, error) {
+// locationCarouselSize is the maximum number of bubbles in a LINE carousel
+const locationCarouselSize = 12
+
+// locationCarouselCount is the maximum number of messages sent in one LINE request
+const locationCarouselCount = 5
+
+// buildLocationSelectionFlexMessages builds carousels with a bubble for each location. Locations beyond what fits in one LINE request are left out.
+func (l LineUtil) buildLocationSelectionFlexMessages(locations []model2.DiscoveredLocation, connectedBusinessIds []bid.BusinessId) ([]linebot.SendingMessage, error) {
+    if len(locations) == 0 {
+        return nil, errors.New("no locations to select")
+    }
+    if len(locations) > locationCarouselSize*locationCarouselCount {
+        log.Warnf("Only the first %d of %d locations are shown for selection", locationCarouselSize*locationCarouselCount, len(locations))
+        locations = locations[:locationCarouselSize*locationCarouselCount]
+    }
+
+    var messages []linebot.SendingMessage
+    for start := 0; start < len(locations); start += locationCarouselSize {
+        end := start + locationCarouselSize
+        if end > len(locations) {
+            end = len(locations)
+        }
+
+        var bubbles []interface{}
+        for _, location := range locations[start:end] {
+            connected := stringUtil.StringInSlice(location.BusinessId, bid.BusinessIdsToStringSlice(connectedBusinessIds))
+            bubble, err := l.buildLocationSelectionBubble(location, connected)
+            if err != nil {
+                return nil, err
+            }
+            bubbles = append(bubbles, bubble)
+        }
+
+        carousel, err := line.JsonMapToLineFlexContainer(map[string]interface{}{
+            "type":     "carousel",
+            "contents": bubbles,
+        })
+        if err != nil {
+            return nil, err
+        }
+        messages = append(messages, linebot.NewFlexMessage("請選擇要連結的商家", carousel))
+    }
+
+    return messages, nil
+}
+
+func (l LineUtil) buildLocationSelectionBubble(location model2.DiscoveredLocation, connected bool) (map[string]interface{}, error) {
+    jsonMap, err := jsonUtil.JsonToMap(l.authJsons.LocationSelection)
+    if err != nil {
+        log.Debug("Error unmarshalling LocationSelection JSON: ", err)
+        return nil, err
+    }
+
+    // substitute business name
+    // hero -> contents[0] -> text
+    jsonMap["hero"].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["text"] = location.Title
+
+    status := "未連結"
+    description := "連結後，您會收到此商家的新評論通知，並可使用快速回覆及 AI 回覆。"
+    if connected {
+        status = "已連結"
+        description = "您正在接收此商家的新評論通知。取消連結後，將不再收到通知，其他使用者不受影響。"
+    }
+
+    // substitute status
+    // body -> contents[0] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["text"] = status
+
+    // substitute description
+    // body -> contents[1] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["text"] = description
+
+    // update buttons
+    // footer -> contents
+    footer := jsonMap["footer"].(map[string]interface{})
+    buttons := footer["contents"].([]interface{})
+    connectAction := buttons[0].(map[string]interface{})["action"].(map[string]interface{})
+    skipAction := buttons[1].(map[string]interface{})["action"].(map[string]interface{})
+    if connected {
+        connectAction["label"] = "取消連結"
+        connectAction["data"] = fmt.Sprintf("/Location/%s/Disconnect", location.BusinessId)
+        // connected locations cannot be skipped
+        footer["contents"] = buttons[:1]
+    } else {
+        connectAction["data"] = fmt.Sprintf("/Location/%s/Connect", location.BusinessId)
+        skipAction["data"] = fmt.Sprintf("/Location/%s/Skip", location.BusinessId)
+    }
+
+    return jsonMap, nil
+}
+
+// finalizeAuthUri sets the redirect URI and the signed state, which authHandler verifies before trusting the user ID in it
+func finalizeAuthUri(uri string, state string, authRedirectUrl string) (string, error) {
     parsedURL, err := url.Parse(uri)
     if err != nil {
         return "", err
@@ -970,7 +1357,7 @@ func finalizeAuthUri(uri string, userId string, authRedirectUrl string) (string,
     }
 
     queryParams.Set("redirect_uri", authRedirectUrl)
-    queryParams.Set("state", userId)
+    queryParams.Set("state", state)
     parsedURL.RawQuery = queryParams.Encode()
 
     return parsedURL.String(), nil
@@ -1044,6 +1431,72 @@ func (l LineUtil) buildReviewRepliedNotificationMessage(review model.Review, rep
     return line.JsonMapToLineFlexContainer(jsonMap)
 }
 
+func (l LineUtil) buildReviewChangedNotificationMessage(oldReview model.Review, newReview model.Review, businessName string, businessIdIndex int) (linebot.FlexContainer, error) {
+    jsonMap, err := jsonUtil.JsonToMap(l.notificationJsons.ReviewChanged)
+    if err != nil {
+        log.Debug("Error unmarshalling ReviewChanged JSON: ", err)
+        return nil, err
+    }
+
+    // substitute business name
+    // hero -> contents[0] -> text
+    jsonMap["hero"].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["text"] = businessName
+
+    // substitute reviewer name
+    // body -> contents[1] -> contents[0] -> contents[1] -> text
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["text"] = newReview.ReviewerName
+
+    // substitute old and new versions
+    // body -> contents[1] -> contents[2] for the old version, contents[4] for the new version
+    for contentIndex, review := range map[int]model.Review{2: oldReview, 4: newReview} {
+        versionContents := jsonMap["body"].
+        (map[string]interface{})["contents"].([]interface{})[1].
+        (map[string]interface{})["contents"].([]interface{})[contentIndex].
+        (map[string]interface{})["contents"].([]interface{})
+
+        starRatingJsonArr, err := review.NumberRating.FlexMessage(l.reviewMessageJsons.GoldStarIcon, l.reviewMessageJsons.GrayStarIcon)
+        if err != nil {
+            log.Error("Error creating starRating JSON: ", err)
+            return nil, err
+        }
+        versionContents[1].(map[string]interface{})["contents"] = starRatingJsonArr
+
+        reviewMessage := "（無文字內容）"
+        if !stringUtil.IsEmptyStringPtr(review.Review) {
+            reviewMessage = *review.Review
+        }
+        versionContents[2].(map[string]interface{})["text"] = reviewMessage
+    }
+
+    // substitute review last updated
+    // body -> contents[1] -> contents[5] -> contents[1] -> text
+    readableReviewTimestamp, err := timeUtil.UtcToReadableTwTimestamp(newReview.ReviewLastUpdated)
+    if err != nil {
+        log.Error("Error converting review timestamp to readable format: ", err)
+        return nil, err
+    }
+    jsonMap["body"].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["contents"].([]interface{})[5].
+    (map[string]interface{})["contents"].([]interface{})[1].
+    (map[string]interface{})["text"] = readableReviewTimestamp
+
+    // substitute button fillInText
+    // footer -> contents[0] -> action -> fillInText
+    jsonMap["footer"].
+    (map[string]interface{})["contents"].([]interface{})[0].
+    (map[string]interface{})["action"].
+    (map[string]interface{})["fillInText"] = fmt.Sprintf("@%d|%s ", businessIdIndex, newReview.ReviewId.String())
+
+    return line.JsonMapToLineFlexContainer(jsonMap)
+}
+
 func (l LineUtil) buildQuickReplySettingsUpdatedNotificationMessage(updaterName string, businessName string) (linebot.FlexContainer, error) {
     jsonMap, err := jsonUtil.JsonToMap(l.notificationJsons.QuickReplySettingsUpdated)
     if err != nil {
@@ -0,0 +1,283 @@
+package locationConnector
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/dbModel"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao/enum"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    "go.uber.org/zap"
+    "time"
+)
+
+// LocationConnector connects the Google business locations the user selects to the user, and disconnects them.
+// A connected location is a business whose userIds has the user and which is in the businessIds of the user.
+type LocationConnector struct {
+    businessDao          *ddbDao.BusinessDao
+    userDao              *ddbDao.UserDao
+    googleAccountDao     *dao.GoogleAccountDao
+    businessUserDao      *dao.BusinessUserDao
+    locationSelectionDao *dao.LocationSelectionDao
+    googleTokenDao       *dao.GoogleTokenDao
+    line                 *lineUtil.LineUtil
+    log                  *zap.SugaredLogger
+}
+
+func NewLocationConnector(
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    googleAccountDao *dao.GoogleAccountDao,
+    businessUserDao *dao.BusinessUserDao,
+    locationSelectionDao *dao.LocationSelectionDao,
+    googleTokenDao *dao.GoogleTokenDao,
+    line *lineUtil.LineUtil,
+    logger *zap.SugaredLogger) *LocationConnector {
+    return &LocationConnector{
+        businessDao:          businessDao,
+        userDao:              userDao,
+        googleAccountDao:     googleAccountDao,
+        businessUserDao:      businessUserDao,
+        locationSelectionDao: locationSelectionDao,
+        googleTokenDao:       googleTokenDao,
+        line:                 line,
+        log:                  logger,
+    }
+}
+
+// GetLocationSelection gets the locations discovered in the latest OAuth of the user.
+// Returns exception.LocationNotDiscoveredException if the user has not completed OAuth since locations are selected.
+func (c *LocationConnector) GetLocationSelection(userId string) (model2.LocationSelection, error) {
+    selection, err := c.locationSelectionDao.GetLocationSelection(context.Background(), userId)
+    if err != nil {
+        return model2.LocationSelection{}, err
+    }
+    if selection == nil {
+        return model2.LocationSelection{}, exception.NewLocationNotDiscoveredException(fmt.Sprintf("user '%s' has no discovered locations", userId))
+    }
+    return *selection, nil
+}
+
+// Connect connects the discovered location of the business to the user, creating the business and the user if they do not exist.
+// Returns exception.LocationNotDiscoveredException if the location was not discovered in the latest OAuth of the user.
+func (c *LocationConnector) Connect(userId string, businessId bid.BusinessId) (model.User, model.Business, error) {
+    selection, err := c.GetLocationSelection(userId)
+    if err != nil {
+        return model.User{}, model.Business{}, err
+    }
+    location, ok := selection.FindLocation(businessId)
+    if !ok {
+        return model.User{}, model.Business{}, exception.NewLocationNotDiscoveredException(fmt.Sprintf("location of business '%s' was not discovered for user '%s'", businessId, userId))
+    }
+
+    userPtr, err := c.userDao.GetUser(userId)
+    if err != nil {
+        c.log.Errorf("Error getting user '%s': %s", userId, err)
+        return model.User{}, model.Business{}, err
+    }
+    if userPtr == nil && selection.Google == nil {
+        return model.User{}, model.Business{}, fmt.Errorf("user '%s' does not exist and has no Google metadata to be created with", userId)
+    }
+
+    business, err := c.connectBusiness(userId, userPtr, businessId, location)
+    if err != nil {
+        return model.User{}, business, err
+    }
+
+    user, err := c.connectUser(userId, userPtr, businessId, selection)
+    if err != nil {
+        return user, business, err
+    }
+
+    c.log.Infof("Connected business '%s' to user '%s'", businessId, userId)
+    return user, business, nil
+}
+
+// connectBusiness creates the business or adds the user to the business, and records the Google business account the location is under
+func (c *LocationConnector) connectBusiness(userId string, user *model.User, businessId bid.BusinessId, location model2.DiscoveredLocation) (model.Business, error) {
+    businessPtr, err := c.businessDao.GetBusiness(businessId)
+    if err != nil {
+        c.log.Errorf("Error retrieving business %s: %s", businessId, err)
+        return model.Business{}, err
+    }
+
+    if businessPtr == nil {
+        c.log.Infof("Business '%s' does not exist. Creating new business.", businessId)
+
+        business := model.NewBusiness(businessId, location.Title, userId)
+
+        // TODO: [INT-91] Remove backfill logic once all legacy users have completed OAUTH
+        if user != nil {
+            backfillBusinessAttributesFromUser(&business, *user)
+        }
+
+        err = c.businessDao.CreateBusiness(business)
+        if err != nil {
+            c.log.Errorf("Error creating business object %v: %v", business, err)
+            return business, err
+        }
+    } else if !stringUtil.StringInSlice(userId, businessPtr.UserIds) {
+        c.log.Infof("Business '%s' is unaware of '%s' yet. Creating association.", businessId, userId)
+
+        userIdAppendAction, err := dbModel.NewAttributeAction(enum.ActionAppendStringSet, "userIds", []string{userId})
+        if err != nil {
+            c.log.Errorf("Error building user id append action: %s", err)
+            return *businessPtr, err
+        }
+        _, err = c.businessDao.UpdateAttributes(businessId, []dbModel.AttributeAction{userIdAppendAction}, userId)
+        if err != nil {
+            c.log.Errorf("Error adding user '%s' to business '%s': %s", userId, businessId, err)
+            return *businessPtr, err
+        }
+    }
+
+    // reviews of the business are listed under the account its location is under
+    return c.googleAccountDao.UpdateBusinessAccountId(businessId, location.AccountId, userId)
+}
+
+// connectUser creates the user with the business or adds the business to the user
+func (c *LocationConnector) connectUser(userId string, userPtr *model.User, businessId bid.BusinessId, selection model2.LocationSelection) (model.User, error) {
+    if userPtr == nil {
+        c.log.Infof("User '%s' does not exist. Creating new user.", userId)
+
+        lineGetUserResp, err := c.line.Base.GetUser(userId)
+        if err != nil {
+            c.log.Errorf("Error retrieving user %s from LINE: %s", userId, err)
+            return model.User{}, err
+        }
+
+        user, err := model.NewUser(userId, []bid.BusinessId{businessId}, lineGetUserResp, *selection.Google)
+        if err != nil {
+            c.log.Errorf("Error creating new user object: %s", err)
+            return model.User{}, err
+        }
+
+        err = c.userDao.CreateUser(user)
+        if err != nil {
+            c.log.Errorf("Error creating user %v: %v", user, err)
+            return user, err
+        }
+
+        user, err = c.googleAccountDao.UpdateUserAccountIds(userId, selection.BusinessAccountIds)
+        if err != nil {
+            return user, err
+        }
+
+        // the Google token is kept with the user, and refreshed by googleTokenHealthHandler, from now on
+        if !stringUtil.IsEmptyString(selection.Google.RefreshToken) {
+            err = c.googleTokenDao.MarkTokenStored(context.Background(), userId, time.Now())
+            if err != nil {
+                return user, err
+            }
+        }
+        err = c.locationSelectionDao.RemoveGoogle(context.Background(), userId)
+        if err != nil {
+            c.log.Warnf("Error removing Google metadata from location selection of user '%s'. Proceeding: %v", userId, err)
+        }
+        return user, nil
+    }
+
+    user := *userPtr
+    var actions []dbModel.AttributeAction
+    if !stringUtil.StringInSlice(businessId.String(), bid.BusinessIdsToStringSlice(user.BusinessIds)) {
+        action, err := dbModel.NewAttributeAction(enum.ActionAppendStringSet, "businessIds", []string{businessId.String()})
+        if err != nil {
+            c.log.Errorf("Error building businessIds append action: %s", err)
+            return user, err
+        }
+        actions = append(actions, action)
+    }
+    if stringUtil.IsEmptyString(user.ActiveBusinessId.String()) {
+        action, err := dbModel.NewAttributeAction(enum.ActionUpdate, "activeBusinessId", businessId.String())
+        if err != nil {
+            c.log.Errorf("Error building activeBusinessId update action: %s", err)
+            return user, err
+        }
+        actions = append(actions, action)
+    }
+    if len(actions) == 0 {
+        return user, nil
+    }
+
+    user, err := c.userDao.UpdateAttributes(userId, actions)
+    if err != nil {
+        c.log.Errorf("Error updating user %s: %s", userId, err)
+        return user, err
+    }
+    return user, nil
+}
+
+// Disconnect disconnects the business from the user. The business keeps its settings and reviews for its other users.
+// Returns exception.LastLocationDisconnectionException if it is the only business of the user, as a user without businesses is asked to complete OAuth again.
+func (c *LocationConnector) Disconnect(user model.User, businessId bid.BusinessId) (model.User, error) {
+    userId := user.UserId
+    var remainingBusinessIds []bid.BusinessId
+    for _, id := range user.BusinessIds {
+        if id != businessId {
+            remainingBusinessIds = append(remainingBusinessIds, id)
+        }
+    }
+    if len(remainingBusinessIds) == 0 {
+        return user, exception.NewLastLocationDisconnectionException(fmt.Sprintf("business '%s' is the only business of user '%s'", businessId, userId))
+    }
+
+    ctx := context.Background()
+    err := c.businessUserDao.RemoveUserFromBusiness(ctx, businessId, userId)
+    if err != nil {
+        return user, err
+    }
+    err = c.businessUserDao.RemoveBusinessFromUser(ctx, userId, businessId)
+    if err != nil {
+        return user, err
+    }
+
+    if user.ActiveBusinessId == businessId {
+        action, err := dbModel.NewAttributeAction(enum.ActionUpdate, "activeBusinessId", remainingBusinessIds[0].String())
+        if err != nil {
+            c.log.Errorf("Error building activeBusinessId update action: %s", err)
+            return user, err
+        }
+        _, err = c.userDao.UpdateAttributes(userId, []dbModel.AttributeAction{action})
+        if err != nil {
+            c.log.Errorf("Error updating active business of user %s: %s", userId, err)
+            return user, err
+        }
+    }
+
+    userPtr, err := c.userDao.GetUser(userId)
+    if err != nil {
+        c.log.Errorf("Error getting user '%s': %s", userId, err)
+        return user, err
+    }
+    if userPtr == nil {
+        return user, errors.New("user not found after disconnecting business")
+    }
+
+    c.log.Infof("Disconnected business '%s' from user '%s'", businessId, userId)
+    return *userPtr, nil
+}
+
+// TODO: [INT-91] Remove backfill logic once all users have been backfilled
+// backfillBusinessAttributesFromUser in-place backfills business attributes from user
+func backfillBusinessAttributesFromUser(business *model.Business, user model.User) {
+    business.BusinessDescription = user.BusinessDescription
+    business.Keywords = user.Keywords
+    if user.KeywordEnabled == nil {
+        business.KeywordEnabled = false
+    } else {
+        business.KeywordEnabled = *user.KeywordEnabled
+    }
+    business.QuickReplyMessage = user.QuickReplyMessage
+    if user.AutoQuickReplyEnabled == nil {
+        business.AutoQuickReplyEnabled = false
+    } else {
+        business.AutoQuickReplyEnabled = *user.AutoQuickReplyEnabled
+    }
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+    "math/rand"
+    "strings"
+    "time"
+    "unicode"
+)
+
+// AiReplyCacheTtl is how long AI replies are reused for identical reviews, so that replies follow recent replies of the business
+const AiReplyCacheTtl = 7 * 24 * time.Hour
+
+// AiReplyCachePoolSize is the maximum number of replies kept for identical reviews. The most recent replies are kept.
+const AiReplyCachePoolSize = 6
+
+// aiReplyCacheReviewerName stands in for the reviewer name in cached replies, which are reused for reviews by other reviewers
+const aiReplyCacheReviewerName = "{{reviewerName}}"
+
+// AiReplyCacheEntry is the AI replies generated for identical reviews of a business with the same prompt and settings
+type AiReplyCacheEntry struct {
+    BusinessId string `dynamodbav:"businessId"`
+    // CacheKey identifies the review text, rating, prompt template and reply settings the replies were generated for
+    CacheKey string `dynamodbav:"cacheKey"`
+    // Replies are the replies with the reviewer name replaced by a placeholder, oldest first
+    Replies []string `dynamodbav:"replies"`
+    // PromptTemplate is the ID of the prompt template the replies were generated with
+    PromptTemplate string    `dynamodbav:"promptTemplate"`
+    CreatedAt      time.Time `dynamodbav:"createdAt,unixtime"`
+    // Version is incremented on every write of the entry, so that concurrent writes do not overwrite each other's replies. Zero if not written yet.
+    Version int `dynamodbav:"version"`
+    // ExpireAt is the TTL attribute of the AiReplyCache table. It is not extended when replies are added.
+    ExpireAt time.Time `dynamodbav:"expireAt,unixtime"`
+}
+
+func NewAiReplyCacheEntry(businessId string, cacheKey string, promptTemplate string) AiReplyCacheEntry {
+    now := time.Now()
+    return AiReplyCacheEntry{
+        BusinessId:     businessId,
+        CacheKey:       cacheKey,
+        PromptTemplate: promptTemplate,
+        CreatedAt:      now,
+        ExpireAt:       now.Add(AiReplyCacheTtl),
+    }
+}
+
+// AddReplies adds the replies to the reviewer, dropping duplicates and the oldest replies beyond AiReplyCachePoolSize.
+// Replies still naming the reviewer after the reviewer name is replaced, e.g., by the given name only, are not added, as they would be sent to other reviewers.
+// Returns the number of replies not added for naming the reviewer.
+func (e *AiReplyCacheEntry) AddReplies(replies []string, reviewerName string) int {
+    reviewerName = strings.TrimSpace(reviewerName)
+    nameTokens := reviewerNameTokens(reviewerName)
+    namingReplies := 0
+    for _, reply := range replies {
+        // names of a single character are too likely to appear in other words to be replaced
+        if len([]rune(reviewerName)) >= 2 {
+            reply = strings.ReplaceAll(reply, reviewerName, aiReplyCacheReviewerName)
+        }
+        if containsAnyToken(strings.ReplaceAll(reply, aiReplyCacheReviewerName, ""), nameTokens) {
+            namingReplies++
+            continue
+        }
+        duplicate := false
+        for _, cached := range e.Replies {
+            if cached == reply {
+                duplicate = true
+                break
+            }
+        }
+        if !duplicate {
+            e.Replies = append(e.Replies, reply)
+        }
+    }
+    if len(e.Replies) > AiReplyCachePoolSize {
+        e.Replies = e.Replies[len(e.Replies)-AiReplyCachePoolSize:]
+    }
+    return namingReplies
+}
+
+// reviewerNameTokens returns the lowercased words of the reviewer name, and the two character parts of words in Chinese characters,
+// e.g., "王小明 Ming" to "王小明", "王小", "小明" and "ming"
+func reviewerNameTokens(reviewerName string) []string {
+    var tokens []string
+    words := strings.FieldsFunc(strings.ToLower(reviewerName), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+    })
+    for _, word := range words {
+        tokens = append(tokens, word)
+        runes := []rune(word)
+        if len(runes) < 3 || !unicode.Is(unicode.Han, runes[0]) {
+            continue
+        }
+        // Chinese given names are written without the family name
+        for i := 0; i+2 <= len(runes); i++ {
+            tokens = append(tokens, string(runes[i:i+2]))
+        }
+    }
+    return tokens
+}
+
+func containsAnyToken(text string, tokens []string) bool {
+    text = strings.ToLower(text)
+    for _, token := range tokens {
+        if strings.Contains(text, token) {
+            return true
+        }
+    }
+    return false
+}
+
+// PickReplies picks n of the replies at random, in random order, addressed to the reviewer.
+// Returns false unless there are more than n replies, so that repeated requests get different replies.
+func (e AiReplyCacheEntry) PickReplies(n int, reviewerName string) ([]string, bool) {
+    if len(e.Replies) <= n {
+        return nil, false
+    }
+    var replies []string
+    for _, i := range rand.Perm(len(e.Replies))[:n] {
+        replies = append(replies, strings.ReplaceAll(e.Replies[i], aiReplyCacheReviewerName, reviewerName))
+    }
+    return replies, true
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+    "github.com/google/uuid"
+    "time"
+)
+
+// AiReplyCandidateSetTtl is how long the candidates of an AI reply card can be published or revised
+const AiReplyCandidateSetTtl = 7 * 24 * time.Hour
+
+// AiReplyCandidateSet is the alternative AI replies to a review shown on one AI reply card.
+// Each card has its own set, so that the buttons of an earlier card still act on the replies shown on it.
+type AiReplyCandidateSet struct {
+    BusinessId     string `dynamodbav:"businessId"`
+    CandidateSetId string `dynamodbav:"candidateSetId"`
+    ReviewId       string `dynamodbav:"reviewId"`
+    // Replies are the candidates in the order shown to LINE users
+    Replies []string `dynamodbav:"replies"`
+    // PromptTemplate is the ID of the prompt template the replies were generated with
+    PromptTemplate string `dynamodbav:"promptTemplate,omitempty"`
+    // GeneratedBy is the user ID who requested the candidates
+    GeneratedBy string    `dynamodbav:"generatedBy"`
+    CreatedAt   time.Time `dynamodbav:"createdAt,unixtime"`
+    // ExpireAt is the TTL attribute of the AiReplyCandidateSet table
+    ExpireAt time.Time `dynamodbav:"expireAt,unixtime"`
+}
+
+func NewAiReplyCandidateSet(businessId string, reviewId string, replies []string, promptTemplate string, generatedBy string) AiReplyCandidateSet {
+    now := time.Now()
+    return AiReplyCandidateSet{
+        BusinessId:     businessId,
+        CandidateSetId: uuid.New().String(),
+        ReviewId:       reviewId,
+        Replies:        replies,
+        PromptTemplate: promptTemplate,
+        GeneratedBy:    generatedBy,
+        CreatedAt:      now,
+        ExpireAt:       now.Add(AiReplyCandidateSetTtl),
+    }
+}
+
+// Reply returns the candidate at the index. Returns false if the index is out of range.
+func (s AiReplyCandidateSet) Reply(index int) (string, bool) {
+    if index < 0 || index >= len(s.Replies) {
+        return "", false
+    }
+    return s.Replies[index], true
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "time"
+)
+
+// AiReplyDraftTtl is how long an AI auto reply draft can be approved
+const AiReplyDraftTtl = 72 * time.Hour
+
+// AiReplyDraft is an AI auto reply to a review awaiting approval of a user of the business
+type AiReplyDraft struct {
+    BusinessId string                  `dynamodbav:"businessId"`
+    ReviewId   string                  `dynamodbav:"reviewId"`
+    Reply      string                  `dynamodbav:"reply"`
+    Status     enum.AiReplyDraftStatus `dynamodbav:"status"`
+    // PromptTemplate is the ID of the prompt template the reply was generated with
+    PromptTemplate string `dynamodbav:"promptTemplate,omitempty"`
+    // ApprovedBy is the user ID who approved the draft
+    ApprovedBy  string    `dynamodbav:"approvedBy,omitempty"`
+    CreatedAt   time.Time `dynamodbav:"createdAt,unixtime"`
+    LastUpdated time.Time `dynamodbav:"lastUpdated,unixtime"`
+    // ExpireAt is when the draft can no longer be approved. It is also the TTL attribute of the AiReplyDraft table.
+    ExpireAt time.Time `dynamodbav:"expireAt,unixtime"`
+}
+
+func NewAiReplyDraft(businessId string, reviewId string, reply string, promptTemplate string) AiReplyDraft {
+    now := time.Now()
+    return AiReplyDraft{
+        BusinessId:     businessId,
+        ReviewId:       reviewId,
+        Reply:          reply,
+        Status:         enum.AiReplyDraftStatusPending,
+        PromptTemplate: promptTemplate,
+        CreatedAt:      now,
+        LastUpdated:    now,
+        ExpireAt:       now.Add(AiReplyDraftTtl),
+    }
+}
+
+// IsExpired returns whether the draft can no longer be approved
+func (d AiReplyDraft) IsExpired(now time.Time) bool {
+    return !now.Before(d.ExpireAt)
+}
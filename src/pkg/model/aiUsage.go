@@ -0,0 +1,110 @@
+package model
+
+import (
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "strings"
+    "time"
+)
+
+// AiUsageDailyTtl is how long the daily AI usage is kept. Monthly usage is kept indefinitely.
+const AiUsageDailyTtl = 90 * 24 * time.Hour
+
+// AiUsage is the AI usage of a business or a user in a month or a day, in Taiwan time
+type AiUsage struct {
+    // Period is the month, e.g., "2023-11", or the day, e.g., "2023-11-07". See MonthlyAiUsagePeriod and DailyAiUsagePeriod.
+    Period string `dynamodbav:"period"`
+    // Subject is the business or user the usage is attributed to. See BusinessAiUsageSubject and UserAiUsageSubject.
+    Subject          string  `dynamodbav:"subject"`
+    Requests         int     `dynamodbav:"requests"`
+    PromptTokens     int     `dynamodbav:"promptTokens"`
+    CompletionTokens int     `dynamodbav:"completionTokens"`
+    EstimatedCostUsd float64 `dynamodbav:"estimatedCostUsd"`
+    // ExpireAt is the TTL attribute of the AiUsage table. Only set on daily usage.
+    ExpireAt *time.Time `dynamodbav:"expireAt,unixtime,omitempty"`
+}
+
+// BusinessId returns the business ID of a business subject, or false if the subject is a user
+func (u AiUsage) BusinessId() (string, bool) {
+    return strings.CutPrefix(u.Subject, aiUsageBusinessSubjectPrefix)
+}
+
+// UserId returns the user ID of a user subject, or false if the subject is a business
+func (u AiUsage) UserId() (string, bool) {
+    return strings.CutPrefix(u.Subject, aiUsageUserSubjectPrefix)
+}
+
+const (
+    aiUsageBusinessSubjectPrefix = "business#"
+    aiUsageUserSubjectPrefix     = "user#"
+)
+
+func BusinessAiUsageSubject(businessId string) string {
+    return aiUsageBusinessSubjectPrefix + businessId
+}
+
+func UserAiUsageSubject(userId string) string {
+    return aiUsageUserSubjectPrefix + userId
+}
+
+// taiwanLocation is fixed rather than loaded, as Lambda does not ship the time zone database
+var taiwanLocation = time.FixedZone("Asia/Taipei", 8*60*60)
+
+// MonthlyAiUsagePeriod returns the month of t in Taiwan time, e.g., "2023-11"
+func MonthlyAiUsagePeriod(t time.Time) string {
+    return t.In(taiwanLocation).Format("2006-01")
+}
+
+// DailyAiUsagePeriod returns the day of t in Taiwan time, e.g., "2023-11-07"
+func DailyAiUsagePeriod(t time.Time) string {
+    return t.In(taiwanLocation).Format("2006-01-02")
+}
+
+// MonthlyAiQuotaUsd is the monthly estimated AI cost allowed for a business on the plan tier
+var MonthlyAiQuotaUsd = map[enum.PlanTier]float64{
+    enum.PlanTierFree:  0.5,
+    enum.PlanTierBasic: 5,
+    enum.PlanTierPro:   30,
+}
+
+// LLMPrice is the price of a model in USD per million tokens
+type LLMPrice struct {
+    PromptUsdPerMillionTokens     float64
+    CompletionUsdPerMillionTokens float64
+}
+
+// LLMPrices are the list prices of models by model name prefix. The longest matching prefix applies, e.g., "gpt-4o-mini" over "gpt-4o".
+var LLMPrices = map[string]LLMPrice{
+    "gpt-4o":            {PromptUsdPerMillionTokens: 2.5, CompletionUsdPerMillionTokens: 10},
+    "gpt-4o-mini":       {PromptUsdPerMillionTokens: 0.15, CompletionUsdPerMillionTokens: 0.6},
+    "gpt-4-turbo":       {PromptUsdPerMillionTokens: 10, CompletionUsdPerMillionTokens: 30},
+    "gpt-4":             {PromptUsdPerMillionTokens: 30, CompletionUsdPerMillionTokens: 60},
+    "gpt-3.5-turbo":     {PromptUsdPerMillionTokens: 0.5, CompletionUsdPerMillionTokens: 1.5},
+    "claude-3-5-sonnet": {PromptUsdPerMillionTokens: 3, CompletionUsdPerMillionTokens: 15},
+    "claude-3-haiku":    {PromptUsdPerMillionTokens: 0.25, CompletionUsdPerMillionTokens: 1.25},
+    "claude-3-opus":     {PromptUsdPerMillionTokens: 15, CompletionUsdPerMillionTokens: 75},
+    // self-hosted and fake models are free
+    "llama": {},
+    "fake":  {},
+}
+
+// unknownLLMPrice is charged for models without a list price, e.g., Azure OpenAI deployments, so that their usage is not underestimated
+var unknownLLMPrice = LLMPrices["gpt-4"]
+
+// EstimateLLMCostUsd estimates the cost of the tokens with the model. Returns false if the model has no list price.
+func EstimateLLMCostUsd(llmModel string, promptTokens int, completionTokens int) (float64, bool) {
+    price, found := unknownLLMPrice, false
+    matched := ""
+    for prefix, p := range LLMPrices {
+        if strings.HasPrefix(llmModel, prefix) && len(prefix) > len(matched) {
+            price, found, matched = p, true, prefix
+        }
+    }
+    cost := (float64(promptTokens)*price.PromptUsdPerMillionTokens + float64(completionTokens)*price.CompletionUsdPerMillionTokens) / 1e6
+    return cost, found
+}
+
+// FormatUsd formats the cost in USD, e.g., "$0.0123"
+func FormatUsd(cost float64) string {
+    return fmt.Sprintf("$%.4f", cost)
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+    "math"
+    "testing"
+)
+
+func TestEstimateLLMCostUsd(t *testing.T) {
+    tests := []struct {
+        name             string
+        llmModel         string
+        promptTokens     int
+        completionTokens int
+        wantCost         float64
+        wantFound        bool
+    }{
+        {
+            name:             "exact prefix",
+            llmModel:         "gpt-4o",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.0075,
+            wantFound:        true,
+        },
+        {
+            name:             "dated model version",
+            llmModel:         "gpt-4o-2024-08-06",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.0075,
+            wantFound:        true,
+        },
+        {
+            name:             "longest prefix over shorter prefixes",
+            llmModel:         "gpt-4o-mini-2024-07-18",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.00045,
+            wantFound:        true,
+        },
+        {
+            name:             "longest prefix of gpt-4 family",
+            llmModel:         "gpt-4-turbo-preview",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.025,
+            wantFound:        true,
+        },
+        {
+            name:             "shortest prefix only",
+            llmModel:         "gpt-4-0613",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.06,
+            wantFound:        true,
+        },
+        {
+            name:             "Anthropic model",
+            llmModel:         "claude-3-haiku-20240307",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.000875,
+            wantFound:        true,
+        },
+        {
+            name:             "free model",
+            llmModel:         "llama3",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0,
+            wantFound:        true,
+        },
+        {
+            name:             "model without list price is charged the unknown price",
+            llmModel:         "my-azure-deployment",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.06,
+            wantFound:        false,
+        },
+        {
+            name:             "prefix must match from the start",
+            llmModel:         "azure-gpt-4o-mini",
+            promptTokens:     1000,
+            completionTokens: 500,
+            wantCost:         0.06,
+            wantFound:        false,
+        },
+        {
+            name:      "no tokens",
+            llmModel:  "gpt-4o",
+            wantCost:  0,
+            wantFound: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cost, found := EstimateLLMCostUsd(tt.llmModel, tt.promptTokens, tt.completionTokens)
+            if found != tt.wantFound {
+                t.Errorf("EstimateLLMCostUsd() found = %v, want %v", found, tt.wantFound)
+            }
+            if math.Abs(cost-tt.wantCost) > 1e-12 {
+                t.Errorf("EstimateLLMCostUsd() cost = %v, want %v", cost, tt.wantCost)
+            }
+        })
+    }
+}
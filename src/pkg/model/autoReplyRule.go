@@ -0,0 +1,229 @@
+package model
+
+import (
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "strconv"
+    "strings"
+)
+
+// ReviewerNamePlaceholder in a reply template is replaced by the name of the reviewer
+const ReviewerNamePlaceholder = "{評論人}"
+
+// AutoReplyRule decides how a new review of the business is auto replied.
+// Rules of a business are evaluated in order and the first enabled rule matching the review applies.
+type AutoReplyRule struct {
+    Enabled bool `dynamodbav:"enabled" json:"enabled"`
+    // MinRating and MaxRating are the inclusive star range of matched reviews
+    MinRating     int                      `dynamodbav:"minRating" json:"minRating"`
+    MaxRating     int                      `dynamodbav:"maxRating" json:"maxRating"`
+    TextCondition enum.ReviewTextCondition `dynamodbav:"textCondition" json:"textCondition"`
+    // Keywords matches reviews containing any of the keywords, case-insensitive. Empty matches all reviews.
+    Keywords []string `dynamodbav:"keywords,omitempty" json:"keywords,omitempty"`
+    // Language matches reviews in the language, as ISO 639-1 code. Empty matches all reviews.
+    Language string               `dynamodbav:"language,omitempty" json:"language,omitempty"`
+    Action   enum.AutoReplyAction `dynamodbav:"action" json:"action"`
+    // Template is the reply of AutoReplyActionTemplate. Empty replies with the quick reply message of the business.
+    Template string `dynamodbav:"template,omitempty" json:"template,omitempty"`
+    // Legacy marks the rule derived from the auto quick reply toggle of a business without stored rules. Not stored.
+    Legacy bool `dynamodbav:"-" json:"-"`
+}
+
+// NewLegacyAutoReplyRule returns the rule equivalent to the auto quick reply toggle, i.e., reply the quick reply message to 5-star reviews without text
+func NewLegacyAutoReplyRule(autoQuickReplyEnabled bool) AutoReplyRule {
+    return AutoReplyRule{
+        Enabled:       autoQuickReplyEnabled,
+        MinRating:     5,
+        MaxRating:     5,
+        TextCondition: enum.ReviewTextConditionNoText,
+        Action:        enum.AutoReplyActionTemplate,
+        Legacy:        true,
+    }
+}
+
+func (r AutoReplyRule) Validate() error {
+    if r.MinRating < 1 || r.MaxRating > 5 || r.MinRating > r.MaxRating {
+        return fmt.Errorf("invalid star range %d-%d", r.MinRating, r.MaxRating)
+    }
+    if r.Action != enum.AutoReplyActionTemplate && r.Template != "" {
+        return fmt.Errorf("template is only allowed for action %s", enum.AutoReplyActionTemplate)
+    }
+    return nil
+}
+
+// Matches returns whether the rule applies to a review with the text, star rating and language
+func (r AutoReplyRule) Matches(text string, rating int, language string) bool {
+    if !r.Enabled || rating < r.MinRating || rating > r.MaxRating {
+        return false
+    }
+
+    hasText := strings.TrimSpace(text) != ""
+    switch r.TextCondition {
+    case enum.ReviewTextConditionHasText:
+        if !hasText {
+            return false
+        }
+    case enum.ReviewTextConditionNoText:
+        if hasText {
+            return false
+        }
+    }
+
+    if r.Language != "" && r.Language != language {
+        return false
+    }
+
+    if len(r.Keywords) == 0 {
+        return true
+    }
+    lowerText := strings.ToLower(text)
+    for _, keyword := range r.Keywords {
+        if strings.Contains(lowerText, strings.ToLower(keyword)) {
+            return true
+        }
+    }
+    return false
+}
+
+// RequiresQuickReplyMessage returns whether the rule replies with the quick reply message of the business
+func (r AutoReplyRule) RequiresQuickReplyMessage() bool {
+    return r.Action == enum.AutoReplyActionTemplate && r.Template == ""
+}
+
+// Reply returns the reply of AutoReplyActionTemplate to the reviewer, falling back to the quick reply message if the rule has no template
+func (r AutoReplyRule) Reply(reviewerName string, quickReplyMessage string) string {
+    template := r.Template
+    if template == "" {
+        template = quickReplyMessage
+    }
+    return strings.ReplaceAll(template, ReviewerNamePlaceholder, reviewerName)
+}
+
+// Describe summarizes the rule for LINE users, e.g., "4-5 星・有文字・關鍵字：好吃、服務 → AI 回覆"
+func (r AutoReplyRule) Describe() string {
+    conditions := []string{fmt.Sprintf("%d 星", r.MinRating)}
+    if r.MinRating != r.MaxRating {
+        conditions[0] = fmt.Sprintf("%d-%d 星", r.MinRating, r.MaxRating)
+    }
+    if r.TextCondition != enum.ReviewTextConditionAny {
+        conditions = append(conditions, r.TextCondition.DisplayName())
+    }
+    if len(r.Keywords) > 0 {
+        conditions = append(conditions, "關鍵字："+strings.Join(r.Keywords, "、"))
+    }
+    if r.Language != "" {
+        conditions = append(conditions, "語言："+r.Language)
+    }
+
+    action := r.Action.DisplayName()
+    if r.Action == enum.AutoReplyActionTemplate && r.Template != "" {
+        action = fmt.Sprintf("回覆「%s」", r.Template)
+    }
+    return strings.Join(conditions, "・") + " → " + action
+}
+
+// MatchAutoReplyRule returns the first rule matching the review
+func MatchAutoReplyRule(rules []AutoReplyRule, text string, rating int, language string) (AutoReplyRule, bool) {
+    for _, rule := range rules {
+        if rule.Matches(text, rating, language) {
+            return rule, true
+        }
+    }
+    return AutoReplyRule{}, false
+}
+
+// ParseAutoReplyRule parses a rule from the space separated conditions and action of the auto reply rule command, e.g., "4-5 有文字 關鍵字:好吃,服務 語言:zh AI"
+//   - star range: "5" or "4-5". Defaults to all ratings.
+//   - text condition: "有文字" or "無文字". Defaults to any.
+//   - keywords: "關鍵字:" followed by comma separated keywords
+//   - language: "語言:" followed by ISO 639-1 code, e.g., "zh", "en"
+//   - action: "快速回覆", "AI", "僅通知", or "回覆:" followed by the reply template, which takes the rest of the text
+//
+// The parsed rule is enabled.
+func ParseAutoReplyRule(spec string) (AutoReplyRule, error) {
+    rule := AutoReplyRule{
+        Enabled:   true,
+        MinRating: 1,
+        MaxRating: 5,
+    }
+
+    hasAction := false
+    rest := strings.TrimSpace(spec)
+    for rest != "" {
+        token, remaining, _ := strings.Cut(rest, " ")
+        rest = strings.TrimSpace(remaining)
+        if token == "" {
+            continue
+        }
+
+        if hasAction {
+            return AutoReplyRule{}, fmt.Errorf("unexpected '%s' after action", token)
+        }
+
+        name, value, hasValue := cutRuleToken(token)
+        switch {
+        case hasValue && (name == "關鍵字" || name == "keywords"):
+            for _, keyword := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '，' || r == '、' }) {
+                rule.Keywords = append(rule.Keywords, strings.TrimSpace(keyword))
+            }
+        case hasValue && (name == "語言" || name == "lang"):
+            rule.Language = strings.ToLower(value)
+        case hasValue && (name == "回覆" || name == "reply"):
+            // the template takes the rest of the text
+            rule.Action = enum.AutoReplyActionTemplate
+            rule.Template = strings.TrimSpace(value + " " + rest)
+            if rule.Template == "" {
+                return AutoReplyRule{}, errors.New("empty reply template")
+            }
+            rest = ""
+            hasAction = true
+        case token == "有文字" || token == "text":
+            rule.TextCondition = enum.ReviewTextConditionHasText
+        case token == "無文字" || token == "notext":
+            rule.TextCondition = enum.ReviewTextConditionNoText
+        case token == "快速回覆" || token == "quickReply":
+            rule.Action = enum.AutoReplyActionTemplate
+            hasAction = true
+        case strings.EqualFold(token, "AI"):
+            rule.Action = enum.AutoReplyActionAiReply
+            hasAction = true
+        case token == "僅通知" || token == "notify":
+            rule.Action = enum.AutoReplyActionNotifyOnly
+            hasAction = true
+        default:
+            minRating, maxRating, err := parseStarRange(token)
+            if err != nil {
+                return AutoReplyRule{}, fmt.Errorf("unknown condition '%s'", token)
+            }
+            rule.MinRating, rule.MaxRating = minRating, maxRating
+        }
+    }
+
+    if !hasAction {
+        return AutoReplyRule{}, errors.New("missing action")
+    }
+    return rule, rule.Validate()
+}
+
+// cutRuleToken splits a "name:value" token. Full-width colons are accepted.
+func cutRuleToken(token string) (string, string, bool) {
+    token = strings.Replace(token, "：", ":", 1)
+    return strings.Cut(token, ":")
+}
+
+func parseStarRange(token string) (int, int, error) {
+    minStr, maxStr, isRange := strings.Cut(token, "-")
+    minRating, err := strconv.Atoi(minStr)
+    if err != nil {
+        return 0, 0, err
+    }
+    if !isRange {
+        return minRating, minRating, nil
+    }
+    maxRating, err := strconv.Atoi(maxStr)
+    if err != nil {
+        return 0, 0, err
+    }
+    return minRating, maxRating, nil
+}
@@ -0,0 +1,287 @@
+package model
+
+import (
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "reflect"
+    "testing"
+)
+
+func TestAutoReplyRuleMatches(t *testing.T) {
+    tests := []struct {
+        name     string
+        rule     AutoReplyRule
+        text     string
+        rating   int
+        language string
+        want     bool
+    }{
+        {
+            name:   "within star range",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 4, MaxRating: 5},
+            text:   "Great",
+            rating: 4,
+            want:   true,
+        },
+        {
+            name:   "below star range",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 4, MaxRating: 5},
+            text:   "Great",
+            rating: 3,
+            want:   false,
+        },
+        {
+            name:   "disabled",
+            rule:   AutoReplyRule{Enabled: false, MinRating: 1, MaxRating: 5},
+            rating: 5,
+            want:   false,
+        },
+        {
+            name:   "has text condition with blank text",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, TextCondition: enum.ReviewTextConditionHasText},
+            text:   "  ",
+            rating: 5,
+            want:   false,
+        },
+        {
+            name:   "no text condition with text",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, TextCondition: enum.ReviewTextConditionNoText},
+            text:   "Great",
+            rating: 5,
+            want:   false,
+        },
+        {
+            name:   "no text condition without text",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, TextCondition: enum.ReviewTextConditionNoText},
+            rating: 5,
+            want:   true,
+        },
+        {
+            name:   "any keyword matches case-insensitively",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, Keywords: []string{"服務", "friendly"}},
+            text:   "Very FRIENDLY staff",
+            rating: 5,
+            want:   true,
+        },
+        {
+            name:   "no keyword matches",
+            rule:   AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, Keywords: []string{"服務", "friendly"}},
+            text:   "好吃",
+            rating: 5,
+            want:   false,
+        },
+        {
+            name:     "language matches",
+            rule:     AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, Language: "en"},
+            text:     "Great",
+            rating:   5,
+            language: "en",
+            want:     true,
+        },
+        {
+            name:     "language does not match",
+            rule:     AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, Language: "en"},
+            text:     "很棒",
+            rating:   5,
+            language: "zh",
+            want:     false,
+        },
+        {
+            name:   "legacy rule matches 5-star review without text",
+            rule:   NewLegacyAutoReplyRule(true),
+            rating: 5,
+            want:   true,
+        },
+        {
+            name:   "legacy rule does not match review with text",
+            rule:   NewLegacyAutoReplyRule(true),
+            text:   "Great",
+            rating: 5,
+            want:   false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.rule.Matches(tt.text, tt.rating, tt.language); got != tt.want {
+                t.Errorf("Matches(%q, %d, %q) = %v, want %v", tt.text, tt.rating, tt.language, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestMatchAutoReplyRule(t *testing.T) {
+    rules := []AutoReplyRule{
+        {Enabled: false, MinRating: 1, MaxRating: 5, Action: enum.AutoReplyActionNotifyOnly},
+        {Enabled: true, MinRating: 1, MaxRating: 2, Action: enum.AutoReplyActionNotifyOnly},
+        {Enabled: true, MinRating: 4, MaxRating: 5, Keywords: []string{"好吃"}, Action: enum.AutoReplyActionTemplate, Template: "謝謝{評論人}"},
+        {Enabled: true, MinRating: 4, MaxRating: 5, Action: enum.AutoReplyActionAiReply},
+    }
+
+    tests := []struct {
+        name       string
+        text       string
+        rating     int
+        wantMatch  bool
+        wantAction enum.AutoReplyAction
+    }{
+        {
+            name:       "skips disabled rule",
+            text:       "不好吃",
+            rating:     1,
+            wantMatch:  true,
+            wantAction: enum.AutoReplyActionNotifyOnly,
+        },
+        {
+            name:       "first matching rule applies",
+            text:       "很好吃",
+            rating:     5,
+            wantMatch:  true,
+            wantAction: enum.AutoReplyActionTemplate,
+        },
+        {
+            name:       "falls through to later rule",
+            text:       "服務很好",
+            rating:     5,
+            wantMatch:  true,
+            wantAction: enum.AutoReplyActionAiReply,
+        },
+        {
+            name:      "no rule matches",
+            text:      "還可以",
+            rating:    3,
+            wantMatch: false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rule, ok := MatchAutoReplyRule(rules, tt.text, tt.rating, "zh")
+            if ok != tt.wantMatch {
+                t.Fatalf("MatchAutoReplyRule() ok = %v, want %v", ok, tt.wantMatch)
+            }
+            if ok && rule.Action != tt.wantAction {
+                t.Errorf("MatchAutoReplyRule() action = %s, want %s", rule.Action, tt.wantAction)
+            }
+        })
+    }
+}
+
+func TestParseAutoReplyRule(t *testing.T) {
+    tests := []struct {
+        name    string
+        spec    string
+        want    AutoReplyRule
+        wantErr bool
+    }{
+        {
+            name: "all conditions",
+            spec: "4-5 有文字 關鍵字:好吃,服務 語言:ZH AI",
+            want: AutoReplyRule{
+                Enabled:       true,
+                MinRating:     4,
+                MaxRating:     5,
+                TextCondition: enum.ReviewTextConditionHasText,
+                Keywords:      []string{"好吃", "服務"},
+                Language:      "zh",
+                Action:        enum.AutoReplyActionAiReply,
+            },
+        },
+        {
+            name: "action only matches all ratings",
+            spec: "僅通知",
+            want: AutoReplyRule{Enabled: true, MinRating: 1, MaxRating: 5, Action: enum.AutoReplyActionNotifyOnly},
+        },
+        {
+            name: "reply template takes the rest of the text",
+            spec: "5 無文字 回覆：謝謝 {評論人} 的支持",
+            want: AutoReplyRule{
+                Enabled:       true,
+                MinRating:     5,
+                MaxRating:     5,
+                TextCondition: enum.ReviewTextConditionNoText,
+                Action:        enum.AutoReplyActionTemplate,
+                Template:      "謝謝 {評論人} 的支持",
+            },
+        },
+        {
+            name: "full-width keyword separators",
+            spec: "keywords:好吃，服務、環境 quickReply",
+            want: AutoReplyRule{
+                Enabled:   true,
+                MinRating: 1,
+                MaxRating: 5,
+                Keywords:  []string{"好吃", "服務", "環境"},
+                Action:    enum.AutoReplyActionTemplate,
+            },
+        },
+        {
+            name:    "missing action",
+            spec:    "4-5 有文字",
+            wantErr: true,
+        },
+        {
+            name:    "condition after action",
+            spec:    "AI 5",
+            wantErr: true,
+        },
+        {
+            name:    "empty reply template",
+            spec:    "回覆:",
+            wantErr: true,
+        },
+        {
+            name:    "invalid star range",
+            spec:    "5-4 AI",
+            wantErr: true,
+        },
+        {
+            name:    "star out of range",
+            spec:    "0-5 AI",
+            wantErr: true,
+        },
+        {
+            name:    "unknown condition",
+            spec:    "好評 AI",
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rule, err := ParseAutoReplyRule(tt.spec)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("ParseAutoReplyRule(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+            }
+            if err == nil && !reflect.DeepEqual(rule, tt.want) {
+                t.Errorf("ParseAutoReplyRule(%q) = %+v, want %+v", tt.spec, rule, tt.want)
+            }
+        })
+    }
+}
+
+func TestAutoReplyRuleReply(t *testing.T) {
+    tests := []struct {
+        name string
+        rule AutoReplyRule
+        want string
+    }{
+        {
+            name: "template with reviewer name",
+            rule: AutoReplyRule{Action: enum.AutoReplyActionTemplate, Template: "謝謝{評論人}！"},
+            want: "謝謝Amy！",
+        },
+        {
+            name: "falls back to quick reply message",
+            rule: AutoReplyRule{Action: enum.AutoReplyActionTemplate},
+            want: "感謝您的評論",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.rule.Reply("Amy", "感謝您的評論"); got != tt.want {
+                t.Errorf("Reply() = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
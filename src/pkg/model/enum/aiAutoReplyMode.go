@@ -0,0 +1,49 @@
+package enum
+
+import "fmt"
+
+// AiAutoReplyMode decides whether a business auto replies to new reviews with AI, and whether the AI replies need approval
+type AiAutoReplyMode int
+
+const (
+    // AiAutoReplyModeOff only replies with AI to reviews matching an auto reply rule with AutoReplyActionAiReply, and publishes the replies
+    AiAutoReplyModeOff AiAutoReplyMode = iota
+    // AiAutoReplyModePublish also replies with AI to reviews matching no auto reply rule, and publishes the replies
+    AiAutoReplyModePublish
+    // AiAutoReplyModeApproval also replies with AI to reviews matching no auto reply rule, and sends the replies as drafts to be approved in LINE
+    AiAutoReplyModeApproval
+)
+
+// AiAutoReplyModes lists the modes in the order shown to LINE users
+var AiAutoReplyModes = []AiAutoReplyMode{AiAutoReplyModeOff, AiAutoReplyModePublish, AiAutoReplyModeApproval}
+
+func (s AiAutoReplyMode) String() string {
+    return []string{
+        "Off",
+        "Publish",
+        "Approval",
+    }[s]
+}
+
+// DisplayName is the name of the mode shown to LINE users
+func (s AiAutoReplyMode) DisplayName() string {
+    return []string{
+        "關閉",
+        "自動發布",
+        "審核後發布",
+    }[s]
+}
+
+// IsOn returns whether reviews matching no auto reply rule are replied with AI
+func (s AiAutoReplyMode) IsOn() bool {
+    return s != AiAutoReplyModeOff
+}
+
+func ToAiAutoReplyMode(s string) (AiAutoReplyMode, error) {
+    for _, mode := range AiAutoReplyModes {
+        if mode.String() == s {
+            return mode, nil
+        }
+    }
+    return 0, fmt.Errorf("invalid AI auto reply mode: '%s'", s)
+}
@@ -0,0 +1,17 @@
+package enum
+
+// AiReplyDraftStatus is the status of an AI auto reply awaiting approval
+type AiReplyDraftStatus int
+
+const (
+    AiReplyDraftStatusPending AiReplyDraftStatus = iota
+    // AiReplyDraftStatusApproved is set before the draft is published, so that it is published at most once
+    AiReplyDraftStatusApproved
+)
+
+func (s AiReplyDraftStatus) String() string {
+    return []string{
+        "Pending",
+        "Approved",
+    }[s]
+}
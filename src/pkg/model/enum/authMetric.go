@@ -0,0 +1,15 @@
+package enum
+
+type AuthMetric int
+
+const (
+    MetricOAuthStateRejected AuthMetric = iota
+    MetricGoogleTokenRevoked
+)
+
+func (s AuthMetric) String() string {
+    return []string{
+        "OAuthStateRejected",
+        "GoogleTokenRevoked",
+    }[s]
+}
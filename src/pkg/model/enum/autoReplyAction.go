@@ -0,0 +1,45 @@
+package enum
+
+import "fmt"
+
+// AutoReplyAction is what an auto reply rule does to a matched review
+type AutoReplyAction int
+
+const (
+    // AutoReplyActionTemplate replies with the template of the rule, or the quick reply message of the business if the rule has no template
+    AutoReplyActionTemplate AutoReplyAction = iota
+    // AutoReplyActionAiReply replies with an AI generated reply
+    AutoReplyActionAiReply
+    // AutoReplyActionNotifyOnly only forwards the review to the users of the business
+    AutoReplyActionNotifyOnly
+)
+
+func (s AutoReplyAction) String() string {
+    return []string{
+        "Template",
+        "AiReply",
+        "NotifyOnly",
+    }[s]
+}
+
+// DisplayName is the name of the action shown to LINE users
+func (s AutoReplyAction) DisplayName() string {
+    return []string{
+        "快速回覆",
+        "AI 回覆",
+        "僅通知",
+    }[s]
+}
+
+func ToAutoReplyAction(s string) (AutoReplyAction, error) {
+    switch s {
+    case AutoReplyActionTemplate.String():
+        return AutoReplyActionTemplate, nil
+    case AutoReplyActionAiReply.String():
+        return AutoReplyActionAiReply, nil
+    case AutoReplyActionNotifyOnly.String():
+        return AutoReplyActionNotifyOnly, nil
+    default:
+        return 0, fmt.Errorf("invalid auto reply action: '%s'", s)
+    }
+}
@@ -6,6 +6,11 @@ const (
     HandlerNameLineEventsHandler HandlerName = iota
     HandlerNameNewReviewEventHandler
     HandlerNameAuthHandler
+    HandlerNameGoogleNotificationHandler
+    HandlerNameReviewBackfillHandler
+    HandlerNameAiUsageReportHandler
+    HandlerNameUnfollowedUserPurgeHandler
+    HandlerNameGoogleTokenHealthHandler
 )
 
 func (s HandlerName) String() string {
@@ -13,5 +18,10 @@ func (s HandlerName) String() string {
         "lineEventsHandler",
         "newReviewEventHandler",
         "authHandler",
+        "googleNotificationHandler",
+        "reviewBackfillHandler",
+        "aiUsageReportHandler",
+        "unfollowedUserPurgeHandler",
+        "googleTokenHealthHandler",
     }[s]
 }
@@ -0,0 +1,25 @@
+package enum
+
+import "github.com/line/line-bot-sdk-go/v7/linebot"
+
+type LineEventMetric int
+
+const (
+    MetricLineEventSucceeded LineEventMetric = iota
+    MetricLineEventFailed
+    MetricLineEventSkipped
+)
+
+func (s LineEventMetric) String() string {
+    return []string{
+        "Succeeded",
+        "Failed",
+        "Skipped",
+    }[s]
+}
+
+// MetricName returns the metric name of the LINE event metric for the given event type
+// e.g., "postback" + MetricLineEventFailed -> "postbackEventFailed"
+func (s LineEventMetric) MetricName(eventType linebot.EventType) string {
+    return string(eventType) + "Event" + s.String()
+}
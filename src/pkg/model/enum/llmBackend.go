@@ -0,0 +1,43 @@
+package enum
+
+import "fmt"
+
+// LLMBackend is the LLM service generating AI replies
+type LLMBackend int
+
+const (
+    LLMBackendOpenAi LLMBackend = iota
+    LLMBackendAzureOpenAi
+    LLMBackendAnthropic
+    // LLMBackendLocal is a local OpenAI-compatible endpoint, e.g., Ollama
+    LLMBackendLocal
+    // LLMBackendFake returns deterministic replies without calling any service
+    LLMBackendFake
+)
+
+func (s LLMBackend) String() string {
+    return []string{
+        "OpenAI",
+        "AzureOpenAI",
+        "Anthropic",
+        "Local",
+        "Fake",
+    }[s]
+}
+
+func ToLLMBackend(s string) (LLMBackend, error) {
+    switch s {
+    case LLMBackendOpenAi.String():
+        return LLMBackendOpenAi, nil
+    case LLMBackendAzureOpenAi.String():
+        return LLMBackendAzureOpenAi, nil
+    case LLMBackendAnthropic.String():
+        return LLMBackendAnthropic, nil
+    case LLMBackendLocal.String():
+        return LLMBackendLocal, nil
+    case LLMBackendFake.String():
+        return LLMBackendFake, nil
+    default:
+        return 0, fmt.Errorf("invalid LLM backend: '%s'", s)
+    }
+}
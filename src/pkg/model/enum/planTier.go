@@ -0,0 +1,40 @@
+package enum
+
+import "fmt"
+
+// PlanTier is the subscription plan of a business, which decides its monthly AI usage quota
+type PlanTier int
+
+const (
+    PlanTierFree PlanTier = iota
+    PlanTierBasic
+    PlanTierPro
+)
+
+var PlanTiers = []PlanTier{PlanTierFree, PlanTierBasic, PlanTierPro}
+
+func (s PlanTier) String() string {
+    return []string{
+        "Free",
+        "Basic",
+        "Pro",
+    }[s]
+}
+
+// DisplayName is the name of the plan shown to LINE users
+func (s PlanTier) DisplayName() string {
+    return []string{
+        "免費版",
+        "基本版",
+        "專業版",
+    }[s]
+}
+
+func ToPlanTier(s string) (PlanTier, error) {
+    for _, tier := range PlanTiers {
+        if tier.String() == s {
+            return tier, nil
+        }
+    }
+    return 0, fmt.Errorf("invalid plan tier: '%s'", s)
+}
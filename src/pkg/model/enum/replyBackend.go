@@ -0,0 +1,32 @@
+package enum
+
+import "fmt"
+
+type ReplyBackend int
+
+const (
+    ReplyBackendGoogle ReplyBackend = iota
+    ReplyBackendZapier
+    ReplyBackendRecorder
+)
+
+func (s ReplyBackend) String() string {
+    return []string{
+        "Google",
+        "Zapier",
+        "Recorder",
+    }[s]
+}
+
+func ToReplyBackend(s string) (ReplyBackend, error) {
+    switch s {
+    case ReplyBackendGoogle.String():
+        return ReplyBackendGoogle, nil
+    case ReplyBackendZapier.String():
+        return ReplyBackendZapier, nil
+    case ReplyBackendRecorder.String():
+        return ReplyBackendRecorder, nil
+    default:
+        return 0, fmt.Errorf("invalid reply backend: '%s'", s)
+    }
+}
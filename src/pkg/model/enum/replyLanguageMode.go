@@ -0,0 +1,44 @@
+package enum
+
+import "fmt"
+
+// ReplyLanguageMode decides the language AI replies to reviews of a business are written in
+type ReplyLanguageMode int
+
+const (
+    // ReplyLanguageModeChinese replies in Taiwanese mandarin regardless of the language of the review
+    ReplyLanguageModeChinese ReplyLanguageMode = iota
+    // ReplyLanguageModeReviewer replies in the language of the review
+    ReplyLanguageModeReviewer
+    // ReplyLanguageModeBilingual replies in the language of the review followed by Taiwanese mandarin if the review is not in Chinese
+    ReplyLanguageModeBilingual
+)
+
+// ReplyLanguageModes lists the modes in the order shown to LINE users
+var ReplyLanguageModes = []ReplyLanguageMode{ReplyLanguageModeChinese, ReplyLanguageModeReviewer, ReplyLanguageModeBilingual}
+
+func (s ReplyLanguageMode) String() string {
+    return []string{
+        "Chinese",
+        "Reviewer",
+        "Bilingual",
+    }[s]
+}
+
+// DisplayName is the name of the mode shown to LINE users
+func (s ReplyLanguageMode) DisplayName() string {
+    return []string{
+        "中文",
+        "評論者的語言",
+        "評論者的語言＋中文",
+    }[s]
+}
+
+func ToReplyLanguageMode(s string) (ReplyLanguageMode, error) {
+    for _, mode := range ReplyLanguageModes {
+        if mode.String() == s {
+            return mode, nil
+        }
+    }
+    return 0, fmt.Errorf("invalid reply language mode: '%s'", s)
+}
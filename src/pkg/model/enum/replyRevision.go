@@ -0,0 +1,41 @@
+package enum
+
+import "fmt"
+
+// ReplyRevision is the instruction for AI to revise a generated reply
+type ReplyRevision int
+
+const (
+    ReplyRevisionShorter ReplyRevision = iota
+    ReplyRevisionWarmer
+    ReplyRevisionMoreFormal
+)
+
+// ReplyRevisions lists the revisions in the order shown to LINE users
+var ReplyRevisions = []ReplyRevision{ReplyRevisionShorter, ReplyRevisionWarmer, ReplyRevisionMoreFormal}
+
+func (s ReplyRevision) String() string {
+    return []string{
+        "Shorter",
+        "Warmer",
+        "MoreFormal",
+    }[s]
+}
+
+// DisplayName is the name of the revision shown to LINE users
+func (s ReplyRevision) DisplayName() string {
+    return []string{
+        "更簡短",
+        "更親切",
+        "更正式",
+    }[s]
+}
+
+func ToReplyRevision(s string) (ReplyRevision, error) {
+    for _, revision := range ReplyRevisions {
+        if revision.String() == s {
+            return revision, nil
+        }
+    }
+    return 0, fmt.Errorf("invalid reply revision: '%s'", s)
+}
@@ -0,0 +1,19 @@
+package enum
+
+// ReviewEventStep is a step of processing a new review event, in order
+type ReviewEventStep int
+
+const (
+    ReviewEventStepStored ReviewEventStep = iota
+    ReviewEventStepNotified
+    ReviewEventStepAutoReplied
+)
+
+// String returns the attribute name of the step in the ReviewEvent table
+func (s ReviewEventStep) String() string {
+    return []string{
+        "stored",
+        "notified",
+        "autoReplied",
+    }[s]
+}
@@ -0,0 +1,22 @@
+package enum
+
+type ReviewIntakeOutcome int
+
+const (
+    ReviewIntakeOutcomeCreated ReviewIntakeOutcome = iota
+    ReviewIntakeOutcomeUpdated
+    ReviewIntakeOutcomeUnchanged
+    ReviewIntakeOutcomeRemoved
+    // ReviewIntakeOutcomeInProgress is a redelivered new review event whose steps are run by another delivery
+    ReviewIntakeOutcomeInProgress
+)
+
+func (s ReviewIntakeOutcome) String() string {
+    return []string{
+        "Created",
+        "Updated",
+        "Unchanged",
+        "Removed",
+        "InProgress",
+    }[s]
+}
@@ -0,0 +1,15 @@
+package enum
+
+type ReviewMetric int
+
+const (
+    MetricReviewBackfilled ReviewMetric = iota
+    MetricReviewBackfillFailed
+)
+
+func (s ReviewMetric) String() string {
+    return []string{
+        "ReviewBackfilled",
+        "ReviewBackfillFailed",
+    }[s]
+}
@@ -0,0 +1,27 @@
+package enum
+
+// ReviewTextCondition is the condition of an auto reply rule on whether the review has text
+type ReviewTextCondition int
+
+const (
+    ReviewTextConditionAny ReviewTextCondition = iota
+    ReviewTextConditionHasText
+    ReviewTextConditionNoText
+)
+
+func (s ReviewTextCondition) String() string {
+    return []string{
+        "Any",
+        "HasText",
+        "NoText",
+    }[s]
+}
+
+// DisplayName is the name of the condition shown to LINE users
+func (s ReviewTextCondition) DisplayName() string {
+    return []string{
+        "不限文字",
+        "有文字",
+        "無文字",
+    }[s]
+}
@@ -0,0 +1,20 @@
+package enum
+
+// UserAuthState is whether the user can use the features requiring Google OAuth
+type UserAuthState int
+
+const (
+    // UserAuthStateNotAuthed users have not completed OAuth or have no business
+    UserAuthStateNotAuthed UserAuthState = iota
+    UserAuthStateAuthed
+    // UserAuthStateNeedsReauth users completed OAuth but their Google token was revoked or purged since
+    UserAuthStateNeedsReauth
+)
+
+func (s UserAuthState) String() string {
+    return []string{
+        "NotAuthed",
+        "Authed",
+        "NeedsReauth",
+    }[s]
+}
@@ -0,0 +1,29 @@
+package model
+
+// LLMConfig is the model, sampling parameters and prompt template of AI replies.
+// The config of a business overrides the config of the stage. Unset fields fall back to the config of the stage.
+type LLMConfig struct {
+    // Model is the model name, or the deployment name for Azure OpenAI
+    Model       string   `dynamodbav:"model,omitempty" json:"model,omitempty"`
+    Temperature *float32 `dynamodbav:"temperature,omitempty" json:"temperature,omitempty"`
+    MaxTokens   int      `dynamodbav:"maxTokens,omitempty" json:"maxTokens,omitempty"`
+    // PromptTemplate is the prompt template ID, e.g., "nailSalon@v1", or name for its latest version, e.g., "nailSalon". See aiUtil.LookupPromptTemplate.
+    PromptTemplate string `dynamodbav:"promptTemplate,omitempty" json:"promptTemplate,omitempty"`
+}
+
+// Override returns the config with the set fields of override replacing those of c
+func (c LLMConfig) Override(override LLMConfig) LLMConfig {
+    if override.Model != "" {
+        c.Model = override.Model
+    }
+    if override.Temperature != nil {
+        c.Temperature = override.Temperature
+    }
+    if override.MaxTokens > 0 {
+        c.MaxTokens = override.MaxTokens
+    }
+    if override.PromptTemplate != "" {
+        c.PromptTemplate = override.PromptTemplate
+    }
+    return c
+}
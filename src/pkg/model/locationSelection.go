@@ -0,0 +1,48 @@
+package model
+
+import (
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "time"
+)
+
+// LocationSelectionGoogleTtl is how long the Google metadata of a user who connects no location is kept, after which the location selection is removed
+const LocationSelectionGoogleTtl = 30 * 24 * time.Hour
+
+// DiscoveredLocation is an open Google business location the user can connect
+type DiscoveredLocation struct {
+    BusinessId string `dynamodbav:"businessId"`
+    Title      string `dynamodbav:"title"`
+    // AccountId is the Google business account the location is listed under
+    AccountId string `dynamodbav:"accountId"`
+}
+
+// LocationSelection is the open Google business locations discovered in the latest OAuth of the user, which the user connects or skips
+type LocationSelection struct {
+    UserId    string               `dynamodbav:"userId"`
+    Locations []DiscoveredLocation `dynamodbav:"locations"`
+    // BusinessAccountIds are the Google business accounts with open locations
+    BusinessAccountIds []string `dynamodbav:"businessAccountIds"`
+    // Google is the Google metadata of a user who has not connected any location yet. The user is created with it on connecting the first location.
+    Google    *model.Google `dynamodbav:"google,omitempty"`
+    UpdatedAt time.Time     `dynamodbav:"updatedAt,unixtime"`
+    // ExpireAt is the TTL attribute of the LocationSelection table, set only while the Google metadata is kept
+    ExpireAt *time.Time `dynamodbav:"expireAt,unixtime,omitempty"`
+}
+
+// KeepGoogle keeps the Google metadata of the user until the user connects a location, for at most LocationSelectionGoogleTtl
+func (s *LocationSelection) KeepGoogle(google model.Google) {
+    expireAt := s.UpdatedAt.Add(LocationSelectionGoogleTtl)
+    s.Google = &google
+    s.ExpireAt = &expireAt
+}
+
+// FindLocation finds the discovered location of the business
+func (s LocationSelection) FindLocation(businessId bid.BusinessId) (DiscoveredLocation, bool) {
+    for _, location := range s.Locations {
+        if location.BusinessId == businessId.String() {
+            return location, true
+        }
+    }
+    return DiscoveredLocation{}, false
+}
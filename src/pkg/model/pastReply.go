@@ -0,0 +1,10 @@
+package model
+
+// PastReply is a review of the business and the reply a user of the business published to it
+type PastReply struct {
+    ReviewerName string  `dynamodbav:"reviewerName"`
+    NumberRating int     `dynamodbav:"numberRating"`
+    Review       *string `dynamodbav:"review"`
+    Reply        string  `dynamodbav:"reply"`
+    RepliedBy    string  `dynamodbav:"repliedBy"`
+}
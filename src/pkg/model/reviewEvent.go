@@ -0,0 +1,66 @@
+package model
+
+import (
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "time"
+)
+
+// reviewEventRetention is how long a review event is kept to deduplicate redeliveries
+const reviewEventRetention = 90 * 24 * time.Hour
+
+// ReviewEventLeaseTtl is how long a delivery of a review event holds the lease to process its open steps.
+// It matches the Lambda timeout, so that the lease of a timed out invocation expires when the invocation does.
+const ReviewEventLeaseTtl = 5 * time.Minute
+
+// ReviewEvent records the progress of processing a new review event, so that a redelivered event resumes the unfinished steps
+type ReviewEvent struct {
+    VendorEventId  string `dynamodbav:"vendorEventId"`
+    VendorReviewId string `dynamodbav:"vendorReviewId"`
+    BusinessId     string `dynamodbav:"businessId"`
+    // ReviewId is reserved before the review is stored, so that a retry stores the review under the same ID
+    ReviewId    string    `dynamodbav:"reviewId,omitempty"`
+    Stored      bool      `dynamodbav:"stored"`
+    Notified    bool      `dynamodbav:"notified"`
+    AutoReplied bool      `dynamodbav:"autoReplied"`
+    CreatedAt   time.Time `dynamodbav:"createdAt,unixtime"`
+    LastUpdated time.Time `dynamodbav:"lastUpdated,unixtime"`
+    // AiPromptTemplate is the ID of the prompt template of the published AI auto reply, if any
+    AiPromptTemplate string `dynamodbav:"aiPromptTemplate,omitempty"`
+    // LeaseUntil is when the lease of the delivery processing the open steps expires. Zero if no delivery holds the lease.
+    LeaseUntil time.Time `dynamodbav:"leaseUntil,unixtime"`
+    // ExpireAt is the TTL attribute of the ReviewEvent table
+    ExpireAt time.Time `dynamodbav:"expireAt,unixtime"`
+}
+
+// NewReviewEvent creates the review event leased to the delivery creating it
+func NewReviewEvent(vendorEventId string, vendorReviewId string, businessId string) ReviewEvent {
+    now := time.Now()
+    return ReviewEvent{
+        VendorEventId:  vendorEventId,
+        VendorReviewId: vendorReviewId,
+        BusinessId:     businessId,
+        CreatedAt:      now,
+        LastUpdated:    now,
+        LeaseUntil:     now.Add(ReviewEventLeaseTtl),
+        ExpireAt:       now.Add(reviewEventRetention),
+    }
+}
+
+// IsStepDone returns whether the step has been completed
+func (e ReviewEvent) IsStepDone(step enum.ReviewEventStep) bool {
+    switch step {
+    case enum.ReviewEventStepStored:
+        return e.Stored
+    case enum.ReviewEventStepNotified:
+        return e.Notified
+    case enum.ReviewEventStepAutoReplied:
+        return e.AutoReplied
+    default:
+        return false
+    }
+}
+
+// IsComplete returns whether all steps have been completed
+func (e ReviewEvent) IsComplete() bool {
+    return e.Stored && e.Notified && e.AutoReplied
+}
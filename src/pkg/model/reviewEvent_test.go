@@ -0,0 +1,79 @@
+package model
+
+import (
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "testing"
+    "time"
+)
+
+func TestReviewEventSteps(t *testing.T) {
+    steps := []enum.ReviewEventStep{enum.ReviewEventStepStored, enum.ReviewEventStepNotified, enum.ReviewEventStepAutoReplied}
+
+    tests := []struct {
+        name         string
+        event        ReviewEvent
+        wantDone     []bool
+        wantComplete bool
+    }{
+        {
+            name:         "new event",
+            event:        NewReviewEvent("event-1", "accounts/1/locations/2/reviews/3", "2"),
+            wantDone:     []bool{false, false, false},
+            wantComplete: false,
+        },
+        {
+            name:         "stored",
+            event:        ReviewEvent{Stored: true},
+            wantDone:     []bool{true, false, false},
+            wantComplete: false,
+        },
+        {
+            name:         "stored and notified",
+            event:        ReviewEvent{Stored: true, Notified: true},
+            wantDone:     []bool{true, true, false},
+            wantComplete: false,
+        },
+        {
+            name:         "auto replied without notifying",
+            event:        ReviewEvent{Stored: true, AutoReplied: true},
+            wantDone:     []bool{true, false, true},
+            wantComplete: false,
+        },
+        {
+            name:         "all steps done",
+            event:        ReviewEvent{Stored: true, Notified: true, AutoReplied: true},
+            wantDone:     []bool{true, true, true},
+            wantComplete: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            for i, step := range steps {
+                if done := tt.event.IsStepDone(step); done != tt.wantDone[i] {
+                    t.Errorf("IsStepDone(%s) = %v, want %v", step, done, tt.wantDone[i])
+                }
+            }
+            if complete := tt.event.IsComplete(); complete != tt.wantComplete {
+                t.Errorf("IsComplete() = %v, want %v", complete, tt.wantComplete)
+            }
+        })
+    }
+}
+
+func TestNewReviewEvent(t *testing.T) {
+    event := NewReviewEvent("event-1", "accounts/1/locations/2/reviews/3", "2")
+
+    if event.ReviewId != "" {
+        t.Errorf("NewReviewEvent() review ID = %q, want no reserved review ID", event.ReviewId)
+    }
+    if retention := event.ExpireAt.Sub(event.CreatedAt); retention != reviewEventRetention {
+        t.Errorf("NewReviewEvent() expires %s after creation, want %s", retention, reviewEventRetention)
+    }
+    if lease := event.LeaseUntil.Sub(event.CreatedAt); lease != ReviewEventLeaseTtl {
+        t.Errorf("NewReviewEvent() lease expires %s after creation, want %s", lease, ReviewEventLeaseTtl)
+    }
+    if time.Since(event.CreatedAt) > time.Minute || !event.LastUpdated.Equal(event.CreatedAt) {
+        t.Errorf("NewReviewEvent() created at %s, last updated at %s, want now", event.CreatedAt, event.LastUpdated)
+    }
+}
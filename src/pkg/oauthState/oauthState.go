@@ -0,0 +1,94 @@
+package oauthState
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "strings"
+    "time"
+)
+
+// StateTtl is how long an auth request can be completed after it is sent
+const StateTtl = time.Hour
+
+// State is what the Google OAuth state parameter stands for. The parameter is signed, so that callbacks cannot be crafted for other users.
+type State struct {
+    // UserId is the LINE user ID completing OAuth
+    UserId string `json:"u"`
+    // Nonce makes each state unique, so that a state can be used only once
+    Nonce    string    `json:"n"`
+    ExpireAt time.Time `json:"e"`
+}
+
+// Signer signs and verifies OAuth states with a secret shared by lineEventsHandler, which sends auth requests, and authHandler, which completes them
+type Signer struct {
+    secret []byte
+}
+
+func NewSigner(secret string) (*Signer, error) {
+    if secret == "" {
+        return nil, errors.New("OAuth state secret is empty")
+    }
+    return &Signer{secret: []byte(secret)}, nil
+}
+
+// Sign returns a new state parameter for the user, i.e., "{base64url payload}.{base64url HMAC-SHA256}"
+func (s *Signer) Sign(userId string) (string, error) {
+    nonce := make([]byte, 16)
+    _, err := rand.Read(nonce)
+    if err != nil {
+        return "", err
+    }
+
+    payload, err := json.Marshal(State{
+        UserId:   userId,
+        Nonce:    hex.EncodeToString(nonce),
+        ExpireAt: time.Now().Add(StateTtl),
+    })
+    if err != nil {
+        return "", err
+    }
+    encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+    return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(s.mac(encodedPayload)), nil
+}
+
+// Verify returns the state of the state parameter.
+// Returns exception.InvalidOAuthStateException if the parameter is malformed, tampered with or expired.
+// The state is also returned if it is only expired, as its user ID can be trusted.
+func (s *Signer) Verify(stateParam string) (State, error) {
+    encodedPayload, encodedMac, found := strings.Cut(stateParam, ".")
+    if !found {
+        return State{}, exception.NewInvalidOAuthStateException("malformed state", false)
+    }
+    mac, err := base64.RawURLEncoding.DecodeString(encodedMac)
+    if err != nil || !hmac.Equal(mac, s.mac(encodedPayload)) {
+        return State{}, exception.NewInvalidOAuthStateException("state signature mismatch", false)
+    }
+
+    payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+    if err != nil {
+        return State{}, exception.NewInvalidOAuthStateException("malformed state payload", false)
+    }
+    var state State
+    err = json.Unmarshal(payload, &state)
+    if err != nil || state.UserId == "" || state.Nonce == "" {
+        return State{}, exception.NewInvalidOAuthStateException("malformed state payload", false)
+    }
+
+    if !time.Now().Before(state.ExpireAt) {
+        return state, exception.NewInvalidOAuthStateException(fmt.Sprintf("state of user '%s' expired at %s", state.UserId, state.ExpireAt), true)
+    }
+    return state, nil
+}
+
+func (s *Signer) mac(encodedPayload string) []byte {
+    h := hmac.New(sha256.New, s.secret)
+    h.Write([]byte(encodedPayload))
+    return h.Sum(nil)
+}
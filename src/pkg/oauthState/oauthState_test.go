@@ -0,0 +1,164 @@
+package oauthState
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "strings"
+    "testing"
+    "time"
+)
+
+const testUserId = "U1234567890abcdef"
+
+func TestNewSigner(t *testing.T) {
+    _, err := NewSigner("")
+    if err == nil {
+        t.Errorf("NewSigner() with empty secret error = nil, want error")
+    }
+}
+
+func TestSignerVerify(t *testing.T) {
+    signer := mustNewSigner(t, "secret")
+    validState := mustSign(t, signer)
+
+    tests := []struct {
+        name        string
+        stateParam  string
+        wantUserId  string
+        wantErr     bool
+        wantExpired bool
+    }{
+        {
+            name:       "valid state",
+            stateParam: validState,
+            wantUserId: testUserId,
+        },
+        {
+            name:       "replayed state still verifies, so that its nonce is checked by the caller",
+            stateParam: validState,
+            wantUserId: testUserId,
+        },
+        {
+            name:       "signed with another secret",
+            stateParam: mustSign(t, mustNewSigner(t, "another secret")),
+            wantErr:    true,
+        },
+        {
+            name:       "payload tampered with",
+            stateParam: signedWith(signer, State{UserId: "Uattacker", Nonce: "n", ExpireAt: time.Now().Add(StateTtl)}, validState),
+            wantErr:    true,
+        },
+        {
+            name:       "signature tampered with",
+            stateParam: withFlippedSignatureBit(validState),
+            wantErr:    true,
+        },
+        {
+            name:       "no signature",
+            stateParam: strings.Split(validState, ".")[0],
+            wantErr:    true,
+        },
+        {
+            name:       "legacy plain user ID",
+            stateParam: testUserId,
+            wantErr:    true,
+        },
+        {
+            name:       "empty",
+            stateParam: "",
+            wantErr:    true,
+        },
+        {
+            name:        "expired",
+            stateParam:  signedWith(signer, State{UserId: testUserId, Nonce: "n", ExpireAt: time.Now().Add(-time.Minute)}, ""),
+            wantUserId:  testUserId,
+            wantErr:     true,
+            wantExpired: true,
+        },
+        {
+            name:       "signed payload without nonce",
+            stateParam: signedWith(signer, State{UserId: testUserId, ExpireAt: time.Now().Add(StateTtl)}, ""),
+            wantErr:    true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            state, err := signer.Verify(tt.stateParam)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err != nil {
+                var invalidOAuthStateException exception.InvalidOAuthStateException
+                if !errors.As(err, &invalidOAuthStateException) {
+                    t.Fatalf("Verify() error = %v, want InvalidOAuthStateException", err)
+                }
+                if invalidOAuthStateException.Expired != tt.wantExpired {
+                    t.Errorf("Verify() expired = %v, want %v", invalidOAuthStateException.Expired, tt.wantExpired)
+                }
+            }
+            if state.UserId != tt.wantUserId {
+                t.Errorf("Verify() user ID = %q, want %q", state.UserId, tt.wantUserId)
+            }
+        })
+    }
+}
+
+func TestSignerSignIsUniquePerRequest(t *testing.T) {
+    signer := mustNewSigner(t, "secret")
+
+    first, err := signer.Verify(mustSign(t, signer))
+    if err != nil {
+        t.Fatalf("Verify() error = %v", err)
+    }
+    second, err := signer.Verify(mustSign(t, signer))
+    if err != nil {
+        t.Fatalf("Verify() error = %v", err)
+    }
+
+    if first.Nonce == second.Nonce {
+        t.Errorf("Sign() nonce %q reused, want a new nonce per state", first.Nonce)
+    }
+    if time.Until(first.ExpireAt) > StateTtl || time.Until(first.ExpireAt) < StateTtl-time.Minute {
+        t.Errorf("Sign() expires at %s, want in %s", first.ExpireAt, StateTtl)
+    }
+}
+
+func mustNewSigner(t *testing.T, secret string) *Signer {
+    t.Helper()
+    signer, err := NewSigner(secret)
+    if err != nil {
+        t.Fatalf("NewSigner() error = %v", err)
+    }
+    return signer
+}
+
+func mustSign(t *testing.T, signer *Signer) string {
+    t.Helper()
+    stateParam, err := signer.Sign(testUserId)
+    if err != nil {
+        t.Fatalf("Sign() error = %v", err)
+    }
+    return stateParam
+}
+
+// signedWith returns the state parameter of the state, with the signature of signatureOf if not empty instead of its own
+func signedWith(signer *Signer, state State, signatureOf string) string {
+    payload, _ := json.Marshal(state)
+    encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+    if signatureOf != "" {
+        _, encodedMac, _ := strings.Cut(signatureOf, ".")
+        return encodedPayload + "." + encodedMac
+    }
+    return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(signer.mac(encodedPayload))
+}
+
+// withFlippedSignatureBit returns the state parameter with the first bit of its signature flipped
+func withFlippedSignatureBit(stateParam string) string {
+    encodedPayload, encodedMac, _ := strings.Cut(stateParam, ".")
+    mac, _ := base64.RawURLEncoding.DecodeString(encodedMac)
+    mac[0] ^= 1
+    return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
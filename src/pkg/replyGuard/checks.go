@@ -0,0 +1,118 @@
+package replyGuard
+
+import (
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "regexp"
+    "strings"
+)
+
+// GoogleReplyMaxBytes is the maximum length of a review reply accepted by Google Business Profile
+const GoogleReplyMaxBytes = 4096
+
+// minPhoneNumberDigits avoids taking dates and prices for phone numbers, e.g., "2023-11-07"
+const minPhoneNumberDigits = 9
+
+var (
+    emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+    phoneNumberPattern = regexp.MustCompile(`\+?\d[\d\-\s()]{6,}\d`)
+    nonDigitPattern    = regexp.MustCompile(`\D`)
+)
+
+// profanityPatterns are matched case-insensitively. English words are matched as whole words.
+var profanityPatterns = []*regexp.Regexp{
+    regexp.MustCompile(`幹你|幹您|靠北|靠腰|操你|他媽|媽的|白痴|白癡|智障|去死|王八蛋|混蛋|婊子`),
+    regexp.MustCompile(`(?i)\b(fuck\w*|shit\w*|bitch\w*|asshole|bastard|damn|idiot|stupid)\b`),
+}
+
+// promisePatterns are offers AI may make up on behalf of the business, e.g., "下次來店打九折".
+// Giving and free are only matched with what is given, so that courtesies like "送您滿滿的祝福", "招待不周" and "feel free to contact us" pass.
+var promisePatterns = []*regexp.Regexp{
+    regexp.MustCompile(`打\s*[一二三四五六七八九\d.]+\s*折|折扣|優惠|折價券|抵用券|禮券|餐券|退款|退費|賠償|補償|免費(招待|贈送|提供)`),
+    regexp.MustCompile(`(免費|招待|贈送|送)(您|你)?\s*(一(份|杯|道|盤|碗|客)|飲料|飲品|甜點|點心|餐點|小菜|咖啡)`),
+    regexp.MustCompile(`(?i)\d+\s*%\s*off|\b(discount\w*|coupons?|vouchers?|refund\w*|compensat\w*|on the house)\b`),
+    regexp.MustCompile(`(?i)\bfree\s+(drinks?|meals?|desserts?|coffees?|appetizers?|dishes|items?|refills?|upgrades?|delivery|gifts?|nights?|stays?|of charge)\b`),
+}
+
+// checkLength returns the reason to block a blank reply or a reply over the Google reply limit
+func checkLength(reply string) string {
+    if stringUtil.IsEmptyString(strings.TrimSpace(reply)) {
+        return "回覆內容為空白。"
+    }
+    if len(reply) > GoogleReplyMaxBytes {
+        return fmt.Sprintf("回覆超過 Google 的長度上限（%d 位元組），請縮短後再試。", GoogleReplyMaxBytes)
+    }
+    return ""
+}
+
+// checkProfanity returns the reason to block a reply with profanity
+func checkProfanity(reply string) string {
+    for _, pattern := range profanityPatterns {
+        if match := pattern.FindString(reply); match != "" {
+            return fmt.Sprintf("回覆含有不雅字詞「%s」。", match)
+        }
+    }
+    return ""
+}
+
+// checkContacts returns the reason to block a reply with a phone number or email not in allowedContacts.
+// Phone numbers are compared by digits only and emails case-insensitively.
+func checkContacts(reply string, allowedContacts []string) string {
+    allowed := map[string]bool{}
+    for _, contact := range allowedContacts {
+        allowed[normalizeContact(contact)] = true
+    }
+
+    for _, email := range emailPattern.FindAllString(reply, -1) {
+        if !allowed[normalizeContact(email)] {
+            return contactNotAllowedReason(email)
+        }
+    }
+    // emails may contain digits, e.g., "shop123456789@example.com"
+    replyWithoutEmails := emailPattern.ReplaceAllString(reply, "")
+    for _, phoneNumber := range phoneNumberPattern.FindAllString(replyWithoutEmails, -1) {
+        normalized := normalizeContact(phoneNumber)
+        if len(normalized) < minPhoneNumberDigits {
+            continue
+        }
+        if !allowed[normalized] {
+            return contactNotAllowedReason(strings.TrimSpace(phoneNumber))
+        }
+    }
+    return ""
+}
+
+// normalizeContact normalizes an email to lowercase and a phone number to its digits
+func normalizeContact(contact string) string {
+    contact = strings.TrimSpace(contact)
+    if strings.Contains(contact, "@") {
+        return strings.ToLower(contact)
+    }
+    return nonDigitPattern.ReplaceAllString(contact, "")
+}
+
+func contactNotAllowedReason(contact string) string {
+    return fmt.Sprintf("回覆含有未允許公開的聯絡方式「%s」。如需公開，請以「/%s」指令加入允許清單。", contact, util.UpdateAllowedContactsCmd)
+}
+
+// checkPromises returns the reason to block a reply with an offer not mentioned in the business description or keywords of the business
+func checkPromises(reply string, business model.Business) string {
+    businessSettings := strings.ToLower(stringOf(business.BusinessDescription) + " " + stringOf(business.Keywords))
+    for _, pattern := range promisePatterns {
+        for _, match := range pattern.FindAllString(reply, -1) {
+            if !strings.Contains(businessSettings, strings.ToLower(match)) {
+                return fmt.Sprintf("AI 回覆提及了商家設定中沒有的「%s」，請確認後手動回覆。", match)
+            }
+        }
+    }
+    return ""
+}
+
+func stringOf(s *string) string {
+    if stringUtil.IsEmptyStringPtr(s) {
+        return ""
+    }
+    return *s
+}
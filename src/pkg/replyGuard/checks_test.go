@@ -0,0 +1,117 @@
+package replyGuard
+
+import (
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "strings"
+    "testing"
+)
+
+func TestCheckLength(t *testing.T) {
+    tests := []struct {
+        name      string
+        reply     string
+        wantBlock bool
+    }{
+        {name: "short reply", reply: "謝謝您的評論！", wantBlock: false},
+        {name: "empty", reply: "", wantBlock: true},
+        {name: "only whitespace", reply: " \n\t", wantBlock: true},
+        {name: "at the Google limit", reply: strings.Repeat("a", GoogleReplyMaxBytes), wantBlock: false},
+        // each Chinese character takes 3 bytes
+        {name: "over the Google limit in bytes", reply: strings.Repeat("謝", GoogleReplyMaxBytes/3+1), wantBlock: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if reason := checkLength(tt.reply); (reason != "") != tt.wantBlock {
+                t.Errorf("checkLength() reason = %q, wantBlock %v", reason, tt.wantBlock)
+            }
+        })
+    }
+}
+
+func TestCheckProfanity(t *testing.T) {
+    tests := []struct {
+        name      string
+        reply     string
+        wantBlock bool
+    }{
+        {name: "polite reply", reply: "感謝您的支持，期待您再次光臨！", wantBlock: false},
+        {name: "Chinese profanity", reply: "你這個白痴", wantBlock: true},
+        {name: "English profanity in any case", reply: "That was a STUPID review", wantBlock: true},
+        {name: "English profanity with suffix", reply: "Shitty service? Not here.", wantBlock: true},
+        {name: "English profanity inside another word", reply: "So glad you enjoyed the Damnation Alley screening!", wantBlock: false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if reason := checkProfanity(tt.reply); (reason != "") != tt.wantBlock {
+                t.Errorf("checkProfanity() reason = %q, wantBlock %v", reason, tt.wantBlock)
+            }
+        })
+    }
+}
+
+func TestCheckContacts(t *testing.T) {
+    allowedContacts := []string{"02-2345-6789", "Hello@Shop.com"}
+
+    tests := []struct {
+        name      string
+        reply     string
+        wantBlock bool
+    }{
+        {name: "no contacts", reply: "謝謝您的評論！", wantBlock: false},
+        {name: "date", reply: "我們將於 2023-11-07 起調整營業時間", wantBlock: false},
+        {name: "price", reply: "套餐價格為 NT$ 1,280 元", wantBlock: false},
+        {name: "opening hours", reply: "營業時間 11:00-21:00", wantBlock: false},
+        {name: "phone number", reply: "歡迎來電 0912-345-678", wantBlock: true},
+        {name: "international phone number", reply: "Call us at +886 912 345 678", wantBlock: true},
+        {name: "allowed phone number in another format", reply: "訂位請撥 (02) 2345 6789", wantBlock: false},
+        {name: "email", reply: "請來信 owner@gmail.com", wantBlock: true},
+        {name: "allowed email in another case", reply: "請來信 hello@shop.com", wantBlock: false},
+        {name: "email with digits is not a phone number", reply: "請來信 shop123456789@gmail.com", wantBlock: true},
+        {name: "allowed and not allowed contacts", reply: "請撥 02-2345-6789 或 0912-345-678", wantBlock: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if reason := checkContacts(tt.reply, allowedContacts); (reason != "") != tt.wantBlock {
+                t.Errorf("checkContacts() reason = %q, wantBlock %v", reason, tt.wantBlock)
+            }
+        })
+    }
+}
+
+func TestCheckPromises(t *testing.T) {
+    description := "平日午餐打九折"
+    tests := []struct {
+        name      string
+        reply     string
+        wantBlock bool
+    }{
+        {name: "no promise", reply: "謝謝您的評論，期待再次為您服務！", wantBlock: false},
+        {name: "discount", reply: "下次來店打 八 折", wantBlock: true},
+        {name: "discount in the business description", reply: "別忘了平日午餐打九折喔", wantBlock: false},
+        {name: "coupon", reply: "我們將寄送抵用券給您", wantBlock: true},
+        {name: "refund", reply: "We will refund your order", wantBlock: true},
+        {name: "percentage off", reply: "Enjoy 20% off your next visit", wantBlock: true},
+        {name: "price is not a promise", reply: "Our lunch set is now 20% cheaper than before at $12", wantBlock: false},
+        {name: "date is not a promise", reply: "We look forward to seeing you again on 2023-11-07", wantBlock: false},
+        {name: "feel free is not a promise", reply: "Feel free to contact us anytime", wantBlock: false},
+        {name: "wishes are not a promise", reply: "送您滿滿的祝福，期待再次光臨", wantBlock: false},
+        {name: "apology for hospitality is not a promise", reply: "招待不周之處，還請見諒", wantBlock: false},
+        {name: "free drink", reply: "Your next visit comes with a free drink", wantBlock: true},
+        {name: "on the house", reply: "Dessert is on the house next time", wantBlock: true},
+        {name: "gift of a dessert", reply: "下次來店送您一份甜點", wantBlock: true},
+        {name: "treat to a coffee", reply: "下次招待您一杯咖啡", wantBlock: true},
+        {name: "free treat", reply: "下次來店免費招待", wantBlock: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            business := model.Business{BusinessDescription: &description}
+            if reason := checkPromises(tt.reply, business); (reason != "") != tt.wantBlock {
+                t.Errorf("checkPromises() reason = %q, wantBlock %v", reason, tt.wantBlock)
+            }
+        })
+    }
+}
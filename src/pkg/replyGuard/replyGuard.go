@@ -0,0 +1,95 @@
+package replyGuard
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "go.uber.org/zap"
+    "os"
+    "strconv"
+)
+
+// Moderator reviews a reply with an LLM before it is published
+type Moderator interface {
+    // Moderate returns the reason to block the reply of the business, or empty if the reply can be published
+    Moderate(ctx context.Context, businessId bid.BusinessId, reply string) (string, error)
+}
+
+// ReplyGuard checks replies before they are published publicly on Google:
+//   - length within the Google reply limit
+//   - no profanity
+//   - no phone numbers or emails, unless the business allows them
+//   - no promises the business did not make, e.g., discounts, in AI generated replies
+//   - optionally, LLM moderation
+type ReplyGuard struct {
+    businessDao   *ddbDao.BusinessDao
+    replyGuardDao *dao.ReplyGuardDao
+    // moderator moderates replies passing all other checks. Skipped if nil.
+    moderator Moderator
+    log       *zap.SugaredLogger
+}
+
+func NewReplyGuard(businessDao *ddbDao.BusinessDao, replyGuardDao *dao.ReplyGuardDao, moderator Moderator, logger *zap.SugaredLogger) *ReplyGuard {
+    return &ReplyGuard{
+        businessDao:   businessDao,
+        replyGuardDao: replyGuardDao,
+        moderator:     moderator,
+        log:           logger,
+    }
+}
+
+// NewReplyGuardFromEnv creates the reply guard with LLM moderation if the REPLY_MODERATION_ENABLED env var is true
+func NewReplyGuardFromEnv(businessDao *ddbDao.BusinessDao, replyGuardDao *dao.ReplyGuardDao, moderator Moderator, logger *zap.SugaredLogger) *ReplyGuard {
+    moderationEnabled, _ := strconv.ParseBool(os.Getenv(util.ReplyModerationEnabledEnvKey))
+    if !moderationEnabled {
+        moderator = nil
+    }
+    logger.Infof("Reply guard is configured with LLM moderation %t", moderationEnabled)
+    return NewReplyGuard(businessDao, replyGuardDao, moderator, logger)
+}
+
+// Check returns exception.ReplyBlockedException if the reply to the review must not be published.
+// aiGenerated replies are also checked for promises the business did not make.
+func (g *ReplyGuard) Check(ctx context.Context, reply string, review model.Review, aiGenerated bool) error {
+    reason := checkLength(reply)
+    if reason == "" {
+        reason = checkProfanity(reply)
+    }
+    if reason == "" {
+        allowedContacts, err := g.replyGuardDao.GetAllowedContacts(ctx, review.BusinessId)
+        if err != nil {
+            return err
+        }
+        reason = checkContacts(reply, allowedContacts)
+    }
+    if reason == "" && aiGenerated {
+        business, err := g.businessDao.GetBusiness(review.BusinessId)
+        if err != nil {
+            return err
+        }
+        if business == nil {
+            return exception.NewBusinessNotFoundException(fmt.Sprintf("business '%s' of review '%s' not found", review.BusinessId, review.ReviewId.String()), nil)
+        }
+        reason = checkPromises(reply, *business)
+    }
+    if reason == "" && g.moderator != nil {
+        var err error
+        reason, err = g.moderator.Moderate(ctx, review.BusinessId, reply)
+        if err != nil {
+            // moderation is an additional pass. Do not block replies when the LLM is unavailable
+            g.log.Warnf("Error moderating reply to review '%s' of business '%s'. Proceeding without moderation: %v", review.ReviewId.String(), review.BusinessId, err)
+            reason = ""
+        }
+    }
+
+    if reason != "" {
+        g.log.Warnf("Blocked reply to review '%s' of business '%s': %s", review.ReviewId.String(), review.BusinessId, reason)
+        return exception.NewReplyBlockedException(fmt.Sprintf("reply to review '%s' blocked", review.ReviewId.String()), reason)
+    }
+    return nil
+}
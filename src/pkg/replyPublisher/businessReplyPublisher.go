@@ -0,0 +1,46 @@
+package replyPublisher
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "go.uber.org/zap"
+)
+
+// BusinessReplyPublisher chooses the backend per business record:
+// replies are published through Google if a user of the business has Google credentials, and through the review's Zapier webhook otherwise.
+// Zapier is also the fallback if publishing through Google fails.
+type BusinessReplyPublisher struct {
+    google *GooglePublisher
+    zapier *ZapierPublisher
+    log    *zap.SugaredLogger
+}
+
+func NewBusinessReplyPublisher(google *GooglePublisher, zapier *ZapierPublisher, logger *zap.SugaredLogger) *BusinessReplyPublisher {
+    return &BusinessReplyPublisher{
+        google: google,
+        zapier: zapier,
+        log:    logger,
+    }
+}
+
+func (p *BusinessReplyPublisher) Publish(ctx context.Context, request PublishRequest) (enum.ReplyBackend, error) {
+    review := request.Review
+
+    backend, err := p.google.Publish(ctx, request)
+    if err == nil {
+        return backend, nil
+    }
+    if stringUtil.IsEmptyString(review.ZapierReplyWebhook) {
+        return backend, err
+    }
+
+    if errors.Is(err, ErrNoGoogleCredentials) {
+        p.log.Infof("Business '%s' has no Google credentials. Publishing reply through Zapier", review.BusinessId)
+    } else {
+        p.log.Warnf("Error publishing reply through Google for review '%s' of business '%s'. Falling back to Zapier: %v", review.ReviewId.String(), review.BusinessId, err)
+    }
+
+    return p.zapier.Publish(ctx, request)
+}
@@ -0,0 +1,76 @@
+package replyPublisher
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "go.uber.org/zap"
+)
+
+// ErrNoGoogleCredentials is returned when no user of the business has Google credentials
+var ErrNoGoogleCredentials = businessProfileUtil.ErrNoGoogleCredentials
+
+// GooglePublisher publishes replies through the Google Business Profile API with the stored Google credentials of a user of the business.
+// Revoked credentials are handled with tokenHealth.TokenHealth.
+type GooglePublisher struct {
+    businessProfile *businessProfileUtil.BusinessProfile
+    businessDao     *ddbDao.BusinessDao
+    userDao         *ddbDao.UserDao
+    tokenHealth     *tokenHealth.TokenHealth
+    log             *zap.SugaredLogger
+}
+
+func NewGooglePublisher(
+    businessProfile *businessProfileUtil.BusinessProfile,
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    tokenHealth *tokenHealth.TokenHealth,
+    logger *zap.SugaredLogger) *GooglePublisher {
+    return &GooglePublisher{
+        businessProfile: businessProfile,
+        businessDao:     businessDao,
+        userDao:         userDao,
+        tokenHealth:     tokenHealth,
+        log:             logger,
+    }
+}
+
+func (p *GooglePublisher) Publish(ctx context.Context, request PublishRequest) (enum.ReplyBackend, error) {
+    review := request.Review
+
+    credentialOwner, err := p.findCredentialOwner(request.RepliedByUserId, bid.BusinessId(review.BusinessId))
+    if err != nil {
+        return enum.ReplyBackendGoogle, err
+    }
+
+    token := businessProfileUtil.TokenOf(credentialOwner)
+    usedToken, err := p.businessProfile.UpdateReply(ctx, token, review.VendorReviewId, request.Message)
+    if err != nil {
+        p.tokenHealth.CheckError(ctx, credentialOwner, err)
+        return enum.ReplyBackendGoogle, err
+    }
+    p.log.Infof("Published reply to review '%s' through Google with credentials of user '%s'", review.ReviewId.String(), credentialOwner.UserId)
+
+    businessProfileUtil.PersistRefreshedToken(credentialOwner, token, usedToken, p.userDao, p.log)
+
+    return enum.ReplyBackendGoogle, nil
+}
+
+// findCredentialOwner finds a user of the business with stored Google credentials, preferring the replying user.
+// Returns ErrNoGoogleCredentials if no user of the business has Google credentials.
+func (p *GooglePublisher) findCredentialOwner(repliedByUserId string, businessId bid.BusinessId) (model.User, error) {
+    businessPtr, err := p.businessDao.GetBusiness(businessId)
+    if err != nil {
+        return model.User{}, err
+    }
+    if businessPtr == nil {
+        return model.User{}, fmt.Errorf("business not found for businessId: %s", businessId)
+    }
+
+    return businessProfileUtil.FindCredentialOwner(*businessPtr, repliedByUserId, p.userDao)
+}
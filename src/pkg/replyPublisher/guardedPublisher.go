@@ -0,0 +1,33 @@
+package replyPublisher
+
+import (
+    "context"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyGuard"
+    "go.uber.org/zap"
+)
+
+// GuardedPublisher checks replies with the reply guard before publishing them through the wrapped publisher.
+// Blocked replies return exception.ReplyBlockedException without being published.
+type GuardedPublisher struct {
+    publisher ReplyPublisher
+    guard     *replyGuard.ReplyGuard
+    log       *zap.SugaredLogger
+}
+
+func NewGuardedPublisher(publisher ReplyPublisher, guard *replyGuard.ReplyGuard, logger *zap.SugaredLogger) *GuardedPublisher {
+    return &GuardedPublisher{
+        publisher: publisher,
+        guard:     guard,
+        log:       logger,
+    }
+}
+
+func (p *GuardedPublisher) Publish(ctx context.Context, request PublishRequest) (enum.ReplyBackend, error) {
+    err := p.guard.Check(ctx, request.Message, request.Review, request.AiGenerated)
+    if err != nil {
+        // the backend is not chosen until the reply passes the guard
+        return enum.ReplyBackendGoogle, err
+    }
+    return p.publisher.Publish(ctx, request)
+}
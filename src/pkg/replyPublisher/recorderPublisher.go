@@ -0,0 +1,39 @@
+package replyPublisher
+
+import (
+    "context"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "go.uber.org/zap"
+    "sync"
+)
+
+// RecorderPublisher records replies in memory instead of publishing them, e.g., to dry run replies or to assert what would have been published
+type RecorderPublisher struct {
+    mu        sync.Mutex
+    published []PublishRequest
+    log       *zap.SugaredLogger
+}
+
+func NewRecorderPublisher(logger *zap.SugaredLogger) *RecorderPublisher {
+    return &RecorderPublisher{
+        log: logger,
+    }
+}
+
+func (p *RecorderPublisher) Publish(_ context.Context, request PublishRequest) (enum.ReplyBackend, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.published = append(p.published, request)
+    p.log.Infof("Recorded reply '%s' from user '%s' to review '%s' of business '%s' without publishing", request.Message, request.RepliedByUserId, request.Review.ReviewId.String(), request.Review.BusinessId)
+
+    return enum.ReplyBackendRecorder, nil
+}
+
+// Published returns all recorded replies in the order they were published
+func (p *RecorderPublisher) Published() []PublishRequest {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    return append([]PublishRequest(nil), p.published...)
+}
@@ -0,0 +1,61 @@
+package replyPublisher
+
+import (
+    "context"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil"
+    "go.uber.org/zap"
+    "os"
+)
+
+// PublishRequest is a reply to be published to a review
+type PublishRequest struct {
+    RepliedByUserId string
+    Message         string
+    Review          model.Review
+    // AiGenerated marks replies generated by AI, which are checked for promises the business did not make
+    AiGenerated bool
+}
+
+// ReplyPublisher publishes review replies to the review platform
+type ReplyPublisher interface {
+    // Publish publishes the reply and returns the backend the reply was published through
+    Publish(ctx context.Context, request PublishRequest) (enum.ReplyBackend, error)
+}
+
+// NewReplyPublisher creates the reply publisher configured by the REPLY_PUBLISHER env var.
+// If not configured, the backend is chosen per business record. See BusinessReplyPublisher.
+func NewReplyPublisher(
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    businessProfile *businessProfileUtil.BusinessProfile,
+    tokenHealth *tokenHealth.TokenHealth,
+    log *zap.SugaredLogger) (ReplyPublisher, error) {
+    google := NewGooglePublisher(businessProfile, businessDao, userDao, tokenHealth, log)
+    zapier := NewZapierPublisher(zapierUtil.NewZapier(log), log)
+
+    backendStr := os.Getenv(util.ReplyPublisherEnvKey)
+    if backendStr == "" {
+        return NewBusinessReplyPublisher(google, zapier, log), nil
+    }
+
+    backend, err := enum.ToReplyBackend(backendStr)
+    if err != nil {
+        return nil, err
+    }
+    log.Infof("Reply publisher is configured to %s", backend)
+
+    switch backend {
+    case enum.ReplyBackendGoogle:
+        return google, nil
+    case enum.ReplyBackendZapier:
+        return zapier, nil
+    default:
+        return NewRecorderPublisher(log), nil
+    }
+}
@@ -0,0 +1,46 @@
+package replyPublisher
+
+import (
+    "context"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil/model"
+    "go.uber.org/zap"
+)
+
+// ZapierPublisher publishes replies to the Zapier reply webhook of the review
+type ZapierPublisher struct {
+    zapier *zapierUtil.Zapier
+    log    *zap.SugaredLogger
+}
+
+func NewZapierPublisher(zapier *zapierUtil.Zapier, logger *zap.SugaredLogger) *ZapierPublisher {
+    return &ZapierPublisher{
+        zapier: zapier,
+        log:    logger,
+    }
+}
+
+func (p *ZapierPublisher) Publish(ctx context.Context, request PublishRequest) (enum.ReplyBackend, error) {
+    review := request.Review
+    if stringUtil.IsEmptyString(review.ZapierReplyWebhook) {
+        return enum.ReplyBackendZapier, fmt.Errorf("review '%s' of business '%s' has no Zapier reply webhook", review.ReviewId.String(), review.BusinessId)
+    }
+
+    zapierEvent := model.ReplyToZapierEvent{
+        VendorReviewId: review.VendorReviewId,
+        Message:        request.Message,
+    }
+
+    err := p.zapier.SendReplyEvent(ctx, review.ZapierReplyWebhook, zapierEvent)
+    if err != nil {
+        p.log.Errorf("Error sending reply event to Zapier for review '%s' of business '%s': %v", review.ReviewId.String(), review.BusinessId, err)
+        return enum.ReplyBackendZapier, err
+    }
+
+    p.log.Infof("Sent reply event '%s' to Zapier from user '%s' of business '%s'", jsonUtil.AnyToJson(zapierEvent), request.RepliedByUserId, review.BusinessId)
+    return enum.ReplyBackendZapier, nil
+}
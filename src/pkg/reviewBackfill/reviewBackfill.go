@@ -0,0 +1,190 @@
+package reviewBackfill
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    exception2 "github.com/IntelliLead/CoreDataAccess/exception"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/reviewIntake"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/tokenHealth"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "go.uber.org/zap"
+    "strings"
+    "time"
+)
+
+// ReviewBackfill reconciles stored reviews against Google and feeds the missing reviews into the review intake,
+// e.g., reviews lost while a Zapier webhook failed or the zap was paused.
+type ReviewBackfill struct {
+    businessDao       *ddbDao.BusinessDao
+    userDao           *ddbDao.UserDao
+    businessIdDao     *dao.BusinessIdDao
+    vendorReviewIdDao *dao.VendorReviewIdDao
+    googleAccountDao  *dao.GoogleAccountDao
+    businessProfile   *businessProfileUtil.BusinessProfile
+    tokenHealth       *tokenHealth.TokenHealth
+    intake            *reviewIntake.ReviewIntake
+    log               *zap.SugaredLogger
+}
+
+func NewReviewBackfill(
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    businessIdDao *dao.BusinessIdDao,
+    vendorReviewIdDao *dao.VendorReviewIdDao,
+    googleAccountDao *dao.GoogleAccountDao,
+    businessProfile *businessProfileUtil.BusinessProfile,
+    tokenHealth *tokenHealth.TokenHealth,
+    intake *reviewIntake.ReviewIntake,
+    logger *zap.SugaredLogger) *ReviewBackfill {
+    return &ReviewBackfill{
+        businessDao:       businessDao,
+        userDao:           userDao,
+        businessIdDao:     businessIdDao,
+        vendorReviewIdDao: vendorReviewIdDao,
+        googleAccountDao:  googleAccountDao,
+        businessProfile:   businessProfile,
+        tokenHealth:       tokenHealth,
+        intake:            intake,
+        log:               logger,
+    }
+}
+
+// Result summarizes a backfill run
+type Result struct {
+    BusinessCount     int              `json:"businessCount"`
+    SkippedBusinesses []bid.BusinessId `json:"skippedBusinesses,omitempty"`
+    FailedBusinesses  []bid.BusinessId `json:"failedBusinesses,omitempty"`
+    BackfilledReviews []string         `json:"backfilledReviews,omitempty"`
+}
+
+// BackfillAll backfills reviews updated at or after since for every business in the Business table.
+// A failure of one business does not stop the others. Returns an error if any business failed.
+func (b *ReviewBackfill) BackfillAll(ctx context.Context, since time.Time) (Result, error) {
+    businessIds, err := b.businessIdDao.ListBusinessIds(ctx)
+    if err != nil {
+        return Result{}, err
+    }
+
+    return b.Backfill(ctx, businessIds, since)
+}
+
+// Backfill backfills reviews updated at or after since for the given businesses.
+// A failure of one business does not stop the others. Returns an error if any business failed.
+func (b *ReviewBackfill) Backfill(ctx context.Context, businessIds []bid.BusinessId, since time.Time) (Result, error) {
+    result := Result{BusinessCount: len(businessIds)}
+
+    var errs []error
+    for _, businessId := range businessIds {
+        backfilled, err := b.backfillBusiness(ctx, businessId, since)
+        result.BackfilledReviews = append(result.BackfilledReviews, backfilled...)
+        switch {
+        case errors.Is(err, businessProfileUtil.ErrNoGoogleCredentials):
+            b.log.Infof("Business '%s' has no Google credentials. Skipping backfill", businessId)
+            result.SkippedBusinesses = append(result.SkippedBusinesses, businessId)
+        case err != nil:
+            b.log.Errorf("Error backfilling reviews of business '%s': %v", businessId, err)
+            result.FailedBusinesses = append(result.FailedBusinesses, businessId)
+            errs = append(errs, fmt.Errorf("business '%s': %w", businessId, err))
+        }
+    }
+
+    b.log.Infof("Backfilled %d reviews of %d businesses. Skipped %d businesses, failed %d businesses",
+        len(result.BackfilledReviews), result.BusinessCount, len(result.SkippedBusinesses), len(result.FailedBusinesses))
+
+    return result, errors.Join(errs...)
+}
+
+// backfillBusiness feeds the reviews of the business updated since the given time and missing from the Review table into the review intake.
+// Returns the vendor review IDs of the backfilled reviews.
+func (b *ReviewBackfill) backfillBusiness(ctx context.Context, businessId bid.BusinessId, since time.Time) ([]string, error) {
+    businessPtr, err := b.businessDao.GetBusiness(businessId)
+    if err != nil {
+        return nil, err
+    }
+    if businessPtr == nil {
+        return nil, fmt.Errorf("business not found for businessId: %s", businessId)
+    }
+
+    credentialOwner, err := businessProfileUtil.FindCredentialOwner(*businessPtr, "", b.userDao)
+    if err != nil {
+        return nil, err
+    }
+
+    // businesses connected before their accounts were recorded are under the first account of the credential owner
+    businessAccountId, err := b.googleAccountDao.GetBusinessAccountId(ctx, businessId)
+    if err != nil {
+        return nil, err
+    }
+    if stringUtil.IsEmptyString(businessAccountId) {
+        businessAccountId = credentialOwner.Google.BusinessAccountId
+    }
+    if stringUtil.IsEmptyString(businessAccountId) {
+        return nil, fmt.Errorf("user '%s' has no Google business account ID", credentialOwner.UserId)
+    }
+
+    token := businessProfileUtil.TokenOf(credentialOwner)
+    locationName := fmt.Sprintf("accounts/%s/locations/%s", businessAccountId, businessId)
+    googleReviews, usedToken, err := b.businessProfile.ListReviewsUpdatedSince(ctx, token, locationName, since)
+    businessProfileUtil.PersistRefreshedToken(credentialOwner, token, usedToken, b.userDao, b.log)
+    if err != nil {
+        b.tokenHealth.CheckError(ctx, credentialOwner, err)
+        return nil, err
+    }
+
+    storedVendorReviewIds, err := b.vendorReviewIdDao.ListVendorReviewIds(ctx, businessId)
+    if err != nil {
+        return nil, err
+    }
+    // compare by the trailing review ID since the account ID of the review name depends on the account the review is listed with
+    storedReviewIds := map[string]bool{}
+    for _, vendorReviewId := range storedVendorReviewIds {
+        storedReviewIds[reviewIdOf(vendorReviewId)] = true
+    }
+
+    var backfilled []string
+    var errs []error
+    for _, googleReview := range googleReviews {
+        if storedReviewIds[reviewIdOf(googleReview.Name)] {
+            continue
+        }
+        b.log.Infof("Review '%s' of business '%s' is missing. Backfilling", googleReview.Name, businessId)
+
+        event, err := reviewIntake.NewReviewEventFromGoogleReview(googleReview)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("error converting review '%s': %w", googleReview.Name, err))
+            continue
+        }
+
+        result, err := b.intake.ProcessReviewEvent(ctx, event)
+        var reviewAlreadyExistException exception2.ReviewAlreadyExistException
+        switch {
+        case errors.As(err, &reviewAlreadyExistException), err == nil && result.Outcome != enum.ReviewIntakeOutcomeCreated:
+            // stored concurrently, e.g., by a late notification
+            b.log.Infof("Review '%s' already exists. Skipping", googleReview.Name)
+            continue
+        case err != nil:
+            metric.EmitMetricWithNamespace(enum.MetricReviewBackfillFailed.String(), 1.0, util.ReviewMetricNamespace)
+            errs = append(errs, fmt.Errorf("error processing review '%s': %w", googleReview.Name, err))
+            continue
+        }
+
+        metric.EmitMetricWithNamespace(enum.MetricReviewBackfilled.String(), 1.0, util.ReviewMetricNamespace)
+        backfilled = append(backfilled, googleReview.Name)
+    }
+
+    return backfilled, errors.Join(errs...)
+}
+
+// reviewIdOf returns the last segment of the review resource name
+// e.g., "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID" -> "BUSINESS_REVIEW_ID"
+func reviewIdOf(reviewName string) string {
+    return reviewName[strings.LastIndex(reviewName, "/")+1:]
+}
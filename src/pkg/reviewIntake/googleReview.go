@@ -0,0 +1,50 @@
+package reviewIntake
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil/model"
+    "strconv"
+    "strings"
+)
+
+// NewReviewEventFromGoogleReview converts a review fetched from Google Business Profile API to the new review event consumed by the intake.
+// The event is built from its JSON form so that it goes through the same parsing as events from Zapier.
+func NewReviewEventFromGoogleReview(review model2.Review) (model.ZapierNewReviewEvent, error) {
+    numberRating, err := review.StarRating.Number()
+    if err != nil {
+        return model.ZapierNewReviewEvent{}, err
+    }
+
+    // business ID used by Zapier is the location resource name, i.e., "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID"
+    locationName, _, found := strings.Cut(review.Name, "/reviews/")
+    if !found {
+        return model.ZapierNewReviewEvent{}, fmt.Errorf("unexpected review name format '%s'", review.Name)
+    }
+
+    eventJson := map[string]string{
+        "businessId":           locationName,
+        "createdAt":            review.CreateTime,
+        "numberRating":         strconv.Itoa(numberRating),
+        "review":               review.Comment,
+        "reviewLastUpdated":    review.UpdateTime,
+        "reviewerName":         review.Reviewer.DisplayName,
+        "reviewerProfilePhoto": review.Reviewer.ProfilePhotoUrl,
+        "vendorEventId":        review.ReviewId,
+        "vendorReviewId":       review.Name,
+    }
+
+    jsonData, err := json.Marshal(eventJson)
+    if err != nil {
+        return model.ZapierNewReviewEvent{}, err
+    }
+
+    var event model.ZapierNewReviewEvent
+    err = json.Unmarshal(jsonData, &event)
+    if err != nil {
+        return model.ZapierNewReviewEvent{}, err
+    }
+
+    return event, nil
+}
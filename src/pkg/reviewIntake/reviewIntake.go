@@ -0,0 +1,625 @@
+package reviewIntake
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/enum"
+    "github.com/IntelliLead/CoreCommonUtil/jsonUtil"
+    "github.com/IntelliLead/CoreCommonUtil/stringUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    exception2 "github.com/IntelliLead/CoreDataAccess/exception"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/CoreDataAccess/model/type/bid"
+    "github.com/IntelliLead/CoreDataAccess/model/type/rid"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/aiUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/languageUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineEventProcessor"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
+    enum2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/replyPublisher"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/google/uuid"
+    "go.uber.org/zap"
+    "os"
+    "strings"
+    "time"
+)
+
+// ReviewIntake stores new and changed reviews, forwards them to the LINE users of the business and auto replies to new reviews by the auto reply rules of the business.
+// It is shared by all review sources, e.g., Zapier and Google Pub/Sub notifications.
+type ReviewIntake struct {
+    businessDao       *ddbDao.BusinessDao
+    userDao           *ddbDao.UserDao
+    reviewDao         *ddbDao.ReviewDao
+    vendorReviewIdDao *dao.VendorReviewIdDao
+    reviewEventDao    *dao.ReviewEventDao
+    autoReplyRuleDao  *dao.AutoReplyRuleDao
+    aiReplyDraftDao   *dao.AiReplyDraftDao
+    ai                *aiUtil.Ai
+    line              *lineUtil.LineUtil
+    publisher         replyPublisher.ReplyPublisher
+    log               *zap.SugaredLogger
+}
+
+func NewReviewIntake(
+    businessDao *ddbDao.BusinessDao,
+    userDao *ddbDao.UserDao,
+    reviewDao *ddbDao.ReviewDao,
+    vendorReviewIdDao *dao.VendorReviewIdDao,
+    reviewEventDao *dao.ReviewEventDao,
+    autoReplyRuleDao *dao.AutoReplyRuleDao,
+    aiReplyDraftDao *dao.AiReplyDraftDao,
+    ai *aiUtil.Ai,
+    line *lineUtil.LineUtil,
+    publisher replyPublisher.ReplyPublisher,
+    logger *zap.SugaredLogger) *ReviewIntake {
+    return &ReviewIntake{
+        businessDao:       businessDao,
+        userDao:           userDao,
+        reviewDao:         reviewDao,
+        vendorReviewIdDao: vendorReviewIdDao,
+        reviewEventDao:    reviewEventDao,
+        autoReplyRuleDao:  autoReplyRuleDao,
+        aiReplyDraftDao:   aiReplyDraftDao,
+        ai:                ai,
+        line:              line,
+        publisher:         publisher,
+        log:               logger,
+    }
+}
+
+// Result is the outcome of processing a review
+type Result struct {
+    Review  model.Review
+    Outcome enum2.ReviewIntakeOutcome
+}
+
+// ProcessReviewEvent processes the review event of a new or changed review.
+// A review already stored under the vendor review ID is updated if its content changed, and is otherwise left untouched.
+// Processing a new review is idempotent on the vendor event ID: a redelivered event resumes the steps that did not complete.
+// Only the delivery holding the lease of the review event runs its steps. Other deliveries are acknowledged with ReviewIntakeOutcomeInProgress.
+// Returns
+//   - *exception.InvalidReviewException if the event is invalid
+//   - *exception.BusinessNotFoundException if the business of the review does not exist
+//   - exception.ReviewAlreadyExistException of CoreDataAccess if the review was stored concurrently
+func (i *ReviewIntake) ProcessReviewEvent(ctx context.Context, event model.ZapierNewReviewEvent) (Result, error) {
+    err := event.Validate()
+    if err != nil {
+        return Result{}, exception.NewInvalidReviewExceptionWithError("Validation error in review event", err)
+    }
+
+    // Google may translate Mandarin to English. Remove the translation
+    removeGoogleTranslate(&event)
+
+    // For local testing: generate a new vendor event ID so that the same test event is processed as a new event
+    if os.Getenv(constant.StageEnvKey) == enum.StageLocal.String() {
+        event.VendorEventId = uuid.New().String()
+    }
+
+    business, err := i.getBusiness(event.VendorReviewId, event.UserId)
+    if err != nil {
+        return Result{}, err
+    }
+
+    reviewEvent, err := i.reviewEventDao.GetReviewEvent(ctx, vendorEventIdOf(event))
+    if err != nil {
+        return Result{}, err
+    }
+    if reviewEvent != nil && !reviewEvent.IsComplete() {
+        leaseUntil := time.Now().Add(model2.ReviewEventLeaseTtl)
+        leasedReviewEvent, err := i.reviewEventDao.AcquireLease(ctx, reviewEvent.VendorEventId, leaseUntil)
+        if err != nil {
+            return Result{}, err
+        }
+        if leasedReviewEvent == nil {
+            i.log.Infof("Review event '%s' is being processed by another delivery. Skipping", reviewEvent.VendorEventId)
+            return Result{Outcome: enum2.ReviewIntakeOutcomeInProgress}, nil
+        }
+
+        // steps may have completed since the event was read, so resume from the event as of acquiring the lease
+        i.log.Infof("Resuming processing of review event '%s': %s", leasedReviewEvent.VendorEventId, jsonUtil.AnyToJson(leasedReviewEvent))
+        review, err := i.processNewReview(ctx, *leasedReviewEvent, event, business)
+        i.releaseLease(ctx, leasedReviewEvent.VendorEventId, leaseUntil)
+        return Result{Review: review, Outcome: enum2.ReviewIntakeOutcomeCreated}, err
+    }
+
+    storedReview, err := i.vendorReviewIdDao.FindReviewByVendorReviewId(ctx, business.BusinessId, event.VendorReviewId)
+    if err != nil {
+        return Result{}, err
+    }
+    if storedReview != nil {
+        return i.processChangedReview(ctx, *storedReview, event, business)
+    }
+    if reviewEvent != nil {
+        i.log.Warnf("Review event '%s' was processed but review '%s' is not stored. Ignoring", reviewEvent.VendorEventId, event.VendorReviewId)
+        return Result{Outcome: enum2.ReviewIntakeOutcomeUnchanged}, nil
+    }
+
+    newReviewEvent := model2.NewReviewEvent(vendorEventIdOf(event), event.VendorReviewId, business.BusinessId.String())
+    err = i.reviewEventDao.CreateReviewEvent(ctx, newReviewEvent)
+    var reviewEventAlreadyExistException *exception.ReviewEventAlreadyExistException
+    if errors.As(err, &reviewEventAlreadyExistException) {
+        i.log.Infof("Review event '%s' was created by another delivery. Skipping", newReviewEvent.VendorEventId)
+        return Result{Outcome: enum2.ReviewIntakeOutcomeInProgress}, nil
+    }
+    if err != nil {
+        return Result{}, err
+    }
+
+    review, err := i.processNewReview(ctx, newReviewEvent, event, business)
+    i.releaseLease(ctx, newReviewEvent.VendorEventId, newReviewEvent.LeaseUntil)
+    return Result{Review: review, Outcome: enum2.ReviewIntakeOutcomeCreated}, err
+}
+
+// releaseLease releases the lease of the review event whether or not processing succeeded, so that a redelivery can resume the open steps right away.
+// Failing to release is only logged, as the lease expires on its own.
+func (i *ReviewIntake) releaseLease(ctx context.Context, vendorEventId string, leaseUntil time.Time) {
+    err := i.reviewEventDao.ReleaseLease(ctx, vendorEventId, leaseUntil)
+    if err != nil {
+        i.log.Warnf("Error releasing lease of review event '%s'. It expires at %s: %v", vendorEventId, leaseUntil, err)
+    }
+}
+
+// RemoveReview marks the stored review with the vendor review ID as removed and notifies the users of the business.
+// Returns *exception.InvalidReviewException if the review is not stored.
+func (i *ReviewIntake) RemoveReview(ctx context.Context, vendorReviewId string) (Result, error) {
+    business, err := i.getBusiness(vendorReviewId, nil)
+    if err != nil {
+        return Result{}, err
+    }
+
+    storedReview, err := i.vendorReviewIdDao.FindReviewByVendorReviewId(ctx, business.BusinessId, vendorReviewId)
+    if err != nil {
+        return Result{}, err
+    }
+    if storedReview == nil {
+        return Result{}, exception.NewInvalidReviewException(fmt.Sprintf("Removed review '%s' is not stored", vendorReviewId))
+    }
+    if storedReview.Removed {
+        i.log.Infof("Review '%s' is already marked removed", vendorReviewId)
+        return Result{Review: storedReview.Review, Outcome: enum2.ReviewIntakeOutcomeUnchanged}, nil
+    }
+
+    err = i.vendorReviewIdDao.MarkReviewRemoved(ctx, *storedReview, time.Now())
+    if err != nil {
+        return Result{}, err
+    }
+
+    err = i.line.NotifyReviewRemoved(storedReview.Review, business)
+    if err != nil {
+        i.log.Errorf("Error notifying users of business '%s' review '%s' was removed: %v", business.BusinessId, storedReview.Review.ReviewId.String(), err)
+        return Result{}, err
+    }
+
+    i.log.Infof("Successfully marked review '%s' of business '%s' as removed", storedReview.Review.ReviewId.String(), business.BusinessId)
+    return Result{Review: storedReview.Review, Outcome: enum2.ReviewIntakeOutcomeRemoved}, nil
+}
+
+// getBusiness gets the business of the review
+func (i *ReviewIntake) getBusiness(vendorReviewId string, userIdPtr *string) (model.Business, error) {
+    businessId, err := BusinessIdOf(vendorReviewId)
+    if err != nil {
+        return model.Business{}, exception.NewInvalidReviewExceptionWithError(fmt.Sprintf("Error parsing business ID from vendorReviewId '%s'", vendorReviewId), err)
+    }
+    businessPtr, err := i.businessDao.GetBusiness(businessId)
+    if err != nil {
+        i.log.Errorf("Error getting business '%s': %v", businessId, err)
+        return model.Business{}, err
+    }
+    if businessPtr == nil {
+        userId := ""
+        if !stringUtil.IsEmptyStringPtr(userIdPtr) {
+            userId = *userIdPtr
+        }
+        return model.Business{}, exception.NewBusinessNotFoundException(fmt.Sprintf("Business '%s' does not exist for user '%s'", businessId, userId), nil)
+    }
+    return *businessPtr, nil
+}
+
+// processChangedReview updates the stored review with the content of the event and notifies the users of the business if the content changed
+func (i *ReviewIntake) processChangedReview(ctx context.Context, storedReview dao.StoredReview, event model.ZapierNewReviewEvent, business model.Business) (Result, error) {
+    oldReview := storedReview.Review
+    newReview, err := model.NewReview(business.BusinessId.String(), oldReview.ReviewId, event)
+    if err != nil {
+        return Result{}, exception.NewInvalidReviewExceptionWithError("Error creating review from review event", err)
+    }
+
+    if reviewText(oldReview) == reviewText(newReview) && oldReview.NumberRating == newReview.NumberRating {
+        i.log.Infof("Review '%s' of business '%s' is unchanged", oldReview.ReviewId.String(), business.BusinessId)
+        return Result{Review: oldReview, Outcome: enum2.ReviewIntakeOutcomeUnchanged}, nil
+    }
+
+    err = i.vendorReviewIdDao.UpdateReviewContent(ctx, storedReview, newReview, languageUtil.Detect(reviewText(newReview)))
+    if err != nil {
+        return Result{}, err
+    }
+    i.log.Infof("Updated changed review '%s' of business '%s'", oldReview.ReviewId.String(), business.BusinessId)
+
+    err = i.line.NotifyReviewChanged(oldReview, newReview, business, i.userDao)
+    if err != nil {
+        i.log.Errorf("Error notifying users of business '%s' review '%s' changed: %v", business.BusinessId, oldReview.ReviewId.String(), err)
+        return Result{Review: newReview, Outcome: enum2.ReviewIntakeOutcomeUpdated}, err
+    }
+
+    return Result{Review: newReview, Outcome: enum2.ReviewIntakeOutcomeUpdated}, nil
+}
+
+// processNewReview stores the new review, forwards it to the LINE users of the business and auto replies if a rule matches.
+// Steps already completed for the review event are skipped.
+func (i *ReviewIntake) processNewReview(ctx context.Context, reviewEvent model2.ReviewEvent, event model.ZapierNewReviewEvent, business model.Business) (model.Review, error) {
+    // --------------------
+    // store review
+    // --------------------
+    review, err := i.storeReview(ctx, reviewEvent, event, business)
+    if err != nil {
+        return model.Review{}, err
+    }
+
+    // quick replies to the review are in the language of the review if the business has a quick reply message in it
+    business, err = i.localizeQuickReplyMessage(ctx, business, languageUtil.Detect(reviewText(review)))
+    if err != nil {
+        return review, err
+    }
+
+    // --------------------------------
+    // forward to LINE by calling LINE messaging API
+    // --------------------------------
+    if reviewEvent.Notified {
+        i.log.Infof("Review '%s' was already sent to users of business '%s'. Skipping", review.ReviewId.String(), business.BusinessId)
+    } else {
+        err = i.line.SendNewReview(review, business, i.userDao)
+        if err != nil {
+            i.log.Errorf("Error sending new review to users of business '%s': %s", business.BusinessId, err)
+            return review, err
+        }
+        i.log.Info("Successfully sent new review to all users belonging to business: ", business.BusinessId)
+
+        err = i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepNotified)
+        if err != nil {
+            return review, err
+        }
+    }
+
+    // --------------------------------
+    // auto reply
+    // --------------------------------
+    if reviewEvent.AutoReplied {
+        i.log.Infof("Review '%s' was already auto replied. Skipping", review.ReviewId.String())
+    } else {
+        err = i.autoReply(ctx, reviewEvent, review, business)
+        if err != nil {
+            return review, err
+        }
+    }
+
+    i.log.Info("Successfully processed new review: ", jsonUtil.AnyToJson(review))
+    return review, nil
+}
+
+// storeReview stores the review under the review ID reserved for the review event, or gets the review if it was already stored
+func (i *ReviewIntake) storeReview(ctx context.Context, reviewEvent model2.ReviewEvent, event model.ZapierNewReviewEvent, business model.Business) (model.Review, error) {
+    // reserve the review ID before storing the review so that a retry does not take another review ID
+    resumed := reviewEvent.ReviewId != ""
+    reviewIdStr := reviewEvent.ReviewId
+    if !resumed {
+        nextReviewId, err := i.reviewDao.GenerateNextReviewID(business.BusinessId)
+        if err != nil {
+            i.log.Errorf("Error getting next review id for business %s: %v", business.BusinessId, err)
+            return model.Review{}, err
+        }
+
+        reviewIdStr, err = i.reviewEventDao.ReserveReviewId(ctx, reviewEvent.VendorEventId, nextReviewId.String())
+        if err != nil {
+            return model.Review{}, err
+        }
+    }
+
+    reviewId, err := rid.NewReviewId(reviewIdStr)
+    if err != nil {
+        i.log.Errorf("Error parsing review ID '%s' reserved for review event '%s': %v", reviewIdStr, reviewEvent.VendorEventId, err)
+        return model.Review{}, err
+    }
+
+    if reviewEvent.Stored {
+        reviewPtr, err := i.reviewDao.GetReview(business.BusinessId.String(), reviewId)
+        if err != nil {
+            i.log.Errorf("Error getting review '%s' of business '%s': %v", reviewIdStr, business.BusinessId, err)
+            return model.Review{}, err
+        }
+        if reviewPtr == nil {
+            return model.Review{}, fmt.Errorf("review '%s' of business '%s' stored for review event '%s' does not exist", reviewIdStr, business.BusinessId, reviewEvent.VendorEventId)
+        }
+        return *reviewPtr, nil
+    }
+
+    review, err := model.NewReview(business.BusinessId.String(), reviewId, event)
+    if err != nil {
+        return model.Review{}, exception.NewInvalidReviewExceptionWithError("Error creating review from review event", err)
+    }
+
+    // For local testing: generate a new vendor review ID to prevent duplication
+    if os.Getenv(constant.StageEnvKey) == enum.StageLocal.String() {
+        review.VendorReviewId = uuid.New().String()
+    }
+
+    i.log.Debug("Storing new review object: ", jsonUtil.AnyToJson(review))
+    err = i.reviewDao.PutReview(review)
+    var reviewAlreadyExistException exception2.ReviewAlreadyExistException
+    if resumed && errors.As(err, &reviewAlreadyExistException) {
+        // stored by the previous attempt, which failed before recording the step
+        i.log.Infof("Review '%s' of business '%s' was already stored for review event '%s'", reviewIdStr, business.BusinessId, reviewEvent.VendorEventId)
+    } else if err != nil {
+        i.log.Error("Error creating review: ", err)
+        return model.Review{}, err
+    }
+
+    storedReview, err := i.vendorReviewIdDao.PutVendorReviewId(ctx, review)
+    if err != nil {
+        return review, err
+    }
+
+    err = i.storeReviewLanguage(ctx, storedReview)
+    if err != nil {
+        return review, err
+    }
+
+    err = i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepStored)
+    if err != nil {
+        return review, err
+    }
+
+    return review, nil
+}
+
+// storeReviewLanguage stores the language detected from the text of the stored review on the review
+func (i *ReviewIntake) storeReviewLanguage(ctx context.Context, storedReview dao.StoredReview) error {
+    language := languageUtil.Detect(reviewText(storedReview.Review))
+    if language == "" {
+        return nil
+    }
+
+    return i.vendorReviewIdDao.UpdateReviewLanguage(ctx, storedReview, language)
+}
+
+// localizeQuickReplyMessage returns the business with its quick reply message replaced by that for reviews in the language, if it has one
+func (i *ReviewIntake) localizeQuickReplyMessage(ctx context.Context, business model.Business, language string) (model.Business, error) {
+    if language == "" {
+        return business, nil
+    }
+
+    messages, err := i.autoReplyRuleDao.GetLocalizedQuickReplyMessages(ctx, business.BusinessId)
+    if err != nil {
+        return business, err
+    }
+    message, ok := messages[language]
+    if !ok {
+        return business, nil
+    }
+
+    business.QuickReplyMessage = &message
+    return business, nil
+}
+
+// autoReply replies to the review by the first auto reply rule of the business matching the review,
+// or with AI if no rule matches and the AI auto reply mode of the business is on.
+// AI replies are sent to LINE as drafts instead of published in enum2.AiAutoReplyModeApproval.
+// The step is recorded completed once users have been told the outcome of the reply, so that a retry does not notify them twice.
+// Transient publishing failures leave the step open, so that the redelivered event retries the reply.
+func (i *ReviewIntake) autoReply(ctx context.Context, reviewEvent model2.ReviewEvent, review model.Review, business model.Business) error {
+    rules, err := i.autoReplyRuleDao.GetAutoReplyRules(ctx, business)
+    if err != nil {
+        return err
+    }
+    aiAutoReplyMode, err := i.autoReplyRuleDao.GetAiAutoReplyMode(ctx, business.BusinessId)
+    if err != nil {
+        return err
+    }
+
+    text := reviewText(review)
+    rule, matched := model2.MatchAutoReplyRule(rules, text, int(review.NumberRating), languageUtil.Detect(text))
+    switch {
+    case matched:
+        i.log.Infof("Review '%s' of business '%s' matched auto reply rule '%s'", review.ReviewId.String(), business.BusinessId, rule.Describe())
+    case aiAutoReplyMode.IsOn():
+        i.log.Infof("Review '%s' of business '%s' matched no auto reply rule. Replying with AI in AI auto reply mode %s", review.ReviewId.String(), business.BusinessId, aiAutoReplyMode)
+        rule = model2.AutoReplyRule{Action: enum2.AutoReplyActionAiReply}
+    default:
+        i.log.Infof("Review '%s' of business '%s' matched no auto reply rule", review.ReviewId.String(), business.BusinessId)
+        return i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+    }
+    if rule.Action == enum2.AutoReplyActionNotifyOnly {
+        return i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+    }
+
+    replyMessage, promptTemplate, err := i.buildAutoReply(rule, review, business)
+    var aiQuotaExceededException *exception.AiQuotaExceededException
+    if errors.As(err, &aiQuotaExceededException) {
+        // the quota does not reset until next month. Tell users so that they reply manually instead of retrying
+        notifyUserErr := i.line.NotifyUsersAutoReplyAiQuotaExceeded(business.UserIds, review.ReviewerName, aiQuotaExceededException.PlanTier, aiQuotaExceededException.QuotaUsd)
+        if notifyUserErr != nil {
+            i.log.Errorf("Error notifying users of business '%s' AI quota exceeded for review '%s': %v", business.BusinessId, review.ReviewId.String(), notifyUserErr)
+            return fmt.Errorf("AI quota exceeded: %w. Failed to notify user of exceeded quota: %v", err, notifyUserErr)
+        }
+        return i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+    }
+    if err != nil {
+        return err
+    }
+
+    if rule.Action == enum2.AutoReplyActionAiReply && aiAutoReplyMode == enum2.AiAutoReplyModeApproval {
+        return i.draftAutoReply(ctx, reviewEvent, review, business, replyMessage, promptTemplate)
+    }
+
+    err = lineEventProcessor.ReplyReview(util.AutoReplyUserId, replyMessage, rule.Action == enum2.AutoReplyActionAiReply, review, i.publisher, i.reviewDao, i.log)
+    var replyBlockedException exception.ReplyBlockedException
+    if errors.As(err, &replyBlockedException) {
+        // blocking is final. Tell users so that they reply manually instead of retrying
+        notifyUserErr := i.line.NotifyUsersAutoReplyBlocked(business.UserIds, review.ReviewerName, replyBlockedException.Reason)
+        if notifyUserErr != nil {
+            i.log.Errorf("Error notifying users of business '%s' auto reply blocked for review '%s': %v", business.BusinessId, review.ReviewId.String(), notifyUserErr)
+            return fmt.Errorf("auto reply blocked: %w. Failed to notify user of blocked reply: %v", err, notifyUserErr)
+        }
+        return i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+    }
+    if lineEventProcessor.IsTransientReplyError(err) {
+        // leave the step open so that the redelivered event retries the reply
+        i.log.Warnf("Auto reply to review '%s' failed transiently. Leaving it to be retried: %v", review.ReviewId.String(), err)
+        return fmt.Errorf("auto reply failed transiently: %w", err)
+    }
+    if err != nil {
+        i.log.Errorf("Error handling replying '%s' to review '%s' : %v", replyMessage, review.ReviewId.String(), err)
+
+        notifyUserErr := i.line.NotifyUsersReplyFailed(business.UserIds, review.ReviewerName, true)
+        if notifyUserErr != nil {
+            i.log.Errorf("Error notifying users of business '%s' reply failed for review '%s': %v", business.BusinessId, review.ReviewId.String(), notifyUserErr)
+            return fmt.Errorf("auto reply failed: %w. Failed to notify user of failure: %v", err, notifyUserErr)
+        }
+        i.log.Infof("Successfully notified users of business '%s' auto reply failed for review '%s'", business.BusinessId, review.ReviewId.String())
+
+        completeErr := i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+        if completeErr != nil {
+            return fmt.Errorf("auto reply failed: %w. Failed to record auto reply step: %v", err, completeErr)
+        }
+        return fmt.Errorf("auto reply failed: %w", err)
+    }
+
+    if promptTemplate != "" {
+        err = i.reviewEventDao.RecordAiPromptTemplate(ctx, reviewEvent.VendorEventId, promptTemplate)
+        if err != nil {
+            // only traces the prompt of the reply. Do not fail the published reply
+            i.log.Warnf("Error recording prompt template of AI auto reply to review '%s'. Proceeding: %v", review.ReviewId.String(), err)
+        }
+    }
+
+    // the reply is published. Record the step before notifying so that a retry does not publish again
+    err = i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+    if err != nil {
+        return err
+    }
+
+    // --------------------
+    // Notify review auto replied
+    // --------------------
+    err = i.line.NotifyReviewAutoReplied(review, replyMessage, business, i.userDao)
+    if err != nil {
+        i.log.Errorf("Error sending review reply notification to all users of business '%s': %v", business.BusinessId, err)
+        return fmt.Errorf("failed to send review reply notification to all users of business '%s': %w", business.BusinessId, err)
+    }
+
+    i.log.Infof("Successfully auto replied review for business '%s' for review '%s'", business.BusinessId, review.ReviewId.String())
+    return nil
+}
+
+// draftAutoReply stores the AI auto reply as a draft and sends it to the users of the business for approval
+func (i *ReviewIntake) draftAutoReply(ctx context.Context, reviewEvent model2.ReviewEvent, review model.Review, business model.Business, aiReply string, promptTemplate string) error {
+    draft := model2.NewAiReplyDraft(business.BusinessId.String(), review.ReviewId.String(), aiReply, promptTemplate)
+    err := i.aiReplyDraftDao.PutAiReplyDraft(ctx, draft)
+    if err != nil {
+        var aiReplyDraftNotPendingException *exception.AiReplyDraftNotPendingException
+        if errors.As(err, &aiReplyDraftNotPendingException) {
+            // a previous attempt sent the draft and a user already approved it
+            i.log.Warnf("AI reply draft of review '%s' has already been approved. Skipping", review.ReviewId.String())
+            return i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+        }
+        return err
+    }
+
+    err = i.line.SendAiReplyDraft(draft, review, business, i.userDao)
+    if err != nil {
+        i.log.Errorf("Error sending AI reply draft of review '%s' to users of business '%s': %v", review.ReviewId.String(), business.BusinessId, err)
+        return fmt.Errorf("failed to send AI reply draft to users of business '%s': %w", business.BusinessId, err)
+    }
+
+    i.log.Infof("Successfully sent AI reply draft of review '%s' to users of business '%s' for approval", review.ReviewId.String(), business.BusinessId)
+    return i.reviewEventDao.CompleteStep(ctx, reviewEvent.VendorEventId, enum2.ReviewEventStepAutoReplied)
+}
+
+// buildAutoReply builds the reply to the review by the action of the rule.
+// Returns the reply and the ID of the prompt template of AI replies, or empty for other replies.
+func (i *ReviewIntake) buildAutoReply(rule model2.AutoReplyRule, review model.Review, business model.Business) (string, string, error) {
+    switch rule.Action {
+    case enum2.AutoReplyActionTemplate:
+        if rule.RequiresQuickReplyMessage() && stringUtil.IsEmptyStringPtr(business.QuickReplyMessage) {
+            i.log.Errorf("Auto reply rule '%s' replies quick reply message but no quickReplyMessage for business '%s'", rule.Describe(), business.BusinessId)
+            return "", "", fmt.Errorf("error getting quick reply message of business '%s'", business.BusinessId)
+        }
+
+        quickReplyMessage := ""
+        if !stringUtil.IsEmptyStringPtr(business.QuickReplyMessage) {
+            quickReplyMessage = *business.QuickReplyMessage
+        }
+        return rule.Reply(review.ReviewerName, quickReplyMessage), "", nil
+
+    case enum2.AutoReplyActionAiReply:
+        // AI reply settings such as emoji and signature are per user. Use those of the first user of the business,
+        // who the AI usage is also attributed to.
+        user, err := i.firstUserOf(business)
+        if err != nil {
+            return "", "", err
+        }
+
+        aiReply, promptTemplate, err := i.ai.GenerateReply(review, business, user)
+        if err != nil {
+            i.log.Errorf("Error generating AI auto reply to review '%s' of business '%s': %v", review.ReviewId.String(), business.BusinessId, err)
+            return "", "", err
+        }
+        return aiReply, promptTemplate, nil
+
+    default:
+        return "", "", fmt.Errorf("auto reply action %s does not reply", rule.Action)
+    }
+}
+
+// firstUserOf gets the first existing user of the business
+func (i *ReviewIntake) firstUserOf(business model.Business) (model.User, error) {
+    for _, userId := range business.UserIds {
+        userPtr, err := i.userDao.GetUser(userId)
+        if err != nil {
+            i.log.Errorf("Error getting user '%s' of business '%s': %v", userId, business.BusinessId, err)
+            return model.User{}, err
+        }
+        if userPtr != nil {
+            return *userPtr, nil
+        }
+    }
+    return model.User{}, fmt.Errorf("business '%s' has no user", business.BusinessId)
+}
+
+// BusinessIdOf parses the business ID from the vendor review ID
+// VendorReviewId is in the format of "accounts/BUSINESS_ACCOUNT_ID/locations/BUSINESS_ID/reviews/BUSINESS_REVIEW_ID"
+func BusinessIdOf(vendorReviewId string) (bid.BusinessId, error) {
+    parts := strings.Split(vendorReviewId, "/")
+    if len(parts) < 4 {
+        var businessId bid.BusinessId
+        return businessId, fmt.Errorf("unexpected vendorReviewId format '%s'", vendorReviewId)
+    }
+    return bid.NewBusinessId(parts[3])
+}
+
+// vendorEventIdOf returns the vendor event ID of the event, falling back to the vendor review ID if the vendor does not send one
+func vendorEventIdOf(event model.ZapierNewReviewEvent) string {
+    if event.VendorEventId != "" {
+        return event.VendorEventId
+    }
+    return event.VendorReviewId
+}
+
+func reviewText(review model.Review) string {
+    if review.Review == nil {
+        return ""
+    }
+    return *review.Review
+}
+
+func removeGoogleTranslate(event *model.ZapierNewReviewEvent) {
+    if event.Review == nil {
+        return
+    }
+    strippedText := stringUtil.StripGoogleTranslate(*event.Review)
+    event.Review = &strippedText
+}
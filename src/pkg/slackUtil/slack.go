@@ -5,8 +5,10 @@ import (
     "github.com/IntelliLead/CoreCommonUtil/enum"
     "github.com/IntelliLead/CoreCommonUtil/timeUtil"
     "github.com/IntelliLead/CoreDataAccess/model"
+    model2 "github.com/IntelliLead/ReviewHandlers/src/pkg/model"
     "github.com/slack-go/slack"
     "go.uber.org/zap"
+    "sort"
     "time"
 )
 
@@ -121,3 +123,170 @@ func (s *Slack) SendNewUserOauthCompletionMessage(user model.User, businesses []
 
     return nil
 }
+
+// SendUserUnfollowedMessage notifies that the user unfollowed (blocked) the LINE Official Account. user is nil if the user never completed OAuth.
+// businesses are the businesses the user is removed from.
+func (s *Slack) SendUserUnfollowedMessage(userId string, user *model.User, businesses []model.Business, timestamp time.Time) error {
+    readableTimestamp, err := timeUtil.UtcToReadableTwTimestamp(timestamp)
+    if err != nil {
+        s.log.Error("Unable to convert timestamp to readable format in SendUserUnfollowedMessage: ", err)
+        return err
+    }
+
+    msg1 := ""
+    if s.stage != enum.StageProd {
+        msg1 += "*[" + s.stage.String() + "]* "
+    }
+    msg1 += "User unfollowed IntelliLead App LINE Official Account at " + readableTimestamp + ". User ID: "
+
+    blocks := []slack.Block{
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, msg1, false, false), nil, nil),
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, userId, false, false), nil, nil),
+    }
+    if user == nil {
+        blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "The user never completed OAUTH.", false, false), nil, nil))
+    } else {
+        businessesStr := ""
+        for _, business := range businesses {
+            businessesStr += fmt.Sprintf("• %s\n%s\n\n", business.BusinessName, business.BusinessId.String())
+        }
+        if businessesStr == "" {
+            businessesStr = "None"
+        }
+        blocks = append(blocks,
+            slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "LINE username: "+user.LineUsername, false, false), nil, nil),
+            slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "\nNo longer notified of businesses:", false, false), nil, nil),
+            slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, businessesStr, false, false), nil, nil),
+        )
+    }
+    blocks = append(blocks, slack.NewDividerBlock())
+
+    respChannel, respTimestamp, err := s.client.PostMessage(
+        s.channelId,
+        slack.MsgOptionBlocks(blocks...),
+    )
+    if err != nil {
+        s.log.Error("Unable to send message to slack in SendUserUnfollowedMessage: ", err)
+        return err
+    }
+
+    s.log.Debugf("Message successfully sent to slack channel %s at %s", respChannel, respTimestamp)
+
+    return nil
+}
+
+// SendUserRefollowedMessage notifies that the user who unfollowed followed the LINE Official Account again and is restored
+func (s *Slack) SendUserRefollowedMessage(user model.User, unfollowedAt time.Time, timestamp time.Time) error {
+    readableTimestamp, err := timeUtil.UtcToReadableTwTimestamp(timestamp)
+    if err != nil {
+        s.log.Error("Unable to convert timestamp to readable format in SendUserRefollowedMessage: ", err)
+        return err
+    }
+    readableUnfollowedAt, err := timeUtil.UtcToReadableTwTimestamp(unfollowedAt)
+    if err != nil {
+        s.log.Error("Unable to convert unfollow time to readable format in SendUserRefollowedMessage: ", err)
+        return err
+    }
+
+    msg1 := ""
+    if s.stage != enum.StageProd {
+        msg1 += "*[" + s.stage.String() + "]* "
+    }
+    msg1 += "User followed IntelliLead App LINE Official Account again at " + readableTimestamp + " after unfollowing at " + readableUnfollowedAt + ". User ID: "
+
+    blocks := []slack.Block{
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, msg1, false, false), nil, nil),
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, user.UserId, false, false), nil, nil),
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "LINE username: "+user.LineUsername, false, false), nil, nil),
+        slack.NewDividerBlock(),
+    }
+
+    respChannel, respTimestamp, err := s.client.PostMessage(
+        s.channelId,
+        slack.MsgOptionBlocks(blocks...),
+    )
+    if err != nil {
+        s.log.Error("Unable to send message to slack in SendUserRefollowedMessage: ", err)
+        return err
+    }
+
+    s.log.Debugf("Message successfully sent to slack channel %s at %s", respChannel, respTimestamp)
+
+    return nil
+}
+
+// aiUsageSummaryTopCount is the number of businesses and users with the highest cost listed in the AI usage summary
+const aiUsageSummaryTopCount = 10
+
+// SendAiUsageSummaryMessage sends the AI usage of businesses and users on the day, e.g., "2023-11-07".
+// names maps business and user IDs to their names shown in the summary. IDs without a name are shown as is.
+func (s *Slack) SendAiUsageSummaryMessage(day string, usages []model2.AiUsage, names map[string]string) error {
+    msg := ""
+    if s.stage != enum.StageProd {
+        msg += "*[" + s.stage.String() + "]* "
+    }
+
+    var businessUsages, userUsages []model2.AiUsage
+    requests, promptTokens, completionTokens, cost := 0, 0, 0, 0.0
+    for _, usage := range usages {
+        if _, ok := usage.BusinessId(); ok {
+            businessUsages = append(businessUsages, usage)
+            // each request is attributed to a business and possibly a user. Total over businesses only so that requests are not counted twice
+            requests += usage.Requests
+            promptTokens += usage.PromptTokens
+            completionTokens += usage.CompletionTokens
+            cost += usage.EstimatedCostUsd
+        } else {
+            userUsages = append(userUsages, usage)
+        }
+    }
+    msg += fmt.Sprintf("AI usage on %s: %d requests, %d input tokens, %d output tokens, estimated cost %s", day, requests, promptTokens, completionTokens, model2.FormatUsd(cost))
+
+    blocks := []slack.Block{
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, msg, false, false), nil, nil),
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "Top businesses:\n"+topAiUsagesStr(businessUsages, names), false, false), nil, nil),
+        slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, "Top users:\n"+topAiUsagesStr(userUsages, names), false, false), nil, nil),
+        slack.NewDividerBlock(),
+    }
+
+    respChannel, respTimestamp, err := s.client.PostMessage(
+        s.channelId,
+        slack.MsgOptionBlocks(blocks...),
+    )
+    if err != nil {
+        s.log.Error("Unable to send message to slack in SendAiUsageSummaryMessage: ", err)
+        return err
+    }
+
+    s.log.Debugf("Message successfully sent to slack channel %s at %s", respChannel, respTimestamp)
+
+    return nil
+}
+
+// topAiUsagesStr lists the usages with the highest cost, one per line
+func topAiUsagesStr(usages []model2.AiUsage, names map[string]string) string {
+    if len(usages) == 0 {
+        return "None"
+    }
+    sort.Slice(usages, func(i, j int) bool {
+        return usages[i].EstimatedCostUsd > usages[j].EstimatedCostUsd
+    })
+
+    str := ""
+    for i, usage := range usages {
+        if i == aiUsageSummaryTopCount {
+            str += fmt.Sprintf("... and %d more\n", len(usages)-aiUsageSummaryTopCount)
+            break
+        }
+        id, ok := usage.BusinessId()
+        if !ok {
+            id, _ = usage.UserId()
+        }
+        name := id
+        if n, ok := names[id]; ok {
+            name = fmt.Sprintf("%s (%s)", n, id)
+        }
+        str += fmt.Sprintf("• %s: %d requests, %s\n", name, usage.Requests, model2.FormatUsd(usage.EstimatedCostUsd))
+    }
+    return str
+}
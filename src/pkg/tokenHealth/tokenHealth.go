@@ -0,0 +1,129 @@
+package tokenHealth
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/CoreCommonUtil/constant"
+    "github.com/IntelliLead/CoreCommonUtil/metric"
+    "github.com/IntelliLead/CoreCommonUtil/ssmUtil"
+    "github.com/IntelliLead/CoreDataAccess/ddbDao"
+    "github.com/IntelliLead/CoreDataAccess/model"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/businessProfileUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/dao"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/lineUtil"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/model/enum"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/oauthState"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/util"
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "go.uber.org/zap"
+    "os"
+)
+
+// TokenHealth keeps the stored Google tokens of users usable.
+// Revoked tokens are cleared, so that the user shows as disconnected from Google, and the user is asked to complete OAuth again.
+type TokenHealth struct {
+    businessProfile *businessProfileUtil.BusinessProfile
+    userDao         *ddbDao.UserDao
+    googleTokenDao  *dao.GoogleTokenDao
+    line            *lineUtil.LineUtil
+    stateSigner     *oauthState.Signer
+    authRedirectUrl string
+    log             *zap.SugaredLogger
+}
+
+func NewTokenHealth(
+    businessProfile *businessProfileUtil.BusinessProfile,
+    userDao *ddbDao.UserDao,
+    googleTokenDao *dao.GoogleTokenDao,
+    line *lineUtil.LineUtil,
+    stateSigner *oauthState.Signer,
+    authRedirectUrl string,
+    logger *zap.SugaredLogger) *TokenHealth {
+    return &TokenHealth{
+        businessProfile: businessProfile,
+        userDao:         userDao,
+        googleTokenDao:  googleTokenDao,
+        line:            line,
+        stateSigner:     stateSigner,
+        authRedirectUrl: authRedirectUrl,
+        log:             logger,
+    }
+}
+
+// NewTokenHealthFromEnv creates TokenHealth with the auth redirect URL and OAuth state secret read from the SSM parameters named by
+// AUTH_REDIRECT_URL_PARAMETER_NAME and OAUTH_STATE_SECRET_PARAMETER_NAME, for handlers which do not sign OAuth states otherwise.
+func NewTokenHealthFromEnv(
+    awsConfig aws.Config,
+    businessProfile *businessProfileUtil.BusinessProfile,
+    userDao *ddbDao.UserDao,
+    googleTokenDao *dao.GoogleTokenDao,
+    line *lineUtil.LineUtil,
+    logger *zap.SugaredLogger) (*TokenHealth, error) {
+    ssm := ssmUtil.NewSsm(awsConfig, logger)
+    authRedirectUrl := ssm.GetSsmParameterValue(os.Getenv(constant.AuthRedirectUrlParameterNameEnvKey))
+    stateSigner, err := oauthState.NewSigner(ssm.GetSsmParameterValue(os.Getenv(util.OAuthStateSecretParameterNameEnvKey)))
+    if err != nil {
+        return nil, err
+    }
+
+    return NewTokenHealth(businessProfile, userDao, googleTokenDao, line, stateSigner, authRedirectUrl, logger), nil
+}
+
+// Refresh refreshes the Google access token of the user and stores it.
+// If the refresh token is revoked, it is handled with HandleRevoked and businessProfileUtil.ErrTokenRevoked is returned.
+func (h *TokenHealth) Refresh(ctx context.Context, user model.User) error {
+    token := businessProfileUtil.TokenOf(user)
+    refreshedToken, err := h.businessProfile.RefreshToken(ctx, token)
+    if err != nil {
+        if errors.Is(err, businessProfileUtil.ErrTokenRevoked) {
+            handleErr := h.HandleRevoked(ctx, user)
+            return errors.Join(err, handleErr)
+        }
+        h.log.Errorf("Error refreshing Google token of user '%s': %v", user.UserId, err)
+        return err
+    }
+
+    businessProfileUtil.PersistRefreshedToken(user, token, refreshedToken, h.userDao, h.log)
+    return nil
+}
+
+// CheckError handles the revoked token of the user if err of a Google call made with the token of the user is businessProfileUtil.ErrTokenRevoked.
+// Other errors are ignored. Failures are only logged, as the caller fails with err anyway.
+func (h *TokenHealth) CheckError(ctx context.Context, user model.User, err error) {
+    if !errors.Is(err, businessProfileUtil.ErrTokenRevoked) {
+        return
+    }
+
+    handleErr := h.HandleRevoked(ctx, user)
+    if handleErr != nil {
+        h.log.Errorf("Error handling revoked Google token of user '%s': %v", user.UserId, handleErr)
+    }
+}
+
+// HandleRevoked clears the revoked Google tokens of the user and asks the user to complete OAuth again.
+// The businesses of the user are replied through other users of the business with Google credentials, or through Zapier if there are none.
+func (h *TokenHealth) HandleRevoked(ctx context.Context, user model.User) error {
+    userId := user.UserId
+    h.log.Warnf("Google token of user '%s' is revoked. Clearing it.", userId)
+    metric.EmitMetricWithNamespace(enum.MetricGoogleTokenRevoked.String(), 1.0, util.AuthMetricNamespace)
+
+    err := h.googleTokenDao.ClearTokens(ctx, userId)
+    if err != nil {
+        return err
+    }
+
+    state, err := h.stateSigner.Sign(userId)
+    if err != nil {
+        h.log.Errorf("Error signing OAuth state of user '%s': %v", userId, err)
+        return err
+    }
+    // the user may have unfollowed, in which case the user is asked to complete OAuth on follow
+    err = h.line.SendReauthRequest(userId, state, h.authRedirectUrl)
+    if err != nil {
+        h.log.Errorf("Error sending re-auth request to user '%s': %v", userId, err)
+        return err
+    }
+
+    h.log.Infof("Cleared revoked Google token of user '%s' and sent re-auth request", userId)
+    return nil
+}
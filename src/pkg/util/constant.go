@@ -2,6 +2,7 @@ package util
 
 import (
     "fmt"
+    "time"
 )
 
 func HelpMessage() string {
@@ -27,58 +28,80 @@ const UpdateBusinessDescriptionMessageCmd = "description"
 const UpdateSignatureMessageCmd = "signature"
 const UpdateKeywordsMessageCmd = "keywords"
 const UpdateRecommendationMessageCmd = "recommendation"
+const UpdateAutoReplyRuleCmd = "autoReplyRule"
+const UpdateAllowedContactsCmd = "allowedContacts"
+const ManageLocationsCmd = "locations"
+const ReauthCmd = "auth"
 
 func BuildMessageCmdPrefix(cmd string) string {
     return "/" + cmd + " "
 }
 
+const AutoReplyRuleUsageMessage = "規則格式：[星等] [有文字|無文字] [關鍵字:詞1,詞2] [語言:zh] 動作\n" +
+    "動作：快速回覆、AI、僅通知，或「回覆:」加上回覆內容（可用 {評論人} 代入評論人名稱）\n" +
+    "例如：4-5 有文字 關鍵字:好吃 AI\n" +
+    "刪除規則：刪除 {規則編號}"
+
 const ToggleOnFlexMessageImageUrl = "https://i.imgur.com/aiAnjYy.png"
 const ToggleOffFlexMessageImageUrl = "https://i.imgur.com/kVS4YbE.png"
 
-const AiReplyPromptFormat = "You are a humble business owner in Taiwan. " +
-    "%s" + // business description
-    "You will be provided a customer review of your business. You will reply in Taiwanese mandarin following best practices:\n" +
-    "- Be nice and don’t get personal. Keep your responses useful, readable, and courteous.\n" +
-    "- Keep it short and sweet under 200 characters. Don't need to begin by addressing the customer. Customers are looking for useful and genuine responses.\n" +
-    "- Thank your reviewers\n" +
-    "%s" + // emoji prompt
-    "%s" + // service recommendation prompt
-    "%s" + // keyword prompt
-    "- Be a friend, not a salesperson. Your reviewers are already customers, so there’s no need to offer incentives or advertisements." +
-    "\n\nFor negative reviews:\n" +
-    "- suggest that they contact you personally by email or phone to resolve the issue. A positive post-review interaction and your reply shows prospective shoppers that you really care and often leads the customer to update their original review.\n" +
-    "- Be honest. Acknowledge mistakes that were made, but don’t take responsibility for things that are out of your control. Explain what you can and can't do in the situation. Show how you can make uncontrollable issues actionable. For example, bad weather caused you to cancel an event, but you monitor the weather and provide advance cancellation warnings when possible.\n" +
-    "- Apologize when appropriate. It’s best to say something that demonstrates compassion and empathy.\n" +
-    "%s" // signature prompt
-
-const BusinessDescriptionPromptFormat = "Your business is %s."
-const EmojiPrompt = "- use emojis when possible to invoke a cordial feeling\n"
-const ServiceRecommendationPromptFormat = "- Recommend other services if possible. %s\n"
-const ServiceToRecommendPromptFormat = "Service to recommend: %s"
-const KeywordPromptFormat = "- Try to mention all or parts of the following in a natural way: %s\n"
-const SignaturePrompt = "- Show that you’re a real person by signing off with '%s'"
-
-// AiReplyPromptNailSalon (experimental) full script
-/*
-You are a humble business owner in Taiwan. Your business is a beauty salon providing services including _____. You will be provided a customer review of your business. You will reply in Taiwanese mandarin following best practices:
-- Be nice and don’t get personal. Keep your responses useful, readable, and courteous
-- Keep it short and sweet under 200 characters. Don't need to begin by addressing the customer. Customers are looking for useful and genuine responses
-- Thank your reviewers
-- Try to mention all or parts of the following in a natural way: ______
-- Be a friend, not a salesperson. Your reviewers are already customers, so there’s no need to offer incentives or advertisements
-
-For negative reviews:
-- suggest that they contact you personally by email or phone to resolve the issue. A positive post-review interaction and your reply shows prospective shoppers that you really care and often leads the customer to update their original review
-- Be honest. Acknowledge mistakes that were made, but don’t take responsibility for things that are out of your control. Explain what you can and can't do in the situation. Show how you can make uncontrollable issues actionable. For example, bad weather caused you to cancel an event, but you monitor the weather and provide advance cancellation warnings when possible
-- Apologize when appropriate. It’s best to say something that demonstrates compassion and empathy
-- Show that you’re a real person by signing off with your name or initials. This helps you come across as more authentic
-*/
+// ReviewAiPromptFormat presents a review to AI with the reviewer name, star rating and review text
+const ReviewAiPromptFormat = "Reviewer: %s\nRating: %d/5\nReview: %s"
+
+// NoTextReviewAiPrompt stands in for the review text of a review without text
+const NoTextReviewAiPrompt = "(no text)"
+
+// revision prompts follow the previous reply when AI revises it. Each asks for a full reply so that it can be posted as is.
+const ShorterReplyRevisionPrompt = "Rewrite your reply to be shorter and more concise. Keep the same language and respond with the reply only."
+const WarmerReplyRevisionPrompt = "Rewrite your reply to be warmer and more personal. Keep the same language and respond with the reply only."
+const MoreFormalReplyRevisionPrompt = "Rewrite your reply to be more formal and polite. Keep the same language and respond with the reply only."
+
+// ReplyModerationPrompt asks AI to moderate a reply before it is published. AI answers ReplyModerationApproved or ReplyModerationBlockedPrefix followed by the reason.
+const ReplyModerationPrompt = "You review replies a business is about to publish publicly to a customer review on Google. " +
+    "Block replies that are rude, offensive, discriminatory, disclose personal information of the reviewer, or make promises such as discounts, refunds or compensation. " +
+    "If the reply can be published, answer " + ReplyModerationApproved + " only. " +
+    "Otherwise, answer " + ReplyModerationBlockedPrefix + " followed by the reason in one short sentence in Taiwanese mandarin."
+const ReplyModerationApproved = "OK"
+const ReplyModerationBlockedPrefix = "BLOCK:"
 
 const TestReplyToken = "TST"
 const TestAuthCode = "TST"
-const TestZapierReplyWebhook = "https://TST/"
 
 // stub userId for auto reply author
 const AutoReplyUserId = "autoReply"
 
+// UnfollowedUserGracePeriod is how long the Google tokens of users who unfollowed the LINE Official Account are kept, so that they can follow again without completing OAuth
+const UnfollowedUserGracePeriod = 30 * 24 * time.Hour
+
 const AuthMetricNamespace = "IntelliLeadAuth/DailyMetrics"
+const LineEventsMetricNamespace = "IntelliLeadLineEvents/Metrics"
+const ReviewMetricNamespace = "IntelliLeadReviews/Metrics"
+
+// GoogleBusinessProfileBaseUrlEnvKey and GoogleOauthTokenUrlEnvKey override Google endpoints, e.g., to test against a local HTTP stub
+const GoogleBusinessProfileBaseUrlEnvKey = "GOOGLE_BUSINESS_PROFILE_BASE_URL"
+const GoogleOauthTokenUrlEnvKey = "GOOGLE_OAUTH_TOKEN_URL"
+
+// ReplyPublisherEnvKey forces the reply backend of all businesses, e.g., "Recorder" to dry run replies
+const ReplyPublisherEnvKey = "REPLY_PUBLISHER"
+
+// PubSubVerificationTokenParameterNameEnvKey is the SSM parameter name of the token appended to the Pub/Sub push endpoint, i.e., "?token=..."
+const PubSubVerificationTokenParameterNameEnvKey = "PUBSUB_VERIFICATION_TOKEN_PARAMETER_NAME"
+
+// OAuthStateSecretParameterNameEnvKey is the SSM parameter name of the secret signing the state of Google OAuth requests. See oauthState.Signer.
+const OAuthStateSecretParameterNameEnvKey = "OAUTH_STATE_SECRET_PARAMETER_NAME"
+
+// ReplyModerationEnabledEnvKey enables the LLM moderation of replies before they are published. See replyGuard.NewReplyGuardFromEnv.
+const ReplyModerationEnabledEnvKey = "REPLY_MODERATION_ENABLED"
+
+// LLM env vars configure the LLM generating AI replies per stage. See aiUtil.NewAiFromEnv.
+// LLMBackendEnvKey is one of "OpenAI" (default), "AzureOpenAI", "Anthropic", "Local" and "Fake"
+const LLMBackendEnvKey = "LLM_BACKEND"
+const LLMModelEnvKey = "LLM_MODEL"
+const LLMTemperatureEnvKey = "LLM_TEMPERATURE"
+const LLMMaxTokensEnvKey = "LLM_MAX_TOKENS"
+
+// LLMBaseUrlEnvKey is the endpoint of the LLM backend, required for Azure OpenAI and local endpoints
+const LLMBaseUrlEnvKey = "LLM_BASE_URL"
+
+// LLMApiKeyParameterNameEnvKey is the SSM parameter name of the API key of the LLM backend. Defaults to the GPT API key secret for OpenAI.
+const LLMApiKeyParameterNameEnvKey = "LLM_API_KEY_PARAMETER_NAME"
@@ -2,55 +2,109 @@ package zapierUtil
 
 import (
     "bytes"
+    "context"
     "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
     "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil/model"
+    "github.com/cenkalti/backoff/v4"
     "go.uber.org/zap"
+    "io"
     "net/http"
+    "time"
+)
+
+const (
+    requestTimeout = 10 * time.Second
+    // maxResponseBodySize limits the response body recorded for diagnostics
+    maxResponseBodySize = 4 * 1024
+    maxRetries          = 3
 )
 
 type Zapier struct {
-    log *zap.SugaredLogger
+    client *http.Client
+    log    *zap.SugaredLogger
 }
 
 func NewZapier(logger *zap.SugaredLogger) *Zapier {
     return &Zapier{
-        log: logger,
+        client: &http.Client{Timeout: requestTimeout},
+        log:    logger,
     }
 }
 
-func (z *Zapier) SendReplyEvent(webhookUrl string, payload model.ReplyToZapierEvent) error {
+// SendReplyEvent sends the reply event to the Zapier webhook.
+// Rate limited (429), 5xx responses and network errors are retried with backoff.
+// Returns exception.ZapierRequestException if the request failed, which indicates whether the failure is transient.
+func (z *Zapier) SendReplyEvent(ctx context.Context, webhookUrl string, payload model.ReplyToZapierEvent) error {
     // Convert the payload object to JSON
     // zapier expects array JSON payload
     jsonData, err := json.Marshal([]model.ReplyToZapierEvent{payload})
     if err != nil {
         z.log.Errorf("error marshaling payload to JSON: %v", err)
+        return exception.NewPermanentZapierRequestException("error marshaling payload to JSON", 0, "", err)
+    }
+
+    operation := func() error {
+        return z.post(ctx, webhookUrl, jsonData)
+    }
+
+    backoffPolicy := backoff.NewExponentialBackOff()
+    backoffPolicy.InitialInterval = 500 * time.Millisecond
+    backoffPolicy.MaxElapsedTime = 30 * time.Second
+    err = backoff.RetryNotify(operation, backoff.WithContext(backoff.WithMaxRetries(backoffPolicy, maxRetries), ctx), func(err error, duration time.Duration) {
+        z.log.Warn("Retrying Zapier request due to error: ", err, ". Next attempt in ", duration)
+    })
+    if err != nil {
+        var zapierRequestException exception.ZapierRequestException
+        if !errors.As(err, &zapierRequestException) {
+            // context cancelled or deadline exceeded while waiting to retry
+            err = exception.NewTransientZapierRequestException("Zapier request aborted", 0, "", err)
+        }
+        z.log.Errorf("Sending reply event to Zapier failed: %v", err)
         return err
     }
 
+    return nil
+}
+
+// post sends a single request to the webhook. Errors that should not be retried are wrapped with backoff.Permanent.
+func (z *Zapier) post(ctx context.Context, webhookUrl string, jsonData []byte) error {
     // Create a new HTTP request with the JSON payload
-    req, err := http.NewRequest("POST", webhookUrl, bytes.NewBuffer(jsonData))
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewBuffer(jsonData))
     if err != nil {
         z.log.Errorf("error creating HTTP request: %v", err)
-        return err
+        return backoff.Permanent(exception.NewPermanentZapierRequestException("error creating HTTP request", 0, "", err))
     }
 
     // Set the Content-Type header to specify JSON data
     req.Header.Set("Content-Type", "application/json")
 
     // Send the HTTP request
-    client := &http.Client{}
-    resp, err := client.Do(req)
+    resp, err := z.client.Do(req)
     if err != nil {
         z.log.Errorf("error sending HTTP request: %v", err)
-        return err
+        return exception.NewTransientZapierRequestException("error sending HTTP request", 0, "", err)
     }
     defer resp.Body.Close()
 
-    // Check the response status code
-    if resp.StatusCode != http.StatusOK {
-        z.log.Errorf("received non-OK status code: %v", resp.StatusCode)
-        return err
+    respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+    if err != nil {
+        z.log.Warnf("error reading response body: %v", err)
     }
 
-    return nil
+    // Check the response status code
+    statusCode := resp.StatusCode
+    switch {
+    case statusCode >= 200 && statusCode < 300:
+        z.log.Debugf("Zapier responded with status code %d: %s", statusCode, respBody)
+        return nil
+    case statusCode == http.StatusTooManyRequests || statusCode >= 500:
+        z.log.Errorf("received retryable status code %d: %s", statusCode, respBody)
+        return exception.NewTransientZapierRequestException("received retryable status code", statusCode, string(respBody), fmt.Errorf("status %s", resp.Status))
+    default:
+        z.log.Errorf("received non-OK status code %d: %s", statusCode, respBody)
+        return backoff.Permanent(exception.NewPermanentZapierRequestException("received non-OK status code", statusCode, string(respBody), fmt.Errorf("status %s", resp.Status)))
+    }
 }
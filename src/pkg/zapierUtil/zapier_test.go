@@ -0,0 +1,148 @@
+package zapierUtil
+
+import (
+    "context"
+    "errors"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/exception"
+    "github.com/IntelliLead/ReviewHandlers/src/pkg/zapierUtil/model"
+    "go.uber.org/zap"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestZapierSendReplyEvent(t *testing.T) {
+    tests := []struct {
+        name string
+        // statusCodes are the responses of the webhook in order. The last status code is repeated.
+        statusCodes    []int
+        wantErr        bool
+        wantTransient  bool
+        wantStatusCode int
+        wantAttempts   int
+    }{
+        {
+            name:         "success",
+            statusCodes:  []int{http.StatusOK},
+            wantAttempts: 1,
+        },
+        {
+            name:         "success after retrying server error",
+            statusCodes:  []int{http.StatusServiceUnavailable, http.StatusOK},
+            wantAttempts: 2,
+        },
+        {
+            name:           "rate limited",
+            statusCodes:    []int{http.StatusTooManyRequests},
+            wantErr:        true,
+            wantTransient:  true,
+            wantStatusCode: http.StatusTooManyRequests,
+            wantAttempts:   maxRetries + 1,
+        },
+        {
+            name:           "server error",
+            statusCodes:    []int{http.StatusInternalServerError},
+            wantErr:        true,
+            wantTransient:  true,
+            wantStatusCode: http.StatusInternalServerError,
+            wantAttempts:   maxRetries + 1,
+        },
+        {
+            name:           "client error is not retried",
+            statusCodes:    []int{http.StatusNotFound},
+            wantErr:        true,
+            wantTransient:  false,
+            wantStatusCode: http.StatusNotFound,
+            wantAttempts:   1,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            attempts := 0
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                statusCode := tt.statusCodes[len(tt.statusCodes)-1]
+                if attempts < len(tt.statusCodes) {
+                    statusCode = tt.statusCodes[attempts]
+                }
+                attempts++
+                w.WriteHeader(statusCode)
+            }))
+            defer server.Close()
+
+            err := NewZapier(zap.NewNop().Sugar()).SendReplyEvent(context.Background(), server.URL, model.ReplyToZapierEvent{VendorReviewId: "1", Message: "Thank you!"})
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("SendReplyEvent() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if attempts != tt.wantAttempts {
+                t.Errorf("SendReplyEvent() attempts = %d, want %d", attempts, tt.wantAttempts)
+            }
+            if err == nil {
+                return
+            }
+
+            var zapierRequestException exception.ZapierRequestException
+            if !errors.As(err, &zapierRequestException) {
+                t.Fatalf("SendReplyEvent() error = %v, want ZapierRequestException", err)
+            }
+            if zapierRequestException.Transient != tt.wantTransient {
+                t.Errorf("SendReplyEvent() transient = %v, want %v", zapierRequestException.Transient, tt.wantTransient)
+            }
+            if zapierRequestException.StatusCode != tt.wantStatusCode {
+                t.Errorf("SendReplyEvent() status code = %d, want %d", zapierRequestException.StatusCode, tt.wantStatusCode)
+            }
+        })
+    }
+}
+
+func TestZapierSendReplyEventWithoutResponse(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    unreachableUrl := server.URL
+    server.Close()
+
+    cancelledCtx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    tests := []struct {
+        name          string
+        ctx           context.Context
+        webhookUrl    string
+        wantTransient bool
+    }{
+        {
+            name:          "network error",
+            ctx:           context.Background(),
+            webhookUrl:    unreachableUrl,
+            wantTransient: true,
+        },
+        {
+            name:          "context cancelled",
+            ctx:           cancelledCtx,
+            webhookUrl:    unreachableUrl,
+            wantTransient: true,
+        },
+        {
+            name:          "malformed webhook URL",
+            ctx:           context.Background(),
+            webhookUrl:    "http://[::1",
+            wantTransient: false,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := NewZapier(zap.NewNop().Sugar()).SendReplyEvent(tt.ctx, tt.webhookUrl, model.ReplyToZapierEvent{VendorReviewId: "1", Message: "Thank you!"})
+
+            var zapierRequestException exception.ZapierRequestException
+            if !errors.As(err, &zapierRequestException) {
+                t.Fatalf("SendReplyEvent() error = %v, want ZapierRequestException", err)
+            }
+            if zapierRequestException.Transient != tt.wantTransient {
+                t.Errorf("SendReplyEvent() transient = %v, want %v", zapierRequestException.Transient, tt.wantTransient)
+            }
+            if zapierRequestException.StatusCode != 0 {
+                t.Errorf("SendReplyEvent() status code = %d, want 0", zapierRequestException.StatusCode)
+            }
+        })
+    }
+}